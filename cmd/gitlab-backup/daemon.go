@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/daemon"
+)
+
+// daemonCommand is the subcommand name that runs backups on cron schedules.
+const daemonCommand = "daemon"
+
+// daemonFlags holds the daemon subcommand flag values.
+type daemonFlags struct {
+	output     string
+	statusAddr string
+}
+
+// applyDaemonOverrides applies daemon flag values to the configuration.
+func applyDaemonOverrides(cfg *config.Config, flags daemonFlags) {
+	if flags.output != "" {
+		cfg.LocalPath = flags.output
+	}
+	if flags.statusAddr != "" {
+		cfg.Daemon.StatusAddr = flags.statusAddr
+	}
+}
+
+// loadDaemonConfiguration loads and validates the daemon configuration. Unlike
+// loadConfiguration it returns errors, so that a bad reload does not stop the
+// daemon.
+func loadDaemonConfiguration(cfgFile string, flags daemonFlags) (*config.Config, error) {
+	var cfg *config.Config
+	var err error
+	if cfgFile != "" {
+		cfg, err = config.NewConfigFromFileNoValidate(cfgFile)
+	} else {
+		cfg, err = config.NewConfigFromEnv()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %w", err)
+	}
+	applyDaemonOverrides(cfg, flags)
+	if err := cfg.ValidateForDaemon(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+	return cfg, nil
+}
+
+// runDaemon implements "gitlab-backup daemon": it keeps running and backs up
+// each target on its cron schedule until SIGINT or SIGTERM. SIGHUP reloads the
+// configuration. It returns the process exit code.
+func runDaemon(args []string) int {
+	fs := flag.NewFlagSet(daemonCommand, flag.ExitOnError)
+	configFile := fs.String("config", "", "Path to configuration file (YAML)")
+	fs.StringVar(configFile, "c", "", "Path to configuration file (YAML) (shorthand)")
+	output := fs.String("output", "", "Output directory for local storage")
+	statusAddr := fs.String("status-addr", "", "Listen address of the HTTP status endpoint (e.g. :8080)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gitlab-backup daemon [OPTIONS]\n\n")
+		fmt.Fprintf(os.Stderr, "Run backups on the cron schedule of each target; SIGHUP reloads the configuration\n\n")
+		fmt.Fprintf(os.Stderr, "OPTIONS:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
+		fmt.Fprintf(os.Stderr, "  # Back up the targets of the config file on their schedules\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup daemon -c config.yaml\n\n")
+		fmt.Fprintf(os.Stderr, "  # Same, with the status endpoint on port 8080\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup daemon -c config.yaml --status-addr :8080\n\n")
+	}
+	_ = fs.Parse(args) // ExitOnError: Parse exits on failure
+
+	flags := daemonFlags{output: *output, statusAddr: *statusAddr}
+	load := func() (*config.Config, error) {
+		return loadDaemonConfiguration(*configFile, flags)
+	}
+	cfg, err := load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	l := initTrace(os.Getenv("DEBUGLEVEL"), cfg.NoLogTime)
+	run := func(ctx context.Context, cfg *config.Config) error {
+		a, err := app.NewApp(ctx, cfg, l)
+		if err != nil {
+			return err //nolint:wrapcheck // NewApp errors are already descriptive
+		}
+		a.SetVersion(version)
+		return a.Run(ctx) //nolint:wrapcheck // logged as is by the daemon
+	}
+	if err := daemon.New(load, run, l).Run(ctx, reload); err != nil {
+		l.Error("daemon failed", "error", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDaemonConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("flags override config", func(t *testing.T) {
+		path := write(t, "gitlabToken: token\ngitlabGroupID: 1\ntmpdir: "+dir+"\n"+
+			"daemon:\n  schedule: \"@daily\"\n  statusAddr: \":8080\"\n")
+		cfg, err := loadDaemonConfiguration(path, daemonFlags{output: dir, statusAddr: "127.0.0.1:9090"})
+		require.NoError(t, err)
+		assert.Equal(t, dir, cfg.LocalPath)
+		assert.Equal(t, "127.0.0.1:9090", cfg.Daemon.StatusAddr)
+		assert.Equal(t, "@daily", cfg.Daemon.Schedule)
+	})
+
+	t.Run("schedule required", func(t *testing.T) {
+		path := write(t, "gitlabToken: token\ngitlabGroupID: 1\ntmpdir: "+dir+"\n")
+		_, err := loadDaemonConfiguration(path, daemonFlags{output: dir})
+		require.ErrorContains(t, err, "daemon.schedule is required")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := loadDaemonConfiguration(filepath.Join(dir, "missing.yaml"), daemonFlags{})
+		require.ErrorContains(t, err, "error loading configuration")
+	})
+}
@@ -30,12 +30,18 @@ var version = "development"
 
 // cliFlags holds command-line flag values.
 type cliFlags struct {
-	groupID   int64
-	projectID int64
-	output    string
-	timeout   int
-	tmpdir    string
-	gitlabURL string
+	groupID     int64
+	projectID   int64
+	output      string
+	timeout     int
+	tmpdir      string
+	gitlabURL   string
+	concurrency int
+	full        bool
+	resume      bool
+	archived    bool
+	dryRun      bool
+	format      string
 }
 
 func printVersion() {
@@ -76,6 +82,7 @@ func loadConfiguration(cfgFile string) *config.Config {
 				TmpDir:            "/tmp",
 				ExportTimeoutMins: constants.DefaultExportTimeoutMins,
 				ImportTimeoutMins: constants.DefaultImportTimeoutMins,
+				MaxConcurrency:    constants.DefaultMaxConcurrency,
 			}
 		}
 	}
@@ -84,12 +91,15 @@ func loadConfiguration(cfgFile string) *config.Config {
 
 // applyCliOverrides applies command-line flag values to the configuration.
 func applyCliOverrides(cfg *config.Config, flags cliFlags) {
-	// Apply group/project ID overrides
+	// Apply group/project ID overrides. A target given on the command line
+	// replaces the targets list of the config file.
 	if flags.groupID > 0 {
 		cfg.GitlabGroupID = flags.groupID
+		cfg.Targets = nil
 	}
 	if flags.projectID > 0 {
 		cfg.GitlabProjectID = flags.projectID
+		cfg.Targets = nil
 	}
 
 	// Apply storage override
@@ -107,11 +117,27 @@ func applyCliOverrides(cfg *config.Config, flags cliFlags) {
 	if flags.gitlabURL != "" {
 		cfg.GitlabURI = flags.gitlabURL
 	}
+	if flags.concurrency > 0 {
+		cfg.MaxConcurrency = flags.concurrency
+	}
+	if flags.full {
+		cfg.FullBackup = true
+	}
+	if flags.resume {
+		cfg.Resume = true
+	}
+	if flags.archived {
+		cfg.IncludeArchived = true
+	}
 }
 
 func init() {
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: gitlab-backup [OPTIONS]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: gitlab-backup [OPTIONS]\n")
+		fmt.Fprintf(os.Stderr, "       gitlab-backup prune [OPTIONS]\n")
+		fmt.Fprintf(os.Stderr, "       gitlab-backup daemon [OPTIONS]\n")
+		fmt.Fprintf(os.Stderr, "       gitlab-backup verify [OPTIONS]\n")
+		fmt.Fprintf(os.Stderr, "       gitlab-backup inspect [OPTIONS] <archive>\n\n")
 		fmt.Fprintf(os.Stderr, "Backup GitLab projects and groups\n\n")
 		fmt.Fprintf(os.Stderr, "OPTIONS:\n")
 		flag.PrintDefaults()
@@ -120,21 +146,54 @@ func init() {
 		fmt.Fprintf(os.Stderr, "  gitlab-backup --project-id 123 --output /backup\n\n")
 		fmt.Fprintf(os.Stderr, "  # Backup group with custom timeout\n")
 		fmt.Fprintf(os.Stderr, "  gitlab-backup --group-id 456 --output /backup --timeout 30\n\n")
+		fmt.Fprintf(os.Stderr, "  # Backup group exporting at most 2 projects at a time\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup --group-id 456 --output /backup --concurrency 2\n\n")
+		fmt.Fprintf(os.Stderr, "  # Force a full backup when stateFile enables incremental backups\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup -c config.yaml --full\n\n")
+		fmt.Fprintf(os.Stderr, "  # Resume an interrupted group backup\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup -c config.yaml --resume\n\n")
+		fmt.Fprintf(os.Stderr, "  # Also back up archived projects (e.g. from a weekly job)\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup -c config.yaml --include-archived\n\n")
+		fmt.Fprintf(os.Stderr, "  # Show what a run would export, with keys, sizes and duration, as JSON\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup -c config.yaml --dry-run --format json\n\n")
+		fmt.Fprintf(os.Stderr, "  # Backup every group, project and user namespace of the targets list\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup -c targets.yaml\n\n")
 		fmt.Fprintf(os.Stderr, "  # Override config file values\n")
 		fmt.Fprintf(os.Stderr, "  gitlab-backup -c config.yaml --timeout 20\n\n")
 		fmt.Fprintf(os.Stderr, "  # Backup to S3 (S3 config must be in config file)\n")
 		fmt.Fprintf(os.Stderr, "  gitlab-backup -c s3-config.yaml --project-id 789\n\n")
+		fmt.Fprintf(os.Stderr, "  # Preview which archives the retention policy would delete\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup prune -c config.yaml --dry-run\n\n")
+		fmt.Fprintf(os.Stderr, "  # Keep running and back up each target on its cron schedule\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup daemon -c config.yaml\n\n")
+		fmt.Fprintf(os.Stderr, "  # Check the stored archives against their checksums (non-zero exit on failure)\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup verify -c config.yaml\n\n")
+		fmt.Fprintf(os.Stderr, "  # Summarize the GitLab export of an archive, checking it is complete\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup inspect /backup/myproject-1234567890.tar.gz\n\n")
 		fmt.Fprintf(os.Stderr, "CONFIGURATION PRECEDENCE:\n")
 		fmt.Fprintf(os.Stderr, "  CLI flags > Config file > Environment variables\n\n")
 		fmt.Fprintf(os.Stderr, "REQUIRED SETTINGS:\n")
 		fmt.Fprintf(os.Stderr, "  - GitLab Token: GITLAB_TOKEN env var or gitlabtoken in config\n")
-		fmt.Fprintf(os.Stderr, "  - Target: --group-id OR --project-id (or in config/env)\n")
+		fmt.Fprintf(os.Stderr, "  - Target: --group-id OR --project-id (or in config/env), or a targets list in config\n")
 		fmt.Fprintf(os.Stderr, "  - Storage: --output for local or S3 config in file\n")
 	}
 }
 
 //nolint:funlen // Main function complexity is acceptable for CLI entry point
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == pruneCommand {
+		os.Exit(runPrune(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == daemonCommand {
+		os.Exit(runDaemon(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == verifyCommand {
+		os.Exit(runVerify(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == inspectCommand {
+		os.Exit(runInspect(os.Args[2:]))
+	}
+
 	// Define flags
 	configFile := flag.String("config", "", "Path to configuration file (YAML)")
 	flag.StringVar(configFile, "c", "", "Path to configuration file (YAML) (shorthand)")
@@ -145,6 +204,12 @@ func main() {
 	timeout := flag.Int("timeout", -1, "Export timeout in minutes (default: 10)")
 	tmpdir := flag.String("tmpdir", "", "Temporary directory (default: /tmp)")
 	gitlabURL := flag.String("gitlab-url", "", "GitLab API endpoint (default: https://gitlab.com)")
+	concurrency := flag.Int("concurrency", 0, "Maximum number of projects exported in parallel (default: 4)")
+	full := flag.Bool("full", false, "Export every project, ignoring the incremental state file")
+	resume := flag.Bool("resume", false, "Resume an interrupted group backup, skipping completed projects")
+	archived := flag.Bool("include-archived", false, "Also back up archived projects")
+	dryRun := flag.Bool("dry-run", false, "Print what would be exported, without exporting anything")
+	format := flag.String("format", app.FormatText, "Output format of --dry-run: text or json")
 
 	showVersion := flag.Bool("version", false, "Show version and exit")
 	flag.BoolVar(showVersion, "v", false, "Show version and exit (shorthand)")
@@ -174,12 +239,18 @@ func main() {
 
 	// Apply CLI overrides
 	flags := cliFlags{
-		groupID:   *groupID,
-		projectID: *projectID,
-		output:    *output,
-		timeout:   *timeout,
-		tmpdir:    *tmpdir,
-		gitlabURL: *gitlabURL,
+		groupID:     *groupID,
+		projectID:   *projectID,
+		output:      *output,
+		timeout:     *timeout,
+		tmpdir:      *tmpdir,
+		gitlabURL:   *gitlabURL,
+		concurrency: *concurrency,
+		full:        *full,
+		resume:      *resume,
+		archived:    *archived,
+		dryRun:      *dryRun,
+		format:      *format,
 	}
 	applyCliOverrides(cfg, flags)
 
@@ -189,6 +260,10 @@ func main() {
 		os.Exit(1)
 	}
 
+	if flags.dryRun {
+		os.Exit(runPlan(cfg, flags.format))
+	}
+
 	// Create context for app initialization and execution
 	ctx := context.Background()
 
@@ -203,6 +278,7 @@ func main() {
 		os.Exit(1)
 	}
 
+	app.SetVersion(version)
 	err = app.Run(ctx)
 
 	if err != nil {
@@ -210,3 +286,29 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// runPlan implements --dry-run: it prints the plan of a backup run in format
+// to stdout, logging to stderr. It returns the process exit code.
+func runPlan(cfg *config.Config, format string) int {
+	if format != app.FormatText && format != app.FormatJSON {
+		fmt.Fprintf(os.Stderr, "invalid --format %q: want %s or %s\n", format, app.FormatText, app.FormatJSON)
+		return 1
+	}
+	ctx := context.Background()
+	l := initTraceTo(os.Stderr, os.Getenv("DEBUGLEVEL"), cfg.NoLogTime)
+	a, err := app.NewApp(ctx, cfg, l)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	plan, err := a.Plan(ctx)
+	if err != nil {
+		l.Error("failed to plan the backup", "error", err)
+		return 1
+	}
+	if err := plan.Write(os.Stdout, format); err != nil {
+		l.Error("failed to print the plan", "error", err)
+		return 1
+	}
+	return 0
+}
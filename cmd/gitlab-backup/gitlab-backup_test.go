@@ -37,6 +37,20 @@ func TestApplyCliOverrides_ProjectID(t *testing.T) {
 	assert.Equal(t, int64(0), baseCfg.GitlabGroupID)
 }
 
+func TestApplyCliOverrides_IDReplacesTargets(t *testing.T) {
+	baseCfg := &config.Config{
+		Targets: []config.TargetConfig{{Group: 1}, {Project: 2}},
+	}
+	flags := cliFlags{
+		groupID: 200,
+	}
+
+	applyCliOverrides(baseCfg, flags)
+
+	assert.Equal(t, int64(200), baseCfg.GitlabGroupID)
+	assert.Empty(t, baseCfg.Targets)
+}
+
 func TestApplyCliOverrides_Output(t *testing.T) {
 	baseCfg := &config.Config{
 		LocalPath: "/old/path",
@@ -102,6 +116,48 @@ func TestApplyCliOverrides_GitLabURL(t *testing.T) {
 	assert.Equal(t, "https://gitlab.example.com", baseCfg.GitlabURI)
 }
 
+func TestApplyCliOverrides_Concurrency(t *testing.T) {
+	baseCfg := &config.Config{
+		MaxConcurrency: 4,
+	}
+
+	applyCliOverrides(baseCfg, cliFlags{concurrency: 0, timeout: -1})
+	assert.Equal(t, 4, baseCfg.MaxConcurrency, "unset flag keeps the configured value")
+
+	applyCliOverrides(baseCfg, cliFlags{concurrency: 2, timeout: -1})
+	assert.Equal(t, 2, baseCfg.MaxConcurrency)
+}
+
+func TestApplyCliOverrides_Full(t *testing.T) {
+	baseCfg := &config.Config{}
+
+	applyCliOverrides(baseCfg, cliFlags{timeout: -1})
+	assert.False(t, baseCfg.FullBackup, "incremental by default")
+
+	applyCliOverrides(baseCfg, cliFlags{full: true, timeout: -1})
+	assert.True(t, baseCfg.FullBackup)
+}
+
+func TestApplyCliOverrides_Resume(t *testing.T) {
+	baseCfg := &config.Config{}
+
+	applyCliOverrides(baseCfg, cliFlags{timeout: -1})
+	assert.False(t, baseCfg.Resume, "fresh run by default")
+
+	applyCliOverrides(baseCfg, cliFlags{resume: true, timeout: -1})
+	assert.True(t, baseCfg.Resume)
+}
+
+func TestApplyCliOverrides_IncludeArchived(t *testing.T) {
+	baseCfg := &config.Config{}
+
+	applyCliOverrides(baseCfg, cliFlags{timeout: -1})
+	assert.False(t, baseCfg.IncludeArchived, "archived projects skipped by default")
+
+	applyCliOverrides(baseCfg, cliFlags{archived: true, timeout: -1})
+	assert.True(t, baseCfg.IncludeArchived)
+}
+
 func TestApplyCliOverrides_MultipleOverrides(t *testing.T) {
 	baseCfg := &config.Config{
 		GitlabProjectID:   100,
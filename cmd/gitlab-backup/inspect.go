@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
+	"github.com/sgaunet/gitlab-backup/pkg/storage/localstorage"
+)
+
+// inspectCommand is the subcommand name that summarizes the content of an archive.
+const inspectCommand = "inspect"
+
+var (
+	errInspectNoS3  = errors.New("s3:// archives need the S3 settings of a config file or the environment")
+	errInspectS3Key = errors.New("s3:// archive must be s3://bucket/key")
+)
+
+// runInspect implements "gitlab-backup inspect <archive>": it reads the whole
+// archive, local or s3://bucket/key, decrypting it when age-encrypted, and
+// prints a summary of the GitLab export it holds. It returns the process exit
+// code, non-zero when the archive is truncated, corrupted or incomplete.
+func runInspect(args []string) int {
+	fs := flag.NewFlagSet(inspectCommand, flag.ExitOnError)
+	configFile := fs.String("config", "", "Path to configuration file (YAML)")
+	fs.StringVar(configFile, "c", "", "Path to configuration file (YAML) (shorthand)")
+	identity := fs.String("identity", "", "age identity file to decrypt an encrypted archive")
+	format := fs.String("format", app.FormatText, "Output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gitlab-backup inspect [OPTIONS] <archive>\n\n")
+		fmt.Fprintf(os.Stderr, "Read a whole archive (local path or s3://bucket/key) and summarize the GitLab export\n\n")
+		fmt.Fprintf(os.Stderr, "OPTIONS:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
+		fmt.Fprintf(os.Stderr, "  # Check an archive before restoring it\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup inspect /backup/myproject-1234567890.tar.gz\n\n")
+		fmt.Fprintf(os.Stderr, "  # Summarize an encrypted S3 archive as JSON\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup inspect -c s3-config.yaml --identity key.txt --format json \\\n")
+		fmt.Fprintf(os.Stderr, "    s3://bucket/myproject-1234567890.tar.gz.age\n\n")
+	}
+	_ = fs.Parse(args) // ExitOnError: Parse exits on failure
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	if *format != app.FormatText && *format != app.FormatJSON {
+		fmt.Fprintf(os.Stderr, "invalid --format %q: want %s or %s\n", *format, app.FormatText, app.FormatJSON)
+		return 1
+	}
+
+	cfg := loadConfiguration(*configFile)
+	if *identity != "" {
+		cfg.Age.IdentityFile = *identity
+	}
+	if err := inspectArchive(context.Background(), cfg, fs.Arg(0), *format); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	return 0
+}
+
+// inspectArchive prints the inspection of archive in format to stdout.
+func inspectArchive(ctx context.Context, cfg *config.Config, archive, format string) error {
+	store, key, err := inspectStorage(ctx, cfg, archive)
+	if err != nil {
+		return err
+	}
+	l := initTraceTo(os.Stderr, os.Getenv("DEBUGLEVEL"), cfg.NoLogTime)
+	inspection, err := app.NewAppWithService(cfg, nil, store, l).Inspect(ctx, key)
+	if err != nil {
+		return err //nolint:wrapcheck // printed as is
+	}
+	if err := inspection.Write(os.Stdout, format); err != nil {
+		return err //nolint:wrapcheck // printed as is
+	}
+	if !inspection.Complete() {
+		return fmt.Errorf("%w: missing %s", storage.ErrArchiveIncomplete, strings.Join(inspection.Missing, ", "))
+	}
+	return nil
+}
+
+// inspectStorage returns the storage holding archive and its key there. As
+// for gitlab-restore, the key of an s3://bucket/key archive is relative to
+// the configured bucket path.
+func inspectStorage(ctx context.Context, cfg *config.Config, archive string) (storage.Storage, string, error) {
+	location, isS3 := strings.CutPrefix(archive, "s3://")
+	if !isS3 {
+		return localstorage.NewLocalStorage(filepath.Dir(archive)), filepath.Base(archive), nil
+	}
+	bucket, key, found := strings.Cut(location, "/")
+	if !found || bucket == "" || key == "" {
+		return nil, "", errInspectS3Key
+	}
+	if !cfg.IsS3ConfigValid() {
+		return nil, "", errInspectNoS3
+	}
+	cfg.S3cfg.BucketName = bucket
+	store, err := app.NewStorage(ctx, cfg)
+	if err != nil {
+		return nil, "", err //nolint:wrapcheck // printed as is
+	}
+	return store, key, nil
+}
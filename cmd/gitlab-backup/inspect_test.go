@@ -0,0 +1,64 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeExport writes a tar.gz holding names to path: JSON entries hold an
+// empty object, project.bundle an empty git bundle, others their name.
+func writeExport(t *testing.T, path string, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for _, name := range names {
+		content := name
+		switch {
+		case strings.HasSuffix(name, ".json"), strings.HasSuffix(name, ".ndjson"):
+			content = "{}\n"
+		case strings.HasSuffix(name, ".bundle"):
+			content = "# v2 git bundle\n\n"
+		}
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	return buf.Bytes()
+}
+
+func TestRunInspect_ExitCode(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "app-1.tar.gz")
+	data := writeExport(t, archive, "./VERSION", "./tree/project.json", "./project.bundle")
+	assert.Equal(t, 0, runInspect([]string{archive}))
+
+	require.NoError(t, os.WriteFile(archive, data[:len(data)-10], 0o600))
+	assert.Equal(t, 1, runInspect([]string{archive}), "truncation must fail the command")
+
+	writeExport(t, archive, "./VERSION", "./project.bundle")
+	assert.Equal(t, 1, runInspect([]string{archive}), "a missing project.json must fail the command")
+
+	writeExport(t, archive, "./VERSION", "./tree/project.json")
+	assert.Equal(t, 0, runInspect([]string{archive}), "an empty repository has no bundle")
+
+	group := filepath.Join(dir, "team.group.tar.gz")
+	writeExport(t, group, "GITLAB_VERSION", "tree/groups/_all.ndjson")
+	assert.Equal(t, 0, runInspect([]string{"--format", "json", group}))
+
+	assert.Equal(t, 1, runInspect([]string{filepath.Join(dir, "missing.tar.gz")}))
+	assert.Equal(t, 1, runInspect([]string{"--format", "yaml", group}))
+	assert.Equal(t, 1, runInspect([]string{"s3://bucket-only"}))
+	assert.Equal(t, 1, runInspect(nil))
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
 
 func initTrace(debugLevel string, noLogTime bool) *slog.Logger {
+	return initTraceTo(os.Stdout, debugLevel, noLogTime)
+}
+
+// initTraceTo is initTrace writing to w, e.g. stderr when stdout carries a plan.
+func initTraceTo(w io.Writer, debugLevel string, noLogTime bool) *slog.Logger {
 	handlerOptions := &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	}
@@ -32,7 +38,7 @@ func initTrace(debugLevel string, noLogTime bool) *slog.Logger {
 		handlerOptions.Level = slog.LevelInfo
 	}
 
-	handler := slog.NewTextHandler(os.Stdout, handlerOptions)
+	handler := slog.NewTextHandler(w, handlerOptions)
 	// handler := slog.NewJSONHandler(os.Stdout, nil) // JSON format
 	logger := slog.New(handler)
 	return logger
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+)
+
+// pruneCommand is the subcommand name that applies the retention policy to stored archives.
+const pruneCommand = "prune"
+
+// pruneFlags holds the prune subcommand flag values. Retention counts use -1
+// as the "not set" sentinel so that 0 can explicitly disable a configured rule.
+type pruneFlags struct {
+	output      string
+	keepLast    int
+	keepDaily   int
+	keepWeekly  int
+	keepMonthly int
+}
+
+// applyPruneOverrides applies prune flag values to the configuration.
+func applyPruneOverrides(cfg *config.Config, flags pruneFlags) {
+	if flags.output != "" {
+		cfg.LocalPath = flags.output
+	}
+	if flags.keepLast >= 0 {
+		cfg.Retention.KeepLast = flags.keepLast
+	}
+	if flags.keepDaily >= 0 {
+		cfg.Retention.KeepDaily = flags.keepDaily
+	}
+	if flags.keepWeekly >= 0 {
+		cfg.Retention.KeepWeekly = flags.keepWeekly
+	}
+	if flags.keepMonthly >= 0 {
+		cfg.Retention.KeepMonthly = flags.keepMonthly
+	}
+}
+
+// runPrune implements "gitlab-backup prune": it deletes the archives that fall
+// outside the retention policy, or only lists them with --dry-run.
+// It returns the process exit code.
+func runPrune(args []string) int {
+	fs := flag.NewFlagSet(pruneCommand, flag.ExitOnError)
+	configFile := fs.String("config", "", "Path to configuration file (YAML)")
+	fs.StringVar(configFile, "c", "", "Path to configuration file (YAML) (shorthand)")
+	output := fs.String("output", "", "Output directory for local storage")
+	dryRun := fs.Bool("dry-run", false, "Only list the archives that would be deleted")
+	keepLast := fs.Int("keep-last", -1, "Keep the N most recent archives of each project")
+	keepDaily := fs.Int("keep-daily", -1, "Keep the newest archive of each of the last N days")
+	keepWeekly := fs.Int("keep-weekly", -1, "Keep the newest archive of each of the last N weeks")
+	keepMonthly := fs.Int("keep-monthly", -1, "Keep the newest archive of each of the last N months")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gitlab-backup prune [OPTIONS]\n\n")
+		fmt.Fprintf(os.Stderr, "Delete stored archives that fall outside the retention policy\n\n")
+		fmt.Fprintf(os.Stderr, "OPTIONS:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
+		fmt.Fprintf(os.Stderr, "  # Show what the configured policy would delete\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup prune -c config.yaml --dry-run\n\n")
+		fmt.Fprintf(os.Stderr, "  # Keep 7 daily and 4 weekly archives per project\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup prune --output /backup --keep-daily 7 --keep-weekly 4\n\n")
+	}
+	_ = fs.Parse(args) // ExitOnError: Parse exits on failure
+
+	cfg := loadConfiguration(*configFile)
+	applyPruneOverrides(cfg, pruneFlags{
+		output:      *output,
+		keepLast:    *keepLast,
+		keepDaily:   *keepDaily,
+		keepWeekly:  *keepWeekly,
+		keepMonthly: *keepMonthly,
+	})
+
+	if err := cfg.ValidateForPrune(); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration validation failed: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	l := initTrace(os.Getenv("DEBUGLEVEL"), cfg.NoLogTime)
+
+	store, err := app.NewStorage(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if err := app.NewAppWithService(cfg, nil, store, l).Prune(ctx, *dryRun); err != nil {
+		l.Error("error(s) occurred", "error", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPruneOverrides(t *testing.T) {
+	unset := pruneFlags{keepLast: -1, keepDaily: -1, keepWeekly: -1, keepMonthly: -1}
+
+	t.Run("unset flags keep config values", func(t *testing.T) {
+		cfg := &config.Config{
+			LocalPath: "/backup",
+			Retention: config.RetentionConfig{KeepLast: 3, KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 12},
+		}
+		applyPruneOverrides(cfg, unset)
+		assert.Equal(t, "/backup", cfg.LocalPath)
+		assert.Equal(t, config.RetentionConfig{KeepLast: 3, KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 12}, cfg.Retention)
+	})
+
+	t.Run("flags override config", func(t *testing.T) {
+		cfg := &config.Config{Retention: config.RetentionConfig{KeepLast: 3, KeepDaily: 7}}
+		flags := unset
+		flags.output = "/other"
+		flags.keepDaily = 0
+		flags.keepWeekly = 4
+		applyPruneOverrides(cfg, flags)
+		assert.Equal(t, "/other", cfg.LocalPath)
+		assert.Equal(t, config.RetentionConfig{KeepLast: 3, KeepWeekly: 4}, cfg.Retention)
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app"
+)
+
+// verifyCommand is the subcommand name that checks stored archives against their checksums.
+const verifyCommand = "verify"
+
+// runVerify implements "gitlab-backup verify": it re-reads the stored archives
+// and compares them with their checksum sidecars and run manifests. It returns
+// the process exit code, non-zero when an archive is missing, truncated or
+// corrupted.
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet(verifyCommand, flag.ExitOnError)
+	configFile := fs.String("config", "", "Path to configuration file (YAML)")
+	fs.StringVar(configFile, "c", "", "Path to configuration file (YAML) (shorthand)")
+	output := fs.String("output", "", "Output directory for local storage")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gitlab-backup verify [OPTIONS]\n\n")
+		fmt.Fprintf(os.Stderr, "Check stored archives against their checksum sidecars and run manifests\n\n")
+		fmt.Fprintf(os.Stderr, "OPTIONS:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
+		fmt.Fprintf(os.Stderr, "  # Verify the archives of the configured storage\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup verify -c config.yaml\n\n")
+		fmt.Fprintf(os.Stderr, "  # Verify a local backup directory\n")
+		fmt.Fprintf(os.Stderr, "  gitlab-backup verify --output /backup\n\n")
+	}
+	_ = fs.Parse(args) // ExitOnError: Parse exits on failure
+
+	cfg := loadConfiguration(*configFile)
+	if *output != "" {
+		cfg.LocalPath = *output
+	}
+	if err := cfg.ValidateForVerify(); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration validation failed: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	l := initTrace(os.Getenv("DEBUGLEVEL"), cfg.NoLogTime)
+
+	store, err := app.NewStorage(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if _, err := app.NewAppWithService(cfg, nil, store, l).Verify(ctx); err != nil {
+		l.Error("error(s) occurred", "error", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunVerify_ExitCode(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("NOLOGTIME", "true")
+	archive := filepath.Join(dir, "app-1.tar.gz")
+	require.NoError(t, os.WriteFile(archive, []byte("test"), 0o600))
+	// sha256 of "test"
+	require.NoError(t, os.WriteFile(archive+".sha256",
+		[]byte("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  app-1.tar.gz\n"), 0o600))
+
+	assert.Equal(t, 0, runVerify([]string{"--output", dir}))
+
+	require.NoError(t, os.WriteFile(archive, []byte("tes"), 0o600))
+	assert.Equal(t, 1, runVerify([]string{"--output", dir}), "corruption must fail the command")
+
+	assert.Equal(t, 1, runVerify([]string{"--output", filepath.Join(dir, "missing")}))
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app/restore"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+)
+
+var (
+	errBulkConflict       = errors.New("--bulk cannot be used with --archive, --group-archive or --project")
+	errMappingConflict    = errors.New("--mapping replaces --namespace: use only one of them")
+	errMappingWithoutBulk = errors.New("--mapping requires --bulk")
+	errBulkOutsideRoot    = errors.New("--bulk must be inside the configured localpath")
+	errBulkBucket         = errors.New("--bulk bucket differs from the configured bucketName")
+)
+
+// validateAndLoadBulkConfig validates the flags of a bulk restore and loads
+// the configuration. It returns the bulk source as a prefix relative to the
+// storage root, against which the run manifests record the archive keys: the
+// configured localpath (the --bulk directory itself when none is configured),
+// or the configured bucket path for an s3://bucket/prefix source, as for
+// --archive. The targets are given by either --namespace or --mapping.
+func validateAndLoadBulkConfig(f *restoreFlags) (*config.Config, string, error) {
+	switch {
+	case f.mapping != "" && f.namespace != "":
+		return nil, "", errMappingConflict
+	case f.mapping == "" && f.namespace == "":
+		return nil, "", errNamespaceRequired
+	}
+	cfg, err := loadConfig(f)
+	if err != nil {
+		return nil, "", err
+	}
+	source := f.bulk
+	cfg.RestoreSource = source
+	cfg.RestoreTargetNS = strings.Trim(f.namespace, "/")
+	cfg.RestoreMappingFile = f.mapping
+	if f.concurrency > 0 {
+		cfg.MaxConcurrency = f.concurrency
+	}
+
+	var prefix string
+	if location, ok := strings.CutPrefix(source, "s3://"); ok {
+		cfg.StorageType = "s3"
+		prefix, err = s3BulkPrefix(cfg, location)
+	} else {
+		cfg.StorageType = "local"
+		prefix, err = localBulkPrefix(cfg, source)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := cfg.ValidateForRestore(); err != nil {
+		return nil, "", fmt.Errorf("configuration validation failed: %w", err)
+	}
+	return cfg, prefix, nil
+}
+
+// s3BulkPrefix returns the prefix of the bulk source bucket/prefix. The
+// bucket is the configured one, which the source must name; it is taken from
+// the source when none is configured.
+func s3BulkPrefix(cfg *config.Config, location string) (string, error) {
+	bucket, prefix, _ := strings.Cut(location, "/")
+	switch cfg.S3cfg.BucketName {
+	case "":
+		cfg.S3cfg.BucketName = bucket
+	case bucket:
+	default:
+		return "", fmt.Errorf("%w: %s is not %s", errBulkBucket, bucket, cfg.S3cfg.BucketName)
+	}
+	return prefix, nil
+}
+
+// localBulkPrefix returns the prefix of the local bulk source, a directory
+// or a run manifest, relative to the configured localpath. Without a
+// configured localpath, the source directory (the directory of a run
+// manifest) is the storage root.
+func localBulkPrefix(cfg *config.Config, source string) (string, error) {
+	info, err := os.Stat(source)
+	isFile := err == nil && !info.IsDir()
+	if cfg.LocalPath == "" {
+		cfg.LocalPath = source
+		if isFile {
+			cfg.LocalPath = filepath.Dir(source)
+			return filepath.Base(source), nil
+		}
+		return "", nil
+	}
+
+	root, err := filepath.Abs(cfg.LocalPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid localpath %s: %w", cfg.LocalPath, err)
+	}
+	path, err := filepath.Abs(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid --bulk %s: %w", source, err)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is not in %s", errBulkOutsideRoot, source, cfg.LocalPath)
+	}
+	switch {
+	case rel == ".":
+		return "", nil
+	case isFile:
+		return filepath.ToSlash(rel), nil
+	default:
+		// The trailing slash keeps run1/ from matching run10/.
+		return filepath.ToSlash(rel) + "/", nil
+	}
+}
+
+// runBulkRestore restores every project of the bulk source prefix and prints
+// the aggregated result. It returns the process exit code, non-zero when a
+// project failed.
+func runBulkRestore(ctx context.Context, orchestrator *restore.Orchestrator, cfg *config.Config, prefix string) int {
+	result, err := orchestrator.RestoreBulk(ctx, cfg, prefix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error during bulk restore: %v\n", redactCredentials(err.Error(), cfg))
+		return 1
+	}
+	printBulkResult(result, cfg)
+	if !result.Success {
+		return 1
+	}
+	return 0
+}
+
+// printBulkResult displays the outcome of every project of a bulk restore,
+// then the totals.
+func printBulkResult(result *restore.BulkResult, cfg *config.Config) {
+	fmt.Println("\n" + strings.Repeat("=", constants.SeparatorWidth))
+	if result.Success {
+		fmt.Println("✓ BULK RESTORE SUCCESSFUL")
+	} else {
+		fmt.Println("✗ BULK RESTORE FAILED")
+	}
+	fmt.Println(strings.Repeat("=", constants.SeparatorWidth))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0) //nolint:mnd // column padding
+	fmt.Fprintln(tw, "\nSTATUS\tSOURCE\tTARGET\tDETAIL")
+	for _, p := range result.Projects {
+		status, detail := "ok", p.Result.ProjectURL
+		switch v := p.Result.Verification; {
+		case !p.Result.Success:
+			status, detail = "failed", bulkFailure(p.Result)
+		case v != nil && !v.Complete():
+			status, detail = "incomplete", strings.Join(v.Differences, "; ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s/%s\t%s\n", status, p.Item.SourcePath, p.Item.TargetNS, p.Item.TargetPath,
+			redactCredentials(detail, cfg))
+	}
+	_ = tw.Flush()
+
+	fmt.Printf("\n%d restored, %d failed in %ds\n", result.Restored, result.Failed, result.DurationSeconds)
+	printCreatedGroups(result.CreatedGroups)
+	printOverwrites(result.Projects)
+	if len(result.Warnings) > 0 {
+		fmt.Println("\nWarnings:")
+		for _, warning := range result.Warnings {
+			fmt.Printf("  %s\n", redactCredentials(warning, cfg))
+		}
+	}
+	fmt.Println(strings.Repeat("=", constants.SeparatorWidth))
+}
+
+// printOverwrites lists the projects replaced by a bulk restore.
+func printOverwrites(projects []restore.BulkProjectResult) {
+	header := false
+	for _, p := range projects {
+		if p.Result.Overwrite == nil {
+			continue
+		}
+		if !header {
+			fmt.Println("\nOverwritten projects:")
+			header = true
+		}
+		fmt.Printf("  %s\n", p.Result.Overwrite)
+	}
+}
+
+// bulkFailure returns the first fatal error of a failed project restore.
+func bulkFailure(result *restore.Result) string {
+	for _, e := range result.Errors {
+		if e.Fatal {
+			return fmt.Sprintf("[%s] %s: %s", e.Phase, e.Component, e.Message)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app/restore"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+	"github.com/sgaunet/gitlab-backup/pkg/storage/localstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backupRoot returns a backup root holding the archive run1/app.tar.gz, and
+// the run manifest recording it at the root, as gitlab-backup stores them.
+func backupRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "run1"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "run1", "app.tar.gz"), []byte("archive"), 0o600))
+	m := manifest.Manifest{Version: manifest.FormatVersion, RunID: "20261016T030000Z", Projects: []manifest.Project{
+		{ID: 1, FullPath: "acme/app", Status: manifest.StatusSuccess, ArchiveKey: "run1/app.tar.gz"},
+	}}
+	data, err := m.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, manifest.Key(m.RunID)), data, 0o600))
+	return root
+}
+
+func TestLocalBulkPrefix_Subdirectory(t *testing.T) {
+	root := backupRoot(t)
+	cfg := &config.Config{LocalPath: root}
+
+	prefix, err := localBulkPrefix(cfg, filepath.Join(root, "run1"))
+	require.NoError(t, err)
+	assert.Equal(t, "run1/", prefix)
+	assert.Equal(t, root, cfg.LocalPath, "the keys of the manifest are relative to the root")
+
+	catalog := &localStorageAdapter{LocalStorage: localstorage.NewLocalStorage(cfg.LocalPath), root: cfg.LocalPath}
+	plan, err := restore.PlanBulk(context.Background(), catalog, prefix, "dr", nil)
+	require.NoError(t, err)
+	require.Len(t, plan.Items, 1)
+	assert.Equal(t, "run1/app.tar.gz", plan.Items[0].ArchiveKey)
+	assert.Equal(t, filepath.Join(root, "run1", "app.tar.gz"), catalog.Source(plan.Items[0].ArchiveKey))
+}
+
+func TestLocalBulkPrefix(t *testing.T) {
+	root := backupRoot(t)
+	manifestPath := filepath.Join(root, manifest.Key("20261016T030000Z"))
+
+	prefix, err := localBulkPrefix(&config.Config{LocalPath: root}, root)
+	require.NoError(t, err)
+	assert.Empty(t, prefix)
+
+	prefix, err = localBulkPrefix(&config.Config{LocalPath: root}, manifestPath)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Key("20261016T030000Z"), prefix)
+
+	_, err = localBulkPrefix(&config.Config{LocalPath: filepath.Join(root, "run1")}, root)
+	require.ErrorIs(t, err, errBulkOutsideRoot)
+
+	// Without a configured localpath, the source is the storage root.
+	cfg := &config.Config{}
+	prefix, err = localBulkPrefix(cfg, manifestPath)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Key("20261016T030000Z"), prefix)
+	assert.Equal(t, root, cfg.LocalPath)
+}
+
+func TestS3BulkPrefix(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.S3cfg.BucketName = "backups"
+	prefix, err := s3BulkPrefix(cfg, "backups/run1/")
+	require.NoError(t, err)
+	assert.Equal(t, "run1/", prefix)
+
+	_, err = s3BulkPrefix(cfg, "other/run1/")
+	require.ErrorIs(t, err, errBulkBucket)
+
+	cfg.S3cfg.BucketName = ""
+	prefix, err = s3BulkPrefix(cfg, "other/manifest-20261016T030000Z.json")
+	require.NoError(t, err)
+	assert.Equal(t, "manifest-20261016T030000Z.json", prefix)
+	assert.Equal(t, "other", cfg.S3cfg.BucketName, "the bucket is taken from the source")
+}
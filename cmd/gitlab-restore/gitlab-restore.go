@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 
@@ -23,26 +24,119 @@ var (
 	version = "development" // Set by GoReleaser ldflags
 )
 
-//nolint:funlen // Main function complexity is acceptable
-func main() {
-	// Define flags
-	configFile := flag.String("config", "", "Path to configuration file (YAML). Optional if using environment variables.")
-	archive := flag.String("archive", "", "Archive path (local path or s3://bucket/key)")
-	namespace := flag.String("namespace", "", "Target GitLab namespace/group")
-	project := flag.String("project", "", "Target GitLab project name")
-	overwrite := flag.Bool("overwrite", false, "Overwrite existing project content (use with caution)")
-	showVersion := flag.Bool("version", false, "Show version and exit")
+// restoreFlags holds the command-line flags of gitlab-restore.
+type restoreFlags struct {
+	configFile      string
+	archive         string
+	groupArchive    string
+	namespace       string
+	project         string
+	overwrite       bool
+	overwriteMode   string
+	confirmDelete   bool
+	identity        string
+	quickValidation bool
+	skipVerify      bool
+	bulk            string
+	concurrency     int
+	groupVisibility string
+	mapping         string
+	overrides       overrideFlags
+	showVersion     bool
+}
+
+// overrideFlags collects the repeated --override name=value flags.
+type overrideFlags []string
+
+func (o *overrideFlags) String() string { return strings.Join(*o, ",") }
 
+func (o *overrideFlags) Set(value string) error {
+	*o = append(*o, value)
+	return nil
+}
+
+// parseFlags defines and parses the command-line flags.
+func parseFlags() *restoreFlags {
+	f := &restoreFlags{}
+	flag.StringVar(&f.configFile, "config", "",
+		"Path to configuration file (YAML). Optional if using environment variables.")
+	flag.StringVar(&f.archive, "archive", "", "Archive path (local path or s3://bucket/key)")
+	flag.StringVar(&f.groupArchive, "group-archive", "",
+		"Group archive to rebuild the target namespace from before the project import (same storage as --archive)")
+	flag.StringVar(&f.namespace, "namespace", "", "Target GitLab namespace/group")
+	flag.StringVar(&f.project, "project", "", "Target GitLab project name")
+	flag.BoolVar(&f.overwrite, "overwrite", false,
+		"Replace the project at the target path, see --overwrite-mode (use with caution)")
+	flag.StringVar(&f.overwriteMode, "overwrite-mode", "",
+		"How --overwrite replaces the project: import (GitLab import overwrite, default), "+
+			"rename (keep it as <path>-pre-restore-<timestamp>) or delete (rename, then delete it); implies --overwrite")
+	flag.BoolVar(&f.confirmDelete, "confirm-delete", false,
+		"Confirm the deletion of the replaced project by --overwrite-mode delete")
+	flag.StringVar(&f.identity, "identity", "",
+		"age identity file (age-keygen output or SSH private key) to decrypt encrypted archives (env: AGE_IDENTITY_FILE)")
+	flag.BoolVar(&f.quickValidation, "quick-validation", false,
+		"Only check the archive headers instead of reading the whole archives before upload")
+	flag.BoolVar(&f.skipVerify, "skip-verification", false,
+		"Do not compare the restored project with the archive after the import")
+	flag.StringVar(&f.bulk, "bulk", "",
+		"Restore every project of a backup directory, s3://bucket/prefix or run manifest into --namespace")
+	flag.IntVar(&f.concurrency, "concurrency", 0,
+		"Maximum number of projects imported in parallel by --bulk (default: 4)")
+	flag.StringVar(&f.groupVisibility, "group-visibility", "",
+		"Visibility of the groups created for a missing namespace: private, internal or public "+
+			"(default: as backed up, else private)")
+	flag.StringVar(&f.mapping, "mapping", "",
+		"Namespace mapping file giving the target of each project of --bulk from its source path (replaces --namespace)")
+	flag.Var(&f.overrides, "override",
+		"Project setting replacing the one of the archive, as name=value (e.g. visibility=private, "+
+			"wikiAccessLevel=disabled); repeatable, overrides restore.overrides of the config file")
+	flag.BoolVar(&f.showVersion, "version", false, "Show version and exit")
 	flag.Parse()
+	return f
+}
+
+// loadRestoreConfig validates the flags of a single or a bulk restore and
+// loads the configuration. The bulk source prefix is empty for a single restore.
+func loadRestoreConfig(f *restoreFlags) (*config.Config, string, error) {
+	if f.bulk != "" {
+		if f.archive != "" || f.groupArchive != "" || f.project != "" {
+			return nil, "", errBulkConflict
+		}
+		cfg, prefix, err := validateAndLoadBulkConfig(f)
+		if err != nil {
+			return nil, "", err
+		}
+		cfg.RestoreQuickValidation = f.quickValidation
+		cfg.RestoreSkipVerification = f.skipVerify
+		return cfg, prefix, nil
+	}
+	if f.mapping != "" {
+		return nil, "", errMappingWithoutBulk
+	}
+	cfg, err := validateAndLoadConfig(f)
+	if err == nil {
+		err = applyGroupArchive(cfg, f.archive, f.groupArchive)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	cfg.RestoreQuickValidation = f.quickValidation
+	cfg.RestoreSkipVerification = f.skipVerify
+	return cfg, "", nil
+}
+
+//nolint:funlen // Main function complexity is acceptable
+func main() {
+	flags := parseFlags()
 
 	// Handle version flag
-	if *showVersion {
+	if flags.showVersion {
 		fmt.Printf("gitlab-restore version %s\n", version)
 		os.Exit(0)
 	}
 
 	// Validate and load configuration
-	cfg, err := validateAndLoadConfig(*configFile, *archive, *namespace, *project, *overwrite)
+	cfg, bulkPrefix, err := loadRestoreConfig(flags)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -81,6 +175,11 @@ func main() {
 
 	// Create restore orchestrator
 	orchestrator := restore.NewOrchestrator(gitlabClient, storage, cfg)
+	if flags.bulk != "" {
+		code := runBulkRestore(ctx, orchestrator, cfg, bulkPrefix)
+		cancel()
+		os.Exit(code) //nolint:gocritic // cancel was called above
+	}
 
 	// Execute restore
 	result, err := orchestrator.Restore(ctx, cfg)
@@ -102,51 +201,38 @@ var (
 	errArchiveRequired   = errors.New("--archive flag is required")
 	errNamespaceRequired = errors.New("--namespace flag is required")
 	errProjectRequired   = errors.New("--project flag is required")
+	errOverrideFormat    = errors.New("--override must be name=value")
 )
 
 // validateAndLoadConfig validates required flags and loads configuration.
 // Configuration can be loaded from a YAML file (--config) or from environment variables.
-func validateAndLoadConfig(configFile, archive, namespace, project string, overwrite bool) (*config.Config, error) {
+func validateAndLoadConfig(f *restoreFlags) (*config.Config, error) {
 	// Validate required restore flags
-	if archive == "" {
+	if f.archive == "" {
 		flag.Usage()
 		return nil, errArchiveRequired
 	}
-	if namespace == "" {
+	if f.namespace == "" {
 		flag.Usage()
 		return nil, errNamespaceRequired
 	}
-	if project == "" {
+	if f.project == "" {
 		flag.Usage()
 		return nil, errProjectRequired
 	}
 
-	// Load configuration from file or environment
-	var cfg *config.Config
-	var err error
-
-	if configFile != "" {
-		// Load from config file if provided
-		cfg, err = config.NewConfigFromFile(configFile)
-		if err != nil {
-			return nil, fmt.Errorf("loading configuration from file: %w", err)
-		}
-	} else {
-		// Load from environment variables if no config file
-		cfg, err = config.NewConfigFromEnv()
-		if err != nil {
-			return nil, fmt.Errorf("loading configuration from environment: %w", err)
-		}
+	cfg, err := loadConfig(f)
+	if err != nil {
+		return nil, err
 	}
 
 	// Override config with CLI flags
-	cfg.RestoreSource = archive
-	cfg.RestoreTargetNS = namespace
-	cfg.RestoreTargetPath = project
-	cfg.RestoreOverwrite = overwrite
+	cfg.RestoreSource = f.archive
+	cfg.RestoreTargetNS = f.namespace
+	cfg.RestoreTargetPath = f.project
 
 	// Determine storage type from archive path
-	if strings.HasPrefix(archive, "s3://") {
+	if strings.HasPrefix(f.archive, "s3://") {
 		cfg.StorageType = "s3"
 	} else {
 		cfg.StorageType = "local"
@@ -160,6 +246,59 @@ func validateAndLoadConfig(configFile, archive, namespace, project string, overw
 	return cfg, nil
 }
 
+// loadConfig loads the configuration from a YAML file (--config) or, without
+// one, from environment variables, then applies the flags shared by single
+// and bulk restores.
+func loadConfig(f *restoreFlags) (*config.Config, error) {
+	var (
+		cfg *config.Config
+		err error
+	)
+	if f.configFile != "" {
+		cfg, err = config.NewConfigFromFile(f.configFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading configuration from file: %w", err)
+		}
+	} else {
+		cfg, err = config.NewConfigFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("loading configuration from environment: %w", err)
+		}
+	}
+	cfg.RestoreOverwrite = f.overwrite || f.overwriteMode != ""
+	cfg.RestoreOverwriteMode = f.overwriteMode
+	cfg.RestoreConfirmDelete = f.confirmDelete
+	cfg.RestoreGroupVisibility = f.groupVisibility
+	if f.identity != "" {
+		cfg.Age.IdentityFile = f.identity
+	}
+	for _, override := range f.overrides {
+		name, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", errOverrideFormat, override)
+		}
+		if err := cfg.Restore.Overrides.Set(name, value); err != nil {
+			return nil, fmt.Errorf("--override: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+var errGroupArchiveStorage = errors.New("--group-archive must use the same storage (local or s3://) as --archive")
+
+// applyGroupArchive sets the group archive to import before the project. It
+// is read from the same storage backend as the project archive.
+func applyGroupArchive(cfg *config.Config, archive, groupArchive string) error {
+	if groupArchive == "" {
+		return nil
+	}
+	if strings.HasPrefix(groupArchive, "s3://") != strings.HasPrefix(archive, "s3://") {
+		return errGroupArchiveStorage
+	}
+	cfg.RestoreGroupSource = groupArchive
+	return nil
+}
+
 // initializeStorage creates the appropriate storage backend.
 // The context is used for S3 client initialization and may respect timeout/cancellation.
 func initializeStorage(ctx context.Context, cfg *config.Config) (restore.Storage, error) {
@@ -171,7 +310,7 @@ func initializeStorage(ctx context.Context, cfg *config.Config) (restore.Storage
 		}
 		return &s3StorageAdapter{s3Store}, nil
 	}
-	return &localStorageAdapter{localstorage.NewLocalStorage(cfg.LocalPath)}, nil
+	return &localStorageAdapter{LocalStorage: localstorage.NewLocalStorage(cfg.LocalPath), root: cfg.LocalPath}, nil
 }
 
 // redactCredentials removes sensitive information from error messages.
@@ -233,9 +372,20 @@ func (a *s3StorageAdapter) Get(ctx context.Context, key string) (string, error)
 	return tempFile.Name(), nil
 }
 
+// Source returns the key: the S3 storage downloads it, see Get.
+func (a *s3StorageAdapter) Source(key string) string {
+	return key
+}
+
 // localStorageAdapter adapts LocalStorage to the restore.Storage interface.
 type localStorageAdapter struct {
 	*localstorage.LocalStorage
+	root string
+}
+
+// Source returns the path of the archive stored under key.
+func (a *localStorageAdapter) Source(key string) string {
+	return filepath.Join(a.root, filepath.FromSlash(key))
 }
 
 // Get returns the local file path (already local, no download needed).
@@ -244,21 +394,41 @@ func (a *localStorageAdapter) Get(_ context.Context, key string) (string, error)
 	return key, nil
 }
 
-// printRestoreResult displays the final restore outcome.
-func printRestoreResult(result *restore.Result, cfg *config.Config) {
+// printRestoreOutcome displays whether the restore succeeded, and whether
+// the project it replaced was left behind.
+func printRestoreOutcome(result *restore.Result) {
 	fmt.Println("\n" + strings.Repeat("=", constants.SeparatorWidth))
-	if result.Success {
+	switch {
+	case result.Success && result.Overwrite != nil && result.Overwrite.Partial():
+		fmt.Println("✓ RESTORE SUCCESSFUL (partial overwrite: the replaced project was not deleted)")
+	case result.Success:
 		fmt.Println("✓ RESTORE SUCCESSFUL")
-	} else {
+	default:
 		fmt.Println("✗ RESTORE FAILED")
 	}
 	fmt.Println(strings.Repeat("=", constants.SeparatorWidth))
+}
+
+// printRestoreResult displays the final restore outcome.
+func printRestoreResult(result *restore.Result, cfg *config.Config) {
+	printRestoreOutcome(result)
+
+	// Print group information
+	if result.GroupURL != "" {
+		fmt.Printf("\nGroup ID: %d\n", result.GroupID)
+		fmt.Printf("Group URL: %s\n", redactCredentials(result.GroupURL, cfg))
+	}
+	printCreatedGroups(result.CreatedGroups)
 
 	// Print project information
 	if result.ProjectID != 0 {
 		fmt.Printf("\nProject ID: %d\n", result.ProjectID)
 		fmt.Printf("Project URL: %s\n", redactCredentials(result.ProjectURL, cfg))
 	}
+	if result.Overwrite != nil {
+		fmt.Printf("\nOverwrite: %s\n", result.Overwrite)
+	}
+	printVerification(result.Verification)
 
 	// Print metrics
 	fmt.Println("\nMetrics:")
@@ -293,3 +463,33 @@ func printRestoreResult(result *restore.Result, cfg *config.Config) {
 
 	fmt.Println(strings.Repeat("=", constants.SeparatorWidth))
 }
+
+// printVerification displays the restored project compared with the archive.
+// The differences are listed with the errors.
+func printVerification(v *restore.Verification) {
+	if v == nil {
+		return
+	}
+	if v.Complete() {
+		fmt.Println("\nVerification: the restored project matches the archive")
+	} else {
+		fmt.Printf("\nVerification: %d difference(s) with the archive, see the errors below\n", len(v.Differences))
+	}
+	for _, c := range v.Counts {
+		fmt.Printf("  %-15s %d/%d\n", c.Resource+":", c.Restored, c.Archive)
+	}
+	if v.DefaultBranch != "" {
+		fmt.Printf("  %-15s %d commits, at %s\n", v.DefaultBranch+":", v.Commits, v.RestoredHead)
+	}
+}
+
+// printCreatedGroups lists the groups created for missing namespaces.
+func printCreatedGroups(groups []string) {
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Println("\nCreated groups:")
+	for _, group := range groups {
+		fmt.Printf("  %s\n", group)
+	}
+}
@@ -12,7 +12,8 @@
 //   - Exports projects using GitLab Export API
 //   - Stores archives to local or S3 storage
 //   - Executes pre/post backup hooks
-//   - Supports concurrent group exports
+//   - Supports concurrent group exports (bounded worker pool and TmpDir budget)
+//   - Backs up a targets list (groups, projects, user namespaces) in one run
 //
 // 2. Restore (restore subpackage):
 //   - Validates target project is empty
@@ -63,12 +64,18 @@ import (
 
 	"filippo.io/age"
 	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
 	"github.com/sgaunet/gitlab-backup/pkg/encryption"
 	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
 	"github.com/sgaunet/gitlab-backup/pkg/storage"
 	"github.com/sgaunet/gitlab-backup/pkg/storage/localstorage"
 	"github.com/sgaunet/gitlab-backup/pkg/storage/s3storage"
-	"golang.org/x/sync/errgroup"
+)
+
+// Output formats accepted by Plan.Write and Inspection.Write.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
 )
 
 var (
@@ -78,6 +85,12 @@ var (
 	ErrBackupErrors = errors.New("errors occurred during backup")
 	// ErrNotDirectory is returned when a path is not a directory.
 	ErrNotDirectory = errors.New("path is not a directory")
+	// ErrProjectArchived is returned when exporting an archived project while
+	// includeArchived is not set.
+	ErrProjectArchived = errors.New("project is archived, set includeArchived to back it up")
+	// ErrInvalidFormat is returned when a plan or an inspection is written in
+	// an unknown output format.
+	ErrInvalidFormat = errors.New("invalid output format")
 	// ErrGitlabClientInit is returned when the GitLab client cannot be initialized.
 	ErrGitlabClientInit = errors.New("failed to initialize gitlab client")
 )
@@ -88,6 +101,7 @@ type App struct {
 	gitlabService gitlab.BackupService
 	storage       storage.Storage
 	log           Logger
+	version       string
 }
 
 // Logger interface defines the logging methods used by the application.
@@ -128,27 +142,40 @@ func NewApp(ctx context.Context, cfg *config.Config, log Logger) (*App, error) {
 		gitlabService: gitlabService,
 		log:           log,
 	}
+	app.storage, err = NewStorage(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// NewStorage builds the storage backend selected by cfg: S3 when the S3
+// configuration is valid, the local directory otherwise.
+//
+//nolint:ireturn // the backend is chosen at runtime
+func NewStorage(ctx context.Context, cfg *config.Config) (storage.Storage, error) {
 	if cfg.IsS3ConfigValid() {
-		app.storage, err = s3storage.NewS3Storage(
+		s3, err := s3storage.NewS3Storage(
 			ctx,
 			cfg.S3cfg.Region,
 			cfg.S3cfg.Endpoint,
 			cfg.S3cfg.BucketName,
 			cfg.S3cfg.BucketPath,
+			s3storage.WithPartSize(int64(cfg.S3cfg.PartSizeMB)*constants.MB),
+			s3storage.WithUploadConcurrency(cfg.S3cfg.UploadConcurrency),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error occurred during s3 storage creation: %w", err)
 		}
-	} else {
-		if len(cfg.LocalPath) == 0 {
-			return nil, ErrNoStorageDefined
-		}
-		app.storage = localstorage.NewLocalStorage(cfg.LocalPath)
-		if stat, err := os.Stat(cfg.LocalPath); err != nil || !stat.IsDir() {
-			return nil, fmt.Errorf("%s: %w", cfg.LocalPath, ErrNotDirectory)
-		}
+		return s3, nil
 	}
-	return app, nil
+	if len(cfg.LocalPath) == 0 {
+		return nil, ErrNoStorageDefined
+	}
+	if stat, err := os.Stat(cfg.LocalPath); err != nil || !stat.IsDir() {
+		return nil, fmt.Errorf("%s: %w", cfg.LocalPath, ErrNotDirectory)
+	}
+	return localstorage.NewLocalStorage(cfg.LocalPath), nil
 }
 
 // NewAppWithService builds an App from already-constructed dependencies. Unlike
@@ -174,17 +201,55 @@ func (a *App) SetLogger(l Logger) {
 	gitlab.SetLogger(l)
 }
 
+// SetVersion sets the tool version recorded in run manifests.
+func (a *App) SetVersion(version string) {
+	a.version = version
+}
+
 // Run runs the app.
 func (a *App) Run(ctx context.Context) error {
+	if len(a.cfg.Targets) > 0 {
+		return a.ExportTargets(ctx)
+	}
 	if a.cfg.GitlabGroupID != 0 {
 		return a.ExportGroup(ctx)
 	}
 	if a.cfg.GitlabProjectID != 0 {
-		return a.ExportProject(ctx, a.cfg.GitlabProjectID)
+		return a.runProject(ctx, a.cfg.GitlabProjectID)
 	}
 	return nil
 }
 
+// runProject exports a single project as a complete run, i.e. followed by its run manifest.
+func (a *App) runProject(ctx context.Context, projectID int64) error {
+	summary := newBackupSummary()
+	start := time.Now()
+	project, archive, err := a.exportProject(ctx, projectID, nil, nil, summary.startTime)
+	switch {
+	case errors.Is(err, ErrProjectArchived):
+		a.log.Info("project is archived, skip", "project name", project.Name)
+		summary.recordSkipped(project)
+		err = nil
+	case err != nil:
+		if project.ID == 0 {
+			project.ID = projectID
+		}
+		summary.recordFailure(project, err, time.Since(start))
+	default:
+		summary.recordSuccess(project, archive, time.Since(start))
+	}
+	if mErr := a.writeManifest(ctx, summary); mErr != nil {
+		a.log.Error("failed to write run manifest", "error", mErr)
+		if err == nil {
+			return mErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return a.pruneAfterBackup(ctx, summary)
+}
+
 // SetGitlabEndpoint sets the gitlab endpoint.
 func (a *App) SetGitlabEndpoint(gitlabAPIEndpoint string) {
 	a.gitlabService.SetGitlabEndpoint(gitlabAPIEndpoint)
@@ -197,34 +262,27 @@ func (a *App) SetToken(token string) {
 
 // ExportGroup will export all projects of the group.
 func (a *App) ExportGroup(ctx context.Context) error {
-	projects, err := a.gitlabService.GetProjectsOfGroup(ctx, a.cfg.GitlabGroupID)
+	group := &config.TargetConfig{Group: a.cfg.GitlabGroupID}
+	projects, err := a.gitlabService.GetProjectsOfGroup(ctx, a.cfg.GitlabGroupID,
+		gitlab.ProjectListOptions{IncludeArchived: a.includeArchived(group)})
 	if err != nil {
 		return fmt.Errorf("failed to get projects of group %d: %w", a.cfg.GitlabGroupID, err)
 	}
-	summary := newBackupSummary()
-	eg := errgroup.Group{}
-	for project := range projects {
-		if !projects[project].Archived {
-			eg.Go(func() error {
-				start := time.Now()
-				err := a.ExportProject(ctx, projects[project].ID)
-				elapsed := time.Since(start)
-				if err != nil {
-					a.log.Error("error occurred during backup", "project name", projects[project].Name, "error", err.Error())
-					summary.recordFailure(projects[project].Name, err, elapsed)
-				} else {
-					summary.recordSuccess(projects[project].Name, elapsed)
-				}
-				return nil
-			})
-		} else {
-			a.log.Info("project is archived, skip", "project name", projects[project].Name)
-			summary.recordSkipped(projects[project].Name)
-		}
+	run, err := a.startRun()
+	if err != nil {
+		return err
+	}
+	a.log.Info("exporting group",
+		"group", a.cfg.GitlabGroupID,
+		"projects", len(projects),
+		"maxConcurrency", resolveMaxConcurrency(a.cfg.MaxConcurrency),
+		"maxTmpSizeMB", a.cfg.MaxTmpSizeMB,
+	)
+	a.exportPlan(ctx, run, []targetProjects{{target: group, projects: projects}})
+	if err := a.finishRun(ctx, run); err != nil {
+		return err
 	}
-	_ = eg.Wait()
-	summary.printSummary(a.log)
-	if summary.hasFailures() {
+	if run.summary.hasFailures() {
 		return fmt.Errorf("%w for group %d", ErrBackupErrors, a.cfg.GitlabGroupID)
 	}
 	return nil
@@ -232,45 +290,112 @@ func (a *App) ExportGroup(ctx context.Context) error {
 
 // ExportProject exports the project of the given ID.
 func (a *App) ExportProject(ctx context.Context, projectID int64) error {
+	_, _, err := a.exportProject(ctx, projectID, nil, nil, time.Now())
+	return err
+}
+
+// exportProject exports the project of the given ID with the overrides of
+// target t (nil for the global settings), holding room for its archive in
+// budget (nil for unlimited) until the archive has left TmpDir. With
+// streamExports, the archive is streamed to the storage and never enters TmpDir.
+// The archive key is rendered from the key template for the run started at runStart.
+// It returns the project as seen by GitLab and a description of the stored archive.
+func (a *App) exportProject(
+	ctx context.Context,
+	projectID int64,
+	t *config.TargetConfig,
+	budget *tmpBudget,
+	runStart time.Time,
+) (gitlab.Project, archiveInfo, error) {
 	project, err := a.gitlabService.GetProject(ctx, projectID)
 	if err != nil {
-		return fmt.Errorf("failed to get project %d: %w", projectID, err)
+		return gitlab.Project{}, archiveInfo{}, fmt.Errorf("failed to get project %d: %w", projectID, err)
+	}
+	if project.Archived && !a.includeArchived(t) {
+		return project, archiveInfo{}, fmt.Errorf("project %s: %w", project.Name, ErrProjectArchived)
+	}
+	key, err := a.archiveKey(t, project, runStart)
+	if err != nil {
+		return project, archiveInfo{}, err
 	}
 
 	// call prebackup hook
 	if err := a.executePreBackupHook(project.Name); err != nil {
-		return err
+		return project, archiveInfo{}, err
 	}
 
+	var archive archiveInfo
+	if saver := a.streamSaver(); saver != nil {
+		archive, err = a.streamProject(ctx, saver, &project, t, key)
+	} else {
+		archive, err = a.exportProjectFile(ctx, &project, t, budget, key)
+	}
+	if err != nil {
+		return project, archiveInfo{}, err
+	}
+	if err := a.storeSidecar(ctx, archive); err != nil {
+		return project, archiveInfo{}, err
+	}
+
+	a.log.Info("project successfully exported", "project", project.Name, "key", key)
+	return project, archive, nil
+}
+
+// exportProjectFile exports project of target t to TmpDir, runs the
+// postbackup hook, encrypts the archive in place when age is configured and
+// stores it under key.
+func (a *App) exportProjectFile(
+	ctx context.Context,
+	project *gitlab.Project,
+	t *config.TargetConfig,
+	budget *tmpBudget,
+	key string,
+) (archiveInfo, error) {
+	release, err := budget.acquire(ctx, project.EstimatedSize)
+	if err != nil {
+		return archiveInfo{}, fmt.Errorf("failed to reserve tmpdir space for project %s: %w", project.Name, err)
+	}
+	defer release()
+
 	// Export GitLab archive directly as final archive
 	archivePath := fmt.Sprintf("%s%s%s-%d.tar.gz", a.cfg.TmpDir, string(os.PathSeparator), project.Name, project.ID)
-	err = a.gitlabService.ExportProject(ctx, &project, archivePath)
+	err = a.gitlabService.ExportProject(ctx, project, archivePath, a.exportOptions(t))
 	if err != nil {
-		return fmt.Errorf("failed to export project %s: %w", project.Name, err)
+		return archiveInfo{}, fmt.Errorf("failed to export project %s: %w", project.Name, err)
 	}
 
 	// call postbackup hook with archive path
 	if err := a.executePostBackupHook(archivePath); err != nil {
-		return err
+		return archiveInfo{}, err
 	}
 
 	// encrypt archive in place with age (recipient public keys), if configured
-	if err := a.encryptArchive(archivePath); err != nil {
-		return err
+	ageCfg := a.ageConfig(t)
+	if err := a.encryptArchive(archivePath, ageCfg); err != nil {
+		return archiveInfo{}, err
 	}
 
-	err = a.StoreArchive(ctx, archivePath)
+	archive, err := describeArchive(archivePath, key, ageCfg.IsEnabled())
 	if err != nil {
-		return fmt.Errorf("failed to store archive %s: %w", archivePath, err)
+		_ = os.Remove(archivePath)
+		return archiveInfo{}, err
 	}
 
-	a.log.Info("project successfully exported", "project", project.Name)
-	return nil
+	err = a.storeArchive(ctx, archivePath, key)
+	if err != nil {
+		return archiveInfo{}, fmt.Errorf("failed to store archive %s: %w", archivePath, err)
+	}
+	return archive, nil
 }
 
-// StoreArchive stores the archive.
+// StoreArchive stores the archive under its file name.
 func (a *App) StoreArchive(ctx context.Context, archiveFilePath string) error {
-	err := a.storage.SaveFile(ctx, archiveFilePath, filepath.Base(archiveFilePath))
+	return a.storeArchive(ctx, archiveFilePath, filepath.Base(archiveFilePath))
+}
+
+// storeArchive stores the archive under key and removes the local file.
+func (a *App) storeArchive(ctx context.Context, archiveFilePath, key string) error {
+	err := a.storage.SaveFile(ctx, archiveFilePath, key)
 	if removeErr := os.Remove(archiveFilePath); removeErr != nil {
 		a.log.Warn("failed to remove temporary file", "file", archiveFilePath, "error", removeErr)
 	}
@@ -304,15 +429,15 @@ func (a *App) executePostBackupHook(archivePath string) error {
 	return nil
 }
 
-// encryptArchive encrypts the archive at archivePath in place with the
-// configured age recipients. No-op when age is not configured. The archive
+// encryptArchive encrypts the archive at archivePath in place with the age
+// recipients of ageCfg. No-op when ageCfg is not enabled. The archive
 // path is preserved on success so downstream upload logic is unaffected.
-func (a *App) encryptArchive(archivePath string) error {
-	if !a.cfg.IsAgeEnabled() {
+func (a *App) encryptArchive(archivePath string, ageCfg config.AgeConfig) error {
+	if !ageCfg.IsEnabled() {
 		return nil
 	}
 
-	recipients, err := a.loadAgeRecipients()
+	recipients, err := loadAgeRecipients(ageCfg)
 	if err != nil {
 		return fmt.Errorf("age encryption: %w", err)
 	}
@@ -320,9 +445,9 @@ func (a *App) encryptArchive(archivePath string) error {
 	a.log.Info("encrypting archive with age",
 		"archivePath", archivePath,
 		"recipients", len(recipients),
-		"armor", a.cfg.Age.Armor,
+		"armor", ageCfg.Armor,
 	)
-	if encErr := encryption.EncryptFileInPlace(archivePath, recipients, a.cfg.Age.Armor); encErr != nil {
+	if encErr := encryption.EncryptFileInPlace(archivePath, recipients, ageCfg.Armor); encErr != nil {
 		return fmt.Errorf("age encryption: %w", encErr)
 	}
 	return nil
@@ -330,20 +455,20 @@ func (a *App) encryptArchive(archivePath string) error {
 
 // loadAgeRecipients merges inline recipients (Recipients) and recipients
 // loaded from RecipientsFile. Either source may be empty; the result must
-// contain at least one recipient (guarded by IsAgeEnabled at the caller).
-func (a *App) loadAgeRecipients() ([]age.Recipient, error) {
+// contain at least one recipient (guarded by IsEnabled at the caller).
+func loadAgeRecipients(ageCfg config.AgeConfig) ([]age.Recipient, error) {
 	var recipients []age.Recipient
 
-	if len(a.cfg.Age.Recipients) > 0 {
-		inline, err := encryption.ParseRecipients(a.cfg.Age.Recipients)
+	if len(ageCfg.Recipients) > 0 {
+		inline, err := encryption.ParseRecipients(ageCfg.Recipients)
 		if err != nil {
 			return nil, fmt.Errorf("inline recipients: %w", err)
 		}
 		recipients = append(recipients, inline...)
 	}
 
-	if a.cfg.Age.RecipientsFile != "" {
-		fromFile, err := encryption.ParseRecipientsFile(a.cfg.Age.RecipientsFile)
+	if ageCfg.RecipientsFile != "" {
+		fromFile, err := encryption.ParseRecipientsFile(ageCfg.RecipientsFile)
 		if err != nil {
 			return nil, fmt.Errorf("recipients file: %w", err)
 		}
@@ -2,16 +2,25 @@ package app_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/sgaunet/gitlab-backup/pkg/app"
+	"github.com/sgaunet/gitlab-backup/pkg/checkpoint"
 	"github.com/sgaunet/gitlab-backup/pkg/config"
 	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
 	gitlabMocks "github.com/sgaunet/gitlab-backup/pkg/gitlab/mocks"
 	"github.com/sgaunet/gitlab-backup/pkg/hooks"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
 	"github.com/sgaunet/gitlab-backup/pkg/storage/localstorage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,10 +30,12 @@ import (
 const testAgeRecipient = "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"
 
 // stubStorage is a minimal storage.Storage used to exercise the StoreArchive
-// error path without a real backend.
+// and prune error paths without a real backend.
 type stubStorage struct {
-	err   error
-	calls int
+	err       error
+	calls     int
+	objects   []storage.Object
+	deleteErr error
 }
 
 func (s *stubStorage) SaveFile(_ context.Context, _, _ string) error {
@@ -32,6 +43,18 @@ func (s *stubStorage) SaveFile(_ context.Context, _, _ string) error {
 	return s.err
 }
 
+func (s *stubStorage) List(_ context.Context, _ string) ([]storage.Object, error) {
+	return s.objects, nil
+}
+
+func (s *stubStorage) Delete(_ context.Context, _ string) error {
+	return s.deleteErr
+}
+
+func (s *stubStorage) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("%w: %s", storage.ErrObjectNotFound, key)
+}
+
 // baseConfig returns a config with temp TmpDir/LocalPath so archives can be
 // written and stored on the local filesystem.
 func baseConfig(t *testing.T) (*config.Config, string) {
@@ -47,9 +70,9 @@ func baseConfig(t *testing.T) (*config.Config, string) {
 
 // writeArchiveFn returns an ExportProject implementation that writes a real
 // (non-empty) archive to the requested path so StoreArchive/encryption can read it.
-func writeArchiveFn(t *testing.T) func(context.Context, *gitlab.Project, string) error {
+func writeArchiveFn(t *testing.T) func(context.Context, *gitlab.Project, string, gitlab.ExportOptions) error {
 	t.Helper()
-	return func(_ context.Context, _ *gitlab.Project, archiveFilePath string) error {
+	return func(_ context.Context, _ *gitlab.Project, archiveFilePath string, _ gitlab.ExportOptions) error {
 		return os.WriteFile(archiveFilePath, []byte("archive-bytes"), 0o600)
 	}
 }
@@ -61,7 +84,7 @@ func TestApp_Run_Dispatch(t *testing.T) {
 
 		called := false
 		svc := &gitlabMocks.BackupServiceMock{
-			GetProjectsOfGroupFunc: func(_ context.Context, groupID int64) ([]gitlab.Project, error) {
+			GetProjectsOfGroupFunc: func(_ context.Context, groupID int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
 				called = true
 				assert.Equal(t, int64(10), groupID)
 				return nil, nil
@@ -140,7 +163,7 @@ func TestApp_ExportProject_ExportError(t *testing.T) {
 		GetProjectFunc: func(_ context.Context, _ int64) (gitlab.Project, error) {
 			return gitlab.Project{ID: 7, Name: "myproj"}, nil
 		},
-		ExportProjectFunc: func(_ context.Context, _ *gitlab.Project, _ string) error {
+		ExportProjectFunc: func(_ context.Context, _ *gitlab.Project, _ string, _ gitlab.ExportOptions) error {
 			return errors.New("export failed")
 		},
 	}
@@ -258,7 +281,7 @@ func TestApp_ExportGroup_ArchivedSkipAndFailureSummary(t *testing.T) {
 	cfg.GitlabGroupID = 100
 
 	svc := &gitlabMocks.BackupServiceMock{
-		GetProjectsOfGroupFunc: func(_ context.Context, _ int64) ([]gitlab.Project, error) {
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
 			return []gitlab.Project{
 				{ID: 1, Name: "ok"},
 				{ID: 2, Name: "arch", Archived: true},
@@ -275,7 +298,7 @@ func TestApp_ExportGroup_ArchivedSkipAndFailureSummary(t *testing.T) {
 				return gitlab.Project{}, errors.New("unexpected project id")
 			}
 		},
-		ExportProjectFunc: func(_ context.Context, project *gitlab.Project, archiveFilePath string) error {
+		ExportProjectFunc: func(_ context.Context, project *gitlab.Project, archiveFilePath string, _ gitlab.ExportOptions) error {
 			if project.ID == 3 {
 				return errors.New("export exploded")
 			}
@@ -296,12 +319,65 @@ func TestApp_ExportGroup_ArchivedSkipAndFailureSummary(t *testing.T) {
 	assert.Len(t, svc.GetProjectCalls(), 2)
 }
 
+func TestApp_ExportGroup_IncludeArchived(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 100
+	cfg.IncludeArchived = true
+	projects := map[int64]gitlab.Project{
+		1: {ID: 1, Name: "ok", PathWithNamespace: "grp/ok"},
+		2: {ID: 2, Name: "arch", PathWithNamespace: "grp/arch", Archived: true},
+	}
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return []gitlab.Project{projects[1], projects[2]}, nil
+		},
+		GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
+			return projects[projectID], nil
+		},
+		ExportProjectFunc: writeArchiveFn(t),
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportGroup(context.Background()))
+
+	require.Len(t, svc.GetProjectsOfGroupCalls(), 1)
+	assert.True(t, svc.GetProjectsOfGroupCalls()[0].Opts.IncludeArchived)
+	require.Len(t, svc.ExportProjectCalls(), 2)
+	for _, c := range svc.ExportProjectCalls() {
+		assert.True(t, c.Opts.IncludeArchived, c.Project.Name)
+	}
+	assert.FileExists(t, filepath.Join(storageDir, "arch-2.tar.gz"))
+	m := readManifest(t, storageDir)
+	require.Len(t, m.Projects, 2)
+	for _, p := range m.Projects {
+		assert.Equal(t, manifest.StatusSuccess, p.Status)
+		assert.Equal(t, p.ID == 2, p.Archived, "project %d", p.ID)
+	}
+}
+
+func TestApp_Run_ArchivedProjectSkipped(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabProjectID = 7
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
+			return gitlab.Project{ID: projectID, Name: "arch", Archived: true}, nil
+		},
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.Run(context.Background()))
+	assert.Empty(t, svc.ExportProjectCalls())
+	m := readManifest(t, storageDir)
+	require.Len(t, m.Projects, 1)
+	assert.Equal(t, manifest.StatusSkipped, m.Projects[0].Status)
+
+	require.ErrorIs(t, a.ExportProject(context.Background(), 7), app.ErrProjectArchived)
+}
+
 func TestApp_ExportGroup_AllSuccess(t *testing.T) {
 	cfg, _ := baseConfig(t)
 	cfg.GitlabGroupID = 100
 
 	svc := &gitlabMocks.BackupServiceMock{
-		GetProjectsOfGroupFunc: func(_ context.Context, _ int64) ([]gitlab.Project, error) {
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
 			return []gitlab.Project{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, nil
 		},
 		GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
@@ -313,3 +389,684 @@ func TestApp_ExportGroup_AllSuccess(t *testing.T) {
 
 	require.NoError(t, a.ExportGroup(context.Background()))
 }
+
+func TestApp_ExportGroup_RespectsMaxConcurrency(t *testing.T) {
+	cfg, _ := baseConfig(t)
+	cfg.GitlabGroupID = 100
+	cfg.MaxConcurrency = 2
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+	)
+	projects := make([]gitlab.Project, 6)
+	for i := range projects {
+		projects[i] = gitlab.Project{ID: int64(i + 1), Name: "p"}
+	}
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return projects, nil
+		},
+		GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
+			return gitlab.Project{ID: projectID, Name: "p"}, nil
+		},
+		ExportProjectFunc: func(_ context.Context, _ *gitlab.Project, archiveFilePath string, _ gitlab.ExportOptions) error {
+			mu.Lock()
+			inFlight++
+			peak = max(peak, inFlight)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return os.WriteFile(archiveFilePath, []byte("archive-bytes"), 0o600)
+		},
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+
+	require.NoError(t, a.ExportGroup(context.Background()))
+	assert.Len(t, svc.ExportProjectCalls(), 6)
+	assert.LessOrEqual(t, peak, 2, "no more than maxConcurrency exports may run at once")
+}
+
+func TestApp_ExportGroup_TmpBudgetSerializesLargeProjects(t *testing.T) {
+	cfg, _ := baseConfig(t)
+	cfg.GitlabGroupID = 100
+	cfg.MaxConcurrency = 4
+	cfg.MaxTmpSizeMB = 1
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+	)
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return []gitlab.Project{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 3, Name: "c"}}, nil
+		},
+		GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
+			// Each project is estimated at the whole budget, so only one fits at a time.
+			return gitlab.Project{ID: projectID, Name: "p", EstimatedSize: 1 << 20}, nil
+		},
+		ExportProjectFunc: func(_ context.Context, _ *gitlab.Project, archiveFilePath string, _ gitlab.ExportOptions) error {
+			mu.Lock()
+			inFlight++
+			peak = max(peak, inFlight)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return os.WriteFile(archiveFilePath, []byte("archive-bytes"), 0o600)
+		},
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+
+	require.NoError(t, a.ExportGroup(context.Background()))
+	assert.Len(t, svc.ExportProjectCalls(), 3)
+	assert.Equal(t, 1, peak, "archives filling the tmpdir budget must be exported one at a time")
+}
+
+func TestApp_ExportGroup_IncrementalSkipsUnchanged(t *testing.T) {
+	cfg, _ := baseConfig(t)
+	cfg.GitlabGroupID = 100
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+	activity := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	projects := []gitlab.Project{
+		{ID: 1, Name: "quiet", LastActivityAt: activity},
+		{ID: 2, Name: "busy", LastActivityAt: activity},
+	}
+	newSvc := func() *gitlabMocks.BackupServiceMock {
+		return &gitlabMocks.BackupServiceMock{
+			GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+				return projects, nil
+			},
+			GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
+				return gitlab.Project{ID: projectID, Name: "p"}, nil
+			},
+			ExportProjectFunc: writeArchiveFn(t),
+		}
+	}
+
+	// First run: no state yet, everything is exported and recorded.
+	svc := newSvc()
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+	require.NoError(t, a.ExportGroup(context.Background()))
+	assert.Len(t, svc.ExportProjectCalls(), 2)
+
+	// Second run: only the project with new activity is exported.
+	projects[1].LastActivityAt = activity.Add(time.Hour)
+	svc = newSvc()
+	a = app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+	require.NoError(t, a.ExportGroup(context.Background()))
+	require.Len(t, svc.GetProjectCalls(), 1)
+	assert.Equal(t, int64(2), svc.GetProjectCalls()[0].ProjectID)
+
+	// --full exports everything regardless of the state.
+	cfg.FullBackup = true
+	svc = newSvc()
+	a = app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+	require.NoError(t, a.ExportGroup(context.Background()))
+	assert.Len(t, svc.ExportProjectCalls(), 2)
+}
+
+func TestApp_ExportGroup_IncrementalFailureNotRecorded(t *testing.T) {
+	cfg, _ := baseConfig(t)
+	cfg.GitlabGroupID = 100
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+	activity := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	failing := true
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return []gitlab.Project{{ID: 1, Name: "flaky", LastActivityAt: activity}}, nil
+		},
+		GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
+			return gitlab.Project{ID: projectID, Name: "flaky"}, nil
+		},
+		ExportProjectFunc: func(ctx context.Context, p *gitlab.Project, path string, opts gitlab.ExportOptions) error {
+			if failing {
+				return errors.New("export failed")
+			}
+			return writeArchiveFn(t)(ctx, p, path, opts)
+		},
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+	require.ErrorIs(t, a.ExportGroup(context.Background()), app.ErrBackupErrors)
+
+	// The failed project must be retried on the next run.
+	failing = false
+	require.NoError(t, a.ExportGroup(context.Background()))
+	assert.Len(t, svc.ExportProjectCalls(), 2)
+}
+
+func TestApp_ExportGroup_InvalidStateFile(t *testing.T) {
+	cfg, _ := baseConfig(t)
+	cfg.GitlabGroupID = 100
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(cfg.StateFile, []byte("garbage"), 0o600))
+
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return []gitlab.Project{{ID: 1, Name: "a"}}, nil
+		},
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+
+	err := a.ExportGroup(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "incremental state")
+	assert.Empty(t, svc.GetProjectCalls(), "no export should start with an unreadable state file")
+}
+
+func TestApp_ExportGroup_Filters(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 100
+	cfg.Filters = config.FiltersConfig{
+		ExcludePaths: []string{"grp/sandbox-*"},
+		ExcludeForks: true,
+		MaxSizeMB:    10,
+	}
+	projects := []gitlab.Project{
+		{ID: 1, Name: "app", PathWithNamespace: "grp/app"},
+		{ID: 2, Name: "sandbox-x", PathWithNamespace: "grp/sandbox-x"},
+		{ID: 3, Name: "fork", PathWithNamespace: "grp/fork", Forked: true},
+		{ID: 4, Name: "huge", PathWithNamespace: "grp/huge"},
+	}
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return projects, nil
+		},
+		// Group listings carry no statistics: sizes come from GetProject.
+		GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
+			p := projects[projectID-1]
+			if p.ID == 4 {
+				p.EstimatedSize = 20 * 1024 * 1024
+			}
+			return p, nil
+		},
+		ExportProjectFunc: writeArchiveFn(t),
+	}
+
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportGroup(context.Background()))
+
+	require.Len(t, svc.ExportProjectCalls(), 1)
+	assert.Equal(t, int64(1), svc.ExportProjectCalls()[0].Project.ID)
+
+	m := readManifest(t, storageDir)
+	reasons := make(map[int64]string)
+	for _, p := range m.Projects {
+		if p.Status == manifest.StatusFiltered {
+			reasons[p.ID] = p.Reason
+		}
+	}
+	assert.Equal(t, map[int64]string{
+		2: `path matches exclude pattern "grp/sandbox-*"`,
+		3: "fork",
+		4: "size 20 MiB above 10 MiB",
+	}, reasons)
+}
+
+// readManifest loads the single run manifest stored in storageDir.
+func readManifest(t *testing.T, storageDir string) *manifest.Manifest {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(storageDir, "manifest-*.json"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1, "exactly one run manifest should be stored")
+	data, err := os.ReadFile(matches[0])
+	require.NoError(t, err)
+	m, err := manifest.Parse(data)
+	require.NoError(t, err)
+	return m
+}
+
+func TestApp_ExportGroup_WritesManifest(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 100
+
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return []gitlab.Project{
+				{ID: 1, Name: "ok", PathWithNamespace: "grp/ok"},
+				{ID: 2, Name: "arch", PathWithNamespace: "grp/arch", Archived: true},
+				{ID: 3, Name: "boom", PathWithNamespace: "grp/sub/boom"},
+			}, nil
+		},
+		GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
+			names := map[int64]string{1: "ok", 3: "boom"}
+			return gitlab.Project{ID: projectID, Name: names[projectID]}, nil
+		},
+		ExportProjectFunc: func(_ context.Context, project *gitlab.Project, archiveFilePath string, _ gitlab.ExportOptions) error {
+			if project.ID == 3 {
+				return errors.New("export exploded")
+			}
+			return os.WriteFile(archiveFilePath, []byte("archive-bytes"), 0o600)
+		},
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+	a.SetVersion("v9.9.9")
+
+	require.ErrorIs(t, a.ExportGroup(context.Background()), app.ErrBackupErrors)
+
+	m := readManifest(t, storageDir)
+	assert.Equal(t, "v9.9.9", m.ToolVersion)
+	assert.Equal(t, "https://gitlab.com", m.GitlabEndpoint)
+	assert.NotEmpty(t, m.RunID)
+	require.Len(t, m.Projects, 3)
+
+	byID := map[int64]manifest.Project{}
+	for _, p := range m.Projects {
+		byID[p.ID] = p
+	}
+
+	sum := sha256.Sum256([]byte("archive-bytes"))
+	ok := byID[1]
+	assert.Equal(t, manifest.StatusSuccess, ok.Status)
+	assert.Equal(t, "grp/ok", ok.FullPath)
+	assert.Equal(t, "ok-1.tar.gz", ok.ArchiveKey)
+	assert.Equal(t, int64(len("archive-bytes")), ok.Size)
+	assert.Equal(t, hex.EncodeToString(sum[:]), ok.SHA256)
+	assert.False(t, ok.Encrypted)
+
+	assert.Equal(t, manifest.StatusSkipped, byID[2].Status)
+	assert.Equal(t, manifest.StatusFailed, byID[3].Status)
+	assert.Equal(t, "grp/sub/boom", byID[3].FullPath)
+	assert.Contains(t, byID[3].Error, "export exploded")
+
+	// The temporary manifest is removed from TmpDir.
+	leftovers, err := filepath.Glob(filepath.Join(cfg.TmpDir, "manifest-*.json"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestApp_Run_ProjectWritesManifest(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabProjectID = 7
+	cfg.Age.Recipients = []string{testAgeRecipient}
+
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectFunc: func(_ context.Context, _ int64) (gitlab.Project, error) {
+			return gitlab.Project{ID: 7, Name: "myproj", PathWithNamespace: "grp/myproj"}, nil
+		},
+		ExportProjectFunc: writeArchiveFn(t),
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+	require.NoError(t, a.Run(context.Background()))
+
+	m := readManifest(t, storageDir)
+	require.Len(t, m.Projects, 1)
+	p := m.Projects[0]
+	assert.Equal(t, manifest.StatusSuccess, p.Status)
+	assert.Equal(t, "grp/myproj", p.FullPath)
+	assert.True(t, p.Encrypted)
+
+	// The recorded checksum matches the stored (encrypted) object.
+	stored, err := os.ReadFile(filepath.Join(storageDir, p.ArchiveKey))
+	require.NoError(t, err)
+	sum := sha256.Sum256(stored)
+	assert.Equal(t, hex.EncodeToString(sum[:]), p.SHA256)
+	assert.Equal(t, int64(len(stored)), p.Size)
+}
+
+func TestApp_Run_ProjectFailureStillWritesManifest(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabProjectID = 7
+
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectFunc: func(_ context.Context, _ int64) (gitlab.Project, error) {
+			return gitlab.Project{}, errors.New("not found")
+		},
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+	require.Error(t, a.Run(context.Background()))
+
+	m := readManifest(t, storageDir)
+	require.Len(t, m.Projects, 1)
+	assert.Equal(t, int64(7), m.Projects[0].ID)
+	assert.Equal(t, manifest.StatusFailed, m.Projects[0].Status)
+}
+
+// writeStoredArchive creates an archive directly in the storage directory with the given modification time.
+func writeStoredArchive(t *testing.T, storageDir, key string, modTime time.Time) {
+	t.Helper()
+	p := filepath.Join(storageDir, filepath.FromSlash(key))
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o750))
+	require.NoError(t, os.WriteFile(p, []byte("archive-bytes"), 0o600))
+	require.NoError(t, os.Chtimes(p, modTime, modTime))
+}
+
+func TestApp_Prune(t *testing.T) {
+	now := time.Now()
+	setup := func(t *testing.T) (*app.App, string) {
+		t.Helper()
+		cfg, storageDir := baseConfig(t)
+		cfg.Retention = config.RetentionConfig{KeepLast: 2}
+		cfg.ArchiveKeyTemplate = "{date}/{name}-{id}.tar.gz"
+		writeStoredArchive(t, storageDir, "2026-10-13/proj-1.tar.gz", now.Add(-72*time.Hour))
+		writeStoredArchive(t, storageDir, "2026-10-13/proj-1.tar.gz.sha256", now.Add(-72*time.Hour))
+		writeStoredArchive(t, storageDir, "2026-10-14/proj-1.tar.gz", now.Add(-48*time.Hour))
+		writeStoredArchive(t, storageDir, "2026-10-15/proj-1.tar.gz", now.Add(-24*time.Hour))
+		writeStoredArchive(t, storageDir, "2026-10-13/other-2.tar.gz", now.Add(-72*time.Hour))
+		writeStoredArchive(t, storageDir, "manifest-20260101T000000Z.json", now.Add(-72*time.Hour))
+		// Not produced by the key template: never pruned.
+		writeStoredArchive(t, storageDir, "manual/proj-1.tar.gz", now.Add(-96*time.Hour))
+		return app.NewAppWithService(cfg, nil, localstorage.NewLocalStorage(storageDir), nil), storageDir
+	}
+
+	t.Run("deletes archives outside the policy", func(t *testing.T) {
+		a, storageDir := setup(t)
+		require.NoError(t, a.Prune(context.Background(), false))
+
+		assert.NoFileExists(t, filepath.Join(storageDir, "2026-10-13", "proj-1.tar.gz"))
+		assert.NoFileExists(t, filepath.Join(storageDir, "2026-10-13", "proj-1.tar.gz.sha256"), "deleted with its archive")
+		assert.FileExists(t, filepath.Join(storageDir, "2026-10-14", "proj-1.tar.gz"))
+		assert.FileExists(t, filepath.Join(storageDir, "2026-10-15", "proj-1.tar.gz"))
+		// Retention is per project: the only archive of project 2 is kept.
+		assert.FileExists(t, filepath.Join(storageDir, "2026-10-13", "other-2.tar.gz"))
+		// Run manifests and foreign files are not project archives.
+		assert.FileExists(t, filepath.Join(storageDir, "manifest-20260101T000000Z.json"))
+		assert.FileExists(t, filepath.Join(storageDir, "manual", "proj-1.tar.gz"))
+	})
+
+	t.Run("dry run deletes nothing", func(t *testing.T) {
+		a, storageDir := setup(t)
+		require.NoError(t, a.Prune(context.Background(), true))
+		assert.FileExists(t, filepath.Join(storageDir, "2026-10-13", "proj-1.tar.gz"))
+		assert.FileExists(t, filepath.Join(storageDir, "2026-10-13", "proj-1.tar.gz.sha256"))
+	})
+}
+
+func TestApp_Prune_DeleteError(t *testing.T) {
+	cfg, _ := baseConfig(t)
+	cfg.Retention = config.RetentionConfig{KeepLast: 1}
+	now := time.Now()
+	stub := &stubStorage{
+		objects: []storage.Object{
+			{Key: "proj-old-1.tar.gz", ModTime: now.Add(-time.Hour)},
+			{Key: "proj-1.tar.gz", ModTime: now},
+		},
+		deleteErr: errors.New("access denied"),
+	}
+	a := app.NewAppWithService(cfg, nil, stub, nil)
+
+	err := a.Prune(context.Background(), false)
+	require.ErrorIs(t, err, app.ErrPruneErrors)
+	assert.Contains(t, err.Error(), "1 archive(s)")
+}
+
+func TestApp_ExportGroup_PrunesSucceededProjects(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 42
+	cfg.Retention = config.RetentionConfig{KeepLast: 1}
+	cfg.ArchiveKeyTemplate = "{runID}/{name}-{id}.tar.gz"
+	old := time.Now().Add(-48 * time.Hour)
+	writeStoredArchive(t, storageDir, "20260101T000000Z/ok-1.tar.gz", old)
+	writeStoredArchive(t, storageDir, "20260101T000000Z/boom-2.tar.gz", old)
+
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return []gitlab.Project{{ID: 1, Name: "ok"}, {ID: 2, Name: "boom"}}, nil
+		},
+		GetProjectFunc: func(_ context.Context, id int64) (gitlab.Project, error) {
+			if id == 2 {
+				return gitlab.Project{ID: 2, Name: "boom"}, nil
+			}
+			return gitlab.Project{ID: 1, Name: "ok"}, nil
+		},
+		ExportProjectFunc: func(_ context.Context, p *gitlab.Project, path string, _ gitlab.ExportOptions) error {
+			if p.ID == 2 {
+				return errors.New("export exploded")
+			}
+			return os.WriteFile(path, []byte("archive-bytes"), 0o600)
+		},
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	require.ErrorIs(t, a.ExportGroup(context.Background()), app.ErrBackupErrors)
+
+	// The fresh archive replaces the old one of the successful project...
+	fresh, err := filepath.Glob(filepath.Join(storageDir, "*", "ok-1.tar.gz"))
+	require.NoError(t, err)
+	require.Len(t, fresh, 1)
+	assert.NotContains(t, fresh[0], "20260101T000000Z")
+	// ...while a failed export never costs a project its previous archive.
+	assert.FileExists(t, filepath.Join(storageDir, "20260101T000000Z", "boom-2.tar.gz"))
+}
+
+func TestApp_ExportGroup_ArchiveKeyTemplate(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 42
+	cfg.ArchiveKeyTemplate = "{namespace}/{path}/{date}/{path}-{id}-{runID}.tar.gz"
+
+	projects := []gitlab.Project{
+		{ID: 1, Name: "Web App", PathWithNamespace: "grp/sub/web-app"},
+		{ID: 2, Name: "tools", PathWithNamespace: "grp/tools"},
+	}
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return projects, nil
+		},
+		GetProjectFunc: func(_ context.Context, id int64) (gitlab.Project, error) {
+			return projects[id-1], nil
+		},
+		ExportProjectFunc: writeArchiveFn(t),
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportGroup(context.Background()))
+
+	m := readManifest(t, storageDir)
+	require.Len(t, m.Projects, 2)
+	date := m.StartedAt.Format(time.DateOnly)
+	want := map[int64]string{
+		1: "grp/sub/web-app/" + date + "/web-app-1-" + m.RunID + ".tar.gz",
+		2: "grp/tools/" + date + "/tools-2-" + m.RunID + ".tar.gz",
+	}
+	for _, p := range m.Projects {
+		assert.Equal(t, want[p.ID], p.ArchiveKey)
+		assert.FileExists(t, filepath.Join(storageDir, filepath.FromSlash(p.ArchiveKey)))
+	}
+}
+
+// groupCheckpointPath mirrors where ExportGroup keeps the checkpoint of a group run.
+func groupCheckpointPath(cfg *config.Config) string {
+	return filepath.Join(cfg.TmpDir, fmt.Sprintf("gitlab-backup-checkpoint-%d.json", cfg.GitlabGroupID))
+}
+
+// checkpointGroupService serves a group of two projects; project 2 fails to export when failSecond is set.
+func checkpointGroupService(t *testing.T, failSecond bool, exported *sync.Map) *gitlabMocks.BackupServiceMock {
+	t.Helper()
+	projects := []gitlab.Project{{ID: 1, Name: "first"}, {ID: 2, Name: "second"}}
+	return &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return projects, nil
+		},
+		GetProjectFunc: func(_ context.Context, id int64) (gitlab.Project, error) {
+			return projects[id-1], nil
+		},
+		ExportProjectFunc: func(_ context.Context, p *gitlab.Project, path string, _ gitlab.ExportOptions) error {
+			exported.Store(p.ID, true)
+			if failSecond && p.ID == 2 {
+				return errors.New("pod evicted")
+			}
+			return os.WriteFile(path, []byte("archive-bytes"), 0o600)
+		},
+	}
+}
+
+func TestApp_ExportGroup_CheckpointRemovedAfterSuccess(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 42
+
+	var exported sync.Map
+	a := app.NewAppWithService(cfg, checkpointGroupService(t, false, &exported), localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportGroup(context.Background()))
+	assert.NoFileExists(t, groupCheckpointPath(cfg))
+}
+
+func TestApp_ExportGroup_ResumeSkipsCompletedProjects(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 42
+
+	// First run: project 2 fails, the checkpoint keeps project 1.
+	var exported sync.Map
+	a := app.NewAppWithService(cfg, checkpointGroupService(t, true, &exported), localstorage.NewLocalStorage(storageDir), nil)
+	require.ErrorIs(t, a.ExportGroup(context.Background()), app.ErrBackupErrors)
+	cp, err := checkpoint.Load(groupCheckpointPath(cfg))
+	require.NoError(t, err)
+	done, ok := cp.Completed(1)
+	require.True(t, ok)
+	_, ok = cp.Completed(2)
+	assert.False(t, ok)
+	first := readManifest(t, storageDir)
+	require.NoError(t, os.Remove(filepath.Join(storageDir, manifest.Key(first.RunID))))
+
+	// Resumed run: only project 2 is exported again, within the same run.
+	cfg.Resume = true
+	exported = sync.Map{}
+	a = app.NewAppWithService(cfg, checkpointGroupService(t, false, &exported), localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportGroup(context.Background()))
+
+	_, again := exported.Load(int64(1))
+	assert.False(t, again, "completed project must not be exported again")
+	_, retried := exported.Load(int64(2))
+	assert.True(t, retried)
+	assert.NoFileExists(t, groupCheckpointPath(cfg))
+
+	m := readManifest(t, storageDir)
+	assert.Equal(t, first.RunID, m.RunID, "a resumed run keeps its run ID")
+	require.Len(t, m.Projects, 2)
+	for _, p := range m.Projects {
+		assert.Equal(t, manifest.StatusSuccess, p.Status)
+		if p.ID == 1 {
+			assert.Equal(t, done.ArchiveKey, p.ArchiveKey)
+			assert.Equal(t, done.SHA256, p.SHA256)
+		}
+	}
+}
+
+func TestApp_ExportGroup_WithoutResumeIgnoresCheckpoint(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 42
+	cp := checkpoint.New(groupCheckpointPath(cfg), 42, time.Now())
+	require.NoError(t, cp.Record(1, checkpoint.Entry{ArchiveKey: "first-1.tar.gz"}))
+
+	var exported sync.Map
+	a := app.NewAppWithService(cfg, checkpointGroupService(t, false, &exported), localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportGroup(context.Background()))
+	_, ok := exported.Load(int64(1))
+	assert.True(t, ok)
+}
+
+func TestApp_ExportGroup_ResumeOtherGroupCheckpoint(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 42
+	cfg.Resume = true
+	cp := checkpoint.New(groupCheckpointPath(cfg), 99, time.Now())
+	require.NoError(t, cp.Record(1, checkpoint.Entry{ArchiveKey: "first-1.tar.gz"}))
+
+	var exported sync.Map
+	a := app.NewAppWithService(cfg, checkpointGroupService(t, false, &exported), localstorage.NewLocalStorage(storageDir), nil)
+	require.ErrorIs(t, a.ExportGroup(context.Background()), app.ErrCheckpointMismatch)
+}
+
+// groupArchiveService returns a BackupService for a one-project group whose
+// native group export writes "group-bytes", or fails with groupErr.
+func groupArchiveService(t *testing.T, groupErr error) *gitlabMocks.BackupServiceMock {
+	t.Helper()
+	project := gitlab.Project{ID: 1, Name: "app", PathWithNamespace: "top/grp/app"}
+	return &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return []gitlab.Project{project}, nil
+		},
+		GetProjectFunc: func(_ context.Context, _ int64) (gitlab.Project, error) {
+			return project, nil
+		},
+		ExportProjectFunc: writeArchiveFn(t),
+		GetGroupFunc: func(_ context.Context, groupID int64) (gitlab.Group, error) {
+			return gitlab.Group{ID: groupID, Name: "Group", Path: "grp", FullPath: "top/grp"}, nil
+		},
+		ExportGroupFunc: func(_ context.Context, _ *gitlab.Group, archiveFilePath string, _ gitlab.ExportOptions) error {
+			if groupErr != nil {
+				return groupErr
+			}
+			return os.WriteFile(archiveFilePath, []byte("group-bytes"), 0o600)
+		},
+	}
+}
+
+func TestApp_ExportGroup_GroupArchive(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 42
+	cfg.ExportGroupArchive = true
+	cfg.ArchiveKeyTemplate = "{namespace}/{path}-{id}.tar.gz"
+
+	a := app.NewAppWithService(cfg, groupArchiveService(t, nil), localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportGroup(context.Background()))
+
+	// The group archive is stored next to the project archives.
+	assert.FileExists(t, filepath.Join(storageDir, "top", "grp", "app-1.tar.gz"))
+	assert.FileExists(t, filepath.Join(storageDir, "top", "grp-42.group.tar.gz"))
+
+	m := readManifest(t, storageDir)
+	require.Len(t, m.Projects, 1)
+	require.Len(t, m.Groups, 1)
+	sum := sha256.Sum256([]byte("group-bytes"))
+	assert.Equal(t, int64(42), m.Groups[0].ID)
+	assert.Equal(t, "top/grp", m.Groups[0].FullPath)
+	assert.Equal(t, manifest.StatusSuccess, m.Groups[0].Status)
+	assert.Equal(t, "top/grp-42.group.tar.gz", m.Groups[0].ArchiveKey)
+	assert.Equal(t, hex.EncodeToString(sum[:]), m.Groups[0].SHA256)
+}
+
+func TestApp_ExportGroup_GroupArchiveFailure(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 42
+	cfg.ExportGroupArchive = true
+
+	a := app.NewAppWithService(cfg, groupArchiveService(t, errors.New("403 Forbidden")), localstorage.NewLocalStorage(storageDir), nil)
+	require.ErrorIs(t, a.ExportGroup(context.Background()), app.ErrBackupErrors)
+
+	// A failed group export does not stop the project exports.
+	assert.FileExists(t, filepath.Join(storageDir, "app-1.tar.gz"))
+	m := readManifest(t, storageDir)
+	require.Len(t, m.Groups, 1)
+	assert.Equal(t, manifest.StatusFailed, m.Groups[0].Status)
+	assert.Contains(t, m.Groups[0].Error, "403 Forbidden")
+	assert.Empty(t, m.Groups[0].ArchiveKey)
+}
+
+func TestApp_ExportGroup_WithoutGroupArchive(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 42
+
+	svc := groupArchiveService(t, nil)
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportGroup(context.Background()))
+
+	assert.Empty(t, svc.ExportGroupCalls())
+	assert.Empty(t, readManifest(t, storageDir).Groups)
+}
+
+func TestApp_Prune_GroupArchives(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.Retention = config.RetentionConfig{KeepLast: 1}
+	cfg.ArchiveKeyTemplate = "{date}/{name}-{id}.tar.gz"
+	now := time.Now()
+	writeStoredArchive(t, storageDir, "2026-10-13/grp-1.group.tar.gz", now.Add(-72*time.Hour))
+	writeStoredArchive(t, storageDir, "2026-10-14/grp-1.group.tar.gz", now.Add(-48*time.Hour))
+	writeStoredArchive(t, storageDir, "2026-10-13/proj-1.tar.gz", now.Add(-72*time.Hour))
+
+	a := app.NewAppWithService(cfg, nil, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.Prune(context.Background(), false))
+
+	assert.NoFileExists(t, filepath.Join(storageDir, "2026-10-13", "grp-1.group.tar.gz"))
+	assert.FileExists(t, filepath.Join(storageDir, "2026-10-14", "grp-1.group.tar.gz"))
+	// Project 1 and group 1 have separate retention histories.
+	assert.FileExists(t, filepath.Join(storageDir, "2026-10-13", "proj-1.tar.gz"))
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/archivekey"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+)
+
+// archiveKey renders the storage key of a project archive for the run started
+// at runStart, under the storage prefix of target t (nil for none).
+func (a *App) archiveKey(t *config.TargetConfig, project gitlab.Project, runStart time.Time) (string, error) {
+	tmpl, err := archivekey.Parse(a.cfg.ArchiveKeyTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse archive key template: %w", err)
+	}
+	key, err := tmpl.Render(keyVars(project, runStart))
+	if err != nil {
+		return "", fmt.Errorf("failed to build archive key for project %s: %w", project.Name, err)
+	}
+	return prefixKey(t, key), nil
+}
+
+// keyVars derives the template variables of a project. The namespace and path
+// come from path_with_namespace; the project name stands in for the path when
+// GitLab did not report it.
+func keyVars(project gitlab.Project, runStart time.Time) archivekey.Vars {
+	vars := archivekey.Vars{
+		Path:  project.Name,
+		Name:  project.Name,
+		ID:    project.ID,
+		Time:  runStart,
+		RunID: manifest.NewRunID(runStart),
+	}
+	if project.PathWithNamespace != "" {
+		vars.Path = path.Base(project.PathWithNamespace)
+		if ns := path.Dir(project.PathWithNamespace); ns != "." {
+			vars.Namespace = ns
+		}
+	}
+	return vars
+}
+
+// groupArchiveKey renders the storage key of a native group export for the run
+// started at runStart, under the storage prefix of target t. The group's
+// parent namespace, path and name fill the template variables; archivekey
+// marks the key as a group archive.
+func (a *App) groupArchiveKey(t *config.TargetConfig, group gitlab.Group, runStart time.Time) (string, error) {
+	tmpl, err := archivekey.Parse(a.cfg.ArchiveKeyTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse archive key template: %w", err)
+	}
+	vars := keyVars(gitlab.Project{ID: group.ID, Name: group.Name, PathWithNamespace: group.FullPath}, runStart)
+	if group.Path != "" {
+		vars.Path = group.Path
+	}
+	key, err := tmpl.RenderGroup(vars)
+	if err != nil {
+		return "", fmt.Errorf("failed to build archive key for group %s: %w", group.Name, err)
+	}
+	return prefixKey(t, key), nil
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/checkpoint"
+)
+
+// checkpointDigestLen is the number of hex digits of the targets digest in the checkpoint name.
+const checkpointDigestLen = 12
+
+// ErrCheckpointMismatch is returned when the checkpoint to resume belongs to another group.
+var ErrCheckpointMismatch = errors.New("checkpoint belongs to another group")
+
+// checkpointPath returns the location of the group (or targets) backup checkpoint in TmpDir.
+// A targets list gets a checkpoint of its own, named after a digest of its
+// targets, so that runs of different lists (e.g. daemon jobs) never share one.
+func (a *App) checkpointPath() string {
+	if len(a.cfg.Targets) > 0 {
+		h := sha256.New()
+		for i := range a.cfg.Targets {
+			fmt.Fprintln(h, a.cfg.Targets[i].String())
+		}
+		digest := hex.EncodeToString(h.Sum(nil))[:checkpointDigestLen]
+		return filepath.Join(a.cfg.TmpDir, fmt.Sprintf("gitlab-backup-checkpoint-targets-%s.json", digest))
+	}
+	return filepath.Join(a.cfg.TmpDir, fmt.Sprintf("gitlab-backup-checkpoint-%d.json", a.cfg.GitlabGroupID))
+}
+
+// startCheckpoint returns the checkpoint of the group run starting at now.
+// With --resume, the checkpoint of the interrupted run is picked up, and the
+// run keeps its original start time (and thus run ID); otherwise, or when
+// there is nothing to resume, a fresh checkpoint replaces any previous one.
+func (a *App) startCheckpoint(now time.Time) (*checkpoint.Checkpoint, error) {
+	path := a.checkpointPath()
+	if !a.cfg.Resume {
+		return checkpoint.New(path, a.cfg.GitlabGroupID, now), nil
+	}
+
+	cp, err := checkpoint.Load(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		a.log.Info("no checkpoint to resume, starting a new run", "checkpoint", path)
+		return checkpoint.New(path, a.cfg.GitlabGroupID, now), nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	case cp.GroupID() != a.cfg.GitlabGroupID:
+		return nil, fmt.Errorf("%w: %s records group %d", ErrCheckpointMismatch, path, cp.GroupID())
+	}
+	a.log.Info("resuming interrupted run",
+		"checkpoint", path,
+		"started", cp.StartedAt(),
+		"completed projects", cp.Len(),
+	)
+	return cp, nil
+}
+
+// finishCheckpoint removes the checkpoint of a run without failures. After a
+// run with failures it is kept, so --resume retries only the failed projects.
+func (a *App) finishCheckpoint(cp *checkpoint.Checkpoint, summary *backupSummary) {
+	if summary.hasFailures() {
+		a.log.Info("checkpoint kept, rerun with --resume to retry the failed projects only",
+			"checkpoint", a.checkpointPath())
+		return
+	}
+	if err := cp.Remove(); err != nil {
+		a.log.Warn("failed to remove checkpoint", "error", err)
+	}
+}
+
+// checkpointEntry converts a stored archive description into a checkpoint entry.
+func checkpointEntry(archive archiveInfo, completedAt time.Time) checkpoint.Entry {
+	return checkpoint.Entry{
+		ArchiveKey:  archive.key,
+		Size:        archive.size,
+		SHA256:      archive.sha256,
+		Encrypted:   archive.encrypted,
+		CompletedAt: completedAt,
+	}
+}
+
+// resumedArchive converts a checkpoint entry back into an archive description.
+func resumedArchive(e checkpoint.Entry) archiveInfo {
+	return archiveInfo{
+		key:       e.ArchiveKey,
+		size:      e.Size,
+		sha256:    e.SHA256,
+		encrypted: e.Encrypted,
+	}
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"golang.org/x/sync/semaphore"
+)
+
+// resolveMaxConcurrency returns the configured worker count, or the default if zero/negative.
+func resolveMaxConcurrency(maxConcurrency int) int {
+	if maxConcurrency <= 0 {
+		return constants.DefaultMaxConcurrency
+	}
+	return maxConcurrency
+}
+
+// tmpBudget caps the archive bytes that concurrent exports may hold in TmpDir.
+// Each export reserves its estimated size before the archive is downloaded and
+// releases it once the archive has been stored and removed. A nil budget is
+// valid and never blocks.
+type tmpBudget struct {
+	sem   *semaphore.Weighted
+	limit int64
+}
+
+// newTmpBudget returns a budget of limit bytes, or nil (unlimited) if limit is zero/negative.
+func newTmpBudget(limit int64) *tmpBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &tmpBudget{
+		sem:   semaphore.NewWeighted(limit),
+		limit: limit,
+	}
+}
+
+// acquire blocks until size bytes are available and returns the matching release func.
+// Projects without statistics (size 0) reserve nothing. Sizes above the whole
+// budget are clamped to it, so an oversized project still runs, but alone.
+func (b *tmpBudget) acquire(ctx context.Context, size int64) (func(), error) {
+	if b == nil || size <= 0 {
+		return func() {}, nil
+	}
+	weight := min(size, b.limit)
+	if err := b.sem.Acquire(ctx, weight); err != nil {
+		return nil, fmt.Errorf("waiting for tmpdir budget: %w", err)
+	}
+	return func() { b.sem.Release(weight) }, nil
+}
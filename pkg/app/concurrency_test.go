@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveMaxConcurrency(t *testing.T) {
+	assert.Equal(t, constants.DefaultMaxConcurrency, resolveMaxConcurrency(0))
+	assert.Equal(t, constants.DefaultMaxConcurrency, resolveMaxConcurrency(-3))
+	assert.Equal(t, 7, resolveMaxConcurrency(7))
+}
+
+func TestTmpBudget_Unlimited(t *testing.T) {
+	b := newTmpBudget(0)
+	assert.Nil(t, b)
+
+	release, err := b.acquire(context.Background(), 1<<40)
+	require.NoError(t, err)
+	release()
+}
+
+func TestTmpBudget_UnknownSizeReservesNothing(t *testing.T) {
+	b := newTmpBudget(10)
+	hold, err := b.acquire(context.Background(), 10)
+	require.NoError(t, err)
+	defer hold()
+
+	release, err := b.acquire(context.Background(), 0)
+	require.NoError(t, err, "a project without statistics must not wait on the budget")
+	release()
+}
+
+func TestTmpBudget_BlocksUntilReleased(t *testing.T) {
+	b := newTmpBudget(10)
+	first, err := b.acquire(context.Background(), 6)
+	require.NoError(t, err)
+
+	acquired := make(chan struct{})
+	go func() {
+		second, err := b.acquire(context.Background(), 6)
+		assert.NoError(t, err)
+		close(acquired)
+		second()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second reservation should wait for the first to be released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	first()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second reservation should proceed once the budget is released")
+	}
+}
+
+func TestTmpBudget_OversizedIsClamped(t *testing.T) {
+	b := newTmpBudget(10)
+	release, err := b.acquire(context.Background(), 1000)
+	require.NoError(t, err, "a project larger than the budget must still run")
+	release()
+}
+
+func TestTmpBudget_ContextCancelled(t *testing.T) {
+	b := newTmpBudget(10)
+	hold, err := b.acquire(context.Background(), 10)
+	require.NoError(t, err)
+	defer hold()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = b.acquire(ctx, 5)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/filter"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	"golang.org/x/sync/errgroup"
+)
+
+// projectAction is what a run does with a project.
+type projectAction int
+
+const (
+	actionExport    projectAction = iota
+	actionCompleted               // exported before an interruption, see --resume
+	actionArchived                // archived while includeArchived is not set
+	actionFiltered                // left out by the project filters
+	actionUnchanged               // no activity since its last backup
+)
+
+// decideProject returns what run does with project of target t, and the
+// reason of actionFiltered.
+func (a *App) decideProject(
+	ctx context.Context,
+	run *backupRun,
+	t *config.TargetConfig,
+	project gitlab.Project,
+) (projectAction, string) {
+	if _, completed := run.checkpoint.Completed(project.ID); completed {
+		return actionCompleted, ""
+	}
+	if project.Archived && !a.includeArchived(t) {
+		return actionArchived, ""
+	}
+	if reason := a.filteredReason(ctx, run, t, project); reason != "" {
+		return actionFiltered, reason
+	}
+	if a.isUnchanged(run.incremental, project) {
+		return actionUnchanged, ""
+	}
+	return actionExport, ""
+}
+
+// scheduleProject records a project that needs no export (completed before
+// an interruption, archived, filtered out, unchanged) or queues its export, with the
+// overrides of target t, on eg.
+func (a *App) scheduleProject(
+	ctx context.Context,
+	eg *errgroup.Group,
+	run *backupRun,
+	t *config.TargetConfig,
+	project gitlab.Project,
+) {
+	action, reason := a.decideProject(ctx, run, t, project)
+	switch action {
+	case actionCompleted:
+		a.log.Info("project completed before interruption, skip", "project name", project.Name)
+		done, _ := run.checkpoint.Completed(project.ID)
+		run.summary.recordSuccess(project, resumedArchive(done), 0)
+		if run.incremental != nil {
+			run.incremental.Record(project.ID, done.CompletedAt, project.LastActivityAt)
+		}
+	case actionArchived:
+		a.log.Info("project is archived, skip", "project name", project.Name)
+		run.summary.recordSkipped(project)
+	case actionFiltered:
+		a.log.Info("project filtered out, skip", "project name", project.Name, "reason", reason)
+		run.summary.recordFiltered(project, reason)
+	case actionUnchanged:
+		a.log.Info("project unchanged since last backup, skip",
+			"project name", project.Name,
+			"last activity", project.LastActivityAt,
+		)
+		run.summary.recordUnchanged(project)
+	case actionExport:
+		eg.Go(func() error {
+			a.exportGroupProject(ctx, run, t, project)
+			return nil
+		})
+	}
+}
+
+// exportGroupProject exports one project of a group or targets run and records
+// the outcome. Failures are recorded, not returned, so the other exports go on.
+func (a *App) exportGroupProject(ctx context.Context, run *backupRun, t *config.TargetConfig, project gitlab.Project) {
+	start := time.Now()
+	_, archive, err := a.exportProject(ctx, project.ID, t, run.budget, run.summary.startTime)
+	elapsed := time.Since(start)
+	if err != nil {
+		a.log.Error("error occurred during backup", "project name", project.Name, "error", err.Error())
+		run.summary.recordFailure(project, err, elapsed)
+		return
+	}
+	run.summary.recordSuccess(project, archive, elapsed)
+	if run.incremental != nil {
+		run.incremental.Record(project.ID, time.Now(), project.LastActivityAt)
+	}
+	if err := run.checkpoint.Record(project.ID, checkpointEntry(archive, time.Now())); err != nil {
+		a.log.Warn("failed to update checkpoint", "project name", project.Name, "error", err)
+	}
+}
+
+// exportGroupArchive exports the group of target t itself with the group
+// import/export API and stores the archive next to the project archives, so
+// that gitlab-restore can rebuild the group before importing its projects.
+// The outcome is recorded in the run summary; a failure does not stop the
+// project exports.
+func (a *App) exportGroupArchive(ctx context.Context, run *backupRun, t *config.TargetConfig) {
+	start := time.Now()
+	group, archive, err := a.exportGroup(ctx, t, run.summary.startTime)
+	if err != nil {
+		a.log.Error("error occurred during group export", "group", t.Group, "error", err.Error())
+	}
+	run.summary.recordGroup(group, archive, err, time.Since(start))
+}
+
+// exportGroup exports the group of target t, encrypts the archive when age
+// is configured and stores it under the group archive key.
+func (a *App) exportGroup(ctx context.Context, t *config.TargetConfig, runStart time.Time) (gitlab.Group, archiveInfo, error) {
+	group, err := a.gitlabService.GetGroup(ctx, t.Group)
+	if err != nil {
+		return gitlab.Group{ID: t.Group}, archiveInfo{}, fmt.Errorf("failed to get group %d: %w", t.Group, err)
+	}
+	key, err := a.groupArchiveKey(t, group, runStart)
+	if err != nil {
+		return group, archiveInfo{}, err
+	}
+
+	archivePath := filepath.Join(a.cfg.TmpDir, fmt.Sprintf("group-%d.tar.gz", group.ID))
+	if err := a.gitlabService.ExportGroup(ctx, &group, archivePath, a.exportOptions(t)); err != nil {
+		return group, archiveInfo{}, fmt.Errorf("failed to export group %s: %w", group.Name, err)
+	}
+	ageCfg := a.ageConfig(t)
+	if err := a.encryptArchive(archivePath, ageCfg); err != nil {
+		_ = os.Remove(archivePath)
+		return group, archiveInfo{}, err
+	}
+	archive, err := describeArchive(archivePath, key, ageCfg.IsEnabled())
+	if err != nil {
+		_ = os.Remove(archivePath)
+		return group, archiveInfo{}, err
+	}
+	if err := a.storeArchive(ctx, archivePath, key); err != nil {
+		return group, archiveInfo{}, fmt.Errorf("failed to store archive %s: %w", archivePath, err)
+	}
+	if err := a.storeSidecar(ctx, archive); err != nil {
+		return group, archiveInfo{}, err
+	}
+
+	a.log.Info("group successfully exported", "group", group.Name, "key", key)
+	return group, archive, nil
+}
+
+// filteredReason reports why the project filters leave project out, or "" when
+// it is backed up. The projects of project targets are never filtered.
+//
+// Group listings carry no statistics, so the project is fetched again for its
+// size when the size rule is set; if that fails the size is left unknown and
+// the rule does not apply.
+func (a *App) filteredReason(ctx context.Context, run *backupRun, t *config.TargetConfig, project gitlab.Project) string {
+	if t.Project > 0 {
+		return ""
+	}
+	if a.cfg.Filters.MaxSizeMB > 0 && project.EstimatedSize == 0 {
+		if p, err := a.gitlabService.GetProject(ctx, project.ID); err != nil {
+			a.log.Warn("failed to get project size for filters", "project name", project.Name, "error", err)
+		} else {
+			project.EstimatedSize = p.EstimatedSize
+		}
+	}
+	return run.filter.Exclude(filter.Project{
+		FullPath:       project.PathWithNamespace,
+		Topics:         project.Topics,
+		Visibility:     project.Visibility,
+		Forked:         project.Forked,
+		Mirror:         project.Mirror,
+		LastActivityAt: project.LastActivityAt,
+		Size:           project.EstimatedSize,
+	}, run.summary.startTime)
+}
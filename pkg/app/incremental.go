@@ -0,0 +1,41 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	"github.com/sgaunet/gitlab-backup/pkg/state"
+)
+
+// loadState opens the incremental backup state. It returns nil when no
+// stateFile is configured, which disables incremental backups.
+func (a *App) loadState() (*state.Store, error) {
+	if a.cfg.StateFile == "" {
+		return nil, nil //nolint:nilnil // nil store means incremental backups are disabled
+	}
+	st, err := state.Load(a.cfg.StateFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load incremental state: %w", err)
+	}
+	return st, nil
+}
+
+// isUnchanged reports whether project can be skipped by an incremental backup.
+// A full backup (--full) never skips, but still refreshes the state afterwards.
+func (a *App) isUnchanged(st *state.Store, project gitlab.Project) bool {
+	if st == nil || a.cfg.FullBackup {
+		return false
+	}
+	return st.Unchanged(project.ID, project.LastActivityAt)
+}
+
+// saveState persists the incremental backup state, if enabled.
+func (a *App) saveState(st *state.Store) error {
+	if st == nil {
+		return nil
+	}
+	if err := st.Save(); err != nil {
+		return fmt.Errorf("failed to save incremental state: %w", err)
+	}
+	return nil
+}
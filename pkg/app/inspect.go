@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"filippo.io/age"
+	"github.com/sgaunet/gitlab-backup/pkg/encryption"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
+)
+
+// Inspection describes a stored archive, see App.Inspect.
+type Inspection struct {
+	Key       string `json:"key"`
+	Encrypted bool   `json:"encrypted"` // age-encrypted, summarized decrypted
+	*storage.ExportSummary
+}
+
+// Inspect reads the whole archive stored under key and summarizes the GitLab
+// export it holds, see storage.InspectExport. An age-encrypted archive is
+// decrypted on the fly with the configured age identity file; nothing is
+// written to disk. A truncated or corrupted archive is an error, an
+// incomplete one is reported by the summary.
+func (a *App) Inspect(ctx context.Context, key string) (*Inspection, error) {
+	var identities []age.Identity
+	if a.cfg.Age.IdentityFile != "" {
+		var err error
+		if identities, err = encryption.ParseIdentitiesFile(a.cfg.Age.IdentityFile); err != nil {
+			return nil, fmt.Errorf("failed to load age identity: %w", err)
+		}
+	}
+
+	rc, err := a.storage.Open(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+	r, encrypted, err := encryption.NewDecryptReader(rc, identities)
+	if err != nil {
+		return nil, fmt.Errorf("archive %s: %w", key, err)
+	}
+	summary, err := storage.InspectExport(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("archive %s: %w", key, err)
+	}
+	return &Inspection{Key: key, Encrypted: encrypted, ExportSummary: summary}, nil
+}
+
+// Write writes the inspection to w in format (FormatText or FormatJSON).
+func (i *Inspection) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(i); err != nil {
+			return fmt.Errorf("failed to write inspection: %w", err)
+		}
+		return nil
+	case FormatText, "":
+		return i.writeText(w)
+	default:
+		return fmt.Errorf("%w: %q (want %s or %s)", ErrInvalidFormat, format, FormatText, FormatJSON)
+	}
+}
+
+// writeText writes the inspection as aligned "field: value" lines.
+func (i *Inspection) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	encrypted := "no"
+	if i.Encrypted {
+		encrypted = "yes (age)"
+	}
+	fmt.Fprintf(tw, "Archive:\t%s\n", i.Key)
+	fmt.Fprintf(tw, "Encrypted:\t%s\n", encrypted)
+	fmt.Fprintf(tw, "Export:\t%s, version %s\n", i.Kind, i.Version)
+	fmt.Fprintf(tw, "Entries:\t%d (%s uncompressed)\n", i.Entries, formatSize(i.Size))
+	if i.Kind == storage.ExportKindProject {
+		fmt.Fprintf(tw, "Name:\t%s\n", i.Name)
+		fmt.Fprintf(tw, "Description:\t%s\n", i.Description)
+		fmt.Fprintf(tw, "Issues:\t%d\n", i.Issues)
+		fmt.Fprintf(tw, "Merge requests:\t%d\n", i.MergeRequests)
+		fmt.Fprintf(tw, "Labels:\t%d\n", i.Labels)
+		fmt.Fprintf(tw, "Milestones:\t%d\n", i.Milestones)
+		fmt.Fprintf(tw, "CI pipelines:\t%d\n", i.Pipelines)
+		fmt.Fprintf(tw, "Releases:\t%d\n", i.Releases)
+		fmt.Fprintf(tw, "LFS objects:\t%d\n", i.LFSObjects)
+		if i.EmptyRepository {
+			fmt.Fprintf(tw, "Repository refs:\t%d (empty repository, no %s)\n", i.Refs, storage.ExportBundleEntry)
+		} else {
+			fmt.Fprintf(tw, "Repository refs:\t%d (%d branches, %d tags)\n", i.Refs, i.Branches, i.Tags)
+		}
+	}
+	if !i.Complete() {
+		fmt.Fprintf(tw, "Missing:\t%s\n", strings.Join(i.Missing, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write inspection: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package app_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/sgaunet/gitlab-backup/pkg/app"
+	"github.com/sgaunet/gitlab-backup/pkg/encryption"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
+	"github.com/sgaunet/gitlab-backup/pkg/storage/localstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// projectExport returns an ndjson project export with two issues.
+func projectExport(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for _, f := range [][2]string{
+		{"./VERSION", "0.2.4"},
+		{"./tree/project.json", `{"name":"app","description":"The app"}`},
+		{"./tree/project/issues.ndjson", "{\"iid\":1}\n{\"iid\":2}\n"},
+		{"./project.bundle", "# v2 git bundle\n2222222222222222222222222222222222222222 refs/heads/main\n\n"},
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f[0], Mode: 0o600, Size: int64(len(f[1]))}))
+		_, err := tw.Write([]byte(f[1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return buf.Bytes()
+}
+
+func TestApp_Inspect(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, "app-1.tar.gz"), projectExport(t), 0o600))
+	a := app.NewAppWithService(cfg, nil, localstorage.NewLocalStorage(storageDir), nil)
+
+	inspection, err := a.Inspect(context.Background(), "app-1.tar.gz")
+	require.NoError(t, err)
+	assert.False(t, inspection.Encrypted)
+	assert.Equal(t, "0.2.4", inspection.Version)
+	assert.Equal(t, "app", inspection.Name)
+	assert.Equal(t, 2, inspection.Issues)
+	assert.Equal(t, 1, inspection.Branches)
+
+	var text bytes.Buffer
+	require.NoError(t, inspection.Write(&text, app.FormatText))
+	assert.Contains(t, text.String(), "Issues:          2\n")
+	assert.Contains(t, text.String(), "Repository refs: 1 (1 branches, 0 tags)\n")
+
+	var js bytes.Buffer
+	require.NoError(t, inspection.Write(&js, app.FormatJSON))
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(js.Bytes(), &decoded))
+	assert.Equal(t, "app-1.tar.gz", decoded["key"])
+	assert.InDelta(t, 2, decoded["issues"], 0)
+	require.ErrorIs(t, inspection.Write(&js, "yaml"), app.ErrInvalidFormat)
+
+	_, err = a.Inspect(context.Background(), "missing.tar.gz")
+	require.ErrorIs(t, err, storage.ErrObjectNotFound)
+}
+
+func TestApp_Inspect_Encrypted(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	var encrypted bytes.Buffer
+	w, err := age.Encrypt(&encrypted, id.Recipient())
+	require.NoError(t, err)
+	_, err = w.Write(projectExport(t))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, "app-1.tar.gz.age"), encrypted.Bytes(), 0o600))
+	a := app.NewAppWithService(cfg, nil, localstorage.NewLocalStorage(storageDir), nil)
+
+	_, err = a.Inspect(context.Background(), "app-1.tar.gz.age")
+	require.ErrorIs(t, err, encryption.ErrIdentityRequired)
+
+	cfg.Age.IdentityFile = filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(cfg.Age.IdentityFile, []byte(id.String()+"\n"), 0o600))
+	inspection, err := a.Inspect(context.Background(), "app-1.tar.gz.age")
+	require.NoError(t, err)
+	assert.True(t, inspection.Encrypted)
+	assert.Equal(t, "app", inspection.Name)
+	assert.True(t, inspection.Complete())
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/sgaunet/gitlab-backup/pkg/checksum"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+)
+
+// archiveInfo describes an archive handed to storage.
+type archiveInfo struct {
+	key       string
+	size      int64
+	sha256    string
+	encrypted bool
+}
+
+// describeArchive computes the size and SHA-256 of the final archive (after
+// the postbackup hook and encryption) that will be stored under key.
+func describeArchive(archivePath, key string, encrypted bool) (archiveInfo, error) {
+	f, err := os.Open(archivePath) //nolint:gosec // G304: archive path is built by the app in TmpDir
+	if err != nil {
+		return archiveInfo{}, fmt.Errorf("failed to open archive %s: %w", archivePath, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return archiveInfo{}, fmt.Errorf("failed to hash archive %s: %w", archivePath, err)
+	}
+	return archiveInfo{
+		key:       key,
+		size:      size,
+		sha256:    hex.EncodeToString(h.Sum(nil)),
+		encrypted: encrypted,
+	}, nil
+}
+
+// writeManifest uploads the run manifest built from summary to the storage
+// backend, next to the archives, as manifest-{runID}.json.
+func (a *App) writeManifest(ctx context.Context, summary *backupSummary) error {
+	m := summary.manifest(a.version, a.cfg.GitlabURI)
+	data, err := m.Marshal()
+	if err != nil {
+		return err //nolint:wrapcheck // already wrapped by manifest.Marshal
+	}
+
+	key := manifest.Key(m.RunID)
+	if err := a.storeObject(ctx, key, data); err != nil {
+		return fmt.Errorf("failed to store manifest %s: %w", key, err)
+	}
+	a.log.Info("run manifest stored", "key", key, "projects", len(m.Projects))
+	return nil
+}
+
+// storeSidecar stores the SHA-256 sidecar of archive when checksumSidecars is
+// enabled, once the archive itself is stored.
+func (a *App) storeSidecar(ctx context.Context, archive archiveInfo) error {
+	if !a.cfg.ChecksumSidecars {
+		return nil
+	}
+	key := checksum.SidecarKey(archive.key)
+	if err := a.storeObject(ctx, key, checksum.FormatSidecar(archive.sha256, archive.key)); err != nil {
+		return fmt.Errorf("failed to store checksum sidecar %s: %w", key, err)
+	}
+	return nil
+}
+
+// storeObject stores data under key through a temporary file in TmpDir,
+// named after key.
+func (a *App) storeObject(ctx context.Context, key string, data []byte) error {
+	base := path.Base(key)
+	ext := path.Ext(base)
+	tmp, err := os.CreateTemp(a.cfg.TmpDir, strings.TrimSuffix(base, ext)+"-*"+ext)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+	}
+	if err := a.storage.SaveFile(ctx, tmpPath, key); err != nil {
+		return fmt.Errorf("failed to save file to storage: %w", err)
+	}
+	return nil
+}
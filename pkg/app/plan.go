@@ -0,0 +1,263 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/checkpoint"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+)
+
+// Planned actions of a project. Skipped projects use the run manifest statuses.
+const (
+	PlanActionExport  = "export"
+	PlanActionResumed = "resumed" // exported before an interruption, kept by --resume
+)
+
+// Plan describes what a backup run would do, see App.Plan.
+type Plan struct {
+	RunID    string           `json:"runId"`
+	Projects []PlannedProject `json:"projects"`
+	Groups   []PlannedGroup   `json:"groups,omitempty"`
+	Errors   []string         `json:"errors,omitempty"` // targets whose projects cannot be listed
+	// Exports is the number of project exports, EstimatedSize their total
+	// estimated size in bytes.
+	Exports       int   `json:"exports"`
+	EstimatedSize int64 `json:"estimatedSize"`
+	// EstimatedDurationSecs is the time the GitLab rate limits alone impose on
+	// the exports and downloads; export processing and transfers come on top.
+	EstimatedDurationSecs int64 `json:"estimatedDurationSecs"`
+}
+
+// PlannedProject is a project of the plan.
+type PlannedProject struct {
+	ID            int64  `json:"id"`
+	Path          string `json:"path"`
+	Target        string `json:"target"`
+	Action        string `json:"action"`           // export, resumed, or a manifest skip status
+	Reason        string `json:"reason,omitempty"` // why a filtered project is left out
+	Key           string `json:"key,omitempty"`    // storage key of the archive, for exports
+	EstimatedSize int64  `json:"estimatedSize,omitempty"`
+}
+
+// PlannedGroup is a native group export of the plan (exportGroupArchive).
+type PlannedGroup struct {
+	ID     int64  `json:"id"`
+	Path   string `json:"path"`
+	Target string `json:"target"`
+	Key    string `json:"key,omitempty"`
+	Error  string `json:"error,omitempty"` // the group could not be fetched
+}
+
+// EstimatedDuration returns EstimatedDurationSecs as a duration.
+func (p *Plan) EstimatedDuration() time.Duration {
+	return time.Duration(p.EstimatedDurationSecs) * time.Second
+}
+
+// Plan resolves the targets, project filters, incremental state and
+// checkpoint (with --resume) exactly like a backup run, and returns what the
+// run would do. Nothing is exported, stored or written.
+//
+// Group listings carry no statistics, so every project to export without an
+// estimated size is fetched again for it.
+func (a *App) Plan(ctx context.Context) (*Plan, error) {
+	run, err := a.startRun()
+	if err != nil {
+		return nil, err
+	}
+	targets, err := a.planTargets(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{RunID: run.summary.runID, Projects: []PlannedProject{}}
+	for _, tp := range targets {
+		if a.cfg.ExportGroupArchive && tp.target.Group > 0 {
+			plan.Groups = append(plan.Groups, a.planGroup(ctx, tp.target, run.summary.startTime))
+		}
+		for _, project := range tp.projects {
+			pp, err := a.planProject(ctx, run, tp.target, project)
+			if err != nil {
+				return nil, err
+			}
+			plan.Projects = append(plan.Projects, pp)
+			if pp.Action == PlanActionExport {
+				plan.Exports++
+				plan.EstimatedSize += pp.EstimatedSize
+			}
+		}
+	}
+	for _, f := range run.summary.targetSnapshot() {
+		plan.Errors = append(plan.Errors, fmt.Sprintf("%s: %v", f.target, f.err))
+	}
+	plan.EstimatedDurationSecs = int64(rateLimitedDuration(plan.Exports, len(plan.Groups)) / time.Second)
+	return plan, nil
+}
+
+// planTargets lists the projects of the configured targets. A single project
+// configuration is planned like runProject, which ignores the incremental
+// state and the checkpoint.
+func (a *App) planTargets(ctx context.Context, run *backupRun) ([]targetProjects, error) {
+	switch {
+	case len(a.cfg.Targets) > 0:
+		return a.resolveTargets(ctx, run.summary), nil
+	case a.cfg.GitlabGroupID != 0:
+		group := &config.TargetConfig{Group: a.cfg.GitlabGroupID}
+		projects, err := a.listTargetProjects(ctx, group)
+		if err != nil {
+			return nil, err
+		}
+		return []targetProjects{{target: group, projects: projects}}, nil
+	default:
+		target := &config.TargetConfig{Project: a.cfg.GitlabProjectID}
+		projects, err := a.listTargetProjects(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		run.incremental = nil
+		run.checkpoint = checkpoint.New(a.checkpointPath(), 0, run.summary.startTime)
+		return []targetProjects{{target: target, projects: projects}}, nil
+	}
+}
+
+// planProject returns the planned action of project of target t.
+func (a *App) planProject(
+	ctx context.Context,
+	run *backupRun,
+	t *config.TargetConfig,
+	project gitlab.Project,
+) (PlannedProject, error) {
+	pp := PlannedProject{ID: project.ID, Path: project.PathWithNamespace, Target: t.String()}
+	if pp.Path == "" {
+		pp.Path = project.Name
+	}
+	action, reason := a.decideProject(ctx, run, t, project)
+	switch action {
+	case actionCompleted:
+		done, _ := run.checkpoint.Completed(project.ID)
+		pp.Action, pp.Key, pp.EstimatedSize = PlanActionResumed, done.ArchiveKey, done.Size
+	case actionArchived:
+		pp.Action = manifest.StatusSkipped
+	case actionFiltered:
+		pp.Action, pp.Reason = manifest.StatusFiltered, reason
+	case actionUnchanged:
+		pp.Action = manifest.StatusUnchanged
+	case actionExport:
+		if project.EstimatedSize == 0 {
+			if p, err := a.gitlabService.GetProject(ctx, project.ID); err != nil {
+				a.log.Warn("failed to get project size", "project name", project.Name, "error", err)
+			} else {
+				project.EstimatedSize = p.EstimatedSize
+			}
+		}
+		key, err := a.archiveKey(t, project, run.summary.startTime)
+		if err != nil {
+			return pp, err
+		}
+		pp.Action, pp.Key, pp.EstimatedSize = PlanActionExport, key, project.EstimatedSize
+	}
+	return pp, nil
+}
+
+// planGroup returns the planned native export of the group of target t.
+func (a *App) planGroup(ctx context.Context, t *config.TargetConfig, runStart time.Time) PlannedGroup {
+	pg := PlannedGroup{ID: t.Group, Target: t.String()}
+	group, err := a.gitlabService.GetGroup(ctx, t.Group)
+	if err == nil {
+		pg.Path = group.FullPath
+		pg.Key, err = a.groupArchiveKey(t, group, runStart)
+	}
+	if err != nil {
+		pg.Error = err.Error()
+	}
+	return pg
+}
+
+// rateLimitedDuration returns how long the GitLab rate limiters hold back
+// the given number of project and group exports: each limiter lets its burst
+// through at once, then one request per interval. Exports and downloads
+// proceed in parallel, so the slowest limiter sets the pace.
+func rateLimitedDuration(projects, groups int) time.Duration {
+	wait := func(requests, burst, intervalSecs int) time.Duration {
+		return time.Duration(max(requests-burst, 0)*intervalSecs) * time.Second
+	}
+	return max(
+		wait(projects+groups, constants.ExportRateLimitBurst, constants.ExportRateLimitIntervalSeconds),
+		wait(projects, constants.DownloadRateLimitBurst, constants.DownloadRateLimitIntervalSeconds),
+		wait(groups, constants.GroupDownloadRateLimitBurst, constants.GroupDownloadRateLimitIntervalSeconds),
+	)
+}
+
+// Write writes the plan to w in format (FormatText or FormatJSON).
+func (p *Plan) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(p); err != nil {
+			return fmt.Errorf("failed to write plan: %w", err)
+		}
+		return nil
+	case FormatText, "":
+		return p.writeText(w)
+	default:
+		return fmt.Errorf("%w: %q (want %s or %s)", ErrInvalidFormat, format, FormatText, FormatJSON)
+	}
+}
+
+// writeText writes the plan as aligned columns followed by the totals.
+func (p *Plan) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd // column padding
+	fmt.Fprintln(tw, "TARGET\tPROJECT\tACTION\tSIZE\tKEY / REASON")
+	for _, pp := range p.Projects {
+		detail := pp.Key
+		if pp.Reason != "" {
+			detail = pp.Reason
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", pp.Target, pp.Path, pp.Action, formatSize(pp.EstimatedSize), detail)
+	}
+	for _, g := range p.Groups {
+		detail := g.Key
+		if g.Error != "" {
+			detail = "error: " + g.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\tgroup export\t-\t%s\n", g.Target, g.Path, detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+
+	for _, e := range p.Errors {
+		fmt.Fprintf(w, "error: %s\n", e)
+	}
+	_, err := fmt.Fprintf(w, "\n%d project(s) to export, %s estimated, at least %s under the GitLab rate limits\n",
+		p.Exports, formatSize(p.EstimatedSize), p.EstimatedDuration())
+	if err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// formatSize formats a size in bytes with a binary unit; zero is unknown.
+func formatSize(size int64) string {
+	if size <= 0 {
+		return "-"
+	}
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
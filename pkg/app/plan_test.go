@@ -0,0 +1,168 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	gitlabMocks "github.com/sgaunet/gitlab-backup/pkg/gitlab/mocks"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+	"github.com/sgaunet/gitlab-backup/pkg/state"
+	"github.com/sgaunet/gitlab-backup/pkg/storage/localstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_Plan_Group(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 100
+	cfg.ArchiveKeyTemplate = "{namespace}/{path}-{id}.tar.gz"
+	cfg.ExportGroupArchive = true
+	cfg.Filters = config.FiltersConfig{ExcludePaths: []string{"grp/sandbox-*"}}
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+	activity := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	st, err := state.Load(cfg.StateFile)
+	require.NoError(t, err)
+	st.Record(4, activity.Add(time.Hour), activity)
+	require.NoError(t, st.Save())
+	stateBefore, err := os.ReadFile(cfg.StateFile)
+	require.NoError(t, err)
+
+	projects := []gitlab.Project{
+		{ID: 1, Name: "app", PathWithNamespace: "grp/app", LastActivityAt: activity},
+		{ID: 2, Name: "sandbox-x", PathWithNamespace: "grp/sandbox-x"},
+		{ID: 3, Name: "old", PathWithNamespace: "grp/old", Archived: true},
+		{ID: 4, Name: "quiet", PathWithNamespace: "grp/quiet", LastActivityAt: activity},
+		{ID: 5, Name: "api", PathWithNamespace: "grp/sub/api"},
+	}
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return projects, nil
+		},
+		// Group listings carry no statistics: sizes come from GetProject.
+		GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
+			p := projects[projectID-1]
+			p.EstimatedSize = projectID * 1024 * 1024
+			return p, nil
+		},
+		GetGroupFunc: func(_ context.Context, groupID int64) (gitlab.Group, error) {
+			return gitlab.Group{ID: groupID, Name: "grp", Path: "grp", FullPath: "grp"}, nil
+		},
+	}
+
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	plan, err := a.Plan(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []app.PlannedProject{
+		{ID: 1, Path: "grp/app", Target: "group 100", Action: app.PlanActionExport,
+			Key: "grp/app-1.tar.gz", EstimatedSize: 1024 * 1024},
+		{ID: 2, Path: "grp/sandbox-x", Target: "group 100", Action: manifest.StatusFiltered,
+			Reason: `path matches exclude pattern "grp/sandbox-*"`},
+		{ID: 3, Path: "grp/old", Target: "group 100", Action: manifest.StatusSkipped},
+		{ID: 4, Path: "grp/quiet", Target: "group 100", Action: manifest.StatusUnchanged},
+		{ID: 5, Path: "grp/sub/api", Target: "group 100", Action: app.PlanActionExport,
+			Key: "grp/sub/api-5.tar.gz", EstimatedSize: 5 * 1024 * 1024},
+	}, plan.Projects)
+	require.Len(t, plan.Groups, 1)
+	assert.Equal(t, "grp", plan.Groups[0].Path)
+	assert.NotEmpty(t, plan.Groups[0].Key)
+	assert.Equal(t, 2, plan.Exports)
+	assert.Equal(t, int64(6*1024*1024), plan.EstimatedSize)
+	assert.Zero(t, plan.EstimatedDurationSecs, "two exports fit in the rate limit bursts")
+
+	// Nothing is exported, stored or written.
+	assert.Empty(t, svc.ExportProjectCalls())
+	assert.Empty(t, svc.ExportGroupCalls())
+	entries, err := os.ReadDir(storageDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	stateAfter, err := os.ReadFile(cfg.StateFile)
+	require.NoError(t, err)
+	assert.Equal(t, stateBefore, stateAfter)
+
+	var text bytes.Buffer
+	require.NoError(t, plan.Write(&text, app.FormatText))
+	assert.Contains(t, text.String(), "grp/sub/api-5.tar.gz")
+	assert.Contains(t, text.String(), "5.0 MiB")
+	assert.Contains(t, text.String(), "2 project(s) to export, 6.0 MiB estimated")
+
+	var js bytes.Buffer
+	require.NoError(t, plan.Write(&js, app.FormatJSON))
+	var decoded app.Plan
+	require.NoError(t, json.Unmarshal(js.Bytes(), &decoded))
+	assert.Equal(t, *plan, decoded)
+
+	require.ErrorIs(t, plan.Write(&js, "yaml"), app.ErrInvalidFormat)
+}
+
+func TestApp_Plan_DurationUnderRateLimits(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 100
+	projects := make([]gitlab.Project, 20)
+	for i := range projects {
+		projects[i] = gitlab.Project{ID: int64(i + 1), Name: "p", EstimatedSize: 1}
+	}
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return projects, nil
+		},
+	}
+
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	plan, err := a.Plan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 20, plan.Exports)
+	assert.Empty(t, svc.GetProjectCalls(), "listed sizes need no extra request")
+	// Downloads are the bottleneck: a burst of 5, then one per minute.
+	assert.Equal(t, 15*time.Minute, plan.EstimatedDuration())
+}
+
+func TestApp_Plan_Targets(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.Targets = []config.TargetConfig{
+		{Group: 10, StoragePrefix: "team-a"},
+		{User: "alice"},
+	}
+	svc := targetsService(t, errors.New("forbidden"))
+
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	plan, err := a.Plan(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, plan.Projects, 2)
+	assert.Equal(t, "user alice", plan.Projects[0].Target)
+	assert.Equal(t, "p3-3.tar.gz", plan.Projects[0].Key)
+	assert.Equal(t, []string{"group 10: failed to get projects of group 10: forbidden"}, plan.Errors)
+
+	var text bytes.Buffer
+	require.NoError(t, plan.Write(&text, app.FormatText))
+	assert.Contains(t, text.String(), "error: group 10: failed to get projects of group 10: forbidden")
+}
+
+func TestApp_Plan_Project(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabProjectID = 7
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
+			return gitlab.Project{ID: projectID, Name: "solo", PathWithNamespace: "me/solo", EstimatedSize: 2048}, nil
+		},
+	}
+
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	plan, err := a.Plan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []app.PlannedProject{{
+		ID: 7, Path: "me/solo", Target: "project 7", Action: app.PlanActionExport,
+		Key: "solo-7.tar.gz", EstimatedSize: 2048,
+	}}, plan.Projects)
+}
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sgaunet/gitlab-backup/pkg/archivekey"
+	"github.com/sgaunet/gitlab-backup/pkg/checksum"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+	"github.com/sgaunet/gitlab-backup/pkg/retention"
+)
+
+// ErrPruneErrors is returned when some archives selected for removal could not be deleted.
+var ErrPruneErrors = errors.New("errors occurred during prune")
+
+// retentionPolicy converts the configured retention rules.
+func (a *App) retentionPolicy() retention.Policy {
+	return retention.Policy{
+		KeepLast:    a.cfg.Retention.KeepLast,
+		KeepDaily:   a.cfg.Retention.KeepDaily,
+		KeepWeekly:  a.cfg.Retention.KeepWeekly,
+		KeepMonthly: a.cfg.Retention.KeepMonthly,
+	}
+}
+
+// archiveOwner identifies the project, or the group for native group
+// exports, whose archives share a retention history.
+type archiveOwner struct {
+	group bool
+	id    int64
+}
+
+// logAttrs returns the log attributes naming the owner.
+func (o archiveOwner) logAttrs() []any {
+	if o.group {
+		return []any{"group", o.id}
+	}
+	return []any{"project", o.id}
+}
+
+// Prune applies the retention policy to every project and group archive found in storage.
+// With dryRun set, the archives that would be deleted are only logged.
+func (a *App) Prune(ctx context.Context, dryRun bool) error {
+	return a.prune(ctx, nil, dryRun)
+}
+
+// pruneAfterBackup applies the retention policy to the projects (and group)
+// that were successfully backed up in this run. It is a no-op without
+// retention rules, so a failed export never costs a project one of its older archives.
+func (a *App) pruneAfterBackup(ctx context.Context, summary *backupSummary) error {
+	if !a.cfg.Retention.IsEnabled() {
+		return nil
+	}
+	only := make(map[archiveOwner]bool)
+	for _, r := range summary.snapshot() {
+		if r.status == statusSuccess {
+			only[archiveOwner{id: r.project.ID}] = true
+		}
+	}
+	for _, g := range summary.groupSnapshot() {
+		if g.err == nil {
+			only[archiveOwner{group: true, id: g.group.ID}] = true
+		}
+	}
+	if len(only) == 0 {
+		return nil
+	}
+	return a.prune(ctx, only, false)
+}
+
+// prune applies the retention policy per project and per group. only restricts
+// pruning to the given owners; nil means every archive found in storage.
+func (a *App) prune(ctx context.Context, only map[archiveOwner]bool, dryRun bool) error {
+	policy := a.retentionPolicy()
+	if !policy.Enabled() {
+		a.log.Info("[PRUNE] no retention rule configured, nothing to prune")
+		return nil
+	}
+
+	byOwner, sidecars, err := a.listArchivesByOwner(ctx, only)
+	if err != nil {
+		return err
+	}
+	owners := make([]archiveOwner, 0, len(byOwner))
+	for o := range byOwner {
+		owners = append(owners, o)
+	}
+	slices.SortFunc(owners, func(x, y archiveOwner) int {
+		if x.group != y.group {
+			if x.group {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(x.id, y.id)
+	})
+
+	var projects, groups, kept, deleted, failed int
+	for _, o := range owners {
+		if o.group {
+			groups++
+		} else {
+			projects++
+		}
+		for _, d := range retention.Apply(policy, byOwner[o]) {
+			key := d.Archive.Key
+			switch {
+			case d.Keep:
+				kept++
+				a.log.Debug("[PRUNE] keep", append(o.logAttrs(), "key", key, "reasons", strings.Join(d.Reasons, ","))...)
+			case dryRun:
+				deleted++
+				a.log.Info("[PRUNE] would delete", append(o.logAttrs(), "key", key, "time", d.Archive.Time)...)
+			case a.deleteArchive(ctx, o, d.Archive, sidecars):
+				deleted++
+			default:
+				failed++
+			}
+		}
+	}
+
+	a.log.Info("[PRUNE] completed",
+		"policy", policy.String(),
+		"projects", projects,
+		"groups", groups,
+		"kept", kept,
+		"deleted", deleted,
+		"failed", failed,
+		"dryRun", dryRun,
+	)
+	if failed > 0 {
+		return fmt.Errorf("%w: %d archive(s) could not be deleted", ErrPruneErrors, failed)
+	}
+	return nil
+}
+
+// deleteArchive deletes archive of owner o, then its checksum sidecar if
+// found in sidecars. It reports whether the archive was deleted; a sidecar left
+// behind is only logged.
+func (a *App) deleteArchive(
+	ctx context.Context,
+	o archiveOwner,
+	archive retention.Archive,
+	sidecars map[string]bool,
+) bool {
+	if err := a.storage.Delete(ctx, archive.Key); err != nil {
+		a.log.Error("[PRUNE] failed to delete archive", append(o.logAttrs(), "key", archive.Key, "error", err)...)
+		return false
+	}
+	a.log.Info("[PRUNE] deleted", append(o.logAttrs(), "key", archive.Key, "time", archive.Time)...)
+	if sidecar := checksum.SidecarKey(archive.Key); sidecars[sidecar] {
+		if err := a.storage.Delete(ctx, sidecar); err != nil {
+			a.log.Warn("[PRUNE] failed to delete checksum sidecar", append(o.logAttrs(), "key", sidecar, "error", err)...)
+		}
+	}
+	return true
+}
+
+// listArchivesByOwner lists the stored archives and groups them by the project
+// or group ID found through the key template; objects the template cannot have
+// produced are left alone. only restricts the result as in prune. The keys of
+// the checksum sidecars found are returned too, deleted with their archive.
+func (a *App) listArchivesByOwner(
+	ctx context.Context,
+	only map[archiveOwner]bool,
+) (map[archiveOwner][]retention.Archive, map[string]bool, error) {
+	tmpl, err := archivekey.Parse(a.cfg.ArchiveKeyTemplate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse archive key template: %w", err)
+	}
+	objects, err := a.storage.List(ctx, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+	prefixes := a.cfg.KeyPrefixes()
+	byOwner := make(map[archiveOwner][]retention.Archive)
+	sidecars := make(map[string]bool)
+	for _, o := range objects {
+		if checksum.IsSidecarKey(o.Key) {
+			sidecars[o.Key] = true
+			continue
+		}
+		if manifest.IsManifestKey(o.Key) {
+			continue
+		}
+		owner, ok := archiveOwnerOf(tmpl, o.Key, prefixes)
+		if !ok || (only != nil && !only[owner]) {
+			continue
+		}
+		byOwner[owner] = append(byOwner[owner], retention.Archive{Key: o.Key, Time: o.ModTime})
+	}
+	return byOwner, sidecars, nil
+}
+
+// archiveOwnerOf returns the owner of the archive stored under key. The key is
+// matched as is, then without each target storage prefix.
+func archiveOwnerOf(tmpl *archivekey.Template, key string, prefixes []string) (archiveOwner, bool) {
+	candidates := []string{key}
+	for _, p := range prefixes {
+		if rest, found := strings.CutPrefix(key, p+"/"); found {
+			candidates = append(candidates, rest)
+		}
+	}
+	for _, k := range candidates {
+		owner := archiveOwner{group: archivekey.IsGroupKey(k)}
+		var ok bool
+		if owner.group {
+			owner.id, ok = tmpl.GroupID(k)
+		} else {
+			owner.id, ok = tmpl.ProjectID(k)
+		}
+		if ok {
+			return owner, true
+		}
+	}
+	return archiveOwner{}, false
+}
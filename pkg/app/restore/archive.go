@@ -0,0 +1,89 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"filippo.io/age"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
+)
+
+// archiveCheck validates the content of an archive stream.
+type archiveCheck func(ctx context.Context, r io.Reader) error
+
+// quickCheck only reads the first tar header, see storage.ValidateArchiveStream.
+func quickCheck(_ context.Context, r io.Reader) error {
+	return storage.ValidateArchiveStream(r) //nolint:wrapcheck // wrapped by archiveAccess.validate
+}
+
+// archiveAccess holds what reading the archives of a restore takes: the age
+// identities decrypting them and the checks of their content.
+type archiveAccess struct {
+	identities   []age.Identity
+	checkProject archiveCheck
+	checkGroup   archiveCheck
+}
+
+// newArchiveAccess loads the age identities of cfg and selects the archive
+// checks. By default the whole archives are read before anything is uploaded,
+// so that a truncated or corrupted archive fails right away rather than during
+// the GitLab import; RestoreQuickValidation only checks their first header.
+func newArchiveAccess(cfg *config.Config) (*archiveAccess, error) {
+	identities, err := loadIdentities(cfg)
+	if err != nil {
+		return nil, err
+	}
+	a := &archiveAccess{
+		identities:   identities,
+		checkProject: storage.ValidateProjectExportStream,
+		checkGroup:   storage.ValidateArchiveStreamFull,
+	}
+	if cfg.RestoreQuickValidation {
+		a.checkProject, a.checkGroup = quickCheck, quickCheck
+	}
+	return a, nil
+}
+
+// open opens the archive at path, decrypting it on the fly, see openArchive.
+func (a *archiveAccess) open(path string) (io.ReadCloser, bool, error) {
+	return openArchive(path, a.identities)
+}
+
+// validate checks the archive file at path, then its content with check. An
+// age-encrypted archive is checked decrypted, without writing the plaintext;
+// encrypted reports whether it was.
+func (a *archiveAccess) validate(ctx context.Context, path string, check archiveCheck) (bool, error) {
+	if err := storage.CheckArchiveFile(path); err != nil {
+		return false, err //nolint:wrapcheck // the error names the archive
+	}
+	r, encrypted, err := a.open(path)
+	if err != nil {
+		return encrypted, err
+	}
+	defer func() { _ = r.Close() }()
+	if err := check(ctx, r); err != nil {
+		return encrypted, fmt.Errorf("invalid archive %s: %w", path, err)
+	}
+	return encrypted, nil
+}
+
+// extractArchive validates the project archive at archivePath and returns its
+// contents. Archives are direct GitLab exports: nothing is extracted.
+func (a *archiveAccess) extractArchive(
+	ctx context.Context,
+	archivePath string,
+	destDir string,
+) (*storage.ArchiveContents, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
+	}
+	if _, err := a.validate(ctx, archivePath, a.checkProject); err != nil {
+		return nil, err
+	}
+	return &storage.ArchiveContents{
+		ProjectExportPath: archivePath,
+		ExtractionDir:     destDir,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package restore_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app/restore"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// truncatedArchive returns a copy of a valid archive missing its last bytes:
+// its first header is intact.
+func truncatedArchive(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(createValidArchive(t))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "truncated.tar.gz")
+	require.NoError(t, os.WriteFile(path, data[:len(data)-16], 0o600))
+	return path
+}
+
+func TestRestore_FullValidation_RejectsTruncatedArchive(t *testing.T) {
+	var uploaded []byte
+	cfg := successRestoreConfig(t, truncatedArchive(t))
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, captureImport(&uploaded)),
+		setupMockStorage(t), restore.NewNoOpProgressReporter())
+
+	result, err := orchestrator.Restore(context.Background(), cfg)
+	require.ErrorIs(t, err, storage.ErrArchiveCorrupted)
+	assert.Equal(t, restore.PhaseExtraction, result.Errors[0].Phase)
+	assert.Nil(t, uploaded, "nothing may be uploaded")
+
+	// The quick check only reads the first header: the import starts.
+	cfg.RestoreQuickValidation = true
+	result, err = orchestrator.Restore(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.NotNil(t, uploaded)
+}
+
+func TestRestore_FullValidation_RequiresExportEntries(t *testing.T) {
+	cfg := successRestoreConfig(t, createTestArchiveWith(t, "project.bundle"))
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, withImportSuccess),
+		setupMockStorage(t), restore.NewNoOpProgressReporter())
+
+	_, err := orchestrator.Restore(context.Background(), cfg)
+	require.ErrorIs(t, err, storage.ErrArchiveIncomplete)
+	assert.Contains(t, err.Error(), "VERSION")
+}
+
+func TestRestore_FullValidation_EmptyRepository(t *testing.T) {
+	// GitLab exports a project with an empty repository without project.bundle.
+	cfg := successRestoreConfig(t, createTestArchiveWith(t, "VERSION", "project.json"))
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, withImportSuccess),
+		setupMockStorage(t), restore.NewNoOpProgressReporter())
+
+	result, err := orchestrator.Restore(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+}
+
+func TestRestore_FullValidation_GroupArchive(t *testing.T) {
+	api := &groupRestoreAPI{existing: map[string]int64{"parent": 12}}
+	cfg := successRestoreConfig(t, createValidArchive(t))
+	cfg.RestoreTargetNS = "parent/restored"
+	cfg.RestoreGroupSource = truncatedArchive(t)
+
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, api.customize),
+		setupMockStorage(t), restore.NewNoOpProgressReporter())
+	result, err := orchestrator.Restore(context.Background(), cfg)
+	require.ErrorIs(t, err, storage.ErrArchiveCorrupted)
+	assert.Equal(t, restore.PhaseGroupImport, result.Errors[0].Phase)
+	assert.Empty(t, api.calls)
+
+	// A group export is not a project export: its entries are not checked.
+	cfg.RestoreGroupSource = createTestArchiveWith(t, "GITLAB_VERSION", "tree/groups/_all.ndjson")
+	result, err = orchestrator.Restore(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, int64(77), result.GroupID)
+}
@@ -0,0 +1,150 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"github.com/sgaunet/gitlab-backup/pkg/nsmap"
+	"golang.org/x/sync/errgroup"
+)
+
+// BulkResult aggregates the outcome of a bulk restore, see Orchestrator.RestoreBulk.
+type BulkResult struct {
+	// Success indicates whether every project was restored.
+	Success bool
+	// Projects holds the outcome of each project, sorted by source path.
+	Projects []BulkProjectResult
+	// Restored is the number of projects restored.
+	Restored int
+	// Failed is the number of projects whose restore failed.
+	Failed int
+	// DurationSeconds is the total bulk restore duration in seconds.
+	DurationSeconds int64
+	// CreatedGroups lists the full paths of the groups created for the
+	// missing target namespaces, parents first.
+	CreatedGroups []string
+	// Warnings lists the archives left out of the restore and the namespaces
+	// that could not be created.
+	Warnings []string
+}
+
+// BulkProjectResult is the outcome of one project of a bulk restore.
+type BulkProjectResult struct {
+	// Item is the archive and the target of the project.
+	Item BulkItem
+	// Result is the outcome of the project restore.
+	Result *Result
+}
+
+// projectReporter is implemented by the progress reporters that can name the
+// project of each message, for the concurrent restores of a bulk restore.
+type projectReporter interface {
+	WithProject(project string) ProgressReporter
+}
+
+// RestoreBulk restores every project archive of source (see PlanBulk) into
+// the root namespace cfg.RestoreTargetNS or, with cfg.RestoreMappingFile,
+// into the targets the mapping file gives their source paths. Projects are restored as Restore
+// does, at most cfg.MaxConcurrency at a time; their imports share the import
+// rate limiter of the GitLab service. A failed project does not stop the
+// others: the returned error is only set when no project could be planned.
+//
+// The missing target namespaces are created first, one at a time, with the
+// group metadata recorded by the backup.
+func (o *Orchestrator) RestoreBulk(ctx context.Context, cfg *config.Config, source string) (*BulkResult, error) {
+	startTime := time.Now()
+	catalog, ok := o.storage.(Catalog)
+	if !ok {
+		return nil, ErrBulkUnsupported
+	}
+	var mapping Mapper
+	if cfg.RestoreMappingFile != "" {
+		m, err := nsmap.Load(cfg.RestoreMappingFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load namespace mapping: %w", err)
+		}
+		mapping = m
+	}
+	plan, err := PlanBulk(ctx, catalog, source, cfg.RestoreTargetNS, mapping)
+	if err != nil {
+		return nil, err
+	}
+	items := plan.Items
+
+	result := &BulkResult{Projects: make([]BulkProjectResult, len(items)), Warnings: plan.Warnings}
+	o.createBulkNamespaces(ctx, cfg, plan, result)
+	workers := cfg.MaxConcurrency
+	if workers <= 0 {
+		workers = constants.DefaultMaxConcurrency
+	}
+	eg := errgroup.Group{}
+	eg.SetLimit(workers)
+	for i, item := range items {
+		eg.Go(func() error {
+			result.Projects[i] = o.restoreBulkItem(ctx, cfg, catalog, item)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	for _, p := range result.Projects {
+		if p.Result.Success {
+			result.Restored++
+		} else {
+			result.Failed++
+		}
+	}
+	result.Success = result.Failed == 0
+	result.DurationSeconds = int64(time.Since(startTime).Seconds())
+	return result, nil
+}
+
+// createBulkNamespaces creates the missing target namespaces of plan before
+// the projects are restored concurrently, so that no two restores create the
+// same group. A namespace that cannot be created is reported as a warning:
+// the restores of its projects fail on it again and record the error.
+func (o *Orchestrator) createBulkNamespaces(
+	ctx context.Context,
+	cfg *config.Config,
+	plan *BulkPlan,
+	result *BulkResult,
+) {
+	var namespaces []string
+	for _, item := range plan.Items {
+		namespaces = append(namespaces, item.TargetNS)
+	}
+	slices.Sort(namespaces)
+	for _, ns := range slices.Compact(namespaces) {
+		created, err := o.ensureNamespace(ctx, ns, plan.Groups, cfg.RestoreGroupVisibility)
+		result.CreatedGroups = append(result.CreatedGroups, created...)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("namespace %s: %v", ns, err))
+		}
+	}
+}
+
+// restoreBulkItem restores the project of item. Restore records its errors
+// in the result.
+func (o *Orchestrator) restoreBulkItem(
+	ctx context.Context,
+	cfg *config.Config,
+	catalog Catalog,
+	item BulkItem,
+) BulkProjectResult {
+	projectCfg := *cfg
+	projectCfg.RestoreSource = catalog.Source(item.ArchiveKey)
+	projectCfg.RestoreGroupSource = ""
+	projectCfg.RestoreTargetNS = item.TargetNS
+	projectCfg.RestoreTargetPath = item.TargetPath
+
+	project := *o
+	if r, ok := o.progress.(projectReporter); ok {
+		project.progress = r.WithProject(item.TargetNS + "/" + item.TargetPath)
+	}
+	result, _ := project.Restore(ctx, &projectCfg)
+	return BulkProjectResult{Item: item, Result: result}
+}
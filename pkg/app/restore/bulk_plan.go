@@ -0,0 +1,316 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/sgaunet/gitlab-backup/pkg/archivekey"
+	"github.com/sgaunet/gitlab-backup/pkg/checksum"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
+)
+
+var (
+	// ErrBulkUnsupported is returned when the storage of a bulk restore cannot
+	// list its archives.
+	ErrBulkUnsupported = errors.New("storage cannot list archives for a bulk restore")
+	// ErrNoBulkArchives is returned when a bulk restore finds no project archive.
+	ErrNoBulkArchives = errors.New("no project archive recorded by a run manifest")
+)
+
+// Catalog is implemented by the storages a bulk restore can read: it lists
+// the archives and reads the run manifests recording the project of each.
+type Catalog interface {
+	// List returns every object whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]storage.Object, error)
+	// Open returns a reader of the object stored under key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Source returns the RestoreSource of the archive stored under key.
+	Source(key string) string
+}
+
+// BulkItem is a project archive of a bulk restore and its target.
+type BulkItem struct {
+	// ArchiveKey is the storage key of the archive.
+	ArchiveKey string
+	// SourcePath is the full path of the project when it was backed up.
+	SourcePath string
+	// TargetNS is the namespace the project is restored into.
+	TargetNS string
+	// TargetPath is the path of the restored project.
+	TargetPath string
+}
+
+// BulkPlan is the outcome of PlanBulk.
+type BulkPlan struct {
+	// Items are the project archives to restore, sorted by source path.
+	Items []BulkItem
+	// Groups holds the metadata recorded by the backup of the target
+	// namespaces, keyed by target full path, to recreate the missing ones.
+	Groups map[string]GroupSpec
+	// Warnings lists the archives left out of the restore.
+	Warnings []string
+}
+
+// backupSet is the content of the run manifests a bulk restore reads.
+type backupSet struct {
+	projects []manifest.Project
+	groups   []manifest.Project
+	warnings []string
+}
+
+// Mapper maps the full path of a backed up project or group to its target
+// full path, and returns false when the path has no target.
+type Mapper interface {
+	Map(fullPath string) (string, bool)
+}
+
+// PlanBulk returns the project archives of a bulk restore and warnings about
+// the archives left out. source is the key of a run manifest, whose
+// successful exports are restored, or a directory or S3 prefix: the latest
+// archive of each project recorded by the run manifests of the storage root
+// is restored. Older archives, and those no manifest records (their project
+// path is unknown), are left out with a warning.
+//
+// Targets are given by mapping, usually a namespace mapping file. When
+// mapping is nil, targets keep the subgroup hierarchy: the namespace shared
+// by every source path is replaced by rootNS. Projects without target, or
+// whose target is taken by another project, are left out with a warning. The
+// groups recorded by the manifests give the metadata of the target namespaces.
+func PlanBulk(ctx context.Context, catalog Catalog, source, rootNS string, mapping Mapper) (*BulkPlan, error) {
+	var (
+		set *backupSet
+		err error
+	)
+	if manifest.IsManifestKey(source) {
+		set, err = manifestSet(ctx, catalog, source)
+	} else {
+		set, err = latestSet(ctx, catalog, source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &BulkPlan{Items: make([]BulkItem, 0, len(set.projects)), Warnings: set.warnings}
+	for _, p := range set.projects {
+		if !strings.Contains(p.FullPath, "/") {
+			plan.Warnings = append(plan.Warnings,
+				fmt.Sprintf("archive %s has no project path recorded, skipped", p.ArchiveKey))
+			continue
+		}
+		plan.Items = append(plan.Items, BulkItem{ArchiveKey: p.ArchiveKey, SourcePath: p.FullPath})
+	}
+	slices.SortFunc(plan.Items, func(a, b BulkItem) int { return strings.Compare(a.SourcePath, b.SourcePath) })
+	if mapping == nil {
+		mapping = newBulkTargets(plan.Items, rootNS)
+	}
+	plan.Items = mapItems(plan, mapping)
+	if len(plan.Items) == 0 {
+		return plan, fmt.Errorf("%w in %q", ErrNoBulkArchives, source)
+	}
+	plan.Groups = groupSpecs(set.groups, mapping)
+	return plan, nil
+}
+
+// mapItems sets the target of the items of plan and returns those with a
+// target of their own; the others are reported in the plan warnings.
+func mapItems(plan *BulkPlan, mapping Mapper) []BulkItem {
+	items := plan.Items[:0]
+	taken := make(map[string]string)
+	for _, item := range plan.Items {
+		target, ok := mapping.Map(item.SourcePath)
+		switch {
+		case !ok:
+			plan.Warnings = append(plan.Warnings,
+				fmt.Sprintf("project %s matches no namespace mapping rule, skipped", item.SourcePath))
+			continue
+		case !strings.Contains(target, "/"):
+			plan.Warnings = append(plan.Warnings,
+				fmt.Sprintf("project %s maps to %q, which has no namespace, skipped", item.SourcePath, target))
+			continue
+		case taken[target] != "":
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("project %s maps to %s, already the target of %s, skipped",
+				item.SourcePath, target, taken[target]))
+			continue
+		}
+		taken[target] = item.SourcePath
+		item.TargetNS, item.TargetPath = path.Dir(target), path.Base(target)
+		items = append(items, item)
+	}
+	return items
+}
+
+// manifestSet returns the projects successfully exported by the run of the
+// manifest stored under key, and the groups it records.
+func manifestSet(ctx context.Context, catalog Catalog, key string) (*backupSet, error) {
+	m, err := readManifest(ctx, catalog, key)
+	if err != nil {
+		return nil, err
+	}
+	set := &backupSet{groups: m.Groups}
+	for _, p := range m.Projects {
+		if p.Status == manifest.StatusSuccess && p.ArchiveKey != "" {
+			set.projects = append(set.projects, p)
+		}
+	}
+	return set, nil
+}
+
+// latestSet returns, for each project, the latest successful export recorded
+// by the run manifests whose archive is stored under prefix, and the groups
+// the manifests record. Manifests are stored at the storage root, whatever
+// the prefix.
+func latestSet(ctx context.Context, catalog Catalog, prefix string) (*backupSet, error) {
+	objects, err := catalog.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+	manifests, err := catalog.List(ctx, manifest.FilePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list run manifests: %w", err)
+	}
+	stored := make(map[string]bool)
+	for _, obj := range objects {
+		stored[obj.Key] = true
+	}
+	set, err := latestExports(ctx, catalog, manifests, stored)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := make(map[string]bool)
+	for _, p := range set.projects {
+		recorded[p.ArchiveKey] = true
+	}
+	for _, obj := range objects {
+		if isUnrecordedArchive(obj.Key, recorded) {
+			set.warnings = append(set.warnings, fmt.Sprintf("archive %s is not the latest export of a project "+
+				"recorded by a run manifest, skipped", obj.Key))
+		}
+	}
+	return set, nil
+}
+
+// latestExports reads the manifests, oldest first, and returns the latest
+// successful export of each project whose archive is stored, and the latest
+// record of each group.
+func latestExports(
+	ctx context.Context,
+	catalog Catalog,
+	manifests []storage.Object,
+	stored map[string]bool,
+) (*backupSet, error) {
+	keys := make([]string, 0, len(manifests))
+	for _, obj := range manifests {
+		if manifest.IsManifestKey(obj.Key) {
+			keys = append(keys, obj.Key)
+		}
+	}
+	slices.Sort(keys) // run IDs are sortable timestamps
+	projects := make(map[int64]manifest.Project)
+	groups := make(map[string]manifest.Project)
+	for _, key := range keys {
+		m, err := readManifest(ctx, catalog, key)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range m.Projects {
+			if p.Status == manifest.StatusSuccess && stored[p.ArchiveKey] {
+				projects[p.ID] = p
+			}
+		}
+		for _, g := range m.Groups {
+			groups[g.FullPath] = g
+		}
+	}
+	return &backupSet{
+		projects: slices.Collect(maps.Values(projects)),
+		groups:   slices.Collect(maps.Values(groups)),
+	}, nil
+}
+
+// isUnrecordedArchive reports whether key names a project archive that no
+// run manifest records.
+func isUnrecordedArchive(key string, recorded map[string]bool) bool {
+	return !recorded[key] && strings.Contains(path.Base(key), ".tar.gz") &&
+		!strings.HasSuffix(key, checksum.SidecarExtension) && !archivekey.IsGroupKey(key)
+}
+
+// readManifest reads and parses the run manifest stored under key.
+func readManifest(ctx context.Context, catalog Catalog, key string) (*manifest.Manifest, error) {
+	r, err := catalog.Open(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest %s: %w", key, err)
+	}
+	defer func() { _ = r.Close() }()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", key, err)
+	}
+	m, err := manifest.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", key, err)
+	}
+	return m, nil
+}
+
+// bulkTargets maps the source namespaces of a bulk restore to their target:
+// the namespace shared by every source path is replaced by the root
+// namespace, and the subgroups below it are kept.
+type bulkTargets struct {
+	common []string
+	rootNS string
+}
+
+// newBulkTargets returns the mapping of the source namespaces of items.
+func newBulkTargets(items []BulkItem, rootNS string) bulkTargets {
+	var common []string
+	for i, item := range items {
+		ns := strings.Split(path.Dir(item.SourcePath), "/")
+		if i == 0 {
+			common = ns
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(ns) && common[n] == ns[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return bulkTargets{common: common, rootNS: rootNS}
+}
+
+// Map returns the target of the source project or group fullPath, and false
+// when fullPath is not below the shared source namespace.
+func (t bulkTargets) Map(fullPath string) (string, bool) {
+	segments := strings.Split(fullPath, "/")
+	if len(segments) < len(t.common) || !slices.Equal(segments[:len(t.common)], t.common) {
+		return "", false
+	}
+	return path.Join(append([]string{t.rootNS}, segments[len(t.common):]...)...), true
+}
+
+// groupSpecs returns the metadata of the recorded groups that map to a target
+// namespace, keyed by target full path. The name of a group is kept only when
+// its path is: the root namespace usually has another name.
+func groupSpecs(recorded []manifest.Project, mapping Mapper) map[string]GroupSpec {
+	specs := make(map[string]GroupSpec)
+	for _, g := range recorded {
+		target, ok := mapping.Map(g.FullPath)
+		if !ok || g.Visibility == "" {
+			continue // older manifests record no group metadata
+		}
+		spec := GroupSpec{Description: g.Description, Visibility: g.Visibility}
+		if path.Base(target) == path.Base(g.FullPath) {
+			spec.Name = g.Name
+		}
+		specs[target] = spec
+	}
+	return specs
+}
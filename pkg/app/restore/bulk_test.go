@@ -0,0 +1,261 @@
+package restore_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app/restore"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+	"github.com/sgaunet/gitlab-backup/pkg/nsmap"
+	"github.com/sgaunet/gitlab-backup/pkg/storage/localstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlabAPI "gitlab.com/gitlab-org/api/client-go"
+)
+
+// bulkStorage is a local restore storage a bulk restore can list.
+type bulkStorage struct {
+	*localstorage.LocalStorage
+	dir string
+}
+
+func (s *bulkStorage) Get(_ context.Context, key string) (string, error) { return key, nil }
+
+func (s *bulkStorage) Source(key string) string { return filepath.Join(s.dir, filepath.FromSlash(key)) }
+
+// newBulkStorage returns a storage holding a valid archive under each of keys.
+func newBulkStorage(t *testing.T, keys ...string) *bulkStorage {
+	t.Helper()
+	dir := t.TempDir()
+	data, err := os.ReadFile(createValidArchive(t))
+	require.NoError(t, err)
+	for _, key := range keys {
+		path := filepath.Join(dir, filepath.FromSlash(key))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+	}
+	return &bulkStorage{LocalStorage: localstorage.NewLocalStorage(dir), dir: dir}
+}
+
+// writeManifest stores the run manifest of runID recording projects.
+func writeManifest(t *testing.T, s *bulkStorage, runID string, projects ...manifest.Project) {
+	t.Helper()
+	storeManifest(t, s, manifest.Manifest{Version: manifest.FormatVersion, RunID: runID, Projects: projects})
+}
+
+// storeManifest stores m under its manifest key.
+func storeManifest(t *testing.T, s *bulkStorage, m manifest.Manifest) {
+	t.Helper()
+	data, err := m.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(s.dir, manifest.Key(m.RunID)), data, 0o600))
+}
+
+func exported(id int64, fullPath, key string) manifest.Project {
+	return manifest.Project{ID: id, FullPath: fullPath, Status: manifest.StatusSuccess, ArchiveKey: key}
+}
+
+func TestPlanBulk_LatestArchiveKeepsHierarchy(t *testing.T) {
+	s := newBulkStorage(t, "acme/app-1.tar.gz", "acme/app-2.tar.gz", "acme/sub/lib-1.tar.gz", "acme/stray-1.tar.gz")
+	writeManifest(t, s, "20261001T000000Z", exported(1, "acme/app", "acme/app-1.tar.gz"),
+		exported(2, "acme/sub/lib", "acme/sub/lib-1.tar.gz"))
+	writeManifest(t, s, "20261002T000000Z", exported(1, "acme/app", "acme/app-2.tar.gz"),
+		manifest.Project{ID: 2, FullPath: "acme/sub/lib", Status: manifest.StatusFailed})
+
+	plan, err := restore.PlanBulk(context.Background(), s, "", "dr", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []restore.BulkItem{
+		{ArchiveKey: "acme/app-2.tar.gz", SourcePath: "acme/app", TargetNS: "dr", TargetPath: "app"},
+		{ArchiveKey: "acme/sub/lib-1.tar.gz", SourcePath: "acme/sub/lib", TargetNS: "dr/sub", TargetPath: "lib"},
+	}, plan.Items)
+	require.Len(t, plan.Warnings, 2)
+	assert.Contains(t, plan.Warnings[0], "acme/app-1.tar.gz")
+	assert.Contains(t, plan.Warnings[1], "acme/stray-1.tar.gz")
+
+	plan, err = restore.PlanBulk(context.Background(), s, "acme/sub", "dr", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []restore.BulkItem{
+		{ArchiveKey: "acme/sub/lib-1.tar.gz", SourcePath: "acme/sub/lib", TargetNS: "dr", TargetPath: "lib"},
+	}, plan.Items)
+}
+
+func TestPlanBulk_GroupMetadata(t *testing.T) {
+	s := newBulkStorage(t, "acme/sub/lib-1.tar.gz", "acme/sub/deep/tool-1.tar.gz")
+	m := manifest.Manifest{
+		Version: manifest.FormatVersion,
+		RunID:   "20261001T000000Z",
+		Projects: []manifest.Project{
+			exported(1, "acme/sub/lib", "acme/sub/lib-1.tar.gz"),
+			exported(2, "acme/sub/deep/tool", "acme/sub/deep/tool-1.tar.gz"),
+		},
+		Groups: []manifest.Project{
+			{FullPath: "acme", Name: "ACME", Visibility: "public"}, // above the shared namespace
+			{FullPath: "acme/sub", Name: "Sub", Visibility: "internal", Description: "The sub team"},
+			{FullPath: "acme/sub/deep", Name: "Deep", Visibility: "private"},
+			{FullPath: "acme/sub/old", Name: "Old"}, // no metadata recorded
+		},
+	}
+	storeManifest(t, s, m)
+
+	plan, err := restore.PlanBulk(context.Background(), s, manifest.Key(m.RunID), "dr", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]restore.GroupSpec{
+		"dr":      {Visibility: "internal", Description: "The sub team"},
+		"dr/deep": {Name: "Deep", Visibility: "private"},
+	}, plan.Groups)
+}
+
+func TestPlanBulk_Mapping(t *testing.T) {
+	s := newBulkStorage(t, "app-1.tar.gz", "lib-1.tar.gz", "old-1.tar.gz", "web-1.tar.gz", "misc-1.tar.gz")
+	m := manifest.Manifest{
+		Version: manifest.FormatVersion,
+		RunID:   "20261001T000000Z",
+		Projects: []manifest.Project{
+			exported(1, "acme/platform/app", "app-1.tar.gz"),
+			exported(2, "acme/platform/sub/lib", "lib-1.tar.gz"),
+			exported(3, "acme/legacy-web", "web-1.tar.gz"),
+			exported(4, "acme/legacy/web", "old-1.tar.gz"),
+			exported(5, "other/misc", "misc-1.tar.gz"),
+		},
+		Groups: []manifest.Project{{FullPath: "acme/platform", Name: "Platform", Visibility: "internal"}},
+	}
+	storeManifest(t, s, m)
+	mapping, err := nsmap.Parse([]byte(`
+rules:
+  - prefix: acme/platform
+    target: eng/platform
+  - regex: ^acme/legacy-(.+)$
+    target: eng/legacy/$1
+  - prefix: acme
+    target: eng
+`))
+	require.NoError(t, err)
+
+	plan, err := restore.PlanBulk(context.Background(), s, manifest.Key(m.RunID), "", mapping)
+	require.NoError(t, err)
+	assert.Equal(t, []restore.BulkItem{
+		{ArchiveKey: "web-1.tar.gz", SourcePath: "acme/legacy-web", TargetNS: "eng/legacy", TargetPath: "web"},
+		{ArchiveKey: "app-1.tar.gz", SourcePath: "acme/platform/app", TargetNS: "eng/platform", TargetPath: "app"},
+		{ArchiveKey: "lib-1.tar.gz", SourcePath: "acme/platform/sub/lib", TargetNS: "eng/platform/sub", TargetPath: "lib"},
+	}, plan.Items)
+	assert.Equal(t, map[string]restore.GroupSpec{"eng/platform": {Name: "Platform", Visibility: "internal"}}, plan.Groups)
+	require.Len(t, plan.Warnings, 2)
+	assert.Contains(t, plan.Warnings[0], "acme/legacy/web maps to eng/legacy/web, already the target of acme/legacy-web")
+	assert.Contains(t, plan.Warnings[1], "other/misc matches no namespace mapping rule")
+}
+
+func TestPlanBulk_Manifest(t *testing.T) {
+	s := newBulkStorage(t, "app-1.tar.gz", "app-2.tar.gz")
+	writeManifest(t, s, "20261001T000000Z", exported(1, "acme/app", "app-1.tar.gz"))
+	writeManifest(t, s, "20261002T000000Z", exported(1, "acme/app", "app-2.tar.gz"))
+
+	plan, err := restore.PlanBulk(context.Background(), s, manifest.Key("20261001T000000Z"), "dr", nil)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Warnings)
+	assert.Equal(t, []restore.BulkItem{
+		{ArchiveKey: "app-1.tar.gz", SourcePath: "acme/app", TargetNS: "dr", TargetPath: "app"},
+	}, plan.Items)
+}
+
+func TestPlanBulk_NoArchives(t *testing.T) {
+	s := newBulkStorage(t, "app-1.tar.gz")
+
+	plan, err := restore.PlanBulk(context.Background(), s, "", "dr", nil)
+	require.ErrorIs(t, err, restore.ErrNoBulkArchives)
+	assert.Len(t, plan.Warnings, 1)
+}
+
+func TestRestoreBulk(t *testing.T) {
+	s := newBulkStorage(t, "app-1.tar.gz", "lib-1.tar.gz", "tool-1.tar.gz")
+	writeManifest(t, s, "20261001T000000Z", exported(1, "acme/app", "app-1.tar.gz"),
+		exported(2, "acme/lib", "lib-1.tar.gz"), exported(3, "acme/tool", "tool-1.tar.gz"))
+	cfg := successRestoreConfig(t, "")
+	cfg.RestoreTargetNS = "dr"
+	cfg.MaxConcurrency = 2
+
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, withImportSuccess), s,
+		restore.NewNoOpProgressReporter())
+	result, err := orchestrator.RestoreBulk(context.Background(), cfg, "")
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 3, result.Restored)
+	assert.Zero(t, result.Failed)
+	require.Len(t, result.Projects, 3)
+	for i, path := range []string{"acme/app", "acme/lib", "acme/tool"} {
+		assert.Equal(t, path, result.Projects[i].Item.SourcePath)
+		assert.True(t, result.Projects[i].Result.Success, path)
+	}
+	assert.Equal(t, "dr", cfg.RestoreTargetNS, "the bulk configuration must not be changed")
+}
+
+func TestRestoreBulk_CreatesMissingNamespaces(t *testing.T) {
+	s := newBulkStorage(t, "app-1.tar.gz", "lib-1.tar.gz", "cli-1.tar.gz")
+	m := manifest.Manifest{
+		Version: manifest.FormatVersion,
+		RunID:   "20261001T000000Z",
+		Projects: []manifest.Project{
+			exported(1, "acme/app", "app-1.tar.gz"),
+			exported(2, "acme/tools/lib", "lib-1.tar.gz"),
+			exported(3, "acme/tools/cli", "cli-1.tar.gz"),
+		},
+		Groups: []manifest.Project{
+			{FullPath: "acme", Name: "ACME", Visibility: "internal"},
+			{FullPath: "acme/tools", Name: "Tooling", Visibility: "public", Description: "Shared tools"},
+		},
+	}
+	storeManifest(t, s, m)
+	api := &groupRestoreAPI{existing: map[string]int64{"org": 12}}
+	cfg := successRestoreConfig(t, "")
+	cfg.RestoreTargetNS = "org/dr"
+
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, api.customize), s,
+		restore.NewNoOpProgressReporter())
+	result, err := orchestrator.RestoreBulk(context.Background(), cfg, "")
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"org/dr", "org/dr/tools"}, result.CreatedGroups)
+	for _, p := range result.Projects {
+		assert.Empty(t, p.Result.CreatedGroups, "namespaces are created before the projects are restored")
+	}
+
+	require.Len(t, api.created, 2)
+	assert.Equal(t, "dr", *api.created[0].Name, "the root namespace keeps its own name")
+	assert.Equal(t, int64(12), *api.created[0].ParentID)
+	assert.Equal(t, gitlabAPI.InternalVisibility, *api.created[0].Visibility)
+	assert.Equal(t, "Tooling", *api.created[1].Name)
+	assert.Equal(t, "Shared tools", *api.created[1].Description)
+	assert.Equal(t, gitlabAPI.InternalVisibility, *api.created[1].Visibility,
+		"a subgroup cannot be more visible than its parent")
+}
+
+func TestRestoreBulk_MappingFile(t *testing.T) {
+	s := newBulkStorage(t, "app-1.tar.gz")
+	writeManifest(t, s, "20261001T000000Z", exported(1, "acme/platform/app", "app-1.tar.gz"))
+	cfg := successRestoreConfig(t, "")
+	cfg.RestoreTargetNS = ""
+	cfg.RestoreMappingFile = filepath.Join(t.TempDir(), "mapping.yaml")
+	require.NoError(t, os.WriteFile(cfg.RestoreMappingFile, []byte("rules:\n  - prefix: acme\n    target: eng\n"), 0o600))
+
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, withImportSuccess), s,
+		restore.NewNoOpProgressReporter())
+	result, err := orchestrator.RestoreBulk(context.Background(), cfg, "")
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	require.Len(t, result.Projects, 1)
+	assert.Equal(t, "eng/platform", result.Projects[0].Item.TargetNS)
+	assert.Equal(t, "app", result.Projects[0].Item.TargetPath)
+
+	require.NoError(t, os.WriteFile(cfg.RestoreMappingFile, []byte("rules: []\n"), 0o600))
+	_, err = orchestrator.RestoreBulk(context.Background(), cfg, "")
+	require.ErrorIs(t, err, nsmap.ErrInvalidMapping)
+}
+
+func TestRestoreBulk_StorageCannotList(t *testing.T) {
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t), setupMockStorage(t),
+		restore.NewNoOpProgressReporter())
+
+	_, err := orchestrator.RestoreBulk(context.Background(), successRestoreConfig(t, ""), "")
+	require.ErrorIs(t, err, restore.ErrBulkUnsupported)
+}
@@ -0,0 +1,43 @@
+package restore
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"filippo.io/age"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/encryption"
+)
+
+// loadIdentities parses the age identity file of cfg. It returns no identity
+// when none is configured: plain archives are restored as before.
+func loadIdentities(cfg *config.Config) ([]age.Identity, error) {
+	if cfg.Age.IdentityFile == "" {
+		return nil, nil
+	}
+	identities, err := encryption.ParseIdentitiesFile(cfg.Age.IdentityFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load age identity: %w", err)
+	}
+	return identities, nil
+}
+
+// openArchive opens the archive at path for import. An age-encrypted archive
+// is decrypted on the fly with identities, so its plaintext is streamed into
+// the import upload and never written to disk; encrypted reports whether it was.
+func openArchive(path string, identities []age.Identity) (io.ReadCloser, bool, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: archive path is provided by the operator
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open archive file: %w", err)
+	}
+	r, encrypted, err := encryption.NewDecryptReader(f, identities)
+	if err != nil {
+		_ = f.Close()
+		return nil, encrypted, fmt.Errorf("archive %s: %w", path, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, f}, encrypted, nil
+}
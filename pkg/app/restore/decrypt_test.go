@@ -0,0 +1,140 @@
+package restore_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/sgaunet/gitlab-backup/pkg/app/restore"
+	"github.com/sgaunet/gitlab-backup/pkg/encryption"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	gitlabMocks "github.com/sgaunet/gitlab-backup/pkg/gitlab/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlabAPI "gitlab.com/gitlab-org/api/client-go"
+)
+
+// encryptedArchive returns a valid archive and a copy of it encrypted to a
+// new identity, whose identity file is also returned.
+func encryptedArchive(t *testing.T, armorEnabled bool) (plainPath, encryptedPath, identityFile string) {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityFile = filepath.Join(t.TempDir(), "identity.txt")
+	require.NoError(t, os.WriteFile(identityFile, []byte(id.String()+"\n"), 0o600))
+
+	plainPath = createValidArchive(t)
+	data, err := os.ReadFile(plainPath)
+	require.NoError(t, err)
+	encryptedPath = filepath.Join(t.TempDir(), "archive.tar.gz")
+	require.NoError(t, os.WriteFile(encryptedPath, data, 0o600))
+	require.NoError(t, encryption.EncryptFileInPlace(encryptedPath, []age.Recipient{id.Recipient()}, armorEnabled))
+	return plainPath, encryptedPath, identityFile
+}
+
+// captureImport records the archive uploaded by the project import into *uploaded.
+func captureImport(uploaded *[]byte) func(*gitlabMocks.GitLabClientMock) {
+	return func(client *gitlabMocks.GitLabClientMock) {
+		withImportSuccess(client)
+		ie := client.ProjectImportExportFunc()
+		client.ProjectImportExportFunc = func() gitlab.ProjectImportExportService {
+			return &gitlabMocks.ProjectImportExportServiceMock{
+				ImportFromFileFunc: func(ctx context.Context, r io.Reader, opt *gitlabAPI.ImportFileOptions, options ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.ImportStatus, *gitlabAPI.Response, error) {
+					data, err := io.ReadAll(r)
+					if err != nil {
+						return nil, nil, err
+					}
+					*uploaded = data
+					return ie.ImportFromFile(ctx, r, opt, options...)
+				},
+				ImportStatusFunc:          ie.ImportStatus,
+				ImportFailedRelationsFunc: ie.ImportFailedRelations,
+			}
+		}
+	}
+}
+
+func TestRestore_EncryptedArchive_DecryptsWhileImporting(t *testing.T) {
+	for _, armorEnabled := range []bool{false, true} {
+		plainPath, encryptedPath, identityFile := encryptedArchive(t, armorEnabled)
+		var uploaded []byte
+		cfg := successRestoreConfig(t, encryptedPath)
+		cfg.Age.IdentityFile = identityFile
+
+		orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, captureImport(&uploaded)),
+			setupMockStorage(t), restore.NewNoOpProgressReporter())
+		result, err := orchestrator.Restore(context.Background(), cfg)
+		require.NoError(t, err, "armor=%v", armorEnabled)
+		assert.True(t, result.Success)
+
+		plain, err := os.ReadFile(plainPath)
+		require.NoError(t, err)
+		assert.Equal(t, plain, uploaded, "the plaintext archive is uploaded")
+		leftovers, err := os.ReadDir(cfg.TmpDir)
+		require.NoError(t, err)
+		assert.Empty(t, leftovers, "no plaintext copy may be written")
+	}
+}
+
+func TestRestore_EncryptedArchive_RequiresMatchingIdentity(t *testing.T) {
+	_, encryptedPath, _ := encryptedArchive(t, false)
+	var uploaded []byte
+	mockGitLab := setupMockGitLabService(t, captureImport(&uploaded))
+	orchestrator := restore.NewOrchestratorWithProgress(mockGitLab, setupMockStorage(t), restore.NewNoOpProgressReporter())
+
+	cfg := successRestoreConfig(t, encryptedPath)
+	result, err := orchestrator.Restore(context.Background(), cfg)
+	require.ErrorIs(t, err, encryption.ErrIdentityRequired)
+	require.NotEmpty(t, result.Errors)
+	assert.Equal(t, restore.PhaseExtraction, result.Errors[0].Phase)
+
+	_, _, otherIdentity := encryptedArchive(t, false)
+	cfg.Age.IdentityFile = otherIdentity
+	_, err = orchestrator.Restore(context.Background(), cfg)
+	var noMatch *age.NoIdentityMatchError
+	require.ErrorAs(t, err, &noMatch)
+	assert.Nil(t, uploaded, "nothing may be imported")
+
+	cfg.Age.IdentityFile = filepath.Join(t.TempDir(), "missing.txt")
+	result, err = orchestrator.Restore(context.Background(), cfg)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	assert.Equal(t, restore.PhaseValidation, result.Errors[0].Phase)
+}
+
+func TestRestore_PlainArchive_IgnoresIdentity(t *testing.T) {
+	_, _, identityFile := encryptedArchive(t, false)
+	cfg := successRestoreConfig(t, createValidArchive(t))
+	cfg.Age.IdentityFile = identityFile
+
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, withImportSuccess),
+		setupMockStorage(t), restore.NewNoOpProgressReporter())
+	result, err := orchestrator.Restore(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+}
+
+func TestRestore_EncryptedGroupArchive_IsStreamed(t *testing.T) {
+	plainGroup, encryptedGroup, identityFile := encryptedArchive(t, true)
+	api := &groupRestoreAPI{existing: map[string]int64{"parent": 12}}
+	cfg := successRestoreConfig(t, createValidArchive(t))
+	cfg.RestoreTargetNS = "parent/restored"
+	cfg.RestoreGroupSource = encryptedGroup
+	cfg.Age.IdentityFile = identityFile
+
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, api.customize),
+		setupMockStorage(t), restore.NewNoOpProgressReporter())
+	result, err := orchestrator.Restore(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, int64(77), result.GroupID)
+	assert.Equal(t, []string{"group", "project"}, api.calls)
+
+	plain, err := os.ReadFile(plainGroup)
+	require.NoError(t, err)
+	assert.Equal(t, plain, api.streamed)
+	require.NotNil(t, api.imported)
+	assert.Nil(t, api.imported.File, "the encrypted file must not be uploaded")
+	assert.Equal(t, int64(12), *api.imported.ParentID)
+}
@@ -0,0 +1,154 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+)
+
+// groupArchive is a group archive checked by fetchGroupArchive, ready to be
+// imported.
+type groupArchive struct {
+	path       string
+	encrypted  bool
+	downloaded bool // path is a temporary download, removed by remove
+}
+
+// remove deletes the downloaded copy of the archive, if any.
+func (a *groupArchive) remove() {
+	if a != nil && a.downloaded {
+		_ = os.Remove(a.path)
+	}
+}
+
+// fetchGroupArchive fetches and checks the group archive before anything is
+// created on GitLab, so that an invalid archive leaves the instance
+// untouched. It returns nil when no group archive was given, or when the
+// namespace already exists: the archive is then not imported.
+func (o *Orchestrator) fetchGroupArchive(
+	ctx context.Context,
+	cfg *config.Config,
+	archives *archiveAccess,
+	result *Result,
+) (*groupArchive, error) {
+	if cfg.RestoreGroupSource == "" {
+		return nil, nil //nolint:nilnil // nil archive means there is no group to import
+	}
+	groups := o.gitlabClient.Client().Groups()
+	if existing, _, err := groups.GetGroup(ctx, cfg.RestoreTargetNS, nil, gitlabapi.WithContext(ctx)); err == nil {
+		o.progress.SkipPhase(PhaseGroupImport, "namespace already exists")
+		result.GroupID = existing.ID
+		result.addWarning(fmt.Sprintf("group %s already exists, group archive %s was not imported",
+			cfg.RestoreTargetNS, cfg.RestoreGroupSource))
+		return nil, nil //nolint:nilnil // nil archive means there is no group to import
+	}
+
+	archive := &groupArchive{path: cfg.RestoreGroupSource}
+	if cfg.StorageType == "s3" {
+		downloaded, err := o.storage.Get(ctx, cfg.RestoreGroupSource)
+		if err != nil {
+			return nil, o.failGroupImport(result, fmt.Errorf("failed to download group archive: %w", err))
+		}
+		archive.path, archive.downloaded = downloaded, true
+	}
+	encrypted, err := archives.validate(ctx, archive.path, archives.checkGroup)
+	if err != nil {
+		archive.remove()
+		return nil, o.failGroupImport(result, fmt.Errorf("invalid group archive: %w", err))
+	}
+	archive.encrypted = encrypted
+	return archive, nil
+}
+
+// importGroup rebuilds the target namespace from the group archive fetched by
+// fetchGroupArchive before the project is imported into it. Nothing is done
+// when archive is nil.
+func (o *Orchestrator) importGroup(
+	ctx context.Context,
+	cfg *config.Config,
+	archives *archiveAccess,
+	archive *groupArchive,
+	result *Result,
+) error {
+	if archive == nil {
+		return nil
+	}
+	o.progress.StartPhase(PhaseGroupImport)
+	group, err := o.importGroupArchive(ctx, cfg, archives, archive)
+	if err != nil {
+		return o.failGroupImport(result, err)
+	}
+	result.GroupID = group.ID
+	result.GroupURL = fmt.Sprintf("%s/%s", cfg.GitlabURI, cfg.RestoreTargetNS)
+	o.progress.CompletePhase(PhaseGroupImport)
+	return nil
+}
+
+// failGroupImport records err as the failure of the group import and returns
+// it.
+func (o *Orchestrator) failGroupImport(result *Result, err error) error {
+	o.progress.FailPhase(PhaseGroupImport, err)
+	result.addError(PhaseGroupImport, "GitLabGroupImport", err.Error())
+	return fmt.Errorf("group import failed: %w", err)
+}
+
+// importGroupArchive resolves the parent group of the target namespace and
+// imports archive as that namespace. An age-encrypted archive is decrypted
+// while it is uploaded.
+func (o *Orchestrator) importGroupArchive(
+	ctx context.Context,
+	cfg *config.Config,
+	archives *archiveAccess,
+	archive *groupArchive,
+) (*gitlabapi.Group, error) {
+	var parentID int64
+	if parent := path.Dir(cfg.RestoreTargetNS); parent != "." {
+		group, _, err := o.gitlabClient.Client().Groups().GetGroup(ctx, parent, nil, gitlabapi.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent group %s: %w", parent, err)
+		}
+		parentID = group.ID
+	}
+
+	importService := gitlab.NewGroupImportService(
+		o.gitlabClient.Client().GroupImportExport(),
+		o.gitlabClient.Client().Groups(),
+		o.gitlabClient.RateLimitImportAPI(),
+		time.Duration(cfg.ImportTimeoutMins)*time.Minute,
+	)
+	var group *gitlabapi.Group
+	var err error
+	if archive.encrypted {
+		group, err = importEncryptedGroup(ctx, importService, archives, archive.path, cfg.RestoreTargetNS, parentID)
+	} else {
+		group, err = importService.ImportGroup(ctx, archive.path, cfg.RestoreTargetNS, parentID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to import group %s: %w", cfg.RestoreTargetNS, err)
+	}
+	return group, nil
+}
+
+// importEncryptedGroup imports the age-encrypted group archive at archivePath,
+// decrypting it while it is uploaded.
+func importEncryptedGroup(
+	ctx context.Context,
+	importService *gitlab.GroupImportService,
+	archives *archiveAccess,
+	archivePath string,
+	fullPath string,
+	parentID int64,
+) (*gitlabapi.Group, error) {
+	r, _, err := archives.open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	return importService.ImportGroupFromReader(ctx, r, fullPath, parentID) //nolint:wrapcheck // wrapped by the caller
+}
@@ -0,0 +1,182 @@
+package restore_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app/restore"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	gitlabMocks "github.com/sgaunet/gitlab-backup/pkg/gitlab/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlabAPI "gitlab.com/gitlab-org/api/client-go"
+)
+
+// groupRestoreAPI fakes the GitLab groups and group import endpoints. Groups
+// listed in existing are found; the imported group appears once ImportFile or
+// ImportFromReader has been called, a created group once CreateGroup has.
+// calls records the import and creation order, streamed the archive uploaded
+// by ImportFromReader, created the options of the created groups.
+type groupRestoreAPI struct {
+	mu       sync.Mutex
+	existing map[string]int64
+	imported *gitlabAPI.GroupImportFileOptions
+	streamed []byte
+	calls    []string
+	created  []*gitlabAPI.CreateGroupOptions
+	// visibility holds the visibility of the created groups.
+	visibility map[string]gitlabAPI.VisibilityValue
+}
+
+func (f *groupRestoreAPI) customize(client *gitlabMocks.GitLabClientMock) {
+	withImportSuccess(client)
+	projectIE := client.ProjectImportExportFunc()
+	client.ProjectImportExportFunc = func() gitlab.ProjectImportExportService {
+		return &gitlabMocks.ProjectImportExportServiceMock{
+			ImportFromFileFunc: func(ctx context.Context, r io.Reader, opt *gitlabAPI.ImportFileOptions, options ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.ImportStatus, *gitlabAPI.Response, error) {
+				f.record("project")
+				return projectIE.ImportFromFile(ctx, r, opt, options...)
+			},
+			ImportStatusFunc:          projectIE.ImportStatus,
+			ImportFailedRelationsFunc: projectIE.ImportFailedRelations,
+		}
+	}
+	client.GroupsFunc = func() gitlab.GroupsService {
+		return &gitlabMocks.GroupsServiceMock{GetGroupFunc: f.getGroup, CreateGroupFunc: f.createGroup}
+	}
+	client.GroupImportExportFunc = func() gitlab.GroupImportExportService {
+		return &gitlabMocks.GroupImportExportServiceMock{
+			ImportFileFunc: func(_ context.Context, opt *gitlabAPI.GroupImportFileOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+				f.mu.Lock()
+				f.imported = opt
+				f.mu.Unlock()
+				f.record("group")
+				return &gitlabAPI.Response{Response: &http.Response{StatusCode: http.StatusAccepted}}, nil
+			},
+			ImportFromReaderFunc: func(_ context.Context, archive io.Reader, opt *gitlabAPI.GroupImportFileOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+				data, err := io.ReadAll(archive)
+				if err != nil {
+					return nil, err
+				}
+				f.mu.Lock()
+				f.imported, f.streamed = opt, data
+				f.mu.Unlock()
+				f.record("group")
+				return &gitlabAPI.Response{Response: &http.Response{StatusCode: http.StatusAccepted}}, nil
+			},
+		}
+	}
+}
+
+func (f *groupRestoreAPI) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *groupRestoreAPI) getGroup(_ context.Context, gid any, _ *gitlabAPI.GetGroupOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Group, *gitlabAPI.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fullPath, _ := gid.(string)
+	if id, ok := f.existing[fullPath]; ok {
+		return &gitlabAPI.Group{ID: id, FullPath: fullPath, Visibility: f.visibility[fullPath]}, &gitlabAPI.Response{}, nil
+	}
+	if f.imported != nil && fullPath == "parent/restored" {
+		return &gitlabAPI.Group{ID: 77, FullPath: fullPath}, &gitlabAPI.Response{}, nil
+	}
+	notFound := &gitlabAPI.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	return nil, notFound, errors.New("404 Group Not Found")
+}
+
+func (f *groupRestoreAPI) createGroup(_ context.Context, opt *gitlabAPI.CreateGroupOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Group, *gitlabAPI.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fullPath := *opt.Path
+	for parentPath, id := range f.existing {
+		if opt.ParentID != nil && *opt.ParentID == id {
+			fullPath = parentPath + "/" + fullPath
+		}
+	}
+	if _, ok := f.existing[fullPath]; ok {
+		return nil, &gitlabAPI.Response{}, errors.New("400 path has already been taken")
+	}
+	id := int64(100 + len(f.created))
+	if f.existing == nil {
+		f.existing = make(map[string]int64)
+	}
+	if f.visibility == nil {
+		f.visibility = make(map[string]gitlabAPI.VisibilityValue)
+	}
+	f.existing[fullPath] = id
+	f.visibility[fullPath] = *opt.Visibility
+	f.created = append(f.created, opt)
+	f.calls = append(f.calls, "create "+fullPath)
+	return &gitlabAPI.Group{ID: id, FullPath: fullPath, Visibility: *opt.Visibility}, &gitlabAPI.Response{}, nil
+}
+
+func TestRestore_GroupArchive_ImportsGroupBeforeProject(t *testing.T) {
+	api := &groupRestoreAPI{existing: map[string]int64{"parent": 12}}
+	mockGitLab := setupMockGitLabService(t, api.customize)
+	cfg := successRestoreConfig(t, createValidArchive(t))
+	cfg.RestoreTargetNS = "parent/restored"
+	cfg.RestoreGroupSource = createValidArchive(t)
+
+	orchestrator := restore.NewOrchestratorWithProgress(mockGitLab, setupMockStorage(t), restore.NewNoOpProgressReporter())
+	result, err := orchestrator.Restore(context.Background(), cfg)
+
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, int64(77), result.GroupID)
+	assert.Equal(t, "https://gitlab.com/parent/restored", result.GroupURL)
+	assert.Equal(t, int64(42), result.ProjectID)
+	assert.Equal(t, []string{"group", "project"}, api.calls)
+
+	require.NotNil(t, api.imported)
+	assert.Equal(t, "restored", *api.imported.Path)
+	assert.Equal(t, cfg.RestoreGroupSource, *api.imported.File)
+	require.NotNil(t, api.imported.ParentID)
+	assert.Equal(t, int64(12), *api.imported.ParentID)
+}
+
+func TestRestore_GroupArchive_ExistingNamespaceIsKept(t *testing.T) {
+	api := &groupRestoreAPI{existing: map[string]int64{"parent": 12, "parent/restored": 5}}
+	mockGitLab := setupMockGitLabService(t, api.customize)
+	cfg := successRestoreConfig(t, createValidArchive(t))
+	cfg.RestoreTargetNS = "parent/restored"
+	cfg.RestoreGroupSource = createValidArchive(t)
+
+	orchestrator := restore.NewOrchestratorWithProgress(mockGitLab, setupMockStorage(t), restore.NewNoOpProgressReporter())
+	result, err := orchestrator.Restore(context.Background(), cfg)
+
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, int64(5), result.GroupID)
+	assert.Empty(t, result.GroupURL)
+	assert.Equal(t, []string{"project"}, api.calls)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "already exists")
+}
+
+func TestRestore_GroupArchive_InvalidArchiveStopsRestore(t *testing.T) {
+	api := &groupRestoreAPI{existing: map[string]int64{"parent": 12}}
+	mockGitLab := setupMockGitLabService(t, api.customize)
+	cfg := successRestoreConfig(t, createValidArchive(t))
+	cfg.RestoreTargetNS = "parent/restored"
+	cfg.RestoreGroupSource = filepath.Join(t.TempDir(), "group.tar.gz")
+	require.NoError(t, os.WriteFile(cfg.RestoreGroupSource, []byte("not a gzip archive"), 0o600))
+
+	orchestrator := restore.NewOrchestratorWithProgress(mockGitLab, setupMockStorage(t), restore.NewNoOpProgressReporter())
+	result, err := orchestrator.Restore(context.Background(), cfg)
+
+	require.Error(t, err)
+	assert.False(t, result.Success)
+	require.NotEmpty(t, result.Errors)
+	assert.Equal(t, restore.PhaseGroupImport, result.Errors[0].Phase)
+	assert.Empty(t, api.calls, "neither the group nor the project may be imported")
+}
@@ -0,0 +1,133 @@
+package restore
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+)
+
+// defaultGroupVisibility is the visibility of the created groups when neither
+// the configuration nor the backup sets one.
+const defaultGroupVisibility = gitlabapi.PrivateVisibility
+
+// visibilityRank orders the visibility levels: a subgroup cannot be more
+// visible than its parent.
+var visibilityRank = map[gitlabapi.VisibilityValue]int{
+	gitlabapi.PrivateVisibility:  0,
+	gitlabapi.InternalVisibility: 1,
+	gitlabapi.PublicVisibility:   2,
+}
+
+// GroupSpec is the metadata of a group recorded by a backup, used when the
+// group is recreated for a missing namespace.
+type GroupSpec struct {
+	// Name is the display name of the group, its path when empty.
+	Name string
+	// Description is the description of the group.
+	Description string
+	// Visibility is the visibility level of the group: private, internal or public.
+	Visibility string
+}
+
+// createNamespace creates the missing groups of the target namespace before
+// the project is imported into it. With a group archive, the namespace itself
+// is created by the group import: only its missing parents are created here.
+func (o *Orchestrator) createNamespace(ctx context.Context, cfg *config.Config, result *Result) error {
+	namespace := cfg.RestoreTargetNS
+	if cfg.RestoreGroupSource != "" {
+		namespace = path.Dir(namespace)
+		if namespace == "." {
+			return nil
+		}
+	}
+	created, err := o.ensureNamespace(ctx, namespace, nil, cfg.RestoreGroupVisibility)
+	result.CreatedGroups = append(result.CreatedGroups, created...)
+	if err != nil {
+		o.progress.FailPhase(PhaseNamespace, err)
+		result.addError(PhaseNamespace, "GitLabGroups", err.Error())
+		return fmt.Errorf("namespace creation failed: %w", err)
+	}
+	return nil
+}
+
+// ensureNamespace creates the missing groups of the namespace fullPath,
+// parents first, and returns their full paths; the namespace phase is only
+// reported when a group is missing. A created group takes its name,
+// description and visibility from specs, keyed by full path; visibility, when
+// set, overrides the visibility of specs. The visibility is lowered to the
+// one of the parent group, as GitLab requires.
+func (o *Orchestrator) ensureNamespace(
+	ctx context.Context,
+	fullPath string,
+	specs map[string]GroupSpec,
+	visibility string,
+) ([]string, error) {
+	groups := o.gitlabClient.Client().Groups()
+	segments := strings.Split(fullPath, "/")
+
+	// Find the deepest existing group of the namespace.
+	var parent *gitlabapi.Group
+	existing := len(segments)
+	for ; existing > 0; existing-- {
+		groupPath := strings.Join(segments[:existing], "/")
+		group, resp, err := groups.GetGroup(ctx, groupPath, nil, gitlabapi.WithContext(ctx))
+		if err == nil {
+			parent = group
+			break
+		}
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return nil, fmt.Errorf("failed to get group %s: %w", groupPath, err)
+		}
+	}
+	if existing < len(segments) {
+		o.progress.StartPhase(PhaseNamespace)
+	}
+
+	var created []string
+	for i := existing; i < len(segments); i++ {
+		groupPath := strings.Join(segments[:i+1], "/")
+		spec := specs[groupPath]
+		opt := &gitlabapi.CreateGroupOptions{
+			Name:       gitlabapi.Ptr(cmp.Or(spec.Name, segments[i])),
+			Path:       gitlabapi.Ptr(segments[i]),
+			Visibility: gitlabapi.Ptr(groupVisibility(cmp.Or(visibility, spec.Visibility), parent)),
+		}
+		if spec.Description != "" {
+			opt.Description = gitlabapi.Ptr(spec.Description)
+		}
+		if parent != nil {
+			opt.ParentID = gitlabapi.Ptr(parent.ID)
+		}
+		group, _, err := groups.CreateGroup(ctx, opt, gitlabapi.WithContext(ctx))
+		if err != nil {
+			return created, fmt.Errorf("failed to create group %s: %w", groupPath, err)
+		}
+		parent = group
+		created = append(created, groupPath)
+	}
+	if len(created) > 0 {
+		o.progress.CompletePhase(PhaseNamespace)
+	}
+	return created, nil
+}
+
+// groupVisibility returns the visibility of a group created below parent:
+// visibility (private when empty), lowered to the visibility of parent.
+func groupVisibility(visibility string, parent *gitlabapi.Group) gitlabapi.VisibilityValue {
+	v := gitlabapi.VisibilityValue(visibility)
+	if _, ok := visibilityRank[v]; !ok {
+		v = defaultGroupVisibility
+	}
+	if parent != nil {
+		if rank, ok := visibilityRank[parent.Visibility]; ok && rank < visibilityRank[v] {
+			return parent.Visibility
+		}
+	}
+	return v
+}
@@ -0,0 +1,182 @@
+package restore_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app/restore"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	gitlabMocks "github.com/sgaunet/gitlab-backup/pkg/gitlab/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlabAPI "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestRestore_CreatesMissingNamespace(t *testing.T) {
+	api := &groupRestoreAPI{existing: map[string]int64{"acme": 12}}
+	cfg := successRestoreConfig(t, createValidArchive(t))
+	cfg.RestoreTargetNS = "acme/team/sub"
+	cfg.RestoreGroupVisibility = "internal"
+
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, api.customize), setupMockStorage(t),
+		restore.NewNoOpProgressReporter())
+	result, err := orchestrator.Restore(context.Background(), cfg)
+
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"acme/team", "acme/team/sub"}, result.CreatedGroups)
+	assert.Equal(t, []string{"create acme/team", "create acme/team/sub", "project"}, api.calls)
+	require.Len(t, api.created, 2)
+	assert.Equal(t, "team", *api.created[0].Name)
+	assert.Equal(t, int64(12), *api.created[0].ParentID)
+	assert.Equal(t, gitlabAPI.InternalVisibility, *api.created[0].Visibility)
+	assert.Equal(t, int64(100), *api.created[1].ParentID)
+}
+
+func TestRestore_ExistingNamespaceIsNotCreated(t *testing.T) {
+	api := &groupRestoreAPI{existing: map[string]int64{"acme": 12, "acme/team": 13}}
+	cfg := successRestoreConfig(t, createValidArchive(t))
+	cfg.RestoreTargetNS = "acme/team"
+
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, api.customize), setupMockStorage(t),
+		restore.NewNoOpProgressReporter())
+	result, err := orchestrator.Restore(context.Background(), cfg)
+
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Empty(t, result.CreatedGroups)
+	assert.Equal(t, []string{"project"}, api.calls)
+}
+
+func TestRestore_GroupArchive_CreatesMissingParents(t *testing.T) {
+	api := &groupRestoreAPI{}
+	cfg := successRestoreConfig(t, createValidArchive(t))
+	cfg.RestoreTargetNS = "parent/restored"
+	cfg.RestoreGroupSource = createValidArchive(t)
+
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, api.customize), setupMockStorage(t),
+		restore.NewNoOpProgressReporter())
+	result, err := orchestrator.Restore(context.Background(), cfg)
+
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"parent"}, result.CreatedGroups)
+	assert.Equal(t, []string{"create parent", "group", "project"}, api.calls)
+	require.Len(t, api.created, 1)
+	assert.Nil(t, api.created[0].ParentID)
+	assert.Equal(t, gitlabAPI.PrivateVisibility, *api.created[0].Visibility)
+	require.NotNil(t, api.imported.ParentID)
+	assert.Equal(t, int64(100), *api.imported.ParentID)
+}
+
+func TestRestore_NamespaceLookupFailureStopsRestore(t *testing.T) {
+	mockGitLab := setupMockGitLabService(t, withImportSuccess, func(client *gitlabMocks.GitLabClientMock) {
+		client.GroupsFunc = func() gitlab.GroupsService {
+			return &gitlabMocks.GroupsServiceMock{
+				GetGroupFunc: func(_ context.Context, _ any, _ *gitlabAPI.GetGroupOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Group, *gitlabAPI.Response, error) {
+					forbidden := &gitlabAPI.Response{Response: &http.Response{StatusCode: http.StatusForbidden}}
+					return nil, forbidden, errors.New("403 Forbidden")
+				},
+			}
+		}
+	})
+	cfg := successRestoreConfig(t, createValidArchive(t))
+
+	orchestrator := restore.NewOrchestratorWithProgress(mockGitLab, setupMockStorage(t), restore.NewNoOpProgressReporter())
+	result, err := orchestrator.Restore(context.Background(), cfg)
+
+	require.Error(t, err)
+	assert.False(t, result.Success)
+	require.NotEmpty(t, result.Errors)
+	assert.Equal(t, restore.PhaseNamespace, result.Errors[0].Phase)
+	assert.Contains(t, result.Errors[0].Message, "403 Forbidden")
+}
+
+// withNonEmptyProject makes the target project exist with an issue.
+func withNonEmptyProject(client *gitlabMocks.GitLabClientMock) {
+	client.ProjectsFunc = func() gitlab.ProjectsService {
+		return &gitlabMocks.ProjectsServiceMock{
+			GetProjectFunc: func(_ context.Context, _ any, _ *gitlabAPI.GetProjectOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Project, *gitlabAPI.Response, error) {
+				return &gitlabAPI.Project{ID: 7}, &gitlabAPI.Response{}, nil
+			},
+		}
+	}
+	client.IssuesFunc = func() gitlab.IssuesService {
+		return &gitlabMocks.IssuesServiceMock{
+			ListProjectIssuesFunc: func(_ context.Context, _ any, _ *gitlabAPI.ListProjectIssuesOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Issue, *gitlabAPI.Response, error) {
+				return []*gitlabAPI.Issue{{ID: 1}}, &gitlabAPI.Response{}, nil
+			},
+		}
+	}
+}
+
+func TestRestore_NothingCreatedWhenValidationFails(t *testing.T) {
+	invalidArchive := func(t *testing.T) string {
+		t.Helper()
+		archive := filepath.Join(t.TempDir(), "invalid.tar.gz")
+		require.NoError(t, os.WriteFile(archive, []byte("not a gzip archive"), 0o600))
+		return archive
+	}
+	tests := []struct {
+		name      string
+		configure func(t *testing.T, cfg *config.Config)
+		customize func(*gitlabMocks.GitLabClientMock)
+	}{
+		{
+			name: "invalid project archive",
+			configure: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				cfg.RestoreSource = invalidArchive(t)
+			},
+		},
+		{
+			name: "invalid project archive with a group archive",
+			configure: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				cfg.RestoreSource = invalidArchive(t)
+				cfg.RestoreGroupSource = createValidArchive(t)
+			},
+		},
+		{
+			name: "invalid group archive",
+			configure: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				cfg.RestoreGroupSource = invalidArchive(t)
+			},
+		},
+		{
+			name: "target project not empty",
+			configure: func(_ *testing.T, cfg *config.Config) {
+				cfg.RestoreOverwrite = false
+			},
+			customize: withNonEmptyProject,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &groupRestoreAPI{}
+			customizations := []func(*gitlabMocks.GitLabClientMock){api.customize}
+			if tt.customize != nil {
+				customizations = append(customizations, tt.customize)
+			}
+			cfg := successRestoreConfig(t, createValidArchive(t))
+			cfg.RestoreTargetNS = "parent/restored"
+			tt.configure(t, cfg)
+
+			orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, customizations...),
+				setupMockStorage(t), restore.NewNoOpProgressReporter())
+			result, err := orchestrator.Restore(context.Background(), cfg)
+
+			require.Error(t, err)
+			assert.False(t, result.Success)
+			assert.Empty(t, api.created, "no group may be created")
+			assert.Empty(t, api.calls, "nothing may be created or imported")
+			assert.Empty(t, result.CreatedGroups)
+		})
+	}
+}
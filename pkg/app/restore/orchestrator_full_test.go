@@ -3,6 +3,7 @@ package restore_test
 import (
 	"context"
 	"errors"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
@@ -27,7 +28,8 @@ func setupMockGitLabService(t *testing.T, customizations ...func(*gitlabMocks.Gi
 		ProjectsFunc: func() gitlab.ProjectsService {
 			return &gitlabMocks.ProjectsServiceMock{
 				GetProjectFunc: func(_ context.Context, pid any, opt *gitlabAPI.GetProjectOptions, options ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Project, *gitlabAPI.Response, error) {
-					return nil, nil, errors.New("404 Project Not Found")
+					notFound := &gitlabAPI.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+					return nil, notFound, errors.New("404 Project Not Found")
 				},
 			}
 		},
@@ -55,6 +57,44 @@ func setupMockGitLabService(t *testing.T, customizations ...func(*gitlabMocks.Gi
 		ProjectImportExportFunc: func() gitlab.ProjectImportExportService {
 			return &gitlabMocks.ProjectImportExportServiceMock{}
 		},
+		// The restored project matches the empty archive of createValidArchive.
+		BranchesFunc: func() gitlab.BranchesService {
+			return &gitlabMocks.BranchesServiceMock{
+				ListBranchesFunc: func(_ context.Context, pid any, opt *gitlabAPI.ListBranchesOptions, options ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Branch, *gitlabAPI.Response, error) {
+					return []*gitlabAPI.Branch{}, &gitlabAPI.Response{}, nil
+				},
+			}
+		},
+		TagsFunc: func() gitlab.TagsService {
+			return &gitlabMocks.TagsServiceMock{
+				ListTagsFunc: func(_ context.Context, pid any, opt *gitlabAPI.ListTagsOptions, options ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Tag, *gitlabAPI.Response, error) {
+					return []*gitlabAPI.Tag{}, &gitlabAPI.Response{}, nil
+				},
+			}
+		},
+		MergeRequestsFunc: func() gitlab.MergeRequestsService {
+			return &gitlabMocks.MergeRequestsServiceMock{
+				ListProjectMergeRequestsFunc: func(_ context.Context, pid any, opt *gitlabAPI.ListProjectMergeRequestsOptions, options ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.BasicMergeRequest, *gitlabAPI.Response, error) {
+					return []*gitlabAPI.BasicMergeRequest{}, &gitlabAPI.Response{}, nil
+				},
+			}
+		},
+		MilestonesFunc: func() gitlab.MilestonesService {
+			return &gitlabMocks.MilestonesServiceMock{
+				ListMilestonesFunc: func(_ context.Context, pid any, opt *gitlabAPI.ListMilestonesOptions, options ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Milestone, *gitlabAPI.Response, error) {
+					return []*gitlabAPI.Milestone{}, &gitlabAPI.Response{}, nil
+				},
+			}
+		},
+		// The target namespaces exist.
+		GroupsFunc: func() gitlab.GroupsService {
+			return &gitlabMocks.GroupsServiceMock{
+				GetGroupFunc: func(_ context.Context, gid any, opt *gitlabAPI.GetGroupOptions, options ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Group, *gitlabAPI.Response, error) {
+					fullPath, _ := gid.(string)
+					return &gitlabAPI.Group{ID: 1, FullPath: fullPath}, &gitlabAPI.Response{}, nil
+				},
+			}
+		},
 	}
 
 	for _, customize := range customizations {
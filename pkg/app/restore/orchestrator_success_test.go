@@ -20,22 +20,43 @@ import (
 	gitlabAPI "gitlab.com/gitlab-org/api/client-go"
 )
 
-// createValidArchive writes a genuinely valid .tar.gz so ExtractArchive's
-// gzip/tar validation passes and the workflow reaches the import phase.
+// createValidArchive writes a genuinely valid GitLab export .tar.gz so the
+// archive validation passes and the workflow reaches the import phase.
 func createValidArchive(t *testing.T) string {
 	t.Helper()
+	// The entries a full validation requires of a GitLab project export.
+	return createTestArchiveWith(t, "./VERSION", "./tree/project.json", "./project.bundle")
+}
+
+// createTestArchiveWith writes a valid .tar.gz holding the entries names.
+func createTestArchiveWith(t *testing.T, names ...string) string {
+	t.Helper()
+
+	entries := make([][2]string, 0, len(names))
+	for _, name := range names {
+		entries = append(entries, [2]string{name, exportEntryBody(name)})
+	}
+	return createExportArchive(t, entries...)
+}
+
+// createExportArchive writes a valid .tar.gz holding the entries, given as
+// name and content.
+func createExportArchive(t *testing.T, entries ...[2]string) string {
+	t.Helper()
 
 	var buf bytes.Buffer
 	gzw := gzip.NewWriter(&buf)
 	tw := tar.NewWriter(gzw)
-	body := []byte("project export placeholder")
-	require.NoError(t, tw.WriteHeader(&tar.Header{
-		Name: "project.bundle",
-		Mode: 0o600,
-		Size: int64(len(body)),
-	}))
-	_, err := tw.Write(body)
-	require.NoError(t, err)
+	for _, entry := range entries {
+		name, body := entry[0], []byte(entry[1])
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0o600,
+			Size: int64(len(body)),
+		}))
+		_, err := tw.Write(body)
+		require.NoError(t, err)
+	}
 	require.NoError(t, tw.Close())
 	require.NoError(t, gzw.Close())
 
@@ -44,6 +65,28 @@ func createValidArchive(t *testing.T) string {
 	return archivePath
 }
 
+// exportEntryBody returns a valid content for the export entry name, read by
+// the verification phase.
+func exportEntryBody(name string) string {
+	switch filepath.Base(name) {
+	case "VERSION":
+		return "0.2.4"
+	case "project.json":
+		return "{}"
+	case "project.bundle":
+		return "# v2 git bundle\n\n"
+	default:
+		return "project export placeholder"
+	}
+}
+
+// noFailedRelations reports that the import failed no relation.
+func noFailedRelations(
+	_ context.Context, _ any, _ ...gitlabAPI.RequestOptionFunc,
+) ([]*gitlab.FailedRelation, *gitlabAPI.Response, error) {
+	return nil, &gitlabAPI.Response{}, nil
+}
+
 // withImportSuccess customizes the mock GitLab client so ImportFromFile is
 // accepted and the first ImportStatus poll reports "finished".
 func withImportSuccess(client *gitlabMocks.GitLabClientMock) {
@@ -55,6 +98,7 @@ func withImportSuccess(client *gitlabMocks.GitLabClientMock) {
 			ImportStatusFunc: func(_ context.Context, _ any, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.ImportStatus, *gitlabAPI.Response, error) {
 				return &gitlabAPI.ImportStatus{ID: 42, ImportStatus: "finished"}, &gitlabAPI.Response{}, nil
 			},
+			ImportFailedRelationsFunc: noFailedRelations,
 		}
 	}
 }
@@ -91,6 +135,48 @@ func TestRestore_FullSuccess_NoOpProgress(t *testing.T) {
 	assert.Empty(t, result.Errors)
 }
 
+func TestRestore_ImportOverrides(t *testing.T) {
+	var opts []*gitlabAPI.ImportFileOptions
+	mockGitLab := setupMockGitLabService(t, func(client *gitlabMocks.GitLabClientMock) {
+		client.ProjectImportExportFunc = func() gitlab.ProjectImportExportService {
+			return &gitlabMocks.ProjectImportExportServiceMock{
+				ImportFromFileFunc: func(_ context.Context, _ io.Reader, opt *gitlabAPI.ImportFileOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.ImportStatus, *gitlabAPI.Response, error) {
+					opts = append(opts, opt)
+					return &gitlabAPI.ImportStatus{ID: 42, ImportStatus: "scheduled"}, &gitlabAPI.Response{}, nil
+				},
+				ImportStatusFunc: func(_ context.Context, _ any, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.ImportStatus, *gitlabAPI.Response, error) {
+					return &gitlabAPI.ImportStatus{ID: 42, ImportStatus: "finished"}, &gitlabAPI.Response{}, nil
+				},
+				ImportFailedRelationsFunc: noFailedRelations,
+			}
+		}
+	})
+	orchestrator := restore.NewOrchestratorWithProgress(mockGitLab, setupMockStorage(t), restore.NewNoOpProgressReporter())
+
+	cfg := successRestoreConfig(t, createValidArchive(t))
+	_, err := orchestrator.Restore(context.Background(), cfg)
+	require.NoError(t, err)
+
+	lfs := false
+	cfg.Restore.Overrides = config.ImportOverrides{
+		Visibility:        "private",
+		DefaultBranch:     "main",
+		BuildsAccessLevel: "disabled",
+		LFSEnabled:        &lfs,
+	}
+	_, err = orchestrator.Restore(context.Background(), cfg)
+	require.NoError(t, err)
+
+	require.Len(t, opts, 2)
+	assert.Nil(t, opts[0].OverrideParams, "the archive settings are kept without overrides")
+	assert.Equal(t, &gitlabAPI.CreateProjectOptions{
+		Visibility:        gitlabAPI.Ptr(gitlabAPI.PrivateVisibility),
+		DefaultBranch:     gitlabAPI.Ptr("main"),
+		BuildsAccessLevel: gitlabAPI.Ptr(gitlabAPI.DisabledAccessControl),
+		LFSEnabled:        &lfs,
+	}, opts[1].OverrideParams)
+}
+
 // TestRestore_FullSuccess_MockProgress asserts the phase transitions reported
 // during a successful restore, using the injectable ProgressReporter seam.
 func TestRestore_FullSuccess_MockProgress(t *testing.T) {
@@ -0,0 +1,36 @@
+package restore
+
+import (
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+)
+
+// overrideParams returns the override_params of the project import set by
+// the import overrides o, nil when o sets none.
+func overrideParams(o *config.ImportOverrides) *gitlabapi.CreateProjectOptions {
+	if o.IsZero() {
+		return nil
+	}
+	return &gitlabapi.CreateProjectOptions{
+		Visibility:                   optional[gitlabapi.VisibilityValue](o.Visibility),
+		Description:                  optional[string](o.Description),
+		DefaultBranch:                optional[string](o.DefaultBranch),
+		IssuesAccessLevel:            optional[gitlabapi.AccessControlValue](o.IssuesAccessLevel),
+		WikiAccessLevel:              optional[gitlabapi.AccessControlValue](o.WikiAccessLevel),
+		BuildsAccessLevel:            optional[gitlabapi.AccessControlValue](o.BuildsAccessLevel),
+		MergeRequestsAccessLevel:     optional[gitlabapi.AccessControlValue](o.MergeRequestsAccessLevel),
+		SnippetsAccessLevel:          optional[gitlabapi.AccessControlValue](o.SnippetsAccessLevel),
+		ContainerRegistryAccessLevel: optional[gitlabapi.AccessControlValue](o.ContainerRegistryAccessLevel),
+		LFSEnabled:                   o.LFSEnabled,
+		PackagesEnabled:              o.PackagesEnabled,
+	}
+}
+
+// optional returns a pointer to v converted to T, nil when v is empty.
+func optional[T ~string](v string) *T {
+	if v == "" {
+		return nil
+	}
+	t := T(v)
+	return &t
+}
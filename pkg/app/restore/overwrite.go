@@ -0,0 +1,128 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+)
+
+// safetyCopyTimeFormat formats the timestamp of the path a replaced project
+// is renamed to, e.g. app-pre-restore-20261016-150405.
+const safetyCopyTimeFormat = "20060102-150405"
+
+// prepareOverwrite records the project at the target path, if any, in
+// result.Overwrite and frees the path for the import: the import mode leaves
+// the project to GitLab's overwrite import parameter, while the rename and
+// delete modes rename it to <path>-pre-restore-<timestamp>. It runs once the
+// archive is downloaded and validated, right before the import, so that a
+// restore failing earlier leaves the project untouched. The delete mode only
+// deletes the renamed project once the import finished, see finishOverwrite;
+// a failed import renames it back, see rollbackOverwrite, unless it timed out,
+// see keepOverwrite.
+func (o *Orchestrator) prepareOverwrite(ctx context.Context, cfg *config.Config, result *Result) error {
+	projects := o.gitlabClient.Client().Projects()
+	fullPath := path.Join(cfg.RestoreTargetNS, cfg.RestoreTargetPath)
+	project, resp, err := projects.GetProject(ctx, fullPath, nil, gitlabapi.WithContext(ctx))
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		result.addError(PhaseOverwrite, "GitLabProjects", err.Error())
+		return fmt.Errorf("failed to look up the project to overwrite: %w", err)
+	}
+
+	mode := cfg.OverwriteMode()
+	audit := &Overwrite{
+		Mode:        mode,
+		ProjectID:   project.ID,
+		ProjectPath: fullPath,
+		Timestamp:   time.Now().UTC(),
+		name:        project.Name,
+		path:        project.Path,
+	}
+	result.Overwrite = audit
+	if mode == config.OverwriteImport {
+		return nil
+	}
+
+	o.progress.StartPhase(PhaseOverwrite)
+	safetyPath := fmt.Sprintf("%s-pre-restore-%s", project.Path, audit.Timestamp.Format(safetyCopyTimeFormat))
+	if err := o.renameProject(ctx, project.ID, safetyPath, safetyPath); err != nil {
+		o.progress.FailPhase(PhaseOverwrite, err)
+		result.addError(PhaseOverwrite, "GitLabProjects", err.Error())
+		return fmt.Errorf("overwrite failed: %w", err)
+	}
+	audit.RenamedTo = path.Join(cfg.RestoreTargetNS, safetyPath)
+	o.progress.CompletePhase(PhaseOverwrite)
+	return nil
+}
+
+// rollbackOverwrite renames the project moved aside by prepareOverwrite back
+// to its path after a failed import. It runs even when ctx is cancelled, so
+// that an interrupted restore does not leave the project renamed.
+func (o *Orchestrator) rollbackOverwrite(ctx context.Context, result *Result) {
+	audit := result.Overwrite
+	if audit == nil || audit.RenamedTo == "" {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	if err := o.renameProject(ctx, audit.ProjectID, audit.name, audit.path); err != nil {
+		result.addError(PhaseOverwrite, "GitLabProjects",
+			fmt.Sprintf("failed to rename the project back after the failed import, it is kept as %s: %v",
+				audit.RenamedTo, err))
+		return
+	}
+	audit.RolledBack = true
+}
+
+// keepOverwrite keeps the project moved aside by prepareOverwrite after the
+// import timed out: GitLab may still finish the import at the target path, so
+// renaming the project back would race with it. The path of the project is
+// reported for the operator to reconcile.
+func (o *Orchestrator) keepOverwrite(result *Result) {
+	audit := result.Overwrite
+	if audit == nil || audit.RenamedTo == "" {
+		return
+	}
+	audit.ImportTimedOut = true
+	result.addWarning(fmt.Sprintf("The import timed out and may still finish: the replaced project is kept as %s, "+
+		"rename it back to %s if the import does not complete", audit.RenamedTo, audit.ProjectPath))
+}
+
+// finishOverwrite deletes the project moved aside by prepareOverwrite once
+// the import finished (delete mode). A failed deletion does not fail the
+// restore, which replaced the project: the overwrite is recorded as partial,
+// with the renamed project left behind, and reported as a non-fatal error.
+func (o *Orchestrator) finishOverwrite(ctx context.Context, result *Result) {
+	audit := result.Overwrite
+	if audit == nil || audit.Mode != config.OverwriteDelete || audit.RenamedTo == "" {
+		return
+	}
+	projects := o.gitlabClient.Client().Projects()
+	if _, err := projects.DeleteProject(ctx, audit.ProjectID, nil, gitlabapi.WithContext(ctx)); err != nil {
+		audit.DeleteError = err.Error()
+		result.Errors = append(result.Errors, Error{
+			Phase:     PhaseOverwrite,
+			Component: "GitLabProjects",
+			Message:   fmt.Sprintf("failed to delete the replaced project, left behind as %s: %v", audit.RenamedTo, err),
+			Fatal:     false,
+			Timestamp: time.Now(),
+		})
+		return
+	}
+	audit.Deleted = true
+}
+
+// renameProject sets the name and path of the project projectID.
+func (o *Orchestrator) renameProject(ctx context.Context, projectID int64, name, projectPath string) error {
+	_, _, err := o.gitlabClient.Client().Projects().EditProject(ctx, projectID, &gitlabapi.EditProjectOptions{
+		Name: gitlabapi.Ptr(name),
+		Path: gitlabapi.Ptr(projectPath),
+	}, gitlabapi.WithContext(ctx))
+	return err //nolint:wrapcheck // wrapped by the GitLab client wrapper
+}
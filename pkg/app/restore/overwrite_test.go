@@ -0,0 +1,256 @@
+package restore_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app/restore"
+	restoreMocks "github.com/sgaunet/gitlab-backup/pkg/app/restore/mocks"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	gitlabMocks "github.com/sgaunet/gitlab-backup/pkg/gitlab/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlabAPI "gitlab.com/gitlab-org/api/client-go"
+)
+
+// overwriteAPI fakes the GitLab projects of an overwriting restore: existing
+// is the project at the target path, nil when there is none.
+type overwriteAPI struct {
+	existing     *gitlabAPI.Project
+	lookupErr    int // status code of a failed project lookup
+	deleteErr    error
+	importFailed bool // the import finishes with the failed status
+	importStuck  bool // the wait for the import times out
+	renamed      *gitlabAPI.EditProjectOptions
+	imported     *gitlabAPI.ImportFileOptions
+	calls        []string
+}
+
+func (a *overwriteAPI) customize(client *gitlabMocks.GitLabClientMock) {
+	client.ProjectsFunc = func() gitlab.ProjectsService {
+		return &gitlabMocks.ProjectsServiceMock{
+			GetProjectFunc: func(_ context.Context, _ any, _ *gitlabAPI.GetProjectOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Project, *gitlabAPI.Response, error) {
+				status := a.lookupErr
+				if status == 0 && a.existing == nil {
+					status = http.StatusNotFound
+				}
+				if status != 0 {
+					resp := &gitlabAPI.Response{Response: &http.Response{StatusCode: status}}
+					return nil, resp, errors.New(http.StatusText(status))
+				}
+				return a.existing, &gitlabAPI.Response{}, nil
+			},
+			EditProjectFunc: func(_ context.Context, _ any, opt *gitlabAPI.EditProjectOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Project, *gitlabAPI.Response, error) {
+				if *opt.Path == a.existing.Path {
+					a.calls = append(a.calls, "rename back")
+					return a.existing, &gitlabAPI.Response{}, nil
+				}
+				a.calls = append(a.calls, "rename")
+				a.renamed = opt
+				return &gitlabAPI.Project{ID: a.existing.ID, Path: *opt.Path}, &gitlabAPI.Response{}, nil
+			},
+			DeleteProjectFunc: func(_ context.Context, _ any, _ *gitlabAPI.DeleteProjectOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+				a.calls = append(a.calls, "delete")
+				return &gitlabAPI.Response{}, a.deleteErr
+			},
+		}
+	}
+	client.ProjectImportExportFunc = func() gitlab.ProjectImportExportService {
+		return &gitlabMocks.ProjectImportExportServiceMock{
+			ImportFromFileFunc: func(_ context.Context, _ io.Reader, opt *gitlabAPI.ImportFileOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.ImportStatus, *gitlabAPI.Response, error) {
+				a.calls = append(a.calls, "import")
+				a.imported = opt
+				return &gitlabAPI.ImportStatus{ID: 42, ImportStatus: "scheduled"}, &gitlabAPI.Response{}, nil
+			},
+			ImportStatusFunc: func(_ context.Context, _ any, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.ImportStatus, *gitlabAPI.Response, error) {
+				if a.importStuck {
+					return nil, nil, context.DeadlineExceeded
+				}
+				if a.importFailed {
+					return &gitlabAPI.ImportStatus{ID: 42, ImportStatus: "failed", ImportError: "invalid bundle"},
+						&gitlabAPI.Response{}, nil
+				}
+				return &gitlabAPI.ImportStatus{ID: 42, ImportStatus: "finished"}, &gitlabAPI.Response{}, nil
+			},
+			ImportFailedRelationsFunc: noFailedRelations,
+		}
+	}
+}
+
+// restoreOverwriting restores over the project of api in mode.
+func restoreOverwriting(t *testing.T, api *overwriteAPI, mode string) (*restore.Result, error) {
+	t.Helper()
+	return restoreOverwritingWith(t, api, mode, successRestoreConfig(t, createValidArchive(t)), setupMockStorage(t))
+}
+
+// restoreOverwritingWith restores over the project of api in mode, with cfg
+// and storage.
+func restoreOverwritingWith(
+	t *testing.T,
+	api *overwriteAPI,
+	mode string,
+	cfg *config.Config,
+	storage restore.Storage,
+) (*restore.Result, error) {
+	t.Helper()
+	cfg.RestoreOverwriteMode = mode
+	cfg.RestoreConfirmDelete = true
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, api.customize), storage,
+		restore.NewNoOpProgressReporter())
+	return orchestrator.Restore(context.Background(), cfg)
+}
+
+func TestRestore_OverwriteImport(t *testing.T) {
+	api := &overwriteAPI{existing: &gitlabAPI.Project{ID: 7, Path: "test-project"}}
+
+	result, err := restoreOverwriting(t, api, "")
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"import"}, api.calls)
+	require.NotNil(t, api.imported.Overwrite)
+	assert.True(t, *api.imported.Overwrite)
+
+	require.NotNil(t, result.Overwrite)
+	assert.Equal(t, config.OverwriteImport, result.Overwrite.Mode)
+	assert.Equal(t, int64(7), result.Overwrite.ProjectID)
+	assert.Equal(t, "test-ns/test-project", result.Overwrite.ProjectPath)
+	assert.Empty(t, result.Overwrite.RenamedTo)
+	assert.Contains(t, result.Overwrite.String(), "project 7 at test-ns/test-project replaced by the import")
+}
+
+func TestRestore_OverwriteRename(t *testing.T) {
+	api := &overwriteAPI{existing: &gitlabAPI.Project{ID: 7, Path: "test-project"}}
+
+	result, err := restoreOverwriting(t, api, config.OverwriteRename)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"rename", "import"}, api.calls)
+	assert.Nil(t, api.imported.Overwrite, "the path is free: the import must not overwrite")
+
+	safetyPath := regexp.MustCompile(`^test-project-pre-restore-\d{8}-\d{6}$`)
+	assert.Regexp(t, safetyPath, *api.renamed.Path)
+	assert.Equal(t, *api.renamed.Path, *api.renamed.Name, "the name of the safety copy must not collide either")
+	require.NotNil(t, result.Overwrite)
+	assert.Equal(t, "test-ns/"+*api.renamed.Path, result.Overwrite.RenamedTo)
+	assert.False(t, result.Overwrite.Deleted)
+	assert.Contains(t, result.Overwrite.String(), "kept as a safety copy")
+}
+
+func TestRestore_OverwriteDelete(t *testing.T) {
+	api := &overwriteAPI{existing: &gitlabAPI.Project{ID: 7, Path: "test-project"}}
+
+	result, err := restoreOverwriting(t, api, config.OverwriteDelete)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"rename", "import", "delete"}, api.calls, "deleted once the import finished")
+	require.NotNil(t, result.Overwrite)
+	assert.True(t, result.Overwrite.Deleted)
+	assert.Contains(t, result.Overwrite.String(), "and deleted (delete mode)")
+	assert.Empty(t, result.Warnings)
+
+	api = &overwriteAPI{existing: &gitlabAPI.Project{ID: 7, Path: "test-project"}, deleteErr: errors.New("403 Forbidden")}
+	result, err = restoreOverwriting(t, api, config.OverwriteDelete)
+	require.NoError(t, err, "the project was replaced")
+	assert.True(t, result.Success)
+	assert.False(t, result.Overwrite.Deleted)
+	assert.True(t, result.Overwrite.Partial())
+	assert.Equal(t, "403 Forbidden", result.Overwrite.DeleteError)
+	assert.Contains(t, result.Overwrite.String(), "not deleted, left behind")
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, restore.PhaseOverwrite, result.Errors[0].Phase)
+	assert.False(t, result.Errors[0].Fatal)
+	assert.Contains(t, result.Errors[0].Message, "left behind as "+result.Overwrite.RenamedTo)
+}
+
+func TestRestore_OverwriteWithoutExistingProject(t *testing.T) {
+	api := &overwriteAPI{}
+
+	result, err := restoreOverwriting(t, api, config.OverwriteRename)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Nil(t, result.Overwrite)
+	assert.Equal(t, []string{"import"}, api.calls)
+}
+
+func TestRestore_OverwriteLookupFailure(t *testing.T) {
+	api := &overwriteAPI{lookupErr: http.StatusForbidden}
+
+	result, err := restoreOverwriting(t, api, config.OverwriteDelete)
+	require.Error(t, err)
+	assert.False(t, result.Success)
+	assert.Empty(t, api.calls)
+	require.NotEmpty(t, result.Errors)
+	assert.Equal(t, restore.PhaseOverwrite, result.Errors[0].Phase)
+}
+
+func TestRestore_OverwriteDownloadFailure(t *testing.T) {
+	for _, mode := range []string{config.OverwriteRename, config.OverwriteDelete} {
+		t.Run(mode, func(t *testing.T) {
+			api := &overwriteAPI{existing: &gitlabAPI.Project{ID: 7, Path: "test-project"}}
+			cfg := successRestoreConfig(t, "backups/test-project.tar.gz")
+			cfg.StorageType = "s3"
+			storage := &restoreMocks.StorageMock{
+				GetFunc: func(_ context.Context, _ string) (string, error) {
+					return "", errors.New("NoSuchKey")
+				},
+			}
+
+			result, err := restoreOverwritingWith(t, api, mode, cfg, storage)
+			require.Error(t, err)
+			assert.False(t, result.Success)
+			assert.Empty(t, api.calls, "the project must stay in place")
+			assert.Nil(t, result.Overwrite)
+		})
+	}
+}
+
+func TestRestore_OverwriteInvalidArchive(t *testing.T) {
+	api := &overwriteAPI{existing: &gitlabAPI.Project{ID: 7, Path: "test-project"}}
+
+	_, err := restoreOverwritingWith(t, api, config.OverwriteDelete,
+		successRestoreConfig(t, createTestArchive(t)), setupMockStorage(t))
+	require.Error(t, err)
+	assert.Empty(t, api.calls, "the project must stay in place")
+}
+
+func TestRestore_OverwriteImportFailure(t *testing.T) {
+	for _, mode := range []string{config.OverwriteRename, config.OverwriteDelete} {
+		t.Run(mode, func(t *testing.T) {
+			api := &overwriteAPI{existing: &gitlabAPI.Project{ID: 7, Path: "test-project"}, importFailed: true}
+
+			result, err := restoreOverwriting(t, api, mode)
+			require.Error(t, err)
+			assert.False(t, result.Success)
+			assert.Equal(t, []string{"rename", "import", "rename back"}, api.calls,
+				"the project must be back in place, not deleted")
+			require.NotNil(t, result.Overwrite)
+			assert.True(t, result.Overwrite.RolledBack)
+			assert.False(t, result.Overwrite.Deleted)
+			assert.Contains(t, result.Overwrite.String(), "then back after the failed import")
+		})
+	}
+}
+
+func TestRestore_OverwriteImportTimeout(t *testing.T) {
+	for _, mode := range []string{config.OverwriteRename, config.OverwriteDelete} {
+		t.Run(mode, func(t *testing.T) {
+			api := &overwriteAPI{existing: &gitlabAPI.Project{ID: 7, Path: "test-project"}, importStuck: true}
+
+			result, err := restoreOverwriting(t, api, mode)
+			require.ErrorIs(t, err, gitlab.ErrImportTimeout)
+			assert.Equal(t, []string{"rename", "import"}, api.calls,
+				"the import may still finish: the project must be neither renamed back nor deleted")
+			require.NotNil(t, result.Overwrite)
+			assert.True(t, result.Overwrite.ImportTimedOut)
+			assert.False(t, result.Overwrite.RolledBack)
+			assert.Contains(t, result.Overwrite.String(), "kept there, the import timed out")
+			require.Len(t, result.Warnings, 1)
+			assert.Contains(t, result.Warnings[0], "kept as "+result.Overwrite.RenamedTo)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 // Package restore implements the 5-phase GitLab project restore workflow.
 //
-// The restore process consists of:
+// The missing groups of the target namespace are first created. When a group
+// archive is given, the target namespace is rebuilt from it with the group
+// import API instead. The restore process then consists of:
 //   1. Validation - Verify target project is empty (unless --overwrite)
 //   2. Download - Fetch archive from S3 if needed
 //   3. Extraction - Extract and validate archive contents
@@ -64,6 +66,12 @@ func NewConsoleProgressReporter(logger *slog.Logger) *ConsoleProgressReporter {
 	}
 }
 
+// WithProject returns a reporter logging the messages of project, for the
+// concurrent restores of a bulk restore.
+func (r *ConsoleProgressReporter) WithProject(project string) ProgressReporter {
+	return NewConsoleProgressReporter(r.logger.With("project", project))
+}
+
 // StartPhase logs the start of a restore phase.
 func (r *ConsoleProgressReporter) StartPhase(phase Phase) {
 	message := getPhaseStartMessage(phase)
@@ -97,14 +105,22 @@ func (r *ConsoleProgressReporter) SkipPhase(phase Phase, reason string) {
 // getPhaseStartMessage returns a human-readable message for each phase.
 func getPhaseStartMessage(phase Phase) string {
 	switch phase {
+	case PhaseNamespace:
+		return "Creating missing namespace groups"
+	case PhaseGroupImport:
+		return "Importing group archive"
 	case PhaseValidation:
 		return "Validating project emptiness"
+	case PhaseOverwrite:
+		return "Moving the existing project aside"
 	case PhaseDownload:
 		return "Downloading archive from S3"
 	case PhaseExtraction:
 		return "Extracting archive"
 	case PhaseImport:
 		return "Importing repository"
+	case PhaseVerification:
+		return "Verifying the restored project against the archive"
 	case PhaseCleanup:
 		return "Cleaning up temporary files"
 	case PhaseComplete:
@@ -124,6 +124,7 @@ func TestConsoleProgressReporter_PhaseMessages(t *testing.T) {
 		{restore.PhaseExtraction, "Extracting archive"},
 		{restore.PhaseImport, "Importing repository"},
 		{restore.PhaseCleanup, "Cleaning up temporary files"},
+		{restore.PhaseGroupImport, "Importing group archive"},
 		{restore.PhaseComplete, "Restore complete"},
 	}
 
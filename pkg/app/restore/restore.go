@@ -13,7 +13,7 @@ import (
 
 	"github.com/sgaunet/gitlab-backup/pkg/config"
 	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
-	"github.com/sgaunet/gitlab-backup/pkg/storage"
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
 )
 
 // Storage interface defines the storage operations needed for restore.
@@ -65,8 +65,8 @@ func NewOrchestratorWithProgress(
 	}
 }
 
-// Restore executes the complete 5-phase restore workflow.
-// It orchestrates validation, download, extraction, and import.
+// Restore executes the complete 6-phase restore workflow.
+// It orchestrates validation, download, extraction, import and verification.
 //
 // Returns Result with success status, metrics, and any errors encountered.
 // Fatal errors stop the workflow; non-fatal errors are collected but allow continuation.
@@ -84,6 +84,13 @@ func (o *Orchestrator) Restore(ctx context.Context, cfg *config.Config) (*Result
 	localArchivePath := cfg.RestoreSource
 	var tempDownloadPath string
 
+	// Age identities decrypt encrypted archives while they are imported.
+	archives, err := newArchiveAccess(cfg)
+	if err != nil {
+		result.addError(PhaseValidation, "AgeIdentity", err.Error())
+		return result, err
+	}
+
 	// Phase 1: Validation (skip if --overwrite flag set)
 	if err := o.validateProject(ctx, cfg, result); err != nil {
 		return result, err
@@ -109,7 +116,7 @@ func (o *Orchestrator) Restore(ctx context.Context, cfg *config.Config) (*Result
 	}
 	defer o.cleanup(result, tempDir, tempDownloadPath)
 
-	archiveContents, err := storage.ExtractArchive(ctx, localArchivePath, tempDir)
+	archiveContents, err := archives.extractArchive(ctx, localArchivePath, tempDir)
 	if err != nil {
 		o.progress.FailPhase(PhaseExtraction, err)
 		result.addError(PhaseExtraction, "ArchiveExtractor", err.Error())
@@ -117,7 +124,40 @@ func (o *Orchestrator) Restore(ctx context.Context, cfg *config.Config) (*Result
 	}
 	o.progress.CompletePhase(PhaseExtraction)
 
+	// Nothing is changed on GitLab before the archive is known to be valid
+	if err := o.prepareTarget(ctx, cfg, archives, result); err != nil {
+		return result, err
+	}
+
 	// Phase 4: Import
+	importStatus, err := o.importProject(ctx, cfg, archives, archiveContents.ProjectExportPath, result)
+	if err != nil {
+		return result, err
+	}
+	o.finishOverwrite(ctx, result)
+
+	// Phase 5: Verification compares the restored project with the archive:
+	// GitLab may finish an import without some of its relations
+	o.verifyRestore(ctx, cfg, archives, archiveContents.ProjectExportPath, importStatus, result)
+
+	// Phase 6: Cleanup (moved from phase 7, now runs in defer at top of function)
+	// Calculate final metrics
+	result.Metrics.DurationSeconds = int64(time.Since(startTime).Seconds())
+	result.Success = !result.hasFatalErrors()
+
+	return result, nil
+}
+
+// importProject imports the project archive at archivePath into the target
+// namespace and waits for GitLab to finish the import. A failed import renames
+// back the project moved aside by prepareOverwrite, unless it timed out.
+func (o *Orchestrator) importProject(
+	ctx context.Context,
+	cfg *config.Config,
+	archives *archiveAccess,
+	archivePath string,
+	result *Result,
+) (*gitlabapi.ImportStatus, error) {
 	o.progress.StartPhase(PhaseImport)
 	importTimeout := time.Duration(cfg.ImportTimeoutMins) * time.Minute
 	importService := gitlab.NewImportServiceWithRateLimiters(
@@ -126,39 +166,73 @@ func (o *Orchestrator) Restore(ctx context.Context, cfg *config.Config) (*Result
 		importTimeout,
 	)
 
-	archiveFile, err := os.Open(archiveContents.ProjectExportPath)
+	archiveFile, _, err := archives.open(archivePath)
 	if err != nil {
 		o.progress.FailPhase(PhaseImport, err)
 		result.addError(PhaseImport, "FileIO", err.Error())
-		return result, fmt.Errorf("failed to open archive file: %w", err)
+		o.rollbackOverwrite(ctx, result)
+		return nil, err
 	}
 	defer func() {
 		_ = archiveFile.Close()
 	}()
 
 	projectURL := fmt.Sprintf("%s/%s/%s", cfg.GitlabURI, cfg.RestoreTargetNS, cfg.RestoreTargetPath)
-	importStatus, err := importService.ImportProject(ctx, archiveFile, cfg.RestoreTargetNS, cfg.RestoreTargetPath)
+	importStatus, err := importService.ImportProject(ctx, archiveFile, cfg.RestoreTargetNS, cfg.RestoreTargetPath,
+		gitlab.ImportOptions{
+			Overwrite:      cfg.OverwriteMode() == config.OverwriteImport,
+			OverrideParams: overrideParams(&cfg.Restore.Overrides),
+		})
 	if err != nil {
 		// On timeout, enrich the error with the project URL so the user knows
-		// where to look — the import may still finish server-side.
-		if errors.Is(err, gitlab.ErrImportTimeout) {
+		// where to look — the import may still finish server-side, so the
+		// project moved aside is kept rather than renamed back onto its path.
+		timedOut := errors.Is(err, gitlab.ErrImportTimeout)
+		if timedOut {
 			err = fmt.Errorf("%w — check %s in a few minutes", err, projectURL)
 		}
 		o.progress.FailPhase(PhaseImport, err)
 		result.addError(PhaseImport, "GitLabImport", err.Error())
-		return result, fmt.Errorf("import failed: %w", err)
+		if timedOut {
+			o.keepOverwrite(result)
+		} else {
+			o.rollbackOverwrite(ctx, result)
+		}
+		return nil, fmt.Errorf("import failed: %w", err)
 	}
 
 	result.ProjectID = importStatus.ID
 	result.ProjectURL = projectURL
 	o.progress.CompletePhase(PhaseImport)
+	return importStatus, nil
+}
 
-	// Phase 5: Cleanup (moved from phase 7, now runs in defer at top of function)
-	// Calculate final metrics
-	result.Metrics.DurationSeconds = int64(time.Since(startTime).Seconds())
-	result.Success = !result.hasFatalErrors()
-
-	return result, nil
+// prepareTarget readies GitLab for the import of the validated project
+// archive: the group archive, if any, is checked, the missing groups of the
+// target namespace are created and the group archive imported into it. With
+// --overwrite, the existing project is then moved aside or left to the import
+// to replace.
+func (o *Orchestrator) prepareTarget(
+	ctx context.Context,
+	cfg *config.Config,
+	archives *archiveAccess,
+	result *Result,
+) error {
+	group, err := o.fetchGroupArchive(ctx, cfg, archives, result)
+	if err != nil {
+		return err
+	}
+	defer group.remove()
+	if err := o.createNamespace(ctx, cfg, result); err != nil {
+		return err
+	}
+	if err := o.importGroup(ctx, cfg, archives, group, result); err != nil {
+		return err
+	}
+	if !cfg.RestoreOverwrite {
+		return nil
+	}
+	return o.prepareOverwrite(ctx, cfg, result)
 }
 
 // addError adds a fatal error to the result.
@@ -188,7 +262,7 @@ func (r *Result) hasFatalErrors() bool {
 }
 
 // ErrProjectHasContent is returned when project is not empty (has commits, issues, or labels).
-var ErrProjectHasContent = errors.New("project is not empty - use --overwrite to skip validation")
+var ErrProjectHasContent = errors.New("project is not empty - use --overwrite to replace it")
 
 // validateProject validates that the target project is empty (if not overwriting).
 func (o *Orchestrator) validateProject(ctx context.Context, cfg *config.Config, result *Result) error {
@@ -1,21 +1,33 @@
 package restore
 
 import (
+	"fmt"
 	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
 )
 
 // Phase represents the current phase of the restore operation.
 type Phase string
 
 const (
+	// PhaseNamespace creates the missing groups of the target namespace.
+	PhaseNamespace Phase = "namespace"
+	// PhaseGroupImport imports the group archive, if any, to rebuild the target namespace.
+	PhaseGroupImport Phase = "group-import"
 	// PhaseValidation validates configuration and target project emptiness.
 	PhaseValidation Phase = "validation"
+	// PhaseOverwrite moves the project at the target path aside (rename and
+	// delete overwrite modes).
+	PhaseOverwrite Phase = "overwrite"
 	// PhaseDownload downloads archive from S3 (if applicable).
 	PhaseDownload Phase = "download"
 	// PhaseExtraction extracts archive contents to temporary directory.
 	PhaseExtraction Phase = "extraction"
 	// PhaseImport imports the GitLab project repository.
 	PhaseImport Phase = "import"
+	// PhaseVerification compares the restored project with the archive.
+	PhaseVerification Phase = "verification"
 	// PhaseCleanup removes temporary files.
 	PhaseCleanup Phase = "cleanup"
 	// PhaseComplete indicates successful completion.
@@ -30,6 +42,20 @@ type Result struct {
 	ProjectID int64
 	// ProjectURL is the web URL of the restored project.
 	ProjectURL string
+	// GroupID is the ID of the target namespace group when a group archive was given.
+	GroupID int64
+	// GroupURL is the web URL of the group rebuilt from the group archive, empty
+	// when the group already existed.
+	GroupURL string
+	// CreatedGroups lists the full paths of the groups created for a missing
+	// target namespace, parents first.
+	CreatedGroups []string
+	// Overwrite records how the project found at the target path was
+	// replaced, nil when there was none or overwrite was not requested.
+	Overwrite *Overwrite
+	// Verification compares the restored project with the archive, nil when
+	// the import failed or the verification was skipped.
+	Verification *Verification
 	// Metrics contains quantitative restore metrics.
 	Metrics Metrics
 	// Errors contains all errors encountered during restore.
@@ -38,6 +64,113 @@ type Result struct {
 	Warnings []string
 }
 
+// Overwrite is the audit record of the project a restore replaced.
+type Overwrite struct {
+	// Mode is the overwrite mode: config.OverwriteImport, OverwriteRename or
+	// OverwriteDelete.
+	Mode string
+	// ProjectID is the ID of the replaced project.
+	ProjectID int64
+	// ProjectPath is the full path of the replaced project, the restore target.
+	ProjectPath string
+	// RenamedTo is the full path the project was renamed to, in the rename
+	// and delete modes.
+	RenamedTo string
+	// Deleted indicates whether the renamed project was deleted (delete mode),
+	// once the import finished. GitLab may keep it until the end of its
+	// deletion delay.
+	Deleted bool
+	// DeleteError is why the deletion failed (delete mode): the overwrite is
+	// partial, the replaced project is left behind at RenamedTo.
+	DeleteError string
+	// RolledBack indicates whether the project was renamed back to
+	// ProjectPath because the import failed.
+	RolledBack bool
+	// ImportTimedOut indicates whether the project was kept at RenamedTo
+	// because the import timed out: GitLab may still finish it.
+	ImportTimedOut bool
+	// Timestamp is when the project was replaced or moved aside.
+	Timestamp time.Time
+
+	// name and path are the original name and path of the project, restored
+	// by a rollback.
+	name, path string
+}
+
+// Partial reports whether the replaced project should have been deleted but
+// was left behind at RenamedTo.
+func (w *Overwrite) Partial() bool {
+	return w.DeleteError != ""
+}
+
+// String describes what happened to the replaced project, for the audit trail.
+func (w *Overwrite) String() string {
+	replaced := fmt.Sprintf("project %d at %s", w.ProjectID, w.ProjectPath)
+	at := w.Timestamp.Format(time.RFC3339)
+	switch {
+	case w.RenamedTo == "":
+		return fmt.Sprintf("%s replaced by the import (%s mode) at %s", replaced, w.Mode, at)
+	case w.RolledBack:
+		return fmt.Sprintf("%s renamed to %s, then back after the failed import (%s mode) at %s",
+			replaced, w.RenamedTo, w.Mode, at)
+	case w.ImportTimedOut:
+		return fmt.Sprintf("%s renamed to %s and kept there, the import timed out and may still finish (%s mode) at %s",
+			replaced, w.RenamedTo, w.Mode, at)
+	case w.Partial():
+		return fmt.Sprintf("%s renamed to %s but not deleted, left behind: %s (%s mode) at %s",
+			replaced, w.RenamedTo, w.DeleteError, w.Mode, at)
+	case w.Deleted:
+		return fmt.Sprintf("%s renamed to %s and deleted (%s mode) at %s", replaced, w.RenamedTo, w.Mode, at)
+	default:
+		return fmt.Sprintf("%s renamed to %s and kept as a safety copy (%s mode) at %s",
+			replaced, w.RenamedTo, w.Mode, at)
+	}
+}
+
+// Verification is the outcome of the verification phase: what GitLab reports
+// of the import, and the restored project compared with the archive.
+type Verification struct {
+	// DefaultBranch is the default branch of the archive repository, empty
+	// when the archive records none.
+	DefaultBranch string
+	// ArchiveHead is the commit of the default branch in the archive.
+	ArchiveHead string
+	// RestoredHead is the commit of the default branch in the restored project.
+	RestoredHead string
+	// Commits is the number of commits on the default branch of the restored
+	// project. The archive does not record it: ArchiveHead and RestoredHead
+	// are compared instead.
+	Commits int
+	// Counts compares the objects of the archive with the restored ones.
+	Counts []Count
+	// ImportError is the import error reported by GitLab, if any.
+	ImportError string
+	// FailedRelations lists the objects GitLab failed to import.
+	FailedRelations []*gitlab.FailedRelation
+	// Differences describes what the restored project lacks: the import
+	// error, each failed relation, count below the archive's and a default
+	// branch missing or at another commit. Each is also reported as a
+	// non-fatal error of Result.Errors.
+	Differences []string
+}
+
+// Count is the number of objects of a kind (issues, labels...) in the
+// archive and in the restored project.
+type Count struct {
+	// Resource is the kind of objects counted, e.g. "merge requests".
+	Resource string
+	// Archive is the number of objects in the archive.
+	Archive int
+	// Restored is the number of objects in the restored project.
+	Restored int
+}
+
+// Complete reports whether the restored project holds everything the archive
+// does, as far as it was checked.
+func (v *Verification) Complete() bool {
+	return len(v.Differences) == 0
+}
+
 // Metrics tracks quantitative restore operation metrics.
 type Metrics struct {
 	// BytesDownloaded is the bytes downloaded from S3 (if applicable).
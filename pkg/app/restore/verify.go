@@ -0,0 +1,236 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+)
+
+// ErrRestoreIncomplete is reported when the restored project lacks some of
+// the content of the archive.
+var ErrRestoreIncomplete = errors.New("restored project differs from the archive")
+
+// onePage lists a single object: the number of objects is read from the
+// X-Total header of the response.
+var onePage = gitlabapi.ListOptions{PerPage: 1, Page: 1}
+
+// counter counts the objects of a kind in the restored project. It returns
+// the objects listed and the response giving their total.
+type counter struct {
+	resource string
+	archive  int
+	count    func() (int, *gitlabapi.Response, error)
+}
+
+// verifyRestore compares the project imported from the archive at
+// archivePath with the archive, once GitLab reported the import finished
+// with status: the import error and failed relations reported by GitLab, the
+// number of issues, merge requests, labels, milestones, branches and tags,
+// and the commit of the default branch. Differences are recorded in
+// result.Verification and as non-fatal errors: the project was imported,
+// possibly without part of its content. What cannot be checked is reported
+// as a warning.
+func (o *Orchestrator) verifyRestore(
+	ctx context.Context,
+	cfg *config.Config,
+	archives *archiveAccess,
+	archivePath string,
+	status *gitlabapi.ImportStatus,
+	result *Result,
+) {
+	if cfg.RestoreSkipVerification {
+		o.progress.SkipPhase(PhaseVerification, "skip-verification flag set")
+		return
+	}
+	o.progress.StartPhase(PhaseVerification)
+	v := &Verification{ImportError: status.ImportError}
+	result.Verification = v
+	if v.ImportError != "" {
+		result.addMismatch("ImportError", v.ImportError)
+	}
+	o.collectFailedRelations(ctx, status.ID, result)
+
+	summary, err := readSummary(ctx, archives, archivePath)
+	if err != nil {
+		result.addWarning(fmt.Sprintf("Verification: failed to read the archive, counts not compared: %v", err))
+	} else {
+		v.DefaultBranch, v.ArchiveHead = summary.DefaultBranch, summary.HeadCommit
+		o.compareCounts(ctx, status.ID, summary, result)
+		o.compareDefaultBranch(ctx, status.ID, result)
+	}
+
+	if !v.Complete() {
+		o.progress.FailPhase(PhaseVerification, ErrRestoreIncomplete)
+		return
+	}
+	o.progress.CompletePhase(PhaseVerification)
+}
+
+// addMismatch records a difference between the restored project and the
+// archive in r.Verification and as a non-fatal verification error.
+func (r *Result) addMismatch(component string, message string) {
+	r.Verification.Differences = append(r.Verification.Differences, message)
+	r.Errors = append(r.Errors, Error{
+		Phase:     PhaseVerification,
+		Component: component,
+		Message:   message,
+		Fatal:     false,
+		Timestamp: time.Now(),
+	})
+}
+
+// readSummary reads the whole archive at path, decrypted when encrypted, and
+// summarizes its content.
+func readSummary(ctx context.Context, archives *archiveAccess, path string) (*storage.ExportSummary, error) {
+	r, _, err := archives.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	summary, err := storage.InspectExport(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive %s: %w", path, err)
+	}
+	return summary, nil
+}
+
+// collectFailedRelations records the relations GitLab failed to import.
+func (o *Orchestrator) collectFailedRelations(ctx context.Context, projectID int64, result *Result) {
+	failed, _, err := o.gitlabClient.Client().ProjectImportExport().ImportFailedRelations(ctx, projectID,
+		gitlabapi.WithContext(ctx))
+	if err != nil {
+		result.addWarning(fmt.Sprintf("Verification: failed to get the failed relations: %v", err))
+		return
+	}
+	result.Verification.FailedRelations = failed
+	for _, f := range failed {
+		result.addMismatch("FailedRelation", fmt.Sprintf("%s not imported: %s", f.RelationName, f.ExceptionMessage))
+	}
+}
+
+// compareCounts counts the objects of the restored project and compares them
+// with those of the archive. A count GitLab does not report is not compared.
+func (o *Orchestrator) compareCounts(
+	ctx context.Context,
+	projectID int64,
+	summary *storage.ExportSummary,
+	result *Result,
+) {
+	for _, c := range o.counters(ctx, projectID, summary) {
+		n, resp, err := c.count()
+		if err != nil {
+			result.addWarning(fmt.Sprintf("Verification: failed to count the restored %s: %v", c.resource, err))
+			continue
+		}
+		restored, ok := totalItems(resp, n)
+		if !ok {
+			result.addWarning(fmt.Sprintf("Verification: GitLab did not report the number of %s", c.resource))
+			continue
+		}
+		result.Verification.Counts = append(result.Verification.Counts,
+			Count{Resource: c.resource, Archive: c.archive, Restored: restored})
+		if restored < c.archive {
+			result.addMismatch(c.resource, fmt.Sprintf("%d of the %d %s of the archive restored",
+				restored, c.archive, c.resource))
+		}
+	}
+}
+
+// counters returns the counters of the objects compared with the archive.
+func (o *Orchestrator) counters(ctx context.Context, projectID int64, summary *storage.ExportSummary) []counter {
+	client := o.gitlabClient.Client()
+	withCtx := gitlabapi.WithContext(ctx)
+	return []counter{
+		{"issues", summary.Issues, func() (int, *gitlabapi.Response, error) {
+			issues, resp, err := client.Issues().ListProjectIssues(ctx, projectID,
+				&gitlabapi.ListProjectIssuesOptions{ListOptions: onePage}, withCtx)
+			return len(issues), resp, err //nolint:wrapcheck // wrapped by the GitLab client wrapper
+		}},
+		{"merge requests", summary.MergeRequests, func() (int, *gitlabapi.Response, error) {
+			mrs, resp, err := client.MergeRequests().ListProjectMergeRequests(ctx, projectID,
+				&gitlabapi.ListProjectMergeRequestsOptions{ListOptions: onePage}, withCtx)
+			return len(mrs), resp, err //nolint:wrapcheck // wrapped by the GitLab client wrapper
+		}},
+		{"labels", summary.Labels, func() (int, *gitlabapi.Response, error) {
+			// Labels of the parent groups are not part of the project export.
+			labels, resp, err := client.Labels().ListLabels(ctx, projectID, &gitlabapi.ListLabelsOptions{
+				ListOptions: onePage, IncludeAncestorGroups: gitlabapi.Ptr(false),
+			}, withCtx)
+			return len(labels), resp, err //nolint:wrapcheck // wrapped by the GitLab client wrapper
+		}},
+		{"milestones", summary.Milestones, func() (int, *gitlabapi.Response, error) {
+			milestones, resp, err := client.Milestones().ListMilestones(ctx, projectID,
+				&gitlabapi.ListMilestonesOptions{ListOptions: onePage}, withCtx)
+			return len(milestones), resp, err //nolint:wrapcheck // wrapped by the GitLab client wrapper
+		}},
+		{"branches", summary.Branches, func() (int, *gitlabapi.Response, error) {
+			branches, resp, err := client.Branches().ListBranches(ctx, projectID,
+				&gitlabapi.ListBranchesOptions{ListOptions: onePage}, withCtx)
+			return len(branches), resp, err //nolint:wrapcheck // wrapped by the GitLab client wrapper
+		}},
+		{"tags", summary.Tags, func() (int, *gitlabapi.Response, error) {
+			tags, resp, err := client.Tags().ListTags(ctx, projectID,
+				&gitlabapi.ListTagsOptions{ListOptions: onePage}, withCtx)
+			return len(tags), resp, err //nolint:wrapcheck // wrapped by the GitLab client wrapper
+		}},
+	}
+}
+
+// compareDefaultBranch checks that the default branch of the archive was
+// restored at the same commit, which implies its whole history was, and
+// counts its commits. The bundle of the archive does not record the number
+// of commits: only the restored one is recorded.
+func (o *Orchestrator) compareDefaultBranch(ctx context.Context, projectID int64, result *Result) {
+	v := result.Verification
+	if v.DefaultBranch == "" {
+		return
+	}
+	client := o.gitlabClient.Client()
+	branch, resp, err := client.Branches().GetBranch(ctx, projectID, v.DefaultBranch, gitlabapi.WithContext(ctx))
+	switch {
+	case err != nil && resp != nil && resp.StatusCode == http.StatusNotFound:
+		result.addMismatch("Commits", fmt.Sprintf("default branch %s not restored", v.DefaultBranch))
+		return
+	case err != nil:
+		result.addWarning(fmt.Sprintf("Verification: failed to get the default branch %s: %v", v.DefaultBranch, err))
+		return
+	case branch.Commit != nil:
+		v.RestoredHead = branch.Commit.ID
+	}
+	if v.RestoredHead != v.ArchiveHead {
+		result.addMismatch("Commits", fmt.Sprintf("default branch %s restored at commit %s instead of %s",
+			v.DefaultBranch, v.RestoredHead, v.ArchiveHead))
+	}
+
+	commits, resp, err := client.Commits().ListCommits(ctx, projectID, &gitlabapi.ListCommitsOptions{
+		ListOptions: onePage, RefName: gitlabapi.Ptr(v.DefaultBranch),
+	}, gitlabapi.WithContext(ctx))
+	if err != nil {
+		result.addWarning(fmt.Sprintf("Verification: failed to count the commits of %s: %v", v.DefaultBranch, err))
+		return
+	}
+	if n, ok := totalItems(resp, len(commits)); ok {
+		v.Commits = n
+	}
+}
+
+// totalItems returns the total number of objects of a list response from
+// its X-Total header, or n, the number of objects listed, when they all fit
+// in the response. It returns false when GitLab omits the header of a list
+// spanning several pages, as it does beyond 10,000 objects.
+func totalItems(resp *gitlabapi.Response, n int) (int, bool) {
+	switch {
+	case resp != nil && resp.TotalItems > 0:
+		return int(resp.TotalItems), true
+	case resp == nil || resp.NextPage == 0:
+		return n, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,252 @@
+package restore_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app/restore"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	gitlabMocks "github.com/sgaunet/gitlab-backup/pkg/gitlab/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlabAPI "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	archiveHead = "2222222222222222222222222222222222222222"
+	otherHead   = "5555555555555555555555555555555555555555"
+)
+
+// createVerifiableArchive writes a project export with 2 issues, 1 merge
+// request, 2 labels, 1 milestone, 2 branches and 1 tag, main being the
+// default branch at archiveHead.
+func createVerifiableArchive(t *testing.T) string {
+	t.Helper()
+	bundle := "# v2 git bundle\n" +
+		archiveHead + " HEAD\n" +
+		archiveHead + " refs/heads/main\n" +
+		"3333333333333333333333333333333333333333 refs/heads/dev\n" +
+		"4444444444444444444444444444444444444444 refs/tags/v1.0.0\n" +
+		"\nPACK"
+	return createExportArchive(t,
+		[2]string{"VERSION", "0.2.4"},
+		[2]string{"tree/project.json", "{}"},
+		[2]string{"tree/project/issues.ndjson", "{\"iid\":1}\n{\"iid\":2}\n"},
+		[2]string{"tree/project/merge_requests.ndjson", "{\"iid\":1}\n"},
+		[2]string{"tree/project/labels.ndjson", "{\"title\":\"bug\"}\n{\"title\":\"doc\"}\n"},
+		[2]string{"tree/project/milestones.ndjson", "{\"iid\":1}\n"},
+		[2]string{"project.bundle", bundle},
+	)
+}
+
+// verifyAPI fakes the GitLab project restored from createVerifiableArchive.
+type verifyAPI struct {
+	issues, mergeRequests, labels, milestones, branches, tags int
+
+	unknownTags   bool // the tags span several pages without X-Total
+	head          string
+	branchMissing bool
+	commits       int64
+	importError   string
+	failed        []*gitlab.FailedRelation
+	labelOpts     *gitlabAPI.ListLabelsOptions
+}
+
+// newVerifyAPI returns a restored project matching createVerifiableArchive.
+func newVerifyAPI() *verifyAPI {
+	return &verifyAPI{
+		issues: 2, mergeRequests: 1, labels: 2, milestones: 1, branches: 2, tags: 1,
+		head: archiveHead, commits: 12,
+	}
+}
+
+// page returns the response of a one-object list of n objects.
+func page(n int) *gitlabAPI.Response {
+	return &gitlabAPI.Response{TotalItems: int64(n)}
+}
+
+// listed returns the one-object page of a list of n objects.
+func listed[T any](n int) []*T {
+	if n == 0 {
+		return []*T{}
+	}
+	return []*T{new(T)}
+}
+
+//nolint:lll // mock signatures
+func (a *verifyAPI) customize(client *gitlabMocks.GitLabClientMock) {
+	client.ProjectImportExportFunc = func() gitlab.ProjectImportExportService {
+		return &gitlabMocks.ProjectImportExportServiceMock{
+			ImportFromFileFunc: func(_ context.Context, _ io.Reader, _ *gitlabAPI.ImportFileOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.ImportStatus, *gitlabAPI.Response, error) {
+				return &gitlabAPI.ImportStatus{ID: 42, ImportStatus: "scheduled"}, &gitlabAPI.Response{}, nil
+			},
+			ImportStatusFunc: func(_ context.Context, _ any, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.ImportStatus, *gitlabAPI.Response, error) {
+				return &gitlabAPI.ImportStatus{ID: 42, ImportStatus: "finished", ImportError: a.importError}, &gitlabAPI.Response{}, nil
+			},
+			ImportFailedRelationsFunc: func(_ context.Context, _ any, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlab.FailedRelation, *gitlabAPI.Response, error) {
+				return a.failed, &gitlabAPI.Response{}, nil
+			},
+		}
+	}
+	client.IssuesFunc = func() gitlab.IssuesService {
+		return &gitlabMocks.IssuesServiceMock{
+			ListProjectIssuesFunc: func(_ context.Context, _ any, _ *gitlabAPI.ListProjectIssuesOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Issue, *gitlabAPI.Response, error) {
+				return listed[gitlabAPI.Issue](a.issues), page(a.issues), nil
+			},
+		}
+	}
+	client.MergeRequestsFunc = func() gitlab.MergeRequestsService {
+		return &gitlabMocks.MergeRequestsServiceMock{
+			ListProjectMergeRequestsFunc: func(_ context.Context, _ any, _ *gitlabAPI.ListProjectMergeRequestsOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.BasicMergeRequest, *gitlabAPI.Response, error) {
+				return listed[gitlabAPI.BasicMergeRequest](a.mergeRequests), page(a.mergeRequests), nil
+			},
+		}
+	}
+	client.LabelsFunc = func() gitlab.LabelsService {
+		return &gitlabMocks.LabelsServiceMock{
+			ListLabelsFunc: func(_ context.Context, _ any, opt *gitlabAPI.ListLabelsOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Label, *gitlabAPI.Response, error) {
+				a.labelOpts = opt
+				return listed[gitlabAPI.Label](a.labels), page(a.labels), nil
+			},
+		}
+	}
+	client.MilestonesFunc = func() gitlab.MilestonesService {
+		return &gitlabMocks.MilestonesServiceMock{
+			ListMilestonesFunc: func(_ context.Context, _ any, _ *gitlabAPI.ListMilestonesOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Milestone, *gitlabAPI.Response, error) {
+				return listed[gitlabAPI.Milestone](a.milestones), page(a.milestones), nil
+			},
+		}
+	}
+	client.TagsFunc = func() gitlab.TagsService {
+		return &gitlabMocks.TagsServiceMock{
+			ListTagsFunc: func(_ context.Context, _ any, _ *gitlabAPI.ListTagsOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Tag, *gitlabAPI.Response, error) {
+				if a.unknownTags {
+					return listed[gitlabAPI.Tag](1), &gitlabAPI.Response{NextPage: 2}, nil
+				}
+				return listed[gitlabAPI.Tag](a.tags), page(a.tags), nil
+			},
+		}
+	}
+	client.BranchesFunc = func() gitlab.BranchesService {
+		return &gitlabMocks.BranchesServiceMock{
+			ListBranchesFunc: func(_ context.Context, _ any, _ *gitlabAPI.ListBranchesOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Branch, *gitlabAPI.Response, error) {
+				return listed[gitlabAPI.Branch](a.branches), page(a.branches), nil
+			},
+			GetBranchFunc: func(_ context.Context, _ any, branch string, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Branch, *gitlabAPI.Response, error) {
+				if a.branchMissing {
+					notFound := &gitlabAPI.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+					return nil, notFound, errors.New("404 Branch Not Found")
+				}
+				return &gitlabAPI.Branch{Name: branch, Commit: &gitlabAPI.Commit{ID: a.head}}, &gitlabAPI.Response{}, nil
+			},
+		}
+	}
+	client.CommitsFunc = func() gitlab.CommitsService {
+		return &gitlabMocks.CommitsServiceMock{
+			ListCommitsFunc: func(_ context.Context, _ any, _ *gitlabAPI.ListCommitsOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Commit, *gitlabAPI.Response, error) {
+				return []*gitlabAPI.Commit{{ID: a.head}}, &gitlabAPI.Response{TotalItems: a.commits}, nil
+			},
+		}
+	}
+}
+
+// restoreVerified restores createVerifiableArchive into the project of api.
+func restoreVerified(t *testing.T, api *verifyAPI) *restore.Result {
+	t.Helper()
+	cfg := successRestoreConfig(t, createVerifiableArchive(t))
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, api.customize), setupMockStorage(t),
+		restore.NewNoOpProgressReporter())
+	result, err := orchestrator.Restore(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.True(t, result.Success, "differences do not fail the restore")
+	require.NotNil(t, result.Verification)
+	return result
+}
+
+func TestRestore_VerificationComplete(t *testing.T) {
+	api := newVerifyAPI()
+
+	result := restoreVerified(t, api)
+	v := result.Verification
+	assert.True(t, v.Complete())
+	assert.Empty(t, result.Errors)
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, []restore.Count{
+		{Resource: "issues", Archive: 2, Restored: 2},
+		{Resource: "merge requests", Archive: 1, Restored: 1},
+		{Resource: "labels", Archive: 2, Restored: 2},
+		{Resource: "milestones", Archive: 1, Restored: 1},
+		{Resource: "branches", Archive: 2, Restored: 2},
+		{Resource: "tags", Archive: 1, Restored: 1},
+	}, v.Counts)
+	assert.Equal(t, "main", v.DefaultBranch)
+	assert.Equal(t, archiveHead, v.ArchiveHead)
+	assert.Equal(t, archiveHead, v.RestoredHead)
+	assert.Equal(t, 12, v.Commits)
+	require.NotNil(t, api.labelOpts.IncludeAncestorGroups)
+	assert.False(t, *api.labelOpts.IncludeAncestorGroups, "group labels are not part of the export")
+}
+
+func TestRestore_VerificationReportsDifferences(t *testing.T) {
+	api := newVerifyAPI()
+	api.issues = 1
+	api.head = otherHead
+	api.importError = "Validation failed"
+	api.failed = []*gitlab.FailedRelation{{RelationName: "issues", ExceptionMessage: "Title is too long"}}
+
+	result := restoreVerified(t, api)
+	v := result.Verification
+	assert.False(t, v.Complete())
+	assert.Equal(t, []string{
+		"Validation failed",
+		"issues not imported: Title is too long",
+		"1 of the 2 issues of the archive restored",
+		"default branch main restored at commit " + otherHead + " instead of " + archiveHead,
+	}, v.Differences)
+	assert.Equal(t, api.failed, v.FailedRelations)
+
+	components := make([]string, 0, len(result.Errors))
+	for _, e := range result.Errors {
+		assert.Equal(t, restore.PhaseVerification, e.Phase)
+		assert.False(t, e.Fatal)
+		components = append(components, e.Component)
+	}
+	assert.Equal(t, []string{"ImportError", "FailedRelation", "issues", "Commits"}, components)
+}
+
+func TestRestore_VerificationMissingDefaultBranch(t *testing.T) {
+	api := newVerifyAPI()
+	api.branchMissing = true
+
+	result := restoreVerified(t, api)
+	assert.Equal(t, []string{"default branch main not restored"}, result.Verification.Differences)
+	assert.Empty(t, result.Verification.RestoredHead)
+}
+
+func TestRestore_VerificationUnknownCount(t *testing.T) {
+	api := newVerifyAPI()
+	api.unknownTags = true
+
+	result := restoreVerified(t, api)
+	assert.True(t, result.Verification.Complete())
+	assert.Len(t, result.Verification.Counts, 5, "the tags are not compared")
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "did not report the number of tags")
+}
+
+func TestRestore_SkipVerification(t *testing.T) {
+	cfg := successRestoreConfig(t, createVerifiableArchive(t))
+	cfg.RestoreSkipVerification = true
+	// The default mocks report none of the content of the archive.
+	orchestrator := restore.NewOrchestratorWithProgress(setupMockGitLabService(t, withImportSuccess),
+		setupMockStorage(t), restore.NewNoOpProgressReporter())
+
+	result, err := orchestrator.Restore(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Nil(t, result.Verification)
+	assert.Empty(t, result.Errors)
+}
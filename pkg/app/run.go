@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/checkpoint"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"github.com/sgaunet/gitlab-backup/pkg/filter"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	"github.com/sgaunet/gitlab-backup/pkg/state"
+	"golang.org/x/sync/errgroup"
+)
+
+// backupRun carries the state shared by the project exports of a group or
+// targets backup.
+type backupRun struct {
+	summary     *backupSummary
+	incremental *state.Store // nil when incremental backups are disabled
+	checkpoint  *checkpoint.Checkpoint
+	budget      *tmpBudget
+	filter      *filter.Filter
+}
+
+// targetProjects pairs a backup target with the projects it resolved to.
+type targetProjects struct {
+	target   *config.TargetConfig
+	projects []gitlab.Project
+}
+
+// startRun compiles the project filters and loads the incremental state and
+// the checkpoint of a new (or resumed) run.
+func (a *App) startRun() (*backupRun, error) {
+	projectFilter, err := filter.New(a.cfg.Filters.Rules())
+	if err != nil {
+		return nil, fmt.Errorf("invalid project filters: %w", err)
+	}
+	incremental, err := a.loadState()
+	if err != nil {
+		return nil, err
+	}
+	cp, err := a.startCheckpoint(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &backupRun{
+		summary:     newBackupSummaryAt(cp.StartedAt()),
+		incremental: incremental,
+		checkpoint:  cp,
+		budget:      newTmpBudget(a.cfg.MaxTmpSizeMB * constants.MB),
+		filter:      projectFilter,
+	}, nil
+}
+
+// exportPlan exports the projects of every target of plan, and the group
+// archive of group targets when exportGroupArchive is set, on a bounded
+// worker pool. Outcomes are recorded in the run summary.
+func (a *App) exportPlan(ctx context.Context, run *backupRun, plan []targetProjects) {
+	eg := errgroup.Group{}
+	eg.SetLimit(resolveMaxConcurrency(a.cfg.MaxConcurrency))
+	for _, tp := range plan {
+		if a.cfg.ExportGroupArchive && tp.target.Group > 0 {
+			eg.Go(func() error {
+				a.exportGroupArchive(ctx, run, tp.target)
+				return nil
+			})
+		}
+		for _, project := range tp.projects {
+			a.scheduleProject(ctx, &eg, run, tp.target, project)
+		}
+	}
+	_ = eg.Wait()
+}
+
+// finishRun reports and records a completed run: summary, incremental state,
+// manifest, checkpoint and retention.
+func (a *App) finishRun(ctx context.Context, run *backupRun) error {
+	run.summary.printSummary(a.log)
+	if err := a.saveState(run.incremental); err != nil {
+		return err
+	}
+	if err := a.writeManifest(ctx, run.summary); err != nil {
+		return err
+	}
+	a.finishCheckpoint(run.checkpoint, run.summary)
+	return a.pruneAfterBackup(ctx, run.summary)
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/encryption"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
+)
+
+// streamSaver returns the storage as a storage.StreamSaver when streamExports
+// is enabled and the storage supports it, or nil for the TmpDir path.
+func (a *App) streamSaver() storage.StreamSaver {
+	if !a.cfg.StreamExports {
+		return nil
+	}
+	saver, _ := a.storage.(storage.StreamSaver)
+	return saver
+}
+
+// streamProject exports project of target t straight to the storage under
+// key. The download is encrypted (when age is configured) and hashed on the
+// fly: nothing is written to TmpDir and the data is read only once.
+//
+//	ExportDownloadStream -> age -> sha256 + size -> pipe -> SaveStream
+//
+// A failed download fails SaveStream too, so no partial archive is stored.
+func (a *App) streamProject(
+	ctx context.Context,
+	saver storage.StreamSaver,
+	project *gitlab.Project,
+	t *config.TargetConfig,
+	key string,
+) (archiveInfo, error) {
+	ageCfg := a.ageConfig(t)
+	pr, pw := io.Pipe()
+	saved := make(chan error, 1)
+	go func() {
+		err := saver.SaveStream(ctx, pr, key)
+		// Unblocks the download if the storage gave up before the end.
+		_ = pr.CloseWithError(err)
+		saved <- err
+	}()
+
+	digest := newDigestWriter(pw)
+	exportErr := a.exportStream(ctx, project, t, ageCfg, digest)
+	_ = pw.CloseWithError(exportErr)
+	saveErr := <-saved
+	if exportErr != nil {
+		return archiveInfo{}, exportErr
+	}
+	if saveErr != nil {
+		return archiveInfo{}, fmt.Errorf("failed to store archive %s: %w", key, saveErr)
+	}
+	return digest.archiveInfo(key, ageCfg.IsEnabled()), nil
+}
+
+// exportStream downloads the export of project to w, through an age writer
+// when ageCfg is enabled.
+func (a *App) exportStream(
+	ctx context.Context,
+	project *gitlab.Project,
+	t *config.TargetConfig,
+	ageCfg config.AgeConfig,
+	w io.Writer,
+) error {
+	if !ageCfg.IsEnabled() {
+		if err := a.gitlabService.ExportProjectStream(ctx, project, w, a.exportOptions(t)); err != nil {
+			return fmt.Errorf("failed to export project %s: %w", project.Name, err)
+		}
+		return nil
+	}
+
+	recipients, err := loadAgeRecipients(ageCfg)
+	if err != nil {
+		return fmt.Errorf("age encryption: %w", err)
+	}
+	a.log.Info("encrypting archive stream with age", "project", project.Name,
+		"recipients", len(recipients), "armor", ageCfg.Armor)
+	encW, err := encryption.NewEncryptWriter(w, recipients, ageCfg.Armor)
+	if err != nil {
+		return fmt.Errorf("age encryption: %w", err)
+	}
+	if err := a.gitlabService.ExportProjectStream(ctx, project, encW, a.exportOptions(t)); err != nil {
+		_ = encW.Close()
+		return fmt.Errorf("failed to export project %s: %w", project.Name, err)
+	}
+	if err := encW.Close(); err != nil {
+		return fmt.Errorf("age encryption: %w", err)
+	}
+	return nil
+}
+
+// digestWriter passes writes through to w while computing their size and
+// SHA-256, the streaming counterpart of describeArchive.
+type digestWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	size int64
+}
+
+func newDigestWriter(w io.Writer) *digestWriter {
+	return &digestWriter{w: w, hash: sha256.New()}
+}
+
+func (d *digestWriter) Write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	d.hash.Write(p[:n])
+	d.size += int64(n)
+	return n, err //nolint:wrapcheck // io.Writer contract, wrapped by callers
+}
+
+// archiveInfo describes the archive written so far, stored under key.
+func (d *digestWriter) archiveInfo(key string, encrypted bool) archiveInfo {
+	return archiveInfo{
+		key:       key,
+		size:      d.size,
+		sha256:    hex.EncodeToString(d.hash.Sum(nil)),
+		encrypted: encrypted,
+	}
+}
@@ -0,0 +1,116 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/sgaunet/gitlab-backup/pkg/app"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	gitlabMocks "github.com/sgaunet/gitlab-backup/pkg/gitlab/mocks"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+	"github.com/sgaunet/gitlab-backup/pkg/storage/localstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamArchiveFn returns an ExportProjectStream implementation writing
+// content in small chunks, as a download does.
+func streamArchiveFn(content string, err error) func(context.Context, *gitlab.Project, io.Writer, gitlab.ExportOptions) error {
+	return func(_ context.Context, _ *gitlab.Project, w io.Writer, _ gitlab.ExportOptions) error {
+		for chunk := range bytes.SplitAfterSeq([]byte(content), []byte("-")) {
+			if _, werr := w.Write(chunk); werr != nil {
+				return werr
+			}
+		}
+		return err
+	}
+}
+
+func TestApp_StreamExports(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabProjectID = 7
+	cfg.StreamExports = true
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	cfg.Age.Recipients = []string{id.Recipient().String()}
+
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectFunc: func(_ context.Context, _ int64) (gitlab.Project, error) {
+			return gitlab.Project{ID: 7, Name: "myproj", PathWithNamespace: "grp/myproj"}, nil
+		},
+		ExportProjectStreamFunc: streamArchiveFn("streamed-archive-bytes", nil),
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+	require.NoError(t, a.Run(context.Background()))
+	assert.Empty(t, svc.ExportProjectCalls(), "streamed exports never go through TmpDir")
+
+	m := readManifest(t, storageDir)
+	require.Len(t, m.Projects, 1)
+	p := m.Projects[0]
+	assert.Equal(t, manifest.StatusSuccess, p.Status)
+	assert.True(t, p.Encrypted)
+
+	// The recorded checksum and size are those of the stored (encrypted) object.
+	stored, err := os.ReadFile(filepath.Join(storageDir, p.ArchiveKey))
+	require.NoError(t, err)
+	sum := sha256.Sum256(stored)
+	assert.Equal(t, hex.EncodeToString(sum[:]), p.SHA256)
+	assert.Equal(t, int64(len(stored)), p.Size)
+
+	r, err := age.Decrypt(bytes.NewReader(stored), id)
+	require.NoError(t, err)
+	plaintext, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "streamed-archive-bytes", string(plaintext))
+
+	tmpEntries, err := os.ReadDir(cfg.TmpDir)
+	require.NoError(t, err)
+	assert.Empty(t, tmpEntries)
+}
+
+func TestApp_StreamExports_DownloadFailure(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.StreamExports = true
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectFunc: func(_ context.Context, _ int64) (gitlab.Project, error) {
+			return gitlab.Project{ID: 7, Name: "myproj"}, nil
+		},
+		ExportProjectStreamFunc: streamArchiveFn("partial-", errors.New("connection reset")),
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(cfg.LocalPath), nil)
+
+	err := a.ExportProject(context.Background(), 7)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to export project myproj")
+	assert.Contains(t, err.Error(), "connection reset")
+
+	// Neither the archive nor a partial file is left in the storage.
+	entries, err := os.ReadDir(storageDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestApp_StreamExports_StorageWithoutStreaming(t *testing.T) {
+	cfg, _ := baseConfig(t)
+	cfg.StreamExports = true
+	stub := &stubStorage{}
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectFunc: func(_ context.Context, _ int64) (gitlab.Project, error) {
+			return gitlab.Project{ID: 7, Name: "myproj"}, nil
+		},
+		ExportProjectFunc: writeArchiveFn(t),
+	}
+	a := app.NewAppWithService(cfg, svc, stub, nil)
+
+	require.NoError(t, a.ExportProject(context.Background(), 7))
+	assert.Len(t, svc.ExportProjectCalls(), 1, "falls back to the TmpDir path")
+	assert.Equal(t, 1, stub.calls)
+}
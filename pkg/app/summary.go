@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
 )
 
 // projectStatus represents the outcome of a single project backup.
@@ -12,52 +15,153 @@ type projectStatus int
 const (
 	statusSuccess projectStatus = iota
 	statusSkipped
+	statusUnchanged
+	statusFiltered
 	statusFailed
 )
 
+// manifestStatus maps a projectStatus to its run manifest representation.
+var manifestStatus = map[projectStatus]string{
+	statusSuccess:   manifest.StatusSuccess,
+	statusSkipped:   manifest.StatusSkipped,
+	statusUnchanged: manifest.StatusUnchanged,
+	statusFiltered:  manifest.StatusFiltered,
+	statusFailed:    manifest.StatusFailed,
+}
+
 // projectResult holds the outcome of a single project backup.
 type projectResult struct {
-	name     string
+	project  gitlab.Project
 	status   projectStatus
 	err      error
+	reason   string // why a filtered project was left out
 	duration time.Duration
+	archive  archiveInfo
 }
 
 // backupSummary collects results from concurrent project backups.
 type backupSummary struct {
 	mu        sync.Mutex
 	results   []projectResult
+	groups    []groupResult   // native group exports, empty when not enabled
+	targets   []targetFailure // targets whose projects could not be listed
 	startTime time.Time
+	runID     string
+}
+
+// groupResult holds the outcome of the native export of the group itself.
+type groupResult struct {
+	group    gitlab.Group
+	err      error
+	duration time.Duration
+	archive  archiveInfo
+}
+
+// targetFailure records a backup target whose projects could not be listed.
+type targetFailure struct {
+	target string
+	err    error
 }
 
 // newBackupSummary creates a new summary with the clock started.
 func newBackupSummary() *backupSummary {
+	return newBackupSummaryAt(time.Now())
+}
+
+// newBackupSummaryAt creates a summary for a run started at start, which may
+// be in the past when an interrupted run is resumed.
+func newBackupSummaryAt(start time.Time) *backupSummary {
 	return &backupSummary{
-		startTime: time.Now(),
+		startTime: start,
+		runID:     manifest.NewRunID(start),
 	}
 }
 
-func (s *backupSummary) recordSuccess(name string, d time.Duration) {
+func (s *backupSummary) recordSuccess(p gitlab.Project, archive archiveInfo, d time.Duration) {
+	s.mu.Lock()
+	s.results = append(s.results, projectResult{project: p, status: statusSuccess, duration: d, archive: archive})
+	s.mu.Unlock()
+}
+
+func (s *backupSummary) recordSkipped(p gitlab.Project) {
+	s.mu.Lock()
+	s.results = append(s.results, projectResult{project: p, status: statusSkipped})
+	s.mu.Unlock()
+}
+
+// recordUnchanged records a project skipped by an incremental backup because
+// it has had no activity since its last successful backup.
+func (s *backupSummary) recordUnchanged(p gitlab.Project) {
 	s.mu.Lock()
-	s.results = append(s.results, projectResult{name: name, status: statusSuccess, duration: d})
+	s.results = append(s.results, projectResult{project: p, status: statusUnchanged})
 	s.mu.Unlock()
 }
 
-func (s *backupSummary) recordSkipped(name string) {
+// recordFiltered records a project left out by the project filters.
+func (s *backupSummary) recordFiltered(p gitlab.Project, reason string) {
 	s.mu.Lock()
-	s.results = append(s.results, projectResult{name: name, status: statusSkipped})
+	s.results = append(s.results, projectResult{project: p, status: statusFiltered, reason: reason})
 	s.mu.Unlock()
 }
 
-func (s *backupSummary) recordFailure(name string, err error, d time.Duration) {
+func (s *backupSummary) recordFailure(p gitlab.Project, err error, d time.Duration) {
 	s.mu.Lock()
-	s.results = append(s.results, projectResult{name: name, status: statusFailed, err: err, duration: d})
+	s.results = append(s.results, projectResult{project: p, status: statusFailed, err: err, duration: d})
 	s.mu.Unlock()
 }
 
+// recordGroup records the outcome of a native group export; err is nil on success.
+func (s *backupSummary) recordGroup(g gitlab.Group, archive archiveInfo, err error, d time.Duration) {
+	s.mu.Lock()
+	s.groups = append(s.groups, groupResult{group: g, err: err, duration: d, archive: archive})
+	s.mu.Unlock()
+}
+
+// recordTargetFailure records a backup target whose projects could not be listed.
+func (s *backupSummary) recordTargetFailure(target string, err error) {
+	s.mu.Lock()
+	s.targets = append(s.targets, targetFailure{target: target, err: err})
+	s.mu.Unlock()
+}
+
+// groupSnapshot returns a copy of the recorded group export outcomes.
+func (s *backupSummary) groupSnapshot() []groupResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	groups := make([]groupResult, len(s.groups))
+	copy(groups, s.groups)
+	return groups
+}
+
+// targetSnapshot returns a copy of the recorded target failures.
+func (s *backupSummary) targetSnapshot() []targetFailure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	targets := make([]targetFailure, len(s.targets))
+	copy(targets, s.targets)
+	return targets
+}
+
+// snapshot returns a copy of the recorded results.
+func (s *backupSummary) snapshot() []projectResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results := make([]projectResult, len(s.results))
+	copy(results, s.results)
+	return results
+}
+
 func (s *backupSummary) hasFailures() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if len(s.targets) > 0 {
+		return true
+	}
+	for _, g := range s.groups {
+		if g.err != nil {
+			return true
+		}
+	}
 	for _, r := range s.results {
 		if r.status == statusFailed {
 			return true
@@ -66,60 +170,167 @@ func (s *backupSummary) hasFailures() bool {
 	return false
 }
 
-func (s *backupSummary) counts() (int, int, int, int) {
+// summaryCounts holds the number of projects per outcome.
+type summaryCounts struct {
+	total     int
+	succeeded int
+	skipped   int
+	unchanged int
+	filtered  int
+	failed    int
+	archived  int // archived projects backed up (includeArchived)
+}
+
+func (s *backupSummary) counts() summaryCounts {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	var total, succeeded, skipped, failed int
+	var c summaryCounts
 	for _, r := range s.results {
-		total++
+		c.total++
 		switch r.status {
 		case statusSuccess:
-			succeeded++
+			c.succeeded++
+			if r.project.Archived {
+				c.archived++
+			}
 		case statusSkipped:
-			skipped++
+			c.skipped++
+		case statusUnchanged:
+			c.unchanged++
+		case statusFiltered:
+			c.filtered++
 		case statusFailed:
-			failed++
+			c.failed++
 		}
 	}
-	return total, succeeded, skipped, failed
+	return c
 }
 
 func (s *backupSummary) printSummary(log Logger) {
-	total, succeeded, skipped, failed := s.counts()
+	c := s.counts()
 	duration := time.Since(s.startTime).Truncate(time.Second)
 
+	// Unchanged and filtered projects were not attempted, so they do not weigh
+	// on the success rate.
 	const percent = 100.0
 	var rate float64
-	if total > 0 {
-		rate = float64(succeeded) / float64(total) * percent
+	if attempted := c.total - c.unchanged - c.filtered; attempted > 0 {
+		rate = float64(c.succeeded) / float64(attempted) * percent
 	}
 
 	log.Info("[BACKUP SUMMARY] completed",
-		"total", total,
-		"succeeded", succeeded,
-		"skipped", skipped,
-		"failed", failed,
+		"total", c.total,
+		"succeeded", c.succeeded,
+		"archived", c.archived,
+		"skipped", c.skipped,
+		"unchanged", c.unchanged,
+		"filtered", c.filtered,
+		"failed", c.failed,
 		"success_rate", fmt.Sprintf("%.1f%%", rate),
 		"duration", duration.String(),
 	)
 
-	s.mu.Lock()
-	results := make([]projectResult, len(s.results))
-	copy(results, s.results)
-	s.mu.Unlock()
+	for _, r := range s.snapshot() {
+		r.print(log)
+	}
 
-	for _, r := range results {
-		switch r.status {
-		case statusSuccess:
-			log.Info("[BACKUP SUMMARY] succeeded", "project", r.name, "duration", r.duration.Truncate(time.Second).String())
-		case statusSkipped:
-			log.Info("[BACKUP SUMMARY] skipped (archived)", "project", r.name)
-		case statusFailed:
-			log.Error("[BACKUP SUMMARY] failed",
-				"project", r.name,
-				"error", r.err.Error(),
-				"duration", r.duration.Truncate(time.Second).String(),
+	for _, g := range s.groupSnapshot() {
+		if g.err != nil {
+			log.Error("[BACKUP SUMMARY] group export failed",
+				"group", g.group.Name,
+				"error", g.err.Error(),
+				"duration", g.duration.Truncate(time.Second).String(),
 			)
+		} else {
+			log.Info("[BACKUP SUMMARY] group export succeeded",
+				"group", g.group.Name,
+				"duration", g.duration.Truncate(time.Second).String(),
+			)
+		}
+	}
+
+	for _, t := range s.targetSnapshot() {
+		log.Error("[BACKUP SUMMARY] target failed", "target", t.target, "error", t.err.Error())
+	}
+}
+
+// print logs the summary line of one project.
+func (r projectResult) print(log Logger) {
+	switch r.status {
+	case statusSuccess:
+		msg := "[BACKUP SUMMARY] succeeded"
+		if r.project.Archived {
+			msg = "[BACKUP SUMMARY] succeeded (archived project)"
+		}
+		log.Info(msg, "project", r.project.Name, "duration", r.duration.Truncate(time.Second).String())
+	case statusSkipped:
+		log.Info("[BACKUP SUMMARY] skipped (archived)", "project", r.project.Name)
+	case statusUnchanged:
+		log.Info("[BACKUP SUMMARY] unchanged since last backup", "project", r.project.Name)
+	case statusFiltered:
+		log.Info("[BACKUP SUMMARY] filtered out", "project", r.project.Name, "reason", r.reason)
+	case statusFailed:
+		log.Error("[BACKUP SUMMARY] failed",
+			"project", r.project.Name,
+			"error", r.err.Error(),
+			"duration", r.duration.Truncate(time.Second).String(),
+		)
+	}
+}
+
+// manifest builds the run manifest from the recorded results.
+func (s *backupSummary) manifest(toolVersion, gitlabEndpoint string) *manifest.Manifest {
+	results := s.snapshot()
+	m := &manifest.Manifest{
+		Version:        manifest.FormatVersion,
+		RunID:          s.runID,
+		ToolVersion:    toolVersion,
+		GitlabEndpoint: gitlabEndpoint,
+		StartedAt:      s.startTime.UTC(),
+		FinishedAt:     time.Now().UTC(),
+		Projects:       make([]manifest.Project, 0, len(results)),
+	}
+	for _, r := range results {
+		entry := manifest.Project{
+			ID:              r.project.ID,
+			Name:            r.project.Name,
+			FullPath:        r.project.PathWithNamespace,
+			Status:          manifestStatus[r.status],
+			Reason:          r.reason,
+			Archived:        r.project.Archived,
+			ArchiveKey:      r.archive.key,
+			Size:            r.archive.size,
+			SHA256:          r.archive.sha256,
+			Encrypted:       r.archive.encrypted,
+			DurationSeconds: r.duration.Seconds(),
+		}
+		if r.err != nil {
+			entry.Error = r.err.Error()
 		}
+		m.Projects = append(m.Projects, entry)
+	}
+	for _, g := range s.groupSnapshot() {
+		entry := manifest.Project{
+			ID:              g.group.ID,
+			Name:            g.group.Name,
+			FullPath:        g.group.FullPath,
+			Visibility:      g.group.Visibility,
+			Description:     g.group.Description,
+			Status:          manifest.StatusSuccess,
+			ArchiveKey:      g.archive.key,
+			Size:            g.archive.size,
+			SHA256:          g.archive.sha256,
+			Encrypted:       g.archive.encrypted,
+			DurationSeconds: g.duration.Seconds(),
+		}
+		if g.err != nil {
+			entry.Status = manifest.StatusFailed
+			entry.Error = g.err.Error()
+		}
+		m.Groups = append(m.Groups, entry)
+	}
+	for _, t := range s.targetSnapshot() {
+		m.Errors = append(m.Errors, fmt.Sprintf("%s: %v", t.target, t.err))
 	}
+	return m
 }
@@ -6,37 +6,50 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// proj returns a minimal project for summary tests.
+func proj(name string) gitlab.Project {
+	return gitlab.Project{Name: name}
+}
+
 func TestBackupSummary_RecordAndCounts(t *testing.T) {
 	s := newBackupSummary()
 
-	s.recordSuccess("alpha", 2*time.Second)
-	s.recordSkipped("beta")
-	s.recordFailure("gamma", errors.New("timeout"), 5*time.Second)
-	s.recordSuccess("delta", 1*time.Second)
+	s.recordSuccess(proj("alpha"), archiveInfo{}, 2*time.Second)
+	s.recordSkipped(proj("beta"))
+	s.recordFailure(proj("gamma"), errors.New("timeout"), 5*time.Second)
+	s.recordSuccess(proj("delta"), archiveInfo{}, 1*time.Second)
+	s.recordUnchanged(proj("epsilon"))
+	s.recordFiltered(proj("zeta"), "fork")
 
-	total, succeeded, skipped, failed := s.counts()
-	assert.Equal(t, 4, total)
-	assert.Equal(t, 2, succeeded)
-	assert.Equal(t, 1, skipped)
-	assert.Equal(t, 1, failed)
+	c := s.counts()
+	assert.Equal(t, 6, c.total)
+	assert.Equal(t, 2, c.succeeded)
+	assert.Equal(t, 1, c.skipped)
+	assert.Equal(t, 1, c.unchanged)
+	assert.Equal(t, 1, c.filtered)
+	assert.Equal(t, 1, c.failed)
 }
 
 func TestBackupSummary_HasFailures(t *testing.T) {
 	t.Run("no failures", func(t *testing.T) {
 		s := newBackupSummary()
-		s.recordSuccess("a", time.Second)
-		s.recordSkipped("b")
+		s.recordSuccess(proj("a"), archiveInfo{}, time.Second)
+		s.recordSkipped(proj("b"))
+		s.recordUnchanged(proj("c"))
+		s.recordFiltered(proj("d"), "mirror")
 		assert.False(t, s.hasFailures())
 	})
 
 	t.Run("with failure", func(t *testing.T) {
 		s := newBackupSummary()
-		s.recordSuccess("a", time.Second)
-		s.recordFailure("b", errors.New("err"), time.Second)
+		s.recordSuccess(proj("a"), archiveInfo{}, time.Second)
+		s.recordFailure(proj("b"), errors.New("err"), time.Second)
 		assert.True(t, s.hasFailures())
 	})
 
@@ -57,19 +70,19 @@ func TestBackupSummary_ConcurrentAccess(t *testing.T) {
 			defer wg.Done()
 			switch i % 3 {
 			case 0:
-				s.recordSuccess("proj", time.Millisecond)
+				s.recordSuccess(proj("proj"), archiveInfo{}, time.Millisecond)
 			case 1:
-				s.recordSkipped("proj")
+				s.recordSkipped(proj("proj"))
 			case 2:
-				s.recordFailure("proj", errors.New("err"), time.Millisecond)
+				s.recordFailure(proj("proj"), errors.New("err"), time.Millisecond)
 			}
 		}(i)
 	}
 	wg.Wait()
 
-	total, succeeded, skipped, failed := s.counts()
-	assert.Equal(t, n, total)
-	require.Equal(t, n, succeeded+skipped+failed)
+	c := s.counts()
+	assert.Equal(t, n, c.total)
+	require.Equal(t, n, c.succeeded+c.skipped+c.failed)
 }
 
 // noopLogger satisfies the Logger interface without producing output.
@@ -82,12 +95,46 @@ func (noopLogger) Info(string, ...any)  {}
 
 func TestBackupSummary_PrintSummary(t *testing.T) {
 	s := newBackupSummary()
-	s.recordSuccess("alpha", 2*time.Second)
-	s.recordSkipped("beta")
-	s.recordFailure("gamma", errors.New("boom"), 3*time.Second)
+	s.recordSuccess(proj("alpha"), archiveInfo{}, 2*time.Second)
+	s.recordSkipped(proj("beta"))
+	s.recordFailure(proj("gamma"), errors.New("boom"), 3*time.Second)
+	s.recordUnchanged(proj("delta"))
+	s.recordFiltered(proj("epsilon"), "fork")
 
 	// Must not panic.
 	require.NotPanics(t, func() {
 		s.printSummary(noopLogger{})
 	})
 }
+
+func TestBackupSummary_Manifest(t *testing.T) {
+	s := newBackupSummary()
+	s.recordSuccess(
+		gitlab.Project{ID: 1, Name: "alpha", PathWithNamespace: "grp/alpha"},
+		archiveInfo{key: "alpha-1.tar.gz", size: 12, sha256: "deadbeef", encrypted: true},
+		2*time.Second,
+	)
+	s.recordSkipped(gitlab.Project{ID: 2, Name: "beta", PathWithNamespace: "grp/beta"})
+	s.recordFailure(gitlab.Project{ID: 3, Name: "gamma"}, errors.New("boom"), time.Second)
+	s.recordFiltered(gitlab.Project{ID: 4, Name: "delta"}, "fork")
+
+	m := s.manifest("v1.0.0", "https://gitlab.example.com")
+
+	assert.Equal(t, manifest.FormatVersion, m.Version)
+	assert.Equal(t, s.runID, m.RunID)
+	assert.Equal(t, "v1.0.0", m.ToolVersion)
+	assert.Equal(t, "https://gitlab.example.com", m.GitlabEndpoint)
+	require.Len(t, m.Projects, 4)
+
+	assert.Equal(t, manifest.Project{
+		ID: 1, Name: "alpha", FullPath: "grp/alpha", Status: manifest.StatusSuccess,
+		ArchiveKey: "alpha-1.tar.gz", Size: 12, SHA256: "deadbeef", Encrypted: true, DurationSeconds: 2,
+	}, m.Projects[0])
+	assert.Equal(t, manifest.StatusSkipped, m.Projects[1].Status)
+	assert.Empty(t, m.Projects[1].ArchiveKey)
+	assert.Equal(t, manifest.StatusFailed, m.Projects[2].Status)
+	assert.Equal(t, "boom", m.Projects[2].Error)
+	assert.Equal(t, manifest.StatusFiltered, m.Projects[3].Status)
+	assert.Equal(t, "fork", m.Projects[3].Reason)
+	assert.Empty(t, m.Projects[3].Error)
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+)
+
+// ExportTargets backs up every entry of the targets list in a single run,
+// with one summary, one manifest and one incremental state.
+//
+// A project reached by several targets is exported once: by its project
+// target if it has one, otherwise by the first group or user target listing
+// it, and by an instance target only when no other target lists it. A target
+// whose projects cannot be listed is reported as failed; the other targets go on.
+func (a *App) ExportTargets(ctx context.Context) error {
+	run, err := a.startRun()
+	if err != nil {
+		return err
+	}
+	plan := a.resolveTargets(ctx, run.summary)
+	projects := 0
+	for _, tp := range plan {
+		projects += len(tp.projects)
+	}
+	a.log.Info("exporting targets",
+		"targets", len(a.cfg.Targets),
+		"projects", projects,
+		"maxConcurrency", resolveMaxConcurrency(a.cfg.MaxConcurrency),
+		"maxTmpSizeMB", a.cfg.MaxTmpSizeMB,
+	)
+	a.exportPlan(ctx, run, plan)
+	if err := a.finishRun(ctx, run); err != nil {
+		return err
+	}
+	if run.summary.hasFailures() {
+		return fmt.Errorf("%w for %d target(s)", ErrBackupErrors, len(a.cfg.Targets))
+	}
+	return nil
+}
+
+// resolveTargets lists the projects of every target and drops the projects
+// already claimed by another target. Project targets claim their project
+// first and instance targets last, so that the overrides of the most specific
+// target win.
+func (a *App) resolveTargets(ctx context.Context, summary *backupSummary) []targetProjects {
+	ordered := make([]*config.TargetConfig, 0, len(a.cfg.Targets))
+	for _, inRound := range []func(*config.TargetConfig) bool{
+		func(t *config.TargetConfig) bool { return t.Project > 0 },
+		func(t *config.TargetConfig) bool { return t.Project == 0 && t.Instance == nil },
+		func(t *config.TargetConfig) bool { return t.Instance != nil },
+	} {
+		for i := range a.cfg.Targets {
+			if inRound(&a.cfg.Targets[i]) {
+				ordered = append(ordered, &a.cfg.Targets[i])
+			}
+		}
+	}
+
+	claimedBy := make(map[int64]*config.TargetConfig)
+	plan := make([]targetProjects, 0, len(ordered))
+	for _, t := range ordered {
+		projects, err := a.listTargetProjects(ctx, t)
+		if err != nil {
+			a.log.Error("failed to list the projects of target", "target", t.String(), "error", err.Error())
+			summary.recordTargetFailure(t.String(), err)
+			continue
+		}
+		kept := make([]gitlab.Project, 0, len(projects))
+		for _, p := range projects {
+			if owner, ok := claimedBy[p.ID]; ok {
+				a.log.Info("project already backed up by another target, skip",
+					"project name", p.Name,
+					"target", t.String(),
+					"backed up by", owner.String(),
+				)
+				continue
+			}
+			claimedBy[p.ID] = t
+			kept = append(kept, p)
+		}
+		plan = append(plan, targetProjects{target: t, projects: kept})
+	}
+	return plan
+}
+
+// listTargetProjects returns the projects covered by target t.
+func (a *App) listTargetProjects(ctx context.Context, t *config.TargetConfig) ([]gitlab.Project, error) {
+	opts := gitlab.ProjectListOptions{IncludeArchived: a.includeArchived(t)}
+	switch {
+	case t.Group > 0:
+		projects, err := a.gitlabService.GetProjectsOfGroup(ctx, t.Group, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get projects of group %d: %w", t.Group, err)
+		}
+		return projects, nil
+	case t.Project > 0:
+		project, err := a.gitlabService.GetProject(ctx, t.Project)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get project %d: %w", t.Project, err)
+		}
+		return []gitlab.Project{project}, nil
+	case t.Instance != nil:
+		scope := gitlab.InstanceScope{Owned: t.Instance.Owned, Membership: t.Instance.Membership}
+		projects, err := a.gitlabService.GetInstanceProjects(ctx, scope, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get projects of the instance: %w", err)
+		}
+		return projects, nil
+	default:
+		projects, err := a.gitlabService.GetProjectsOfUser(ctx, t.User, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get projects of user %s: %w", t.User, err)
+		}
+		return projects, nil
+	}
+}
+
+// ageConfig returns the age settings that apply to the archives of target t
+// (nil for the global settings).
+func (a *App) ageConfig(t *config.TargetConfig) config.AgeConfig {
+	if t != nil && t.Age != nil {
+		return *t.Age
+	}
+	return a.cfg.Age
+}
+
+// includeArchived reports whether the archived projects of target t (nil for
+// the global settings) are backed up.
+func (a *App) includeArchived(t *config.TargetConfig) bool {
+	if t != nil && t.IncludeArchived != nil {
+		return *t.IncludeArchived
+	}
+	return a.cfg.IncludeArchived
+}
+
+// exportOptions returns the archived projects setting and the export timeout
+// override of target t (nil for the global settings).
+func (a *App) exportOptions(t *config.TargetConfig) gitlab.ExportOptions {
+	opts := gitlab.ExportOptions{IncludeArchived: a.includeArchived(t)}
+	if t != nil {
+		opts.Timeout = time.Duration(t.ExportTimeoutMins) * time.Minute
+	}
+	return opts
+}
+
+// prefixKey prepends the storage prefix of target t, if any, to key.
+func prefixKey(t *config.TargetConfig, key string) string {
+	if t == nil || t.KeyPrefix() == "" {
+		return key
+	}
+	return path.Join(t.KeyPrefix(), key)
+}
@@ -0,0 +1,270 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app"
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	gitlabMocks "github.com/sgaunet/gitlab-backup/pkg/gitlab/mocks"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+	"github.com/sgaunet/gitlab-backup/pkg/storage/localstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// targetsService serves the projects of group 10 (1 and 2), project 2 and
+// user alice (3 and, again, 1). groupErr makes listing group 10 fail.
+func targetsService(t *testing.T, groupErr error) *gitlabMocks.BackupServiceMock {
+	t.Helper()
+	projects := map[int64]gitlab.Project{
+		1: {ID: 1, Name: "p1", PathWithNamespace: "grp/p1"},
+		2: {ID: 2, Name: "p2", PathWithNamespace: "grp/p2"},
+		3: {ID: 3, Name: "p3", PathWithNamespace: "alice/p3"},
+	}
+	return &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, groupID int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			assert.Equal(t, int64(10), groupID)
+			if groupErr != nil {
+				return nil, groupErr
+			}
+			return []gitlab.Project{projects[1], projects[2]}, nil
+		},
+		GetProjectsOfUserFunc: func(_ context.Context, username string, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			assert.Equal(t, "alice", username)
+			return []gitlab.Project{projects[3], projects[1]}, nil
+		},
+		GetProjectFunc: func(_ context.Context, projectID int64) (gitlab.Project, error) {
+			return projects[projectID], nil
+		},
+		ExportProjectFunc: writeArchiveFn(t),
+	}
+}
+
+func TestApp_Run_Targets(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.Targets = []config.TargetConfig{
+		{Group: 10, StoragePrefix: "team-a"},
+		{User: "alice", Age: &config.AgeConfig{Recipients: []string{testAgeRecipient}}},
+		{Project: 2, StoragePrefix: "special", ExportTimeoutMins: 5},
+	}
+	svc := targetsService(t, nil)
+
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.Run(context.Background()))
+
+	// Every project is exported exactly once, although 1 and 2 are reached
+	// twice, with the export timeout of the target backing it up.
+	exported := make(map[int64]int)
+	timeouts := make(map[int64]time.Duration)
+	for _, c := range svc.ExportProjectCalls() {
+		exported[c.Project.ID]++
+		timeouts[c.Project.ID] = c.Opts.Timeout
+	}
+	assert.Equal(t, map[int64]int{1: 1, 2: 1, 3: 1}, exported)
+	assert.Equal(t, map[int64]time.Duration{1: 0, 2: 5 * time.Minute, 3: 0}, timeouts)
+
+	// Project 2 is backed up by its project target, project 1 by the group
+	// target listed before the user target.
+	assert.FileExists(t, filepath.Join(storageDir, "team-a", "p1-1.tar.gz"))
+	assert.FileExists(t, filepath.Join(storageDir, "special", "p2-2.tar.gz"))
+	assert.FileExists(t, filepath.Join(storageDir, "p3-3.tar.gz"))
+	assert.NoFileExists(t, filepath.Join(storageDir, "team-a", "p2-2.tar.gz"))
+
+	m := readManifest(t, storageDir)
+	require.Len(t, m.Projects, 3)
+	encrypted := make(map[int64]bool)
+	for _, p := range m.Projects {
+		assert.Equal(t, manifest.StatusSuccess, p.Status)
+		encrypted[p.ID] = p.Encrypted
+	}
+	// Only the user target overrides the (disabled) global encryption.
+	assert.Equal(t, map[int64]bool{1: false, 2: false, 3: true}, encrypted)
+	assert.Empty(t, m.Errors)
+}
+
+func TestApp_ExportTargets_Instance(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.Filters = config.FiltersConfig{ExcludePaths: []string{"alice/**"}}
+	cfg.Targets = []config.TargetConfig{
+		{Instance: &config.InstanceTarget{Owned: true}, StoragePrefix: "all"},
+		{Group: 10, StoragePrefix: "team-a"},
+	}
+	svc := targetsService(t, nil)
+	svc.GetInstanceProjectsFunc = func(_ context.Context, scope gitlab.InstanceScope, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+		assert.Equal(t, gitlab.InstanceScope{Owned: true}, scope)
+		return []gitlab.Project{
+			{ID: 1, Name: "p1", PathWithNamespace: "grp/p1"},
+			{ID: 3, Name: "p3", PathWithNamespace: "alice/p3"},
+			{ID: 4, Name: "p4", PathWithNamespace: "bob/p4"},
+		}, nil
+	}
+	svc.GetProjectFunc = func(_ context.Context, projectID int64) (gitlab.Project, error) {
+		return gitlab.Project{ID: projectID, Name: fmt.Sprintf("p%d", projectID)}, nil
+	}
+
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportTargets(context.Background()))
+
+	// The group target claims its projects although listed after the instance.
+	assert.FileExists(t, filepath.Join(storageDir, "team-a", "p1-1.tar.gz"))
+	assert.FileExists(t, filepath.Join(storageDir, "team-a", "p2-2.tar.gz"))
+	assert.FileExists(t, filepath.Join(storageDir, "all", "p4-4.tar.gz"))
+	assert.NoFileExists(t, filepath.Join(storageDir, "all", "p1-1.tar.gz"))
+
+	// Instance projects go through the filters like any other.
+	status := make(map[int64]string)
+	for _, p := range readManifest(t, storageDir).Projects {
+		status[p.ID] = p.Status
+	}
+	assert.Equal(t, map[int64]string{
+		1: manifest.StatusSuccess,
+		2: manifest.StatusSuccess,
+		3: manifest.StatusFiltered,
+		4: manifest.StatusSuccess,
+	}, status)
+}
+
+func TestApp_ExportTargets_TargetFailure(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.Targets = []config.TargetConfig{{Group: 10}, {Project: 3}}
+
+	a := app.NewAppWithService(cfg, targetsService(t, errors.New("403 Forbidden")), localstorage.NewLocalStorage(storageDir), nil)
+	require.ErrorIs(t, a.ExportTargets(context.Background()), app.ErrBackupErrors)
+
+	// The other target is still backed up.
+	assert.FileExists(t, filepath.Join(storageDir, "p3-3.tar.gz"))
+	m := readManifest(t, storageDir)
+	require.Len(t, m.Projects, 1)
+	require.Len(t, m.Errors, 1)
+	assert.Contains(t, m.Errors[0], "group 10")
+	assert.Contains(t, m.Errors[0], "403 Forbidden")
+}
+
+func TestApp_ExportTargets_CheckpointPerTargetsList(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	checkpoints := func() []string {
+		t.Helper()
+		paths, err := filepath.Glob(filepath.Join(cfg.TmpDir, "gitlab-backup-checkpoint-targets-*.json"))
+		require.NoError(t, err)
+		return paths
+	}
+
+	// A failed run keeps the checkpoint of its targets list.
+	cfg.Targets = []config.TargetConfig{{Group: 10}, {Project: 3}}
+	a := app.NewAppWithService(cfg, targetsService(t, errors.New("403 Forbidden")), localstorage.NewLocalStorage(storageDir), nil)
+	require.ErrorIs(t, a.ExportTargets(context.Background()), app.ErrBackupErrors)
+	kept := checkpoints()
+	require.Len(t, kept, 1)
+
+	// A run of another list neither reuses nor removes it.
+	cfg.Targets = []config.TargetConfig{{Project: 2}}
+	a = app.NewAppWithService(cfg, targetsService(t, nil), localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportTargets(context.Background()))
+	assert.Equal(t, kept, checkpoints())
+}
+
+func TestApp_ExportTargets_Filters(t *testing.T) {
+	run := func(t *testing.T, targets ...config.TargetConfig) (string, *manifest.Manifest) {
+		t.Helper()
+		cfg, storageDir := baseConfig(t)
+		cfg.Filters = config.FiltersConfig{ExcludePaths: []string{"grp/p2"}}
+		cfg.Targets = targets
+		a := app.NewAppWithService(cfg, targetsService(t, nil), localstorage.NewLocalStorage(storageDir), nil)
+		require.NoError(t, a.ExportTargets(context.Background()))
+		return storageDir, readManifest(t, storageDir)
+	}
+
+	t.Run("group target", func(t *testing.T) {
+		storageDir, m := run(t, config.TargetConfig{Group: 10})
+		assert.NoFileExists(t, filepath.Join(storageDir, "p2-2.tar.gz"))
+		status := make(map[int64]string)
+		for _, p := range m.Projects {
+			status[p.ID] = p.Status
+		}
+		assert.Equal(t, map[int64]string{1: manifest.StatusSuccess, 2: manifest.StatusFiltered}, status)
+	})
+
+	t.Run("project target is never filtered", func(t *testing.T) {
+		storageDir, _ := run(t, config.TargetConfig{Group: 10}, config.TargetConfig{Project: 2})
+		assert.FileExists(t, filepath.Join(storageDir, "p2-2.tar.gz"))
+	})
+}
+
+func TestApp_ExportTargets_IncludeArchivedOverride(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	include := true
+	cfg.Targets = []config.TargetConfig{{Project: 3, IncludeArchived: &include}, {Project: 2}}
+	svc := targetsService(t, nil)
+	svc.GetProjectFunc = func(_ context.Context, projectID int64) (gitlab.Project, error) {
+		return gitlab.Project{ID: projectID, Name: fmt.Sprintf("p%d", projectID), Archived: true}, nil
+	}
+
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportTargets(context.Background()))
+
+	// Only the target that opts in backs up its archived project.
+	assert.FileExists(t, filepath.Join(storageDir, "p3-3.tar.gz"))
+	assert.NoFileExists(t, filepath.Join(storageDir, "p2-2.tar.gz"))
+	status := make(map[int64]string)
+	for _, p := range readManifest(t, storageDir).Projects {
+		status[p.ID] = p.Status
+	}
+	assert.Equal(t, map[int64]string{3: manifest.StatusSuccess, 2: manifest.StatusSkipped}, status)
+}
+
+func TestApp_ExportTargets_GroupArchivePerGroupTarget(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.ExportGroupArchive = true
+	cfg.Targets = []config.TargetConfig{
+		{Group: 10, StoragePrefix: "team-a"},
+		{Project: 3},
+	}
+	svc := targetsService(t, nil)
+	var mu sync.Mutex
+	var groups []int64
+	svc.GetGroupFunc = func(_ context.Context, groupID int64) (gitlab.Group, error) {
+		return gitlab.Group{ID: groupID, Name: "grp", Path: "grp", FullPath: "grp"}, nil
+	}
+	svc.ExportGroupFunc = func(_ context.Context, group *gitlab.Group, archiveFilePath string, _ gitlab.ExportOptions) error {
+		mu.Lock()
+		groups = append(groups, group.ID)
+		mu.Unlock()
+		return os.WriteFile(archiveFilePath, []byte("group-bytes"), 0o600)
+	}
+
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.ExportTargets(context.Background()))
+
+	assert.Equal(t, []int64{10}, groups, "only group targets have a group archive")
+	assert.FileExists(t, filepath.Join(storageDir, "team-a", "grp-10.group.tar.gz"))
+	m := readManifest(t, storageDir)
+	require.Len(t, m.Groups, 1)
+	assert.Equal(t, "team-a/grp-10.group.tar.gz", m.Groups[0].ArchiveKey)
+}
+
+func TestApp_Prune_TargetStoragePrefixes(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.Retention = config.RetentionConfig{KeepLast: 1}
+	cfg.ArchiveKeyTemplate = "{date}/{name}-{id}.tar.gz"
+	cfg.Targets = []config.TargetConfig{{Group: 10, StoragePrefix: "team-a"}}
+	now := time.Now()
+	writeStoredArchive(t, storageDir, "team-a/2026-10-13/p1-1.tar.gz", now.Add(-72*time.Hour))
+	writeStoredArchive(t, storageDir, "team-a/2026-10-14/p1-1.tar.gz", now.Add(-48*time.Hour))
+	writeStoredArchive(t, storageDir, "other/2026-10-13/p1-1.tar.gz", now.Add(-72*time.Hour))
+
+	a := app.NewAppWithService(cfg, nil, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.Prune(context.Background(), false))
+
+	assert.NoFileExists(t, filepath.Join(storageDir, "team-a", "2026-10-13", "p1-1.tar.gz"))
+	assert.FileExists(t, filepath.Join(storageDir, "team-a", "2026-10-14", "p1-1.tar.gz"))
+	// Keys under an unknown prefix do not match the template and are left alone.
+	assert.FileExists(t, filepath.Join(storageDir, "other", "2026-10-13", "p1-1.tar.gz"))
+}
@@ -0,0 +1,283 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/sgaunet/gitlab-backup/pkg/checksum"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
+)
+
+// ErrVerifyFailed is returned when stored archives are missing, truncated or
+// do not match their recorded checksum.
+var ErrVerifyFailed = errors.New("archive verification failed")
+
+// Verification statuses of a stored archive.
+const (
+	VerifyStatusOK         = "ok"
+	VerifyStatusMismatch   = "mismatch"   // checksum or size differs from the record
+	VerifyStatusTruncated  = "truncated"  // fewer bytes stored than recorded
+	VerifyStatusMissing    = "missing"    // recorded but not stored
+	VerifyStatusUnreadable = "unreadable" // cannot be read, or neither can its record
+	VerifyStatusUnverified = "unverified" // stored without any checksum record
+)
+
+// verifySourceSidecar is the VerifiedArchive.Source of checksums read from a sidecar.
+const verifySourceSidecar = "sidecar"
+
+// VerifyReport is the outcome of App.Verify, one entry per archive by key.
+type VerifyReport struct {
+	Archives []VerifiedArchive
+}
+
+// VerifiedArchive is the verification result of one archive.
+type VerifiedArchive struct {
+	Key    string
+	Status string
+	// Source is "sidecar", or the key of the manifest the checksum comes from.
+	Source         string
+	ExpectedSHA256 string
+	SHA256         string
+	ExpectedSize   int64 // zero when only a sidecar records the archive
+	Size           int64
+	Error          string
+}
+
+// Failed reports whether the archive failed verification. Archives without
+// a checksum record are not failures.
+func (v VerifiedArchive) Failed() bool {
+	return v.Status != VerifyStatusOK && v.Status != VerifyStatusUnverified
+}
+
+// Failures returns the archives that failed verification.
+func (r *VerifyReport) Failures() []VerifiedArchive {
+	var failed []VerifiedArchive
+	for _, v := range r.Archives {
+		if v.Failed() {
+			failed = append(failed, v)
+		}
+	}
+	return failed
+}
+
+// verifyRecord is the expected checksum of an archive.
+type verifyRecord struct {
+	sha256 string
+	size   int64
+	source string
+	// required is set for the archives of the latest run and those with a
+	// sidecar: they are missing when not stored. The archives of older runs
+	// may have been pruned since.
+	required bool
+	err      error // the sidecar could not be read
+}
+
+// Verify re-reads every archive stored with a checksum record and compares it
+// with the record. Checksums come from the sidecars (checksumSidecars) and
+// from the run manifests, the newest manifest winning for a key written by
+// several runs; sizes only come from the manifests, so truncation is only told
+// apart from other mismatches for archives listed in a manifest.
+//
+// The archives listed in the latest manifest, and those with a sidecar, are
+// reported missing when not stored. The report lists every archive checked;
+// the returned error wraps ErrVerifyFailed when one of them failed.
+func (a *App) Verify(ctx context.Context) (*VerifyReport, error) {
+	objects, err := a.storage.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+	records, report := a.verifyRecords(ctx, objects)
+
+	stored := make(map[string]bool, len(objects))
+	for _, o := range objects {
+		stored[o.Key] = true
+		_, recorded := records[o.Key]
+		if !recorded && !manifest.IsManifestKey(o.Key) && !checksum.IsSidecarKey(o.Key) {
+			report.Archives = append(report.Archives,
+				VerifiedArchive{Key: o.Key, Status: VerifyStatusUnverified, Size: o.Size})
+		}
+	}
+	for key, rec := range records {
+		if stored[key] || rec.required {
+			report.Archives = append(report.Archives, a.verifyArchive(ctx, key, rec))
+		}
+	}
+	slices.SortFunc(report.Archives, func(x, y VerifiedArchive) int { return strings.Compare(x.Key, y.Key) })
+
+	a.logVerifyReport(report)
+	if failed := len(report.Failures()); failed > 0 {
+		return report, fmt.Errorf("%w: %d of %d archive(s)", ErrVerifyFailed, failed, len(report.Archives))
+	}
+	return report, nil
+}
+
+// verifyRecords returns the checksum records of the manifests and sidecars
+// among objects, with a report of the manifests that cannot be read.
+func (a *App) verifyRecords(ctx context.Context, objects []storage.Object) (map[string]*verifyRecord, *VerifyReport) {
+	report := &VerifyReport{}
+	var manifests, sidecars []string
+	for _, o := range objects {
+		switch {
+		case manifest.IsManifestKey(o.Key):
+			manifests = append(manifests, o.Key)
+		case checksum.IsSidecarKey(o.Key):
+			sidecars = append(sidecars, o.Key)
+		}
+	}
+	records := a.manifestRecords(ctx, manifests, report)
+	for _, key := range sidecars {
+		a.addSidecarRecord(ctx, records, key)
+	}
+	return records, report
+}
+
+// manifestRecords returns the checksum records of the manifests stored under
+// keys, the newest run winning. Manifests that cannot be read are added to report.
+func (a *App) manifestRecords(ctx context.Context, keys []string, report *VerifyReport) map[string]*verifyRecord {
+	// Run IDs are sortable timestamps: oldest manifest first.
+	slices.SortFunc(keys, func(x, y string) int { return strings.Compare(path.Base(x), path.Base(y)) })
+	runs := make(map[string]*manifest.Manifest)
+	var readable []string
+	for _, key := range keys {
+		m, err := a.readManifest(ctx, key)
+		if err != nil {
+			report.Archives = append(report.Archives, VerifiedArchive{
+				Key: key, Status: VerifyStatusUnreadable, Error: err.Error(),
+			})
+			continue
+		}
+		runs[key] = m
+		readable = append(readable, key)
+	}
+
+	records := make(map[string]*verifyRecord)
+	for i, key := range readable {
+		latest := i == len(readable)-1
+		for _, p := range slices.Concat(runs[key].Projects, runs[key].Groups) {
+			if p.Status == manifest.StatusSuccess && p.ArchiveKey != "" && p.SHA256 != "" {
+				records[p.ArchiveKey] = &verifyRecord{sha256: p.SHA256, size: p.Size, source: key, required: latest}
+			}
+		}
+	}
+	return records
+}
+
+// addSidecarRecord records the checksum of the sidecar stored under key. The
+// sidecar is written with the archive, so it prevails over the manifests; the
+// manifest size is kept only when both checksums agree.
+func (a *App) addSidecarRecord(ctx context.Context, records map[string]*verifyRecord, key string) {
+	archiveKey := checksum.ArchiveKey(key)
+	rec := &verifyRecord{source: verifySourceSidecar, required: true}
+	data, err := a.readObject(ctx, key)
+	if err == nil {
+		rec.sha256, err = checksum.ParseSidecar(data)
+	}
+	if err != nil {
+		rec.err = fmt.Errorf("checksum sidecar %s: %w", key, err)
+	}
+	if prev, ok := records[archiveKey]; ok && err == nil && prev.sha256 == rec.sha256 {
+		rec.size = prev.size
+	}
+	records[archiveKey] = rec
+}
+
+// verifyArchive re-reads the archive stored under key and compares it with rec.
+func (a *App) verifyArchive(ctx context.Context, key string, rec *verifyRecord) VerifiedArchive {
+	v := VerifiedArchive{Key: key, Source: rec.source, ExpectedSHA256: rec.sha256, ExpectedSize: rec.size}
+	if rec.err != nil {
+		v.Status, v.Error = VerifyStatusUnreadable, rec.err.Error()
+		return v
+	}
+	r, err := a.storage.Open(ctx, key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		v.Status = VerifyStatusMissing
+		return v
+	}
+	if err != nil {
+		v.Status, v.Error = VerifyStatusUnreadable, err.Error()
+		return v
+	}
+	defer func() { _ = r.Close() }()
+
+	h := sha256.New()
+	v.Size, err = io.Copy(h, r)
+	if err != nil {
+		v.Status, v.Error = VerifyStatusUnreadable, fmt.Sprintf("read failed after %d bytes: %v", v.Size, err)
+		return v
+	}
+	v.SHA256 = hex.EncodeToString(h.Sum(nil))
+	switch {
+	case rec.size > 0 && v.Size < rec.size:
+		v.Status = VerifyStatusTruncated
+	case rec.size > 0 && v.Size != rec.size, v.SHA256 != rec.sha256:
+		v.Status = VerifyStatusMismatch
+	default:
+		v.Status = VerifyStatusOK
+	}
+	return v
+}
+
+// readManifest reads and parses the run manifest stored under key.
+func (a *App) readManifest(ctx context.Context, key string) (*manifest.Manifest, error) {
+	data, err := a.readObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	m, err := manifest.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", key, err)
+	}
+	return m, nil
+}
+
+// readObject returns the content of the object stored under key.
+func (a *App) readObject(ctx context.Context, key string) ([]byte, error) {
+	r, err := a.storage.Open(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", key, err)
+	}
+	defer func() { _ = r.Close() }()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// logVerifyReport logs the failures and unverified archives of report, then the totals.
+func (a *App) logVerifyReport(report *VerifyReport) {
+	counts := make(map[string]int)
+	for _, v := range report.Archives {
+		counts[v.Status]++
+		attrs := []any{"key", v.Key, "source", v.Source}
+		switch {
+		case v.Status == VerifyStatusOK:
+			a.log.Debug("[VERIFY] ok", attrs...)
+		case v.Status == VerifyStatusUnverified:
+			a.log.Warn("[VERIFY] no checksum recorded", "key", v.Key)
+		case v.Error != "":
+			a.log.Error("[VERIFY] "+v.Status, append(attrs, "error", v.Error)...)
+		default:
+			a.log.Error("[VERIFY] "+v.Status, append(attrs,
+				"expectedSHA256", v.ExpectedSHA256, "sha256", v.SHA256,
+				"expectedSize", v.ExpectedSize, "size", v.Size)...)
+		}
+	}
+	a.log.Info("[VERIFY] completed",
+		"archives", len(report.Archives),
+		VerifyStatusOK, counts[VerifyStatusOK],
+		VerifyStatusMismatch, counts[VerifyStatusMismatch],
+		VerifyStatusTruncated, counts[VerifyStatusTruncated],
+		VerifyStatusMissing, counts[VerifyStatusMissing],
+		VerifyStatusUnreadable, counts[VerifyStatusUnreadable],
+		VerifyStatusUnverified, counts[VerifyStatusUnverified],
+	)
+}
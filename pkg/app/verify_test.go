@@ -0,0 +1,139 @@
+package app_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/app"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	gitlabMocks "github.com/sgaunet/gitlab-backup/pkg/gitlab/mocks"
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+	"github.com/sgaunet/gitlab-backup/pkg/storage/localstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// verifyStatuses returns the verification status of each archive by key.
+func verifyStatuses(report *app.VerifyReport) map[string]string {
+	statuses := map[string]string{}
+	for _, v := range report.Archives {
+		statuses[v.Key] = v.Status
+	}
+	return statuses
+}
+
+// writeStoredManifest stores a manifest of runID listing archives (key -> content).
+func writeStoredManifest(t *testing.T, storageDir, runID string, archives map[string]string) {
+	t.Helper()
+	m := &manifest.Manifest{Version: manifest.FormatVersion, RunID: runID}
+	for key, content := range archives {
+		sum := sha256.Sum256([]byte(content))
+		m.Projects = append(m.Projects, manifest.Project{
+			Status: manifest.StatusSuccess, ArchiveKey: key,
+			Size: int64(len(content)), SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+	data, err := m.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, manifest.Key(runID)), data, 0o600))
+}
+
+func TestApp_Verify_Sidecars(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	cfg.GitlabGroupID = 100
+	cfg.ChecksumSidecars = true
+	cfg.ExportGroupArchive = true
+	svc := &gitlabMocks.BackupServiceMock{
+		GetProjectsOfGroupFunc: func(_ context.Context, _ int64, _ gitlab.ProjectListOptions) ([]gitlab.Project, error) {
+			return []gitlab.Project{{ID: 1, Name: "one"}, {ID: 2, Name: "two"}, {ID: 3, Name: "three"}}, nil
+		},
+		GetProjectFunc: func(_ context.Context, id int64) (gitlab.Project, error) {
+			return gitlab.Project{ID: id, Name: map[int64]string{1: "one", 2: "two", 3: "three"}[id]}, nil
+		},
+		ExportProjectFunc: writeArchiveFn(t),
+		GetGroupFunc: func(_ context.Context, id int64) (gitlab.Group, error) {
+			return gitlab.Group{ID: id, Name: "grp", FullPath: "grp"}, nil
+		},
+		ExportGroupFunc: func(_ context.Context, _ *gitlab.Group, path string, _ gitlab.ExportOptions) error {
+			return os.WriteFile(path, []byte("group-bytes"), 0o600)
+		},
+	}
+	a := app.NewAppWithService(cfg, svc, localstorage.NewLocalStorage(storageDir), nil)
+	require.NoError(t, a.Run(context.Background()))
+
+	// Each archive gets a sha256sum-compatible sidecar.
+	sidecar, err := os.ReadFile(filepath.Join(storageDir, "one-1.tar.gz.sha256"))
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte("archive-bytes"))
+	assert.Equal(t, hex.EncodeToString(sum[:])+"  one-1.tar.gz\n", string(sidecar))
+	groupSidecars, err := filepath.Glob(filepath.Join(storageDir, "*.group.tar.gz.sha256"))
+	require.NoError(t, err)
+	assert.Len(t, groupSidecars, 1)
+
+	report, err := a.Verify(context.Background())
+	require.NoError(t, err)
+	require.Len(t, report.Archives, 4)
+	for _, v := range report.Archives {
+		assert.Equal(t, app.VerifyStatusOK, v.Status, v.Key)
+		assert.Equal(t, "sidecar", v.Source, v.Key)
+	}
+
+	// Corrupt, truncate, remove an archive and drop a foreign file.
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, "one-1.tar.gz"), []byte("archive-BYTES"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, "two-2.tar.gz"), []byte("archive"), 0o600))
+	require.NoError(t, os.Remove(filepath.Join(storageDir, "three-3.tar.gz")))
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, "notes.txt"), []byte("hi"), 0o600))
+
+	report, err = a.Verify(context.Background())
+	require.ErrorIs(t, err, app.ErrVerifyFailed)
+	assert.Contains(t, err.Error(), "3 of 5 archive(s)")
+	statuses := verifyStatuses(report)
+	assert.Equal(t, app.VerifyStatusMismatch, statuses["one-1.tar.gz"])
+	// The manifest of the run provides the size the sidecar lacks.
+	assert.Equal(t, app.VerifyStatusTruncated, statuses["two-2.tar.gz"])
+	assert.Equal(t, app.VerifyStatusMissing, statuses["three-3.tar.gz"])
+	assert.Equal(t, app.VerifyStatusUnverified, statuses["notes.txt"])
+	assert.Len(t, report.Failures(), 3)
+}
+
+func TestApp_Verify_Manifests(t *testing.T) {
+	cfg, storageDir := baseConfig(t)
+	writeStoredArchive(t, storageDir, "old/pruned-1.tar.gz", time.Now())
+	require.NoError(t, os.Remove(filepath.Join(storageDir, "old", "pruned-1.tar.gz")))
+	writeStoredArchive(t, storageDir, "old/kept-2.tar.gz", time.Now())
+	writeStoredArchive(t, storageDir, "new/kept-2.tar.gz", time.Now())
+
+	writeStoredManifest(t, storageDir, "20261014T030000Z", map[string]string{
+		"old/pruned-1.tar.gz": "archive-bytes",
+		"old/kept-2.tar.gz":   "something else",
+	})
+	// The latest run rewrote old/kept-2.tar.gz.
+	writeStoredManifest(t, storageDir, "20261015T030000Z", map[string]string{
+		"old/kept-2.tar.gz":  "archive-bytes",
+		"new/kept-2.tar.gz":  "archive-bytes",
+		"new/missing.tar.gz": "archive-bytes",
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, "manifest-20261016T030000Z.json"), []byte("{"), 0o600))
+
+	a := app.NewAppWithService(cfg, nil, localstorage.NewLocalStorage(storageDir), nil)
+	report, err := a.Verify(context.Background())
+	require.ErrorIs(t, err, app.ErrVerifyFailed)
+
+	// An archive of an older run may have been pruned: it is not reported.
+	assert.Equal(t, map[string]string{
+		"old/kept-2.tar.gz":              app.VerifyStatusOK,
+		"new/kept-2.tar.gz":              app.VerifyStatusOK,
+		"new/missing.tar.gz":             app.VerifyStatusMissing,
+		"manifest-20261016T030000Z.json": app.VerifyStatusUnreadable,
+	}, verifyStatuses(report))
+	for _, v := range report.Archives {
+		if v.Status == app.VerifyStatusOK {
+			assert.Equal(t, "manifest-20261015T030000Z.json", v.Source)
+		}
+	}
+}
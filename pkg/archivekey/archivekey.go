@@ -0,0 +1,235 @@
+// Package archivekey renders the storage keys of project archives from a
+// configurable template such as "{namespace}/{path}/{date}/{path}-{time}.tar.gz".
+//
+// A template is plain text with {variable} placeholders. It must reference
+// {id} so that every project gets its own keys and retention can tell which
+// project an archive belongs to. Keys may contain "/" to build a directory
+// hierarchy; the rendered key is cleaned and must stay relative.
+//
+// Native group exports are stored under the same template, rendered with the
+// group's variables and marked with ".group" before the extension, so that
+// group and project archives never share a key or a retention history.
+package archivekey
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTemplate reproduces the historical "{name}-{id}.tar.gz" archive name,
+// which is overwritten by every run.
+const DefaultTemplate = "{name}-{id}.tar.gz"
+
+// groupMarker is inserted before archiveExt in group archive keys.
+const (
+	groupMarker = ".group"
+	archiveExt  = ".tar.gz"
+)
+
+// Template variables.
+const (
+	VarNamespace = "namespace" // full path of the project's parent namespace, e.g. "group/subgroup"
+	VarPath      = "path"      // project path (URL slug), e.g. "my-project"
+	VarName      = "name"      // project display name
+	VarID        = "id"        // numeric project ID
+	VarDate      = "date"      // run date, YYYY-MM-DD (UTC)
+	VarTime      = "time"      // run time, HHMMSS (UTC)
+	VarYear      = "year"      // run year, YYYY (UTC)
+	VarMonth     = "month"     // run month, MM (UTC)
+	VarDay       = "day"       // run day of month, DD (UTC)
+	VarRunID     = "runID"     // run identifier shared with the run manifest
+)
+
+var (
+	// ErrInvalidTemplate is returned when a template cannot be parsed.
+	ErrInvalidTemplate = errors.New("invalid archive key template")
+	// ErrInvalidKey is returned when a rendered key is empty or escapes the storage root.
+	ErrInvalidKey = errors.New("invalid archive key")
+)
+
+// varPatterns holds, per variable, the regular expression matching its
+// rendered value. It doubles as the list of known variables.
+var varPatterns = map[string]string{
+	VarNamespace: `.*`,
+	VarPath:      `[^/]+`,
+	VarName:      `[^/]+`,
+	VarID:        `(\d+)`,
+	VarDate:      `\d{4}-\d{2}-\d{2}`,
+	VarTime:      `\d{6}`,
+	VarYear:      `\d{4}`,
+	VarMonth:     `\d{2}`,
+	VarDay:       `\d{2}`,
+	VarRunID:     `\d{8}T\d{6}(?:\.\d{9})?Z`, // seconds only before run IDs had nanoseconds
+}
+
+// placeholder matches one {variable} reference.
+var placeholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// Vars are the values substituted into a template.
+type Vars struct {
+	Namespace string
+	Path      string
+	Name      string
+	ID        int64
+	Time      time.Time
+	RunID     string
+}
+
+// Template is a parsed archive key template.
+type Template struct {
+	raw     string
+	matcher *regexp.Regexp
+}
+
+// Parse checks a template and prepares it for rendering and matching.
+// An empty template selects DefaultTemplate.
+func Parse(tmpl string) (*Template, error) {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+
+	hasID := false
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, m := range placeholder.FindAllStringSubmatchIndex(tmpl, -1) {
+		literal := tmpl[last:m[0]]
+		if strings.ContainsAny(literal, "{}") {
+			return nil, fmt.Errorf("%w %q: unbalanced braces", ErrInvalidTemplate, tmpl)
+		}
+		pattern.WriteString(regexp.QuoteMeta(literal))
+
+		name := tmpl[m[2]:m[3]]
+		re, ok := varPatterns[name]
+		if !ok {
+			return nil, fmt.Errorf("%w %q: unknown variable {%s}", ErrInvalidTemplate, tmpl, name)
+		}
+		if name == VarID {
+			if hasID {
+				// A second capture group would make ProjectID ambiguous.
+				re = `\d+`
+			}
+			hasID = true
+		}
+		pattern.WriteString(re)
+		last = m[1]
+	}
+	if strings.ContainsAny(tmpl[last:], "{}") {
+		return nil, fmt.Errorf("%w %q: unbalanced braces", ErrInvalidTemplate, tmpl)
+	}
+	pattern.WriteString(regexp.QuoteMeta(tmpl[last:]))
+	pattern.WriteString("$")
+
+	if !hasID {
+		return nil, fmt.Errorf("%w %q: must contain {%s}", ErrInvalidTemplate, tmpl, VarID)
+	}
+	if strings.HasPrefix(tmpl, "/") {
+		return nil, fmt.Errorf("%w %q: must be relative", ErrInvalidTemplate, tmpl)
+	}
+	for _, segment := range strings.Split(tmpl, "/") {
+		if segment == ".." {
+			return nil, fmt.Errorf("%w %q: must not contain \"..\"", ErrInvalidTemplate, tmpl)
+		}
+	}
+
+	return &Template{
+		raw:     tmpl,
+		matcher: regexp.MustCompile(pattern.String()),
+	}, nil
+}
+
+// String returns the template text.
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Render substitutes vars into the template and returns the cleaned key.
+// Empty values collapse, so "{namespace}/{path}" renders as "{path}" for a
+// project without namespace.
+func (t *Template) Render(vars Vars) (string, error) {
+	ts := vars.Time.UTC()
+	values := map[string]string{
+		VarNamespace: vars.Namespace,
+		VarPath:      vars.Path,
+		VarName:      vars.Name,
+		VarID:        strconv.FormatInt(vars.ID, 10),
+		VarDate:      ts.Format(time.DateOnly),
+		VarTime:      ts.Format("150405"),
+		VarYear:      ts.Format("2006"),
+		VarMonth:     ts.Format("01"),
+		VarDay:       ts.Format("02"),
+		VarRunID:     vars.RunID,
+	}
+	key := placeholder.ReplaceAllStringFunc(t.raw, func(ref string) string {
+		return values[ref[1:len(ref)-1]]
+	})
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if !fs.ValidPath(key) {
+		return "", fmt.Errorf("%w: %q rendered from %q", ErrInvalidKey, key, t.raw)
+	}
+	return key, nil
+}
+
+// RenderGroup renders the key of a native group export. vars describe the
+// group (Namespace is the full path of its parent) and the key is marked as a
+// group archive, e.g. "{name}-{id}.tar.gz" renders "my-group-7.group.tar.gz".
+func (t *Template) RenderGroup(vars Vars) (string, error) {
+	key, err := t.Render(vars)
+	if err != nil {
+		return "", err
+	}
+	if base, ok := strings.CutSuffix(key, archiveExt); ok {
+		return base + groupMarker + archiveExt, nil
+	}
+	return key + groupMarker, nil
+}
+
+// IsGroupKey reports whether key names a group archive rendered by RenderGroup.
+func IsGroupKey(key string) bool {
+	return strings.HasSuffix(key, groupMarker+archiveExt) || strings.HasSuffix(key, groupMarker)
+}
+
+// ProjectID extracts the project ID from a key rendered by this template.
+// Keys the template cannot have produced (run manifests, sidecar files,
+// group archives, archives written under another template) report false.
+func (t *Template) ProjectID(key string) (int64, bool) {
+	if IsGroupKey(key) {
+		return 0, false
+	}
+	return t.matchID(key)
+}
+
+// GroupID extracts the group ID from a group archive key rendered by RenderGroup.
+func (t *Template) GroupID(key string) (int64, bool) {
+	if base, ok := strings.CutSuffix(key, groupMarker+archiveExt); ok {
+		return t.matchID(base + archiveExt)
+	}
+	if base, ok := strings.CutSuffix(key, groupMarker); ok {
+		return t.matchID(base)
+	}
+	return 0, false
+}
+
+// matchID extracts the {id} value of a key rendered by this template.
+func (t *Template) matchID(key string) (int64, bool) {
+	m := t.matcher.FindStringSubmatch(key)
+	if m == nil {
+		// A leading variable rendered empty (a project without namespace)
+		// took its "/" separator with it when the key was cleaned.
+		m = t.matcher.FindStringSubmatch("/" + key)
+	}
+	if m == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,164 @@
+package archivekey_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/archivekey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var runTime = time.Date(2026, 10, 16, 5, 4, 3, 0, time.FixedZone("CEST", 2*3600))
+
+func vars() archivekey.Vars {
+	return archivekey.Vars{
+		Namespace: "group/subgroup",
+		Path:      "my-project",
+		Name:      "My Project",
+		ID:        42,
+		Time:      runTime,
+		RunID:     "20261016T030403.000000000Z",
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"missing id":       "{path}.tar.gz",
+		"unknown variable": "{path}-{id}-{branch}.tar.gz",
+		"empty variable":   "{}{id}.tar.gz",
+		"unbalanced open":  "{path-{id}.tar.gz",
+		"unbalanced close": "{id}}.tar.gz",
+		"absolute":         "/backups/{id}.tar.gz",
+		"parent directory": "../{id}.tar.gz",
+	}
+	for name, tmpl := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := archivekey.Parse(tmpl)
+			require.ErrorIs(t, err, archivekey.ErrInvalidTemplate)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"", "My Project-42.tar.gz"},
+		{archivekey.DefaultTemplate, "My Project-42.tar.gz"},
+		{"{namespace}/{path}/{year}/{month}/{day}/{path}-{id}-{time}.tar.gz",
+			"group/subgroup/my-project/2026/10/16/my-project-42-030403.tar.gz"},
+		{"{runID}/{namespace}/{path}-{id}.tar.gz", "20261016T030403.000000000Z/group/subgroup/my-project-42.tar.gz"},
+		{"{date}/{id}.tar.gz", "2026-10-16/42.tar.gz"},
+	}
+	for _, tt := range tests {
+		tmpl, err := archivekey.Parse(tt.tmpl)
+		require.NoError(t, err, tt.tmpl)
+		got, err := tmpl.Render(vars())
+		require.NoError(t, err, tt.tmpl)
+		assert.Equal(t, tt.want, got, tt.tmpl)
+	}
+}
+
+func TestRender_EmptyNamespaceCollapses(t *testing.T) {
+	tmpl, err := archivekey.Parse("{namespace}/{path}-{id}.tar.gz")
+	require.NoError(t, err)
+	v := vars()
+	v.Namespace = ""
+	got, err := tmpl.Render(v)
+	require.NoError(t, err)
+	assert.Equal(t, "my-project-42.tar.gz", got)
+}
+
+func TestRender_ValuesCannotEscape(t *testing.T) {
+	tmpl, err := archivekey.Parse("{namespace}/{id}.tar.gz")
+	require.NoError(t, err)
+	v := vars()
+	v.Namespace = "../../etc"
+	got, err := tmpl.Render(v)
+	require.NoError(t, err)
+	assert.Equal(t, "etc/42.tar.gz", got)
+}
+
+func TestProjectID(t *testing.T) {
+	tmpl, err := archivekey.Parse("{namespace}/{path}/{date}/{path}-{id}-{time}.tar.gz")
+	require.NoError(t, err)
+
+	// Every rendered key maps back to its project, with or without namespace.
+	for _, ns := range []string{"group/subgroup", "group", ""} {
+		v := vars()
+		v.Namespace = ns
+		key, err := tmpl.Render(v)
+		require.NoError(t, err)
+		id, ok := tmpl.ProjectID(key)
+		require.True(t, ok, key)
+		assert.Equal(t, int64(42), id, key)
+	}
+
+	for _, key := range []string{
+		"manifest-20261016T030403Z.json",
+		"group/my-project/2026-10-16/my-project-42-030403.tar.gz.sha256",
+		"group/my-project/latest/my-project-42-030403.tar.gz",
+	} {
+		_, ok := tmpl.ProjectID(key)
+		assert.False(t, ok, key)
+	}
+}
+
+func TestProjectID_RunID(t *testing.T) {
+	tmpl, err := archivekey.Parse("{runID}/{path}-{id}.tar.gz")
+	require.NoError(t, err)
+
+	// Keys of runs from before run IDs had nanoseconds still match.
+	for _, key := range []string{
+		"20261016T030403.120000000Z/my-project-42.tar.gz",
+		"20261016T030403Z/my-project-42.tar.gz",
+	} {
+		id, ok := tmpl.ProjectID(key)
+		require.True(t, ok, key)
+		assert.Equal(t, int64(42), id, key)
+	}
+}
+
+func TestProjectID_DefaultTemplate(t *testing.T) {
+	tmpl, err := archivekey.Parse("")
+	require.NoError(t, err)
+
+	id, ok := tmpl.ProjectID("my-proj-v2-1001.tar.gz")
+	require.True(t, ok)
+	assert.Equal(t, int64(1001), id)
+
+	_, ok = tmpl.ProjectID("myproj.tar.gz")
+	assert.False(t, ok)
+}
+
+func TestRenderGroup(t *testing.T) {
+	for _, tc := range []struct {
+		tmpl string
+		want string
+	}{
+		{"", "My Project-42.group.tar.gz"},
+		{"{namespace}/{path}-{id}.tar.gz", "group/subgroup/my-project-42.group.tar.gz"},
+		{"{runID}/{id}", "20261016T030403.000000000Z/42.group"},
+	} {
+		tmpl, err := archivekey.Parse(tc.tmpl)
+		require.NoError(t, err)
+		key, err := tmpl.RenderGroup(vars())
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, key)
+		assert.True(t, archivekey.IsGroupKey(key), key)
+
+		// A group key maps back to its group, never to a project.
+		id, ok := tmpl.GroupID(key)
+		require.True(t, ok, key)
+		assert.Equal(t, int64(42), id)
+		_, ok = tmpl.ProjectID(key)
+		assert.False(t, ok, key)
+
+		projectKey, err := tmpl.Render(vars())
+		require.NoError(t, err)
+		_, ok = tmpl.GroupID(projectKey)
+		assert.False(t, ok, projectKey)
+	}
+}
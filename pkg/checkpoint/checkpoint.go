@@ -0,0 +1,146 @@
+// Package checkpoint records the progress of a group backup so that an
+// interrupted run can be resumed without exporting finished projects again.
+//
+// The checkpoint is rewritten atomically after every completed project, so a
+// crash loses at most the exports that were in flight.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"github.com/sgaunet/gitlab-backup/pkg/fileutil"
+)
+
+// currentVersion is the on-disk format version written by Record.
+const currentVersion = 1
+
+// ErrUnsupportedVersion is returned when the checkpoint was written by an incompatible version.
+var ErrUnsupportedVersion = errors.New("unsupported checkpoint version")
+
+// Entry describes the stored archive of a completed project.
+type Entry struct {
+	ArchiveKey  string    `json:"archiveKey"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	Encrypted   bool      `json:"encrypted"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// fileFormat is the JSON layout of the checkpoint file.
+type fileFormat struct {
+	Version   int             `json:"version"`
+	GroupID   int64           `json:"groupId"`
+	StartedAt time.Time       `json:"startedAt"`
+	Completed map[int64]Entry `json:"completed"`
+}
+
+// Checkpoint is a JSON file backed, concurrency-safe record of the projects
+// completed by a group backup run.
+type Checkpoint struct {
+	mu        sync.Mutex
+	path      string
+	groupID   int64
+	startedAt time.Time
+	completed map[int64]Entry
+}
+
+// New returns an empty checkpoint for the run of groupID started at startedAt.
+// Nothing is written until the first project is recorded.
+func New(path string, groupID int64, startedAt time.Time) *Checkpoint {
+	return &Checkpoint{
+		path:      path,
+		groupID:   groupID,
+		startedAt: startedAt.UTC(),
+		completed: make(map[int64]Entry),
+	}
+}
+
+// Load reads the checkpoint at path. A missing file is reported as an error
+// wrapping os.ErrNotExist.
+func Load(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: checkpoint path is derived from configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", path, err)
+	}
+
+	var f fileFormat
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	if f.Version != currentVersion {
+		return nil, fmt.Errorf("%w: %d (checkpoint %s)", ErrUnsupportedVersion, f.Version, path)
+	}
+	c := New(path, f.GroupID, f.StartedAt)
+	for id, e := range f.Completed {
+		c.completed[id] = e
+	}
+	return c, nil
+}
+
+// GroupID returns the group the checkpoint belongs to.
+func (c *Checkpoint) GroupID() int64 {
+	return c.groupID
+}
+
+// StartedAt returns the start time of the run the checkpoint belongs to.
+func (c *Checkpoint) StartedAt() time.Time {
+	return c.startedAt
+}
+
+// Len returns the number of completed projects.
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.completed)
+}
+
+// Completed returns the entry of a project completed by the run.
+func (c *Checkpoint) Completed(projectID int64) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.completed[projectID]
+	return e, ok
+}
+
+// Record marks a project as completed and rewrites the checkpoint file.
+func (c *Checkpoint) Record(projectID int64, e Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.CompletedAt = e.CompletedAt.UTC()
+	c.completed[projectID] = e
+	return c.save()
+}
+
+// Remove deletes the checkpoint file once the run has completed.
+// A checkpoint that was never written is not an error.
+func (c *Checkpoint) Remove() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// save writes the checkpoint atomically (temporary file, then rename).
+// The caller must hold c.mu.
+func (c *Checkpoint) save() error {
+	data, err := json.MarshalIndent(fileFormat{
+		Version:   currentVersion,
+		GroupID:   c.groupID,
+		StartedAt: c.startedAt,
+		Completed: c.completed,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	if err := fileutil.WriteAtomic(c.path, data, constants.DefaultFilePermission); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package checkpoint_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/checkpoint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	_, err := checkpoint.Load(filepath.Join(t.TempDir(), "checkpoint.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoad_InvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+	_, err := checkpoint.Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse checkpoint")
+}
+
+func TestLoad_UnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":99,"completed":{}}`), 0o600))
+
+	_, err := checkpoint.Load(path)
+	require.ErrorIs(t, err, checkpoint.ErrUnsupportedVersion)
+}
+
+func TestCheckpoint_RecordAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	started := time.Date(2026, 10, 16, 3, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	c := checkpoint.New(path, 42, started)
+
+	// Nothing is written before the first project completes.
+	_, err := os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	entry := checkpoint.Entry{
+		ArchiveKey:  "grp/proj-7.tar.gz",
+		Size:        1024,
+		SHA256:      "abc",
+		Encrypted:   true,
+		CompletedAt: started.Add(time.Hour),
+	}
+	require.NoError(t, c.Record(7, entry))
+
+	loaded, err := checkpoint.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), loaded.GroupID())
+	assert.True(t, started.Equal(loaded.StartedAt()))
+	assert.Equal(t, 1, loaded.Len())
+	got, ok := loaded.Completed(7)
+	require.True(t, ok)
+	assert.Equal(t, "grp/proj-7.tar.gz", got.ArchiveKey)
+	assert.Equal(t, int64(1024), got.Size)
+	assert.True(t, got.Encrypted)
+	assert.True(t, entry.CompletedAt.Equal(got.CompletedAt))
+	_, ok = loaded.Completed(8)
+	assert.False(t, ok)
+}
+
+func TestCheckpoint_Remove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	c := checkpoint.New(path, 42, time.Now())
+
+	// Removing a checkpoint that was never written is fine.
+	require.NoError(t, c.Remove())
+
+	require.NoError(t, c.Record(1, checkpoint.Entry{ArchiveKey: "a-1.tar.gz"}))
+	require.NoError(t, c.Remove())
+	_, err := os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
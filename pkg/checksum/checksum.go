@@ -0,0 +1,55 @@
+// Package checksum handles the SHA-256 sidecars gitlab-backup stores next to
+// its archives. A sidecar of key "grp/app-1.tar.gz" is stored under
+// "grp/app-1.tar.gz.sha256" in the sha256sum format, so it can also be checked
+// with "sha256sum -c" once downloaded along with the archive:
+//
+//	9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  app-1.tar.gz
+package checksum
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SidecarExtension is appended to an archive key to get its sidecar key.
+const SidecarExtension = ".sha256"
+
+// ErrInvalidSidecar is returned when a sidecar does not hold a SHA-256 checksum.
+var ErrInvalidSidecar = errors.New("invalid checksum sidecar")
+
+// SidecarKey returns the key of the sidecar of the archive stored under key.
+func SidecarKey(key string) string {
+	return key + SidecarExtension
+}
+
+// IsSidecarKey reports whether a storage key names a sidecar.
+func IsSidecarKey(key string) bool {
+	return strings.HasSuffix(key, SidecarExtension)
+}
+
+// ArchiveKey returns the key of the archive of the sidecar stored under key.
+func ArchiveKey(sidecarKey string) string {
+	return strings.TrimSuffix(sidecarKey, SidecarExtension)
+}
+
+// FormatSidecar returns the sidecar content of the archive stored under key
+// with the hex-encoded SHA-256 sum.
+func FormatSidecar(sum, key string) []byte {
+	return []byte(sum + "  " + path.Base(key) + "\n")
+}
+
+// ParseSidecar returns the hex-encoded SHA-256 sum of a sidecar.
+func ParseSidecar(data []byte) (string, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%w: empty", ErrInvalidSidecar)
+	}
+	sum := strings.ToLower(fields[0])
+	if b, err := hex.DecodeString(sum); err != nil || len(b) != 32 { //nolint:mnd // SHA-256 size
+		return "", fmt.Errorf("%w: %q is not a SHA-256 sum", ErrInvalidSidecar, fields[0])
+	}
+	return sum, nil
+}
@@ -0,0 +1,41 @@
+package checksum_test
+
+import (
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/checksum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestSidecarKeys(t *testing.T) {
+	key := checksum.SidecarKey("grp/app-1.tar.gz")
+	assert.Equal(t, "grp/app-1.tar.gz.sha256", key)
+	assert.True(t, checksum.IsSidecarKey(key))
+	assert.False(t, checksum.IsSidecarKey("grp/app-1.tar.gz"))
+	assert.Equal(t, "grp/app-1.tar.gz", checksum.ArchiveKey(key))
+}
+
+func TestFormatParseSidecar(t *testing.T) {
+	data := checksum.FormatSidecar(sum, "grp/app-1.tar.gz")
+	assert.Equal(t, sum+"  app-1.tar.gz\n", string(data))
+
+	parsed, err := checksum.ParseSidecar(data)
+	require.NoError(t, err)
+	assert.Equal(t, sum, parsed)
+
+	// Written by hand with sha256sum, upper case or in binary mode.
+	upper := "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08"
+	parsed, err = checksum.ParseSidecar([]byte(upper + " *app-1.tar.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, sum, parsed)
+}
+
+func TestParseSidecar_Errors(t *testing.T) {
+	for _, data := range []string{"", "  \n", "abc  app-1.tar.gz", sum[:62] + "zz  app-1.tar.gz"} {
+		_, err := checksum.ParseSidecar([]byte(data))
+		require.ErrorIs(t, err, checksum.ErrInvalidSidecar, "%q", data)
+	}
+}
@@ -11,13 +11,15 @@ import (
 	"strings"
 
 	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/sgaunet/gitlab-backup/pkg/archivekey"
 	"github.com/sgaunet/gitlab-backup/pkg/constants"
 	"github.com/sgaunet/gitlab-backup/pkg/hooks"
+	"github.com/sgaunet/gitlab-backup/pkg/nsmap"
 	"gopkg.in/yaml.v3"
 )
 
-// errAgeRecipientsFileIsDir is returned when AgeConfig.RecipientsFile points at a directory.
-var errAgeRecipientsFileIsDir = errors.New("path is a directory, not a file")
+// errAgeFileIsDir is returned when an age recipients or identity file points at a directory.
+var errAgeFileIsDir = errors.New("path is a directory, not a file")
 
 // S3Config holds the configuration for S3 storage backend.
 type S3Config struct {
@@ -27,6 +29,10 @@ type S3Config struct {
 	Region     string `env:"S3REGION"              env-default:""   yaml:"region"`
 	AccessKey  string `env:"AWS_ACCESS_KEY_ID"     yaml:"accessKey"`
 	SecretKey  string `env:"AWS_SECRET_ACCESS_KEY" yaml:"secretKey"`
+	// Archives larger than PartSizeMB are uploaded in parts, UploadConcurrency
+	// at a time. Zero keeps the defaults.
+	PartSizeMB        int `env:"S3_PART_SIZE_MB"       env-default:"16" yaml:"partSizeMB"`
+	UploadConcurrency int `env:"S3_UPLOAD_CONCURRENCY" env-default:"4"  yaml:"uploadConcurrency"`
 }
 
 // AgeConfig holds the configuration for age archive encryption.
@@ -35,10 +41,37 @@ type S3Config struct {
 // matching private identity must stay offline and is only used for restore.
 // Set Recipients (inline, comma-separated env var) or RecipientsFile (path).
 // At least one must be provided to enable encryption.
+//
+// IdentityFile is only read by gitlab-restore: it holds the PRIVATE identity
+// (age-keygen output, or an unencrypted SSH private key) that decrypts the
+// archives.
 type AgeConfig struct {
 	Recipients     []string `env:"AGE_RECIPIENTS"      env-separator:","   yaml:"recipients"`
 	RecipientsFile string   `env:"AGE_RECIPIENTS_FILE" env-default:""      yaml:"recipientsFile"`
 	Armor          bool     `env:"AGE_ARMOR"           env-default:"false" yaml:"armor"`
+	IdentityFile   string   `env:"AGE_IDENTITY_FILE"   env-default:""      yaml:"identityFile"`
+}
+
+// IsEnabled reports whether at least one inline recipient or a recipients file is set.
+func (a AgeConfig) IsEnabled() bool {
+	return len(a.Recipients) > 0 || a.RecipientsFile != ""
+}
+
+// RetentionConfig holds the per-project archive retention policy.
+//
+// Each rule keeps the newest archive of the last N periods (runs, days, ISO
+// weeks, months); an archive matched by any rule is kept. All zero disables
+// pruning entirely.
+type RetentionConfig struct {
+	KeepLast    int `env:"RETENTION_KEEP_LAST"    env-default:"0" yaml:"keepLast"`
+	KeepDaily   int `env:"RETENTION_KEEP_DAILY"   env-default:"0" yaml:"keepDaily"`
+	KeepWeekly  int `env:"RETENTION_KEEP_WEEKLY"  env-default:"0" yaml:"keepWeekly"`
+	KeepMonthly int `env:"RETENTION_KEEP_MONTHLY" env-default:"0" yaml:"keepMonthly"`
+}
+
+// IsEnabled reports whether at least one retention rule is set.
+func (r RetentionConfig) IsEnabled() bool {
+	return r.KeepLast > 0 || r.KeepDaily > 0 || r.KeepWeekly > 0 || r.KeepMonthly > 0
 }
 
 // Config holds the application configuration.
@@ -51,16 +84,39 @@ type Config struct {
 	TmpDir             string      `env:"TMPDIR"             env-default:"/tmp"               yaml:"tmpdir"`
 	ExportTimeoutMins  int         `env:"EXPORT_TIMEOUT_MIN" env-default:"1440"               yaml:"exportTimeoutMins"`
 	ImportTimeoutMins  int         `env:"IMPORT_TIMEOUT_MIN" env-default:"60"                 yaml:"importTimeoutMins"`
+	MaxConcurrency     int         `env:"MAX_CONCURRENCY"    env-default:"4"                  yaml:"maxConcurrency"`
+	MaxTmpSizeMB       int64       `env:"MAX_TMP_SIZE_MB"    env-default:"0"                  yaml:"maxTmpSizeMB"`
+	StreamExports      bool        `env:"STREAM_EXPORTS"     env-default:"false"              yaml:"streamExports"`
+	ChecksumSidecars   bool        `env:"CHECKSUM_SIDECARS"  env-default:"false"              yaml:"checksumSidecars"`
+	StateFile          string      `env:"STATE_FILE"         env-default:""                   yaml:"stateFile"`
+	ArchiveKeyTemplate string      `env:"ARCHIVE_KEY_TEMPLATE" env-default:""                 yaml:"archiveKeyTemplate"`
+	ExportGroupArchive bool        `env:"EXPORT_GROUP_ARCHIVE" env-default:"false"            yaml:"exportGroupArchive"`
+	IncludeArchived    bool        `env:"INCLUDE_ARCHIVED"   env-default:"false"              yaml:"includeArchived"`
+	Targets            []TargetConfig `yaml:"targets"`
 	Hooks              hooks.Hooks `yaml:"hooks"`
 	S3cfg              S3Config    `yaml:"s3cfg"`
 	Age                AgeConfig   `yaml:"age"`
+	Retention          RetentionConfig `yaml:"retention"`
+	Filters            FiltersConfig   `yaml:"filters"`
+	Daemon             DaemonConfig    `yaml:"daemon"`
+	Restore            RestoreSettings `yaml:"restore"`
 	NoLogTime          bool        `env:"NOLOGTIME"          env-default:"false"              yaml:"noLogTime"`
+	// Backup run options (set via CLI flags, not config file)
+	FullBackup         bool   `yaml:"-"` // Ignore incremental state and export every project
+	Resume             bool   `yaml:"-"` // Skip projects completed by an interrupted group run
 	// Restore-specific fields (set via CLI flags, not config file)
-	RestoreSource      string `yaml:"-"` // Archive path (local or s3://)
-	RestoreTargetNS    string `yaml:"-"` // Target namespace/group
-	RestoreTargetPath  string `yaml:"-"` // Target project path
-	RestoreOverwrite   bool   `yaml:"-"` // Overwrite existing project content
-	StorageType        string `yaml:"-"` // Storage type: "local" or "s3"
+	RestoreSource           string `yaml:"-"` // Archive path (local or s3://)
+	RestoreGroupSource      string `yaml:"-"` // Group archive path (local or s3://), imported before the project
+	RestoreTargetNS         string `yaml:"-"` // Target namespace/group
+	RestoreTargetPath       string `yaml:"-"` // Target project path
+	RestoreOverwrite        bool   `yaml:"-"` // Replace the project at the target path, see OverwriteMode
+	RestoreOverwriteMode    string `yaml:"-"` // Overwrite mode: import (default), rename or delete
+	RestoreConfirmDelete    bool   `yaml:"-"` // Confirms the deletion of the replaced project (delete mode)
+	RestoreQuickValidation  bool   `yaml:"-"` // Only check the first header of the archives before upload
+	RestoreGroupVisibility  string `yaml:"-"` // Visibility of the groups created for a missing namespace
+	RestoreMappingFile      string `yaml:"-"` // Namespace mapping file giving the targets of a bulk restore
+	RestoreSkipVerification bool   `yaml:"-"` // Do not compare the restored project with the archive
+	StorageType             string `yaml:"-"` // Storage type: "local" or "s3"
 }
 
 // NewConfigFromFile returns a new Config struct from the given file.
@@ -114,14 +170,14 @@ func (c *Config) IsLocalConfigValid() bool {
 
 // IsConfigValid returns true if the config is valid.
 func (c *Config) IsConfigValid() bool {
-	valid := c.GitlabGroupID > 0 || c.GitlabProjectID > 0
+	valid := c.GitlabGroupID > 0 || c.GitlabProjectID > 0 || len(c.Targets) > 0
 	return (c.IsS3ConfigValid() || c.IsLocalConfigValid()) && valid && len(c.GitlabToken) > 0
 }
 
 // IsAgeEnabled reports whether age encryption is configured.
 // Age is enabled when at least one inline recipient or a recipients file is set.
 func (c *Config) IsAgeEnabled() bool {
-	return len(c.Age.Recipients) > 0 || c.Age.RecipientsFile != ""
+	return c.Age.IsEnabled()
 }
 
 func (c *Config) String() string {
@@ -152,18 +208,34 @@ func (c *Config) Redacted() string {
 }
 
 // Validate performs comprehensive validation of configuration parameters.
-// This validation is for backup operations which require gitlabGroupID or gitlabProjectID.
+// This validation is for backup operations which require gitlabGroupID,
+// gitlabProjectID or a targets list.
 func (c *Config) Validate() error {
 	// Validate basic configuration requirements
 	if err := c.validateBasicConfig(); err != nil {
 		return err
 	}
 
+	// Validate the targets list
+	if err := c.validateTargets(); err != nil {
+		return err
+	}
+
 	// Validate timeout range
 	if err := c.validateTimeout(); err != nil {
 		return err
 	}
 
+	// Validate concurrency limits
+	if err := c.validateConcurrency(); err != nil {
+		return err
+	}
+
+	// Validate streamed exports
+	if err := c.validateStreamExports(); err != nil {
+		return err
+	}
+
 	// Validate TmpDir
 	if err := c.validateTmpDir(); err != nil {
 		return err
@@ -179,11 +251,36 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	// Validate incremental state file location
+	if err := c.validateStateFile(); err != nil {
+		return err
+	}
+
+	// Validate archive key template
+	if err := c.validateArchiveKeyTemplate(); err != nil {
+		return err
+	}
+
 	// Validate age encryption configuration if enabled
 	if err := c.validateAgeConfig(); err != nil {
 		return err
 	}
 
+	// Validate retention policy
+	if err := c.validateRetention(); err != nil {
+		return err
+	}
+
+	// Validate project filters
+	if err := c.validateFilters(); err != nil {
+		return err
+	}
+
+	// Validate daemon schedules
+	if err := c.validateDaemon(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -193,15 +290,62 @@ func (c *Config) Validate() error {
 //
 //nolint:funcorder // grouped with Validate()
 func (c *Config) validateAgeConfig() error {
-	if c.Age.RecipientsFile == "" {
+	return validateAge(c.Age)
+}
+
+// validateAge checks the recipients file of an age configuration, see validateAgeConfig.
+func validateAge(age AgeConfig) error {
+	return validateAgeFile("age recipients file", age.RecipientsFile)
+}
+
+// validateAgeFile checks that the age file described by what, if set, is an
+// existing regular file.
+func validateAgeFile(what, path string) error {
+	if path == "" {
 		return nil
 	}
-	stat, err := os.Stat(c.Age.RecipientsFile)
+	stat, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("age recipients file %s: %w", c.Age.RecipientsFile, err)
+		return fmt.Errorf("%s %s: %w", what, path, err)
 	}
 	if stat.IsDir() {
-		return fmt.Errorf("age recipients file %s: %w", c.Age.RecipientsFile, errAgeRecipientsFileIsDir)
+		return fmt.Errorf("%s %s: %w", what, path, errAgeFileIsDir)
+	}
+	return nil
+}
+
+// validateStateFile checks that the incremental state file, if configured,
+// can be created: its directory must exist and the path must not be a directory.
+//
+//nolint:err113,funcorder // validation errors are dynamic for context; grouped with Validate()
+func (c *Config) validateStateFile() error {
+	if c.StateFile == "" {
+		return nil
+	}
+	if err := validatePath(c.StateFile, "state file"); err != nil {
+		return err
+	}
+	dir := filepath.Dir(c.StateFile)
+	stat, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("state file directory %s is not accessible: %w", dir, err)
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("state file directory %s is not a directory", dir)
+	}
+	if stat, err := os.Stat(c.StateFile); err == nil && stat.IsDir() {
+		return fmt.Errorf("state file %s is a directory", c.StateFile)
+	}
+	return nil
+}
+
+// validateArchiveKeyTemplate checks the archive key template syntax.
+// Empty selects archivekey.DefaultTemplate.
+//
+//nolint:funcorder // grouped with Validate()
+func (c *Config) validateArchiveKeyTemplate() error {
+	if _, err := archivekey.Parse(c.ArchiveKeyTemplate); err != nil {
+		return fmt.Errorf("archiveKeyTemplate: %w", err)
 	}
 	return nil
 }
@@ -237,15 +381,105 @@ func (c *Config) ValidateForRestore() error {
 	// For restore, storage validation is handled separately based on archive path
 	// (local vs S3), so we don't validate storage here
 
+	// Bulk restores import maxConcurrency projects at a time
+	if err := c.validateConcurrency(); err != nil {
+		return err
+	}
+
+	if err := c.validateRestoreOptions(); err != nil {
+		return err
+	}
+
+	// The identity file is parsed by the restore; fail fast if it is missing.
+	return validateAgeFile("age identity file", c.Age.IdentityFile)
+}
+
+// validateRestoreOptions checks the visibility of the groups created for a
+// missing namespace and the import overrides, and parses the namespace
+// mapping file, if set.
+//
+//nolint:err113,funcorder // validation errors provide user context; grouped with ValidateForRestore()
+func (c *Config) validateRestoreOptions() error {
+	switch c.RestoreGroupVisibility {
+	case "", "private", "internal", "public":
+	default:
+		return fmt.Errorf("invalid group visibility %q (want private, internal or public)", c.RestoreGroupVisibility)
+	}
+	if err := c.validateOverwriteMode(); err != nil {
+		return err
+	}
+	if err := c.Restore.Overrides.validate(); err != nil {
+		return err
+	}
+	if c.RestoreMappingFile == "" {
+		return nil
+	}
+	if _, err := nsmap.Load(c.RestoreMappingFile); err != nil {
+		return fmt.Errorf("invalid namespace mapping file: %w", err)
+	}
 	return nil
 }
 
+// ValidateForPrune validates configuration for the prune command.
+// Unlike Validate(), this requires neither GitLab credentials nor a group or
+// project ID, but a storage backend and at least one retention rule.
+//
+//nolint:err113 // validation errors provide user context
+func (c *Config) ValidateForPrune() error {
+	if !c.IsS3ConfigValid() && !c.IsLocalConfigValid() {
+		return errors.New(
+			"no storage configured: " +
+				"use --output for local storage or configure S3 in config file",
+		)
+	}
+
+	if err := c.validateStorageConfig(); err != nil {
+		return err
+	}
+
+	if err := c.validateRetention(); err != nil {
+		return err
+	}
+
+	if err := c.validateArchiveKeyTemplate(); err != nil {
+		return err
+	}
+
+	if !c.Retention.IsEnabled() {
+		return errors.New(
+			"no retention rule configured: " +
+				"set retention.keepLast/keepDaily/keepWeekly/keepMonthly or the --keep-* flags",
+		)
+	}
+
+	return nil
+}
+
+// ValidateForVerify validates configuration for the verify command, which
+// only needs a storage backend.
+//
+//nolint:err113 // validation errors provide user context
+func (c *Config) ValidateForVerify() error {
+	if !c.IsS3ConfigValid() && !c.IsLocalConfigValid() {
+		return errors.New(
+			"no storage configured: " +
+				"use --output for local storage or configure S3 in config file",
+		)
+	}
+	return c.validateStorageConfig()
+}
+
 //nolint:err113,funcorder // validation errors provide user context; grouped with Validate()
 func (c *Config) validateBasicConfig() error {
-	// Must have exactly one of group ID or project ID
-	if c.GitlabGroupID <= 0 && c.GitlabProjectID <= 0 {
+	// A targets list replaces gitlabGroupID/gitlabProjectID
+	if len(c.Targets) > 0 {
+		if c.GitlabGroupID > 0 || c.GitlabProjectID > 0 {
+			return errors.New("cannot specify targets together with gitlabGroupID or gitlabProjectID")
+		}
+	} else if c.GitlabGroupID <= 0 && c.GitlabProjectID <= 0 {
+		// Must have exactly one of group ID or project ID
 		return errors.New(
-			"either gitlabGroupID or gitlabProjectID must be set " +
+			"either gitlabGroupID or gitlabProjectID must be set, or a targets list " +
 				"(use --group-id or --project-id flag, config file, or environment variable)",
 		)
 	}
@@ -297,6 +531,58 @@ func (c *Config) validateTimeout() error {
 	return nil
 }
 
+// validateConcurrency checks the group export worker count and TmpDir budget.
+// Zero keeps the defaults (DefaultMaxConcurrency workers, unlimited TmpDir usage).
+//
+//nolint:err113,funcorder // validation errors are dynamic for context; grouped with Validate()
+func (c *Config) validateConcurrency() error {
+	if c.MaxConcurrency < 0 {
+		return fmt.Errorf("maxConcurrency must not be negative, got %d", c.MaxConcurrency)
+	}
+	if c.MaxConcurrency > constants.MaxConcurrencyLimit {
+		return fmt.Errorf(
+			"maxConcurrency must not exceed %d, got %d",
+			constants.MaxConcurrencyLimit, c.MaxConcurrency,
+		)
+	}
+	if c.MaxTmpSizeMB < 0 {
+		return fmt.Errorf("maxTmpSizeMB must not be negative, got %d", c.MaxTmpSizeMB)
+	}
+	return nil
+}
+
+// validateStreamExports rejects streamExports with a postbackup hook: the hook
+// is given the archive file, which streamed exports never write.
+//
+//nolint:err113,funcorder // validation errors are dynamic for context; grouped with Validate()
+func (c *Config) validateStreamExports() error {
+	if c.StreamExports && c.Hooks.HasPostBackup() {
+		return errors.New("streamExports cannot be used with a postbackup hook, which needs the archive file")
+	}
+	return nil
+}
+
+// validateRetention rejects negative retention counts. Zero disables a rule.
+//
+//nolint:err113,funcorder // validation errors are dynamic for context; grouped with Validate()
+func (c *Config) validateRetention() error {
+	rules := []struct {
+		name  string
+		value int
+	}{
+		{"retention.keepLast", c.Retention.KeepLast},
+		{"retention.keepDaily", c.Retention.KeepDaily},
+		{"retention.keepWeekly", c.Retention.KeepWeekly},
+		{"retention.keepMonthly", c.Retention.KeepMonthly},
+	}
+	for _, r := range rules {
+		if r.value < 0 {
+			return fmt.Errorf("%s must not be negative, got %d", r.name, r.value)
+		}
+	}
+	return nil
+}
+
 //nolint:err113,funcorder // validation errors are dynamic for context; grouped with Validate()
 func (c *Config) validateTmpDir() error {
 	// Check if directory exists
@@ -380,6 +666,22 @@ func (c *Config) validateS3Config() error {
 		return err
 	}
 
+	return validateS3Upload(c.S3cfg)
+}
+
+// validateS3Upload checks the multipart upload settings against the S3 limits.
+//
+//nolint:err113 // validation errors are intentionally dynamic to include context
+func validateS3Upload(s3cfg S3Config) error {
+	if s3cfg.PartSizeMB != 0 &&
+		(s3cfg.PartSizeMB < constants.S3MinPartSizeMB || s3cfg.PartSizeMB > constants.S3MaxPartSizeMB) {
+		return fmt.Errorf("s3cfg.partSizeMB must be between %d and %d, got %d",
+			constants.S3MinPartSizeMB, constants.S3MaxPartSizeMB, s3cfg.PartSizeMB)
+	}
+	if s3cfg.UploadConcurrency < 0 || s3cfg.UploadConcurrency > constants.S3UploadConcurrencyLimit {
+		return fmt.Errorf("s3cfg.uploadConcurrency must be between 0 and %d, got %d",
+			constants.S3UploadConcurrencyLimit, s3cfg.UploadConcurrency)
+	}
 	return nil
 }
 
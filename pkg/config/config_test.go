@@ -4,7 +4,11 @@ import (
 	"os"
 	"testing"
 
+	"github.com/sgaunet/gitlab-backup/pkg/archivekey"
 	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"github.com/sgaunet/gitlab-backup/pkg/cron"
+	"github.com/sgaunet/gitlab-backup/pkg/filter"
 	"github.com/sgaunet/gitlab-backup/pkg/hooks"
 	"github.com/stretchr/testify/require"
 )
@@ -61,6 +65,21 @@ func TestNewConfigFromEnv(t *testing.T) {
 		t.Setenv("NOLOGTIME", "true")
 		t.Setenv("AGE_RECIPIENTS", "age1qqqq,age1rrrr")
 		t.Setenv("AGE_ARMOR", "true")
+		t.Setenv("AGE_IDENTITY_FILE", "/etc/gitlab-backup/age-identity.txt")
+		t.Setenv("MAX_CONCURRENCY", "8")
+		t.Setenv("MAX_TMP_SIZE_MB", "2048")
+		t.Setenv("STREAM_EXPORTS", "true")
+		t.Setenv("CHECKSUM_SIDECARS", "true")
+		t.Setenv("S3_PART_SIZE_MB", "64")
+		t.Setenv("S3_UPLOAD_CONCURRENCY", "8")
+		t.Setenv("STATE_FILE", "/var/lib/gitlab-backup/state.json")
+		t.Setenv("ARCHIVE_KEY_TEMPLATE", "{namespace}/{path}/{date}/{path}-{id}.tar.gz")
+		t.Setenv("EXPORT_GROUP_ARCHIVE", "true")
+		t.Setenv("INCLUDE_ARCHIVED", "true")
+		t.Setenv("RETENTION_KEEP_LAST", "3")
+		t.Setenv("RETENTION_KEEP_DAILY", "7")
+		t.Setenv("RETENTION_KEEP_WEEKLY", "4")
+		t.Setenv("RETENTION_KEEP_MONTHLY", "12")
 
 		cfg, err := config.NewConfigFromEnv()
 		require.NoError(t, err)
@@ -77,9 +96,21 @@ func TestNewConfigFromEnv(t *testing.T) {
 		require.Equal(t, "myregion", cfg.S3cfg.Region)
 		require.Equal(t, "myaccesskey", cfg.S3cfg.AccessKey)
 		require.Equal(t, "mysecretkey", cfg.S3cfg.SecretKey)
+		require.Equal(t, 64, cfg.S3cfg.PartSizeMB)
+		require.Equal(t, 8, cfg.S3cfg.UploadConcurrency)
 		require.Equal(t, true, cfg.NoLogTime)
+		require.Equal(t, 8, cfg.MaxConcurrency)
+		require.Equal(t, int64(2048), cfg.MaxTmpSizeMB)
+		require.True(t, cfg.StreamExports)
+		require.True(t, cfg.ChecksumSidecars)
+		require.Equal(t, "/var/lib/gitlab-backup/state.json", cfg.StateFile)
+		require.Equal(t, "{namespace}/{path}/{date}/{path}-{id}.tar.gz", cfg.ArchiveKeyTemplate)
+		require.True(t, cfg.ExportGroupArchive)
+		require.True(t, cfg.IncludeArchived)
+		require.Equal(t, config.RetentionConfig{KeepLast: 3, KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 12}, cfg.Retention)
 		require.Equal(t, []string{"age1qqqq", "age1rrrr"}, cfg.Age.Recipients)
 		require.True(t, cfg.Age.Armor)
+		require.Equal(t, "/etc/gitlab-backup/age-identity.txt", cfg.Age.IdentityFile)
 	})
 }
 
@@ -486,6 +517,50 @@ func TestConfigValidate_S3Storage(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestConfigValidate_S3Upload(t *testing.T) {
+	tests := []struct {
+		name        string
+		partSizeMB  int
+		concurrency int
+		wantErr     string
+	}{
+		{name: "defaults", partSizeMB: 0, concurrency: 0},
+		{name: "explicit values", partSizeMB: 64, concurrency: 8},
+		{name: "S3 limits", partSizeMB: constants.S3MaxPartSizeMB, concurrency: constants.S3UploadConcurrencyLimit},
+		{name: "part too small", partSizeMB: 4, wantErr: "s3cfg.partSizeMB must be between 5 and 5120"},
+		{name: "part too large", partSizeMB: 6000, wantErr: "s3cfg.partSizeMB must be between 5 and 5120"},
+		{name: "negative concurrency", concurrency: -1, wantErr: "s3cfg.uploadConcurrency must be between 0 and 32"},
+		{name: "concurrency too high", concurrency: 33, wantErr: "s3cfg.uploadConcurrency must be between 0 and 32"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				GitlabGroupID:     123,
+				GitlabToken:       "test-token",
+				GitlabURI:         "https://gitlab.example.com",
+				TmpDir:            "/tmp",
+				ExportTimeoutMins: 30,
+				ImportTimeoutMins: 60,
+				S3cfg: config.S3Config{
+					BucketName:        "my-backup-bucket",
+					BucketPath:        "backups",
+					Region:            "us-east-1",
+					PartSizeMB:        tt.partSizeMB,
+					UploadConcurrency: tt.concurrency,
+				},
+			}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
 func TestConfigValidate_MissingGroupAndProject(t *testing.T) {
 	cfg := &config.Config{
 		GitlabToken:       "test-token",
@@ -595,6 +670,274 @@ func TestConfigValidate_ImportTimeoutTooHigh(t *testing.T) {
 	require.Contains(t, err.Error(), "importTimeoutMins must not exceed 1440 minutes")
 }
 
+func TestConfigValidate_Concurrency(t *testing.T) {
+	newCfg := func() *config.Config {
+		return &config.Config{
+			GitlabGroupID:     123,
+			GitlabToken:       "test-token",
+			GitlabURI:         "https://gitlab.com",
+			LocalPath:         "/tmp",
+			TmpDir:            "/tmp",
+			ExportTimeoutMins: 10,
+			ImportTimeoutMins: 60,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		concurrency int
+		tmpSizeMB   int64
+		wantErr     string
+	}{
+		{name: "defaults", concurrency: 0, tmpSizeMB: 0},
+		{name: "explicit values", concurrency: 8, tmpSizeMB: 2048},
+		{name: "upper limit", concurrency: constants.MaxConcurrencyLimit},
+		{name: "negative concurrency", concurrency: -1, wantErr: "maxConcurrency must not be negative"},
+		{name: "concurrency too high", concurrency: constants.MaxConcurrencyLimit + 1, wantErr: "maxConcurrency must not exceed"},
+		{name: "negative tmp size", tmpSizeMB: -5, wantErr: "maxTmpSizeMB must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCfg()
+			cfg.MaxConcurrency = tt.concurrency
+			cfg.MaxTmpSizeMB = tt.tmpSizeMB
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestConfigValidate_StreamExports(t *testing.T) {
+	cfg := &config.Config{
+		GitlabGroupID:     123,
+		GitlabToken:       "test-token",
+		GitlabURI:         "https://gitlab.com",
+		LocalPath:         "/tmp",
+		TmpDir:            "/tmp",
+		ExportTimeoutMins: 10,
+		ImportTimeoutMins: 60,
+		StreamExports:     true,
+	}
+	cfg.Hooks.PreBackup = "echo start"
+	require.NoError(t, cfg.Validate())
+
+	cfg.Hooks.PostBackup = "scan %INPUTFILE%"
+	err := cfg.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "streamExports cannot be used with a postbackup hook")
+}
+
+func TestConfigValidate_StateFile(t *testing.T) {
+	newCfg := func(stateFile string) *config.Config {
+		return &config.Config{
+			GitlabGroupID:     123,
+			GitlabToken:       "test-token",
+			GitlabURI:         "https://gitlab.com",
+			LocalPath:         "/tmp",
+			TmpDir:            "/tmp",
+			ExportTimeoutMins: 10,
+			ImportTimeoutMins: 60,
+			StateFile:         stateFile,
+		}
+	}
+
+	t.Run("new file in existing directory", func(t *testing.T) {
+		require.NoError(t, newCfg(t.TempDir()+"/state.json").Validate())
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		err := newCfg(t.TempDir() + "/missing/state.json").Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "state file directory")
+	})
+
+	t.Run("path is a directory", func(t *testing.T) {
+		err := newCfg(t.TempDir()).Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is a directory")
+	})
+}
+
+func TestConfigValidate_Retention(t *testing.T) {
+	newCfg := func(r config.RetentionConfig) *config.Config {
+		return &config.Config{
+			GitlabGroupID:     123,
+			GitlabToken:       "test-token",
+			GitlabURI:         "https://gitlab.com",
+			LocalPath:         "/tmp",
+			TmpDir:            "/tmp",
+			ExportTimeoutMins: 10,
+			ImportTimeoutMins: 60,
+			Retention:         r,
+		}
+	}
+
+	require.NoError(t, newCfg(config.RetentionConfig{}).Validate())
+	require.NoError(t, newCfg(config.RetentionConfig{KeepLast: 3, KeepMonthly: 6}).Validate())
+
+	err := newCfg(config.RetentionConfig{KeepWeekly: -1}).Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "retention.keepWeekly must not be negative")
+}
+
+func TestConfigValidate_ArchiveKeyTemplate(t *testing.T) {
+	newCfg := func(tmpl string) *config.Config {
+		return &config.Config{
+			GitlabGroupID:      123,
+			GitlabToken:        "test-token",
+			GitlabURI:          "https://gitlab.com",
+			LocalPath:          "/tmp",
+			TmpDir:             "/tmp",
+			ExportTimeoutMins:  10,
+			ImportTimeoutMins:  60,
+			ArchiveKeyTemplate: tmpl,
+		}
+	}
+
+	require.NoError(t, newCfg("").Validate())
+	require.NoError(t, newCfg("{namespace}/{path}/{year}/{month}/{day}/{path}-{id}-{time}.tar.gz").Validate())
+
+	err := newCfg("{namespace}/{path}.tar.gz").Validate()
+	require.ErrorIs(t, err, archivekey.ErrInvalidTemplate)
+	require.Contains(t, err.Error(), "archiveKeyTemplate")
+
+	prune := &config.Config{
+		LocalPath:          "/tmp",
+		Retention:          config.RetentionConfig{KeepLast: 1},
+		ArchiveKeyTemplate: "{path}-{id}-{branch}.tar.gz",
+	}
+	require.ErrorIs(t, prune.ValidateForPrune(), archivekey.ErrInvalidTemplate)
+}
+
+func TestConfigValidate_Filters(t *testing.T) {
+	newCfg := func(f config.FiltersConfig) *config.Config {
+		return &config.Config{
+			GitlabGroupID:     123,
+			GitlabToken:       "test-token",
+			GitlabURI:         "https://gitlab.com",
+			LocalPath:         "/tmp",
+			TmpDir:            "/tmp",
+			ExportTimeoutMins: 10,
+			ImportTimeoutMins: 60,
+			Filters:           f,
+		}
+	}
+
+	require.NoError(t, newCfg(config.FiltersConfig{}).Validate())
+	require.NoError(t, newCfg(config.FiltersConfig{
+		IncludePaths: []string{"grp/**"},
+		ExcludePaths: []string{"regex:-(tmp|sandbox)$"},
+		Visibility:   []string{"private"},
+		MaxSizeMB:    1024,
+	}).Validate())
+
+	err := newCfg(config.FiltersConfig{ExcludePaths: []string{"regex:("}}).Validate()
+	require.ErrorIs(t, err, filter.ErrInvalidRule)
+	require.Contains(t, err.Error(), "filters")
+
+	err = newCfg(config.FiltersConfig{Visibility: []string{"hidden"}}).Validate()
+	require.ErrorIs(t, err, filter.ErrInvalidRule)
+}
+
+func TestConfigValidate_Daemon(t *testing.T) {
+	newCfg := func(d config.DaemonConfig, targets ...config.TargetConfig) *config.Config {
+		c := &config.Config{
+			GitlabToken:       "test-token",
+			GitlabURI:         "https://gitlab.com",
+			LocalPath:         "/tmp",
+			TmpDir:            "/tmp",
+			ExportTimeoutMins: 10,
+			ImportTimeoutMins: 60,
+			Targets:           targets,
+			Daemon:            d,
+		}
+		if len(targets) == 0 {
+			c.GitlabGroupID = 123
+		}
+		return c
+	}
+
+	require.NoError(t, newCfg(config.DaemonConfig{}).Validate())
+	require.NoError(t, newCfg(config.DaemonConfig{Schedule: "@daily", JitterSecs: 60}).ValidateForDaemon())
+
+	err := newCfg(config.DaemonConfig{Schedule: "0 25 * * *"}).Validate()
+	require.ErrorIs(t, err, cron.ErrInvalidExpression)
+	require.Contains(t, err.Error(), "daemon.schedule")
+
+	err = newCfg(config.DaemonConfig{Schedule: "0 0 31 2 *"}).Validate()
+	require.ErrorContains(t, err, "never fires")
+
+	err = newCfg(config.DaemonConfig{JitterSecs: -1}).Validate()
+	require.ErrorContains(t, err, "jitterSecs")
+
+	err = newCfg(config.DaemonConfig{}, config.TargetConfig{Group: 1, Schedule: "every day"}).Validate()
+	require.ErrorIs(t, err, cron.ErrInvalidExpression)
+	require.Contains(t, err.Error(), "targets[0]")
+
+	// One-shot runs need no schedule, the daemon does.
+	err = newCfg(config.DaemonConfig{}).ValidateForDaemon()
+	require.ErrorContains(t, err, "daemon.schedule is required")
+
+	err = newCfg(config.DaemonConfig{},
+		config.TargetConfig{Group: 1, Schedule: "@hourly"}, config.TargetConfig{Project: 2}).ValidateForDaemon()
+	require.ErrorContains(t, err, "targets[1]: project 2 has no schedule")
+
+	require.NoError(t, newCfg(config.DaemonConfig{Schedule: "@daily"},
+		config.TargetConfig{Group: 1, Schedule: "@hourly"}, config.TargetConfig{Project: 2}).ValidateForDaemon())
+}
+
+func TestRetentionConfig_IsEnabled(t *testing.T) {
+	require.False(t, config.RetentionConfig{}.IsEnabled())
+	require.True(t, config.RetentionConfig{KeepLast: 1}.IsEnabled())
+	require.True(t, config.RetentionConfig{KeepDaily: 1}.IsEnabled())
+	require.True(t, config.RetentionConfig{KeepWeekly: 1}.IsEnabled())
+	require.True(t, config.RetentionConfig{KeepMonthly: 1}.IsEnabled())
+}
+
+func TestConfigValidateForPrune(t *testing.T) {
+	t.Run("no GitLab settings required", func(t *testing.T) {
+		c := &config.Config{LocalPath: "/tmp", Retention: config.RetentionConfig{KeepLast: 5}}
+		require.NoError(t, c.ValidateForPrune())
+	})
+
+	t.Run("storage required", func(t *testing.T) {
+		c := &config.Config{Retention: config.RetentionConfig{KeepLast: 5}}
+		err := c.ValidateForPrune()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no storage configured")
+	})
+
+	t.Run("retention rule required", func(t *testing.T) {
+		c := &config.Config{LocalPath: "/tmp"}
+		err := c.ValidateForPrune()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no retention rule configured")
+	})
+
+	t.Run("negative rule rejected", func(t *testing.T) {
+		c := &config.Config{LocalPath: "/tmp", Retention: config.RetentionConfig{KeepLast: 5, KeepDaily: -2}}
+		err := c.ValidateForPrune()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "retention.keepDaily must not be negative")
+	})
+}
+
+func TestConfigValidateForVerify(t *testing.T) {
+	require.NoError(t, (&config.Config{LocalPath: "/tmp"}).ValidateForVerify(),
+		"neither GitLab settings nor retention rules are required")
+
+	err := (&config.Config{}).ValidateForVerify()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no storage configured")
+}
+
 func TestConfigValidate_TmpDirNotExists(t *testing.T) {
 	cfg := &config.Config{
 		GitlabGroupID:     123,
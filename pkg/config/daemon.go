@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/cron"
+)
+
+// DaemonConfig holds the settings of the daemon command, which keeps running
+// and backs up each target on its cron schedule.
+type DaemonConfig struct {
+	// Schedule is the cron expression of targets without their own schedule,
+	// and of the gitlabGroupID/gitlabProjectID configuration.
+	Schedule string `env:"DAEMON_SCHEDULE" env-default:"" yaml:"schedule"`
+	// JitterSecs delays every run by a random duration of up to N seconds,
+	// so that targets sharing a schedule do not hit GitLab at the same instant.
+	JitterSecs int `env:"DAEMON_JITTER_SECS" env-default:"0" yaml:"jitterSecs"`
+	// StatusAddr is the listen address of the HTTP status endpoint
+	// (e.g. ":8080"). Empty disables it.
+	StatusAddr string `env:"DAEMON_STATUS_ADDR" env-default:"" yaml:"statusAddr"`
+}
+
+// TargetSchedule returns the cron expression of t: its own schedule, or the
+// daemon default.
+func (c *Config) TargetSchedule(t *TargetConfig) string {
+	if t.Schedule != "" {
+		return t.Schedule
+	}
+	return c.Daemon.Schedule
+}
+
+// ValidateForDaemon validates configuration for the daemon command: the
+// backup configuration must be valid and every target must have a schedule.
+//
+//nolint:err113 // validation errors provide user context
+func (c *Config) ValidateForDaemon() error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if len(c.Targets) == 0 && c.Daemon.Schedule == "" {
+		return errors.New("daemon.schedule is required (or a schedule on every entry of a targets list)")
+	}
+	for i := range c.Targets {
+		if c.TargetSchedule(&c.Targets[i]) == "" {
+			return fmt.Errorf("targets[%d]: %s has no schedule and daemon.schedule is not set", i, &c.Targets[i])
+		}
+	}
+	return nil
+}
+
+// validateDaemon checks the cron expressions and the jitter, so that a bad
+// schedule also fails a one-shot run of the same file.
+//
+//nolint:err113,funcorder // validation errors provide user context; grouped with Validate()
+func (c *Config) validateDaemon() error {
+	if c.Daemon.JitterSecs < 0 {
+		return fmt.Errorf("daemon.jitterSecs must not be negative, got %d", c.Daemon.JitterSecs)
+	}
+	if err := validateSchedule(c.Daemon.Schedule, "daemon.schedule"); err != nil {
+		return err
+	}
+	for i := range c.Targets {
+		if err := validateSchedule(c.Targets[i].Schedule, fmt.Sprintf("targets[%d]: schedule", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSchedule parses a cron expression and checks that it fires at all.
+// Empty is valid.
+//
+//nolint:err113 // validation errors provide user context
+func validateSchedule(expr, field string) error {
+	if expr == "" {
+		return nil
+	}
+	s, err := cron.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	if s.Next(time.Now()).IsZero() {
+		return fmt.Errorf("%s: %q never fires", field, expr)
+	}
+	return nil
+}
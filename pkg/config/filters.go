@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/sgaunet/gitlab-backup/pkg/filter"
+)
+
+// FiltersConfig selects the projects of group and user targets that are
+// backed up. Project targets are always backed up. Every rule is optional; a
+// project left out by any rule is reported as filtered in the run summary.
+//
+// Path patterns are globs on the full project path ("*" within a segment,
+// "**" across segments) or, with a "regex:" prefix, regular expressions.
+type FiltersConfig struct {
+	IncludePaths    []string `yaml:"includePaths"`
+	ExcludePaths    []string `yaml:"excludePaths"`
+	IncludeTopics   []string `yaml:"includeTopics"`
+	ExcludeTopics   []string `yaml:"excludeTopics"`
+	Visibility      []string `yaml:"visibility"` // private, internal and/or public
+	ExcludeForks    bool     `yaml:"excludeForks"`
+	ExcludeMirrors  bool     `yaml:"excludeMirrors"`
+	MaxInactiveDays int      `yaml:"maxInactiveDays"` // no activity for more than N days
+	MaxSizeMB       int64    `yaml:"maxSizeMB"`       // estimated export size
+}
+
+// Rules converts the filter settings for the filter package.
+func (f FiltersConfig) Rules() filter.Rules {
+	return filter.Rules{
+		IncludePaths:    f.IncludePaths,
+		ExcludePaths:    f.ExcludePaths,
+		IncludeTopics:   f.IncludeTopics,
+		ExcludeTopics:   f.ExcludeTopics,
+		Visibility:      f.Visibility,
+		ExcludeForks:    f.ExcludeForks,
+		ExcludeMirrors:  f.ExcludeMirrors,
+		MaxInactiveDays: f.MaxInactiveDays,
+		MaxSizeMB:       f.MaxSizeMB,
+	}
+}
+
+// validateFilters compiles the filter rules so that a bad pattern fails at startup.
+//
+//nolint:funcorder // grouped with Validate()
+func (c *Config) validateFilters() error {
+	if _, err := filter.New(c.Filters.Rules()); err != nil {
+		return fmt.Errorf("filters: %w", err)
+	}
+	return nil
+}
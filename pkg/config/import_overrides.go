@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownOverride is returned when an import override names no setting.
+var ErrUnknownOverride = errors.New("unknown import override")
+
+// RestoreSettings holds the restore settings of the configuration file.
+type RestoreSettings struct {
+	// Overrides replace the project settings recorded by the archives.
+	Overrides ImportOverrides `yaml:"overrides"`
+}
+
+// ImportOverrides replace the settings of the restored projects, which
+// otherwise keep those recorded by the archive. They are passed to GitLab as
+// the override_params of every project import; an empty field keeps the
+// archive setting.
+//
+// Access levels are disabled, private (project members only) or enabled.
+type ImportOverrides struct {
+	Visibility                   string `yaml:"visibility"` // private, internal or public
+	Description                  string `yaml:"description"`
+	DefaultBranch                string `yaml:"defaultBranch"`
+	IssuesAccessLevel            string `yaml:"issuesAccessLevel"`
+	WikiAccessLevel              string `yaml:"wikiAccessLevel"`
+	BuildsAccessLevel            string `yaml:"buildsAccessLevel"` // CI/CD pipelines and jobs
+	MergeRequestsAccessLevel     string `yaml:"mergeRequestsAccessLevel"`
+	SnippetsAccessLevel          string `yaml:"snippetsAccessLevel"`
+	ContainerRegistryAccessLevel string `yaml:"containerRegistryAccessLevel"`
+	LFSEnabled                   *bool  `yaml:"lfsEnabled"`
+	PackagesEnabled              *bool  `yaml:"packagesEnabled"`
+}
+
+// IsZero reports whether no override is set.
+func (o *ImportOverrides) IsZero() bool {
+	return *o == ImportOverrides{}
+}
+
+// Set sets the override named as in the configuration file (e.g.
+// "wikiAccessLevel") to value, for the --override flag of gitlab-restore.
+func (o *ImportOverrides) Set(name, value string) error {
+	if p := o.stringField(name); p != nil {
+		*p = value
+		return nil
+	}
+	var p **bool
+	switch name {
+	case "lfsEnabled":
+		p = &o.LFSEnabled
+	case "packagesEnabled":
+		p = &o.PackagesEnabled
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownOverride, name)
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("import override %s: %w", name, err)
+	}
+	*p = &b
+	return nil
+}
+
+// stringField returns the string override named name, nil if there is none.
+func (o *ImportOverrides) stringField(name string) *string {
+	return map[string]*string{
+		"visibility":                   &o.Visibility,
+		"description":                  &o.Description,
+		"defaultBranch":                &o.DefaultBranch,
+		"issuesAccessLevel":            &o.IssuesAccessLevel,
+		"wikiAccessLevel":              &o.WikiAccessLevel,
+		"buildsAccessLevel":            &o.BuildsAccessLevel,
+		"mergeRequestsAccessLevel":     &o.MergeRequestsAccessLevel,
+		"snippetsAccessLevel":          &o.SnippetsAccessLevel,
+		"containerRegistryAccessLevel": &o.ContainerRegistryAccessLevel,
+	}[name]
+}
+
+// validate checks the visibility and the access levels.
+//
+//nolint:err113 // validation errors provide user context
+func (o *ImportOverrides) validate() error {
+	switch o.Visibility {
+	case "", "private", "internal", "public":
+	default:
+		return fmt.Errorf("restore.overrides.visibility: invalid value %q (want private, internal or public)",
+			o.Visibility)
+	}
+	levels := map[string]string{
+		"issuesAccessLevel":            o.IssuesAccessLevel,
+		"wikiAccessLevel":              o.WikiAccessLevel,
+		"buildsAccessLevel":            o.BuildsAccessLevel,
+		"mergeRequestsAccessLevel":     o.MergeRequestsAccessLevel,
+		"snippetsAccessLevel":          o.SnippetsAccessLevel,
+		"containerRegistryAccessLevel": o.ContainerRegistryAccessLevel,
+	}
+	valid := []string{"", "disabled", "private", "enabled"}
+	for _, name := range slices.Sorted(maps.Keys(levels)) {
+		if !slices.Contains(valid, levels[name]) {
+			return fmt.Errorf("restore.overrides.%s: invalid value %q (want %s)", name, levels[name],
+				strings.Join(valid[1:], ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestImportOverrides_YAML(t *testing.T) {
+	var cfg config.Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+restore:
+  overrides:
+    visibility: private
+    defaultBranch: main
+    buildsAccessLevel: disabled
+    lfsEnabled: false
+`), &cfg))
+
+	lfs := false
+	assert.Equal(t, config.ImportOverrides{
+		Visibility:        "private",
+		DefaultBranch:     "main",
+		BuildsAccessLevel: "disabled",
+		LFSEnabled:        &lfs,
+	}, cfg.Restore.Overrides)
+}
+
+func TestImportOverrides_Set(t *testing.T) {
+	var o config.ImportOverrides
+	assert.True(t, o.IsZero())
+
+	require.NoError(t, o.Set("wikiAccessLevel", "private"))
+	require.NoError(t, o.Set("description", "Restored from gitlab.com"))
+	require.NoError(t, o.Set("packagesEnabled", "true"))
+	assert.False(t, o.IsZero())
+	assert.Equal(t, "private", o.WikiAccessLevel)
+	assert.Equal(t, "Restored from gitlab.com", o.Description)
+	require.NotNil(t, o.PackagesEnabled)
+	assert.True(t, *o.PackagesEnabled)
+
+	require.ErrorIs(t, o.Set("wiki", "disabled"), config.ErrUnknownOverride)
+	require.ErrorContains(t, o.Set("lfsEnabled", "maybe"), "import override lfsEnabled")
+	assert.Nil(t, o.LFSEnabled)
+}
@@ -154,3 +154,47 @@ func (c *RestoreConfig) validateRestoreTarget() error {
 
 	return nil
 }
+
+// Overwrite modes of a restore whose target path holds a project, see
+// Config.OverwriteMode.
+const (
+	// OverwriteImport replaces the project with GitLab's overwrite import parameter.
+	OverwriteImport = "import"
+	// OverwriteRename renames the project to <path>-pre-restore-<timestamp>
+	// and keeps it as a safety copy.
+	OverwriteRename = "rename"
+	// OverwriteDelete renames the project as OverwriteRename, then deletes it.
+	OverwriteDelete = "delete"
+)
+
+// OverwriteMode returns how a restore replaces the project at its target
+// path: one of the Overwrite* modes (OverwriteImport when RestoreOverwriteMode
+// is empty), or an empty string when RestoreOverwrite is not set and the
+// project must be empty instead.
+func (c *Config) OverwriteMode() string {
+	if !c.RestoreOverwrite {
+		return ""
+	}
+	if c.RestoreOverwriteMode == "" {
+		return OverwriteImport
+	}
+	return c.RestoreOverwriteMode
+}
+
+// validateOverwriteMode checks the overwrite mode; the delete mode must be
+// confirmed.
+//
+//nolint:err113 // validation errors provide user context
+func (c *Config) validateOverwriteMode() error {
+	switch c.RestoreOverwriteMode {
+	case "", OverwriteImport, OverwriteRename:
+	case OverwriteDelete:
+		if !c.RestoreConfirmDelete {
+			return errors.New("the delete overwrite mode deletes the existing project: confirm it with --confirm-delete")
+		}
+	default:
+		return fmt.Errorf("invalid overwrite mode %q (want %s, %s or %s)", c.RestoreOverwriteMode,
+			OverwriteImport, OverwriteRename, OverwriteDelete)
+	}
+	return nil
+}
@@ -1,10 +1,13 @@
 package config_test
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/nsmap"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -252,4 +255,73 @@ func TestConfig_ValidateForRestore(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "exportTimeoutMins")
 	})
+
+	t.Run("age identity file", func(t *testing.T) {
+		c := baseValid(t)
+		c.Age.IdentityFile = filepath.Join(t.TempDir(), "missing.txt")
+		err := c.ValidateForRestore()
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.Contains(t, err.Error(), "age identity file")
+
+		c.Age.IdentityFile = t.TempDir()
+		require.ErrorContains(t, c.ValidateForRestore(), "is a directory")
+
+		c.Age.IdentityFile = filepath.Join(t.TempDir(), "key.txt")
+		require.NoError(t, os.WriteFile(c.Age.IdentityFile, []byte("AGE-SECRET-KEY-1\n"), 0o600))
+		require.NoError(t, c.ValidateForRestore())
+	})
+
+	t.Run("group visibility", func(t *testing.T) {
+		c := baseValid(t)
+		c.RestoreGroupVisibility = "internal"
+		require.NoError(t, c.ValidateForRestore())
+
+		c.RestoreGroupVisibility = "secret"
+		require.ErrorContains(t, c.ValidateForRestore(), "invalid group visibility")
+	})
+
+	t.Run("overwrite mode", func(t *testing.T) {
+		c := baseValid(t)
+		assert.Empty(t, c.OverwriteMode(), "the target project must be empty without --overwrite")
+
+		c.RestoreOverwrite = true
+		require.NoError(t, c.ValidateForRestore())
+		assert.Equal(t, config.OverwriteImport, c.OverwriteMode())
+
+		c.RestoreOverwriteMode = config.OverwriteRename
+		require.NoError(t, c.ValidateForRestore())
+		assert.Equal(t, config.OverwriteRename, c.OverwriteMode())
+
+		c.RestoreOverwriteMode = config.OverwriteDelete
+		require.ErrorContains(t, c.ValidateForRestore(), "--confirm-delete")
+		c.RestoreConfirmDelete = true
+		require.NoError(t, c.ValidateForRestore())
+
+		c.RestoreOverwriteMode = "replace"
+		require.ErrorContains(t, c.ValidateForRestore(), `invalid overwrite mode "replace"`)
+	})
+
+	t.Run("import overrides", func(t *testing.T) {
+		c := baseValid(t)
+		c.Restore.Overrides = config.ImportOverrides{Visibility: "private", IssuesAccessLevel: "enabled"}
+		require.NoError(t, c.ValidateForRestore())
+
+		c.Restore.Overrides.Visibility = "secret"
+		require.ErrorContains(t, c.ValidateForRestore(), "restore.overrides.visibility")
+
+		c.Restore.Overrides = config.ImportOverrides{WikiAccessLevel: "public"}
+		require.ErrorContains(t, c.ValidateForRestore(), `restore.overrides.wikiAccessLevel: invalid value "public"`)
+	})
+
+	t.Run("namespace mapping file", func(t *testing.T) {
+		c := baseValid(t)
+		c.RestoreMappingFile = filepath.Join(t.TempDir(), "mapping.yaml")
+		require.ErrorContains(t, c.ValidateForRestore(), "invalid namespace mapping file")
+
+		require.NoError(t, os.WriteFile(c.RestoreMappingFile, []byte("rules:\n  - prefix: acme\n    target: eng\n"), 0o600))
+		require.NoError(t, c.ValidateForRestore())
+
+		require.NoError(t, os.WriteFile(c.RestoreMappingFile, []byte("rules:\n  - prefix: acme\n"), 0o600))
+		require.ErrorIs(t, c.ValidateForRestore(), nsmap.ErrInvalidMapping)
+	})
 }
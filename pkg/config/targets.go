@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+)
+
+// TargetConfig is one entry of the targets list. Exactly one of Group,
+// Project, User or Instance selects what is backed up; the other fields
+// override the global settings for this target only.
+type TargetConfig struct {
+	Group   int64  `yaml:"group"`   // group ID, subgroups included
+	Project int64  `yaml:"project"` // project ID
+	User    string `yaml:"user"`    // username whose personal namespace is backed up
+	// Instance backs up every project visible to the token (GET /projects).
+	Instance *InstanceTarget `yaml:"instance"`
+	// StoragePrefix is prepended to the archive keys of the target.
+	StoragePrefix string `yaml:"storagePrefix"`
+	// ExportTimeoutMins overrides exportTimeoutMins; zero keeps the global value.
+	ExportTimeoutMins int `yaml:"exportTimeoutMins"`
+	// IncludeArchived overrides includeArchived; nil keeps the global value.
+	IncludeArchived *bool `yaml:"includeArchived"`
+	// Age overrides the global age settings. An empty block disables
+	// encryption for the target.
+	Age *AgeConfig `yaml:"age"`
+	// Schedule is the cron expression of the target in daemon mode; empty
+	// keeps daemon.schedule. One-shot runs ignore it.
+	Schedule string `yaml:"schedule"`
+}
+
+// InstanceTarget narrows an instance target. The zero value selects every
+// project visible to the token, personal namespaces included.
+type InstanceTarget struct {
+	Owned      bool `yaml:"owned"`      // only projects owned by the token user
+	Membership bool `yaml:"membership"` // only projects the token user is a member of
+}
+
+// String names the target in logs and errors, e.g. "group 42", "user alice"
+// or "instance (owned)".
+func (t *TargetConfig) String() string {
+	switch {
+	case t.Group > 0:
+		return fmt.Sprintf("group %d", t.Group)
+	case t.Project > 0:
+		return fmt.Sprintf("project %d", t.Project)
+	case t.Instance != nil:
+		var scope []string
+		if t.Instance.Owned {
+			scope = append(scope, "owned")
+		}
+		if t.Instance.Membership {
+			scope = append(scope, "membership")
+		}
+		if len(scope) == 0 {
+			return "instance"
+		}
+		return "instance (" + strings.Join(scope, ", ") + ")"
+	default:
+		return "user " + t.User
+	}
+}
+
+// KeyPrefix returns the cleaned storage prefix of the target, without
+// leading or trailing slash. It is empty when no prefix is set.
+func (t *TargetConfig) KeyPrefix() string {
+	prefix := strings.Trim(t.StoragePrefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return path.Clean(prefix)
+}
+
+// KeyPrefixes returns the distinct storage prefixes of the targets list.
+func (c *Config) KeyPrefixes() []string {
+	var prefixes []string
+	seen := make(map[string]bool)
+	for i := range c.Targets {
+		if p := c.Targets[i].KeyPrefix(); p != "" && !seen[p] {
+			seen[p] = true
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
+}
+
+// validateTargets checks every entry of the targets list: exactly one kind,
+// valid overrides, and no target listed twice.
+//
+//nolint:funcorder // grouped with Validate()
+func (c *Config) validateTargets() error {
+	seen := make(map[string]bool, len(c.Targets))
+	for i := range c.Targets {
+		t := &c.Targets[i]
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("targets[%d]: %w", i, err)
+		}
+		if seen[t.String()] {
+			return fmt.Errorf("targets[%d]: %s is listed more than once", i, t) //nolint:err113 // validation errors provide user context
+		}
+		seen[t.String()] = true
+	}
+	return nil
+}
+
+// validate checks a single target.
+//
+//nolint:err113 // validation errors provide user context
+func (t *TargetConfig) validate() error {
+	kinds := 0
+	if t.Group != 0 {
+		kinds++
+	}
+	if t.Project != 0 {
+		kinds++
+	}
+	if t.User != "" {
+		kinds++
+	}
+	if t.Instance != nil {
+		kinds++
+	}
+	if kinds != 1 {
+		return errors.New("exactly one of group, project, user or instance must be set")
+	}
+	if t.Group < 0 || t.Project < 0 {
+		return errors.New("group and project IDs must be positive")
+	}
+	if strings.Contains(t.User, "/") {
+		return fmt.Errorf("user %q must be a username, not a path", t.User)
+	}
+
+	if err := validatePath(t.StoragePrefix, "storage prefix"); err != nil {
+		return err
+	}
+	if t.ExportTimeoutMins < 0 || t.ExportTimeoutMins > constants.MaxExportTimeoutMinutes {
+		return fmt.Errorf(
+			"exportTimeoutMins must be between 1 and %d minutes (0 keeps the global value), got %d",
+			constants.MaxExportTimeoutMinutes, t.ExportTimeoutMins,
+		)
+	}
+	if t.Age != nil {
+		if err := validateAge(*t.Age); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+// targetsConfig returns a valid config whose targets list is set to targets.
+func targetsConfig(targets ...config.TargetConfig) *config.Config {
+	return &config.Config{
+		GitlabToken:       "token",
+		GitlabURI:         "https://gitlab.com",
+		LocalPath:         "/backup",
+		TmpDir:            "/tmp",
+		ExportTimeoutMins: 10,
+		ImportTimeoutMins: 60,
+		Targets:           targets,
+	}
+}
+
+func TestNewConfigFromFile_Targets(t *testing.T) {
+	t.Setenv("GITLAB_TOKEN", "mytoken")
+	cfg, err := config.NewConfigFromFile("testdata/targets-cfg.yaml")
+	require.NoError(t, err)
+
+	require.Len(t, cfg.Targets, 4)
+	require.Equal(t, int64(123), cfg.Targets[0].Group)
+	require.Equal(t, "team-a", cfg.Targets[0].KeyPrefix())
+	require.Nil(t, cfg.Targets[0].Age)
+	require.Equal(t, int64(456), cfg.Targets[1].Project)
+	require.Equal(t, 240, cfg.Targets[1].ExportTimeoutMins)
+	require.NotNil(t, cfg.Targets[1].IncludeArchived)
+	require.True(t, *cfg.Targets[1].IncludeArchived)
+	require.Nil(t, cfg.Targets[0].IncludeArchived)
+	require.False(t, cfg.IncludeArchived)
+	require.Equal(t, "alice", cfg.Targets[2].User)
+	require.Equal(t, "users/alice", cfg.Targets[2].KeyPrefix())
+
+	// An empty age block disables encryption for the target only.
+	require.NotNil(t, cfg.Targets[2].Age)
+	require.False(t, cfg.Targets[2].Age.IsEnabled())
+	require.True(t, cfg.IsAgeEnabled())
+
+	require.Equal(t, &config.InstanceTarget{Membership: true}, cfg.Targets[3].Instance)
+	require.Equal(t, []string{"team-a", "users/alice", "all"}, cfg.KeyPrefixes())
+
+	rules := cfg.Filters.Rules()
+	require.Equal(t, []string{"**/sandbox-*", "regex:^team-a/archive/"}, rules.ExcludePaths)
+	require.Equal(t, []string{"private", "internal"}, rules.Visibility)
+	require.True(t, rules.ExcludeForks)
+	require.False(t, rules.ExcludeMirrors)
+	require.Equal(t, 365, rules.MaxInactiveDays)
+	require.Equal(t, int64(2048), rules.MaxSizeMB)
+	require.True(t, cfg.IsConfigValid())
+
+	require.Equal(t, "0 2 * * *", cfg.TargetSchedule(&cfg.Targets[0]))
+	require.Equal(t, "30 */6 * * *", cfg.TargetSchedule(&cfg.Targets[1]))
+	require.Equal(t, 300, cfg.Daemon.JitterSecs)
+	require.Equal(t, "127.0.0.1:8080", cfg.Daemon.StatusAddr)
+	require.NoError(t, cfg.ValidateForDaemon())
+}
+
+func TestTargetConfig_String(t *testing.T) {
+	require.Equal(t, "group 1", (&config.TargetConfig{Group: 1}).String())
+	require.Equal(t, "project 2", (&config.TargetConfig{Project: 2}).String())
+	require.Equal(t, "user alice", (&config.TargetConfig{User: "alice"}).String())
+	require.Equal(t, "instance", (&config.TargetConfig{Instance: &config.InstanceTarget{}}).String())
+	require.Equal(t, "instance (owned, membership)",
+		(&config.TargetConfig{Instance: &config.InstanceTarget{Owned: true, Membership: true}}).String())
+}
+
+func TestValidate_Targets(t *testing.T) {
+	t.Run("valid mix of targets", func(t *testing.T) {
+		cfg := targetsConfig(
+			config.TargetConfig{Group: 1, StoragePrefix: "team-a"},
+			config.TargetConfig{Project: 2, ExportTimeoutMins: 120},
+			config.TargetConfig{User: "alice", Age: &config.AgeConfig{}},
+		)
+		require.NoError(t, cfg.Validate())
+	})
+
+	t.Run("targets combined with gitlabGroupID", func(t *testing.T) {
+		cfg := targetsConfig(config.TargetConfig{Group: 1})
+		cfg.GitlabGroupID = 5
+		err := cfg.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cannot specify targets together with gitlabGroupID or gitlabProjectID")
+	})
+
+	tests := []struct {
+		name    string
+		targets []config.TargetConfig
+		errMsg  string
+	}{
+		{
+			name:    "no kind",
+			targets: []config.TargetConfig{{StoragePrefix: "x"}},
+			errMsg:  "targets[0]: exactly one of group, project, user or instance must be set",
+		},
+		{
+			name:    "two kinds",
+			targets: []config.TargetConfig{{Group: 1, Project: 2}},
+			errMsg:  "targets[0]: exactly one of group, project, user or instance must be set",
+		},
+		{
+			name:    "instance and user",
+			targets: []config.TargetConfig{{User: "bob", Instance: &config.InstanceTarget{}}},
+			errMsg:  "targets[0]: exactly one of group, project, user or instance must be set",
+		},
+		{
+			name:    "instance twice",
+			targets: []config.TargetConfig{{Instance: &config.InstanceTarget{}}, {Instance: &config.InstanceTarget{}}},
+			errMsg:  "targets[1]: instance is listed more than once",
+		},
+		{
+			name:    "negative ID",
+			targets: []config.TargetConfig{{Group: 1}, {Project: -2}},
+			errMsg:  "targets[1]: group and project IDs must be positive",
+		},
+		{
+			name:    "user path",
+			targets: []config.TargetConfig{{User: "alice/project"}},
+			errMsg:  "must be a username",
+		},
+		{
+			name:    "prefix traversal",
+			targets: []config.TargetConfig{{Group: 1, StoragePrefix: "../other"}},
+			errMsg:  "storage prefix contains path traversal sequence",
+		},
+		{
+			name:    "timeout out of range",
+			targets: []config.TargetConfig{{Group: 1, ExportTimeoutMins: 100000}},
+			errMsg:  "exportTimeoutMins must be between 1 and",
+		},
+		{
+			name:    "missing recipients file",
+			targets: []config.TargetConfig{{Group: 1, Age: &config.AgeConfig{RecipientsFile: "/nonexistent/recipients.txt"}}},
+			errMsg:  "age recipients file /nonexistent/recipients.txt",
+		},
+		{
+			name:    "duplicate target",
+			targets: []config.TargetConfig{{Group: 1}, {User: "bob"}, {Group: 1, StoragePrefix: "again"}},
+			errMsg:  "targets[2]: group 1 is listed more than once",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := targetsConfig(tc.targets...).Validate()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+}
@@ -19,6 +19,8 @@ func TestConstantValues(t *testing.T) {
 		{"DownloadRateLimitIntervalSeconds", constants.DownloadRateLimitIntervalSeconds, 60},
 		{"ExportRateLimitIntervalSeconds", constants.ExportRateLimitIntervalSeconds, 60},
 		{"ImportRateLimitIntervalSeconds", constants.ImportRateLimitIntervalSeconds, 60},
+		{"GroupDownloadRateLimitBurst", constants.GroupDownloadRateLimitBurst, 1},
+		{"GroupDownloadRateLimitIntervalSeconds", constants.GroupDownloadRateLimitIntervalSeconds, 60},
 	}
 
 	for _, tt := range tests {
@@ -71,6 +73,15 @@ func TestTimeoutConstants(t *testing.T) {
 	}
 }
 
+func TestConcurrencyConstants(t *testing.T) {
+	if constants.DefaultMaxConcurrency < 1 {
+		t.Error("DefaultMaxConcurrency should be at least 1")
+	}
+	if constants.DefaultMaxConcurrency > constants.MaxConcurrencyLimit {
+		t.Error("DefaultMaxConcurrency should not exceed MaxConcurrencyLimit")
+	}
+}
+
 func TestBufferSizeConstants(t *testing.T) {
 	// Verify buffer sizes are reasonable
 	if constants.CopyBufferSize != 32*constants.KB {
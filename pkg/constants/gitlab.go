@@ -15,6 +15,7 @@ const (
 // These limits are based on GitLab's documented API rate limits per user:
 // - Repository Files API: 5 requests/minute (for downloads)
 // - Project Import/Export API: 6 requests/minute (for exports and imports)
+// - Group export download: 1 request/minute
 //
 // ⚠️  WARNING: DO NOT increase these values above GitLab's documented limits.
 // Exceeding limits results in HTTP 429 errors and potential account restrictions.
@@ -44,6 +45,14 @@ const (
 	// ImportRateLimitBurst is the maximum number of import requests allowed per interval.
 	// GitLab project import/export API limit: 6 requests per minute per user.
 	ImportRateLimitBurst = 6
+
+	// GroupDownloadRateLimitIntervalSeconds is the time window for group export download rate limiting.
+	GroupDownloadRateLimitIntervalSeconds = 60
+
+	// GroupDownloadRateLimitBurst is the maximum number of group export downloads allowed per interval.
+	// GitLab group export download limit: 1 request per minute per user. The group
+	// export has no status endpoint, so every poll is a download attempt.
+	GroupDownloadRateLimitBurst = 1
 )
 
 // Export Operation Constants
@@ -66,6 +75,21 @@ const (
 	DefaultExportTimeoutMins = 1440
 )
 
+// Backup Concurrency Constants
+//
+// These bound how many projects a group backup exports at the same time.
+// Exports still go through the export/download rate limiters, so a higher
+// concurrency mainly helps when many exports are waiting on GitLab to finish.
+const (
+	// DefaultMaxConcurrency is the default number of projects exported in parallel.
+	// Default: 4 workers.
+	DefaultMaxConcurrency = 4
+
+	// MaxConcurrencyLimit is the highest accepted maxConcurrency value.
+	// Prevents configuration typos from spawning hundreds of concurrent exports.
+	MaxConcurrencyLimit = 64
+)
+
 // API Retry Constants
 //
 // These control retry behavior for transient GitLab API failures (HTTP 5xx, 429, network errors).
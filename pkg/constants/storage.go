@@ -33,6 +33,33 @@ const (
 	DefaultBufferSize = 32 * KB
 )
 
+// S3 Multipart Upload Constants
+//
+// Archives larger than one part are uploaded in parts, several at a time.
+// Each part in flight is buffered in memory, so an upload holds up to
+// part size x upload concurrency bytes.
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/userguide/qfacts.html
+const (
+	// DefaultS3PartSizeMB is the default part size in megabytes.
+	// Streamed archives are limited to 10,000 parts: 160GB with the default.
+	DefaultS3PartSizeMB = 16
+
+	// S3MinPartSizeMB is the smallest part size accepted by S3 (the last part excepted).
+	S3MinPartSizeMB = 5
+
+	// S3MaxPartSizeMB is the largest part size accepted by S3 (5GB).
+	S3MaxPartSizeMB = 5 * 1024
+
+	// S3MaxParts is the maximum number of parts of a multipart upload.
+	S3MaxParts = 10000
+
+	// DefaultS3UploadConcurrency is the default number of parts uploaded in parallel.
+	DefaultS3UploadConcurrency = 4
+
+	// S3UploadConcurrencyLimit is the highest accepted upload concurrency.
+	S3UploadConcurrencyLimit = 32
+)
+
 // File Permissions
 //
 // Standard Unix file permission constants.
@@ -0,0 +1,221 @@
+// Package cron parses standard five-field cron expressions and computes
+// their next activation time.
+//
+// An expression has the fields minute (0-59), hour (0-23), day of month
+// (1-31), month (1-12 or JAN-DEC) and day of week (0-7 or SUN-SAT, 0 and 7
+// both being Sunday). Each field is "*", a value, a range "a-b", a list
+// "a,b,c", or any of those followed by a step "/n". As in Vixie cron, when
+// both the day of month and the day of week are restricted, a day matching
+// either one fires. Only a bare "*" leaves a field unrestricted: "*/n" is a
+// restriction like any other, so "0 0 */2 * MON" fires on odd days of the
+// month and on Mondays. The descriptors @yearly (@annually), @monthly, @weekly,
+// @daily (@midnight) and @hourly are accepted as shorthands.
+//
+// Times are evaluated in the location of the time passed to Next.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExpression is returned by Parse for a malformed expression.
+var ErrInvalidExpression = errors.New("invalid cron expression")
+
+// searchLimit bounds Next for expressions that match no date, e.g. "0 0 30 2 *".
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+const fieldCount = 5
+
+// descriptors maps the @ shorthands to their expression.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field describes the bounds and names of one field.
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var fields = [fieldCount]field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}},
+}
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expr    string
+	minute  uint64 // bit i set when minute i matches
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64 // Sunday is bit 0 only, 7 is folded into it
+	domStar bool   // day of month is unrestricted
+	dowStar bool   // day of week is unrestricted
+}
+
+// Parse parses a five-field cron expression or an @ descriptor.
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if d, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != fieldCount {
+		return nil, fmt.Errorf("%w %q: want %d fields (minute hour day-of-month month day-of-week), got %d",
+			ErrInvalidExpression, expr, fieldCount, len(parts))
+	}
+
+	var bits [fieldCount]uint64
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidExpression, expr, err)
+		}
+		bits[i] = b
+	}
+	const sunday, sundayAlias = 0, 7
+	if bits[4]&(1<<sundayAlias) != 0 {
+		bits[4] = bits[4]&^(1<<sundayAlias) | 1<<sunday
+	}
+	return &Schedule{
+		expr:    strings.TrimSpace(expr),
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: unrestricted(parts[2]),
+		dowStar: unrestricted(parts[4]),
+	}, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first activation strictly after t, truncated to the
+// minute. It returns the zero time when the expression matches no date
+// within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.Add(searchLimit)
+	for next.Before(limit) {
+		switch {
+		case !has(s.month, int(next.Month())):
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, loc)
+		case !has(s.hour, next.Hour()):
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, loc)
+		case !has(s.minute, next.Minute()):
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the day of month / day of week rule.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(bits uint64, i int) bool {
+	return bits&(1<<uint(i)) != 0 //nolint:gosec // i is a small calendar value
+}
+
+// unrestricted reports whether a day field leaves the day to the other one.
+// A step ("*/n") restricts the field, even over its whole range.
+func unrestricted(part string) bool {
+	return part == "*"
+}
+
+// parseField parses a comma separated list of ranges with optional steps.
+func parseField(part string, f field) (uint64, error) {
+	var bits uint64
+	for item := range strings.SplitSeq(part, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, stepStr)
+			}
+			step = n
+		}
+
+		lo, hi, err := parseRange(rng, f)
+		if err != nil {
+			return 0, err
+		}
+		if hasStep && !strings.Contains(rng, "-") && rng != "*" {
+			hi = f.max // "a/n" means from a to the end of the range
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v) //nolint:gosec // v is within the field bounds
+		}
+	}
+	return bits, nil
+}
+
+// parseRange parses "*", "a" or "a-b" within the bounds of f.
+func parseRange(rng string, f field) (int, int, error) {
+	if rng == "*" {
+		return f.min, f.max, nil
+	}
+	loStr, hiStr, isRange := strings.Cut(rng, "-")
+	lo, err := parseValue(loStr, f)
+	if err != nil {
+		return 0, 0, err
+	}
+	hi := lo
+	if isRange {
+		if hi, err = parseValue(hiStr, f); err != nil {
+			return 0, 0, err
+		}
+	}
+	if hi < lo {
+		return 0, 0, fmt.Errorf("%s: range %q ends before it starts", f.name, rng)
+	}
+	return lo, hi, nil
+}
+
+// parseValue parses a number or a name within the bounds of f.
+func parseValue(s string, f field) (int, error) {
+	if v, ok := f.names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %q is not between %d and %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
@@ -0,0 +1,100 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/cron"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// Friday 2026-10-16 10:17:42 UTC.
+	from := time.Date(2026, 10, 16, 10, 17, 42, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 16, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)},
+		{"30 2,14 * * *", time.Date(2026, 10, 16, 14, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2026, 10, 16, 10, 25, 0, 0, time.UTC)},
+		{"0 0 * * SUN", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * mon-wed", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Day of month OR day of week when both are restricted.
+		{"0 0 20 * 6", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		// A step restricts its field: the 18th (day of month) comes before
+		// Wednesday the 21st, and Saturday the 17th before the 20th.
+		{"0 0 */17 * WED", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 20 * */3", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 */17 * *", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			s, err := cron.Parse(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, s.Next(from))
+			assert.Equal(t, tc.expr, s.String())
+		})
+	}
+}
+
+func TestSchedule_NextIsStrictlyAfter(t *testing.T) {
+	s, err := cron.Parse("0 3 * * *")
+	require.NoError(t, err)
+	at := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+	assert.Equal(t, at.AddDate(0, 0, 1), s.Next(at))
+}
+
+func TestSchedule_NextKeepsLocation(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	s, err := cron.Parse("0 3 * * *")
+	require.NoError(t, err)
+	next := s.Next(time.Date(2026, 10, 16, 12, 0, 0, 0, paris))
+	assert.Equal(t, time.Date(2026, 10, 17, 3, 0, 0, 0, paris), next)
+	assert.Equal(t, paris, next.Location())
+}
+
+func TestSchedule_NextNeverMatches(t *testing.T) {
+	s, err := cron.Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(time.Now()).IsZero())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every 5m",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := cron.Parse(expr)
+			require.ErrorIs(t, err, cron.ErrInvalidExpression)
+		})
+	}
+}
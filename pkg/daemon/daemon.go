@@ -0,0 +1,376 @@
+// Package daemon runs backups on cron schedules in a long-lived process.
+//
+// The entries of the targets list sharing a cron schedule (or the single
+// gitlabGroupID / gitlabProjectID configuration) form a job, run like a
+// one-shot backup of those targets with a checkpoint of its own. Runs are
+// executed one at a time, in the order they fall due: jobs share the GitLab
+// rate limits and the incremental state file, so running them side by side
+// would only make them compete. A job that falls due while its previous run
+// is still running or waiting is skipped rather than queued twice, so runs of
+// the same target never overlap.
+//
+// The configuration is loaded again on Reload (SIGHUP in the daemon command).
+// An invalid configuration is reported and the current schedule is kept. A
+// run in progress always completes with the configuration it started with.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/sgaunet/gitlab-backup/pkg/cron"
+)
+
+// idleWait is how long the loop sleeps when no job is scheduled.
+const idleWait = time.Hour
+
+// ErrNoJobs is returned when a configuration schedules nothing.
+var ErrNoJobs = errors.New("no job to schedule")
+
+// Logger is the logging interface used by the daemon.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// LoadFunc loads and validates the configuration. It is called at start and
+// on every reload.
+type LoadFunc func() (*config.Config, error)
+
+// RunFunc runs one backup with the configuration of a job.
+type RunFunc func(ctx context.Context, cfg *config.Config) error
+
+// Job is one scheduled backup.
+type Job struct {
+	Name     string         // targets of the job, e.g. "group 42, project 7"
+	Schedule *cron.Schedule // when the job runs
+	Config   *config.Config // configuration restricted to the targets of the job
+}
+
+// Jobs splits cfg into one job per schedule: the targets sharing a schedule
+// run together, in a copy of cfg whose targets list holds those targets only.
+// Within a job, a project reached by several targets is still backed up once,
+// by the target that claims it first. Without a targets list, cfg itself is
+// the single job.
+func Jobs(cfg *config.Config) ([]Job, error) {
+	if len(cfg.Targets) == 0 {
+		name := fmt.Sprintf("group %d", cfg.GitlabGroupID)
+		if cfg.GitlabGroupID <= 0 {
+			name = fmt.Sprintf("project %d", cfg.GitlabProjectID)
+		}
+		job, err := newJob(name, cfg.Daemon.Schedule, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return []Job{job}, nil
+	}
+
+	var schedules []string // in order of first use
+	targets := make(map[string][]config.TargetConfig)
+	for i := range cfg.Targets {
+		t := &cfg.Targets[i]
+		expr := strings.TrimSpace(cfg.TargetSchedule(t))
+		if _, ok := targets[expr]; !ok {
+			schedules = append(schedules, expr)
+		}
+		targets[expr] = append(targets[expr], *t)
+	}
+
+	jobs := make([]Job, 0, len(schedules))
+	for _, expr := range schedules {
+		jobCfg := *cfg
+		jobCfg.Targets = targets[expr]
+		job, err := newJob(jobName(jobCfg.Targets), expr, &jobCfg)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// jobName names a job after its targets, e.g. "group 42, project 7". A target
+// is listed once in a configuration, so the names of its jobs are unique.
+func jobName(targets []config.TargetConfig) string {
+	names := make([]string, len(targets))
+	for i := range targets {
+		names[i] = targets[i].String()
+	}
+	return strings.Join(names, ", ")
+}
+
+func newJob(name, expr string, cfg *config.Config) (Job, error) {
+	if expr == "" {
+		return Job{}, fmt.Errorf("%w: %s has no schedule", ErrNoJobs, name)
+	}
+	s, err := cron.Parse(expr)
+	if err != nil {
+		return Job{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return Job{Name: name, Schedule: s, Config: cfg}, nil
+}
+
+// jobState is a job with its schedule and run history.
+type jobState struct {
+	Job
+
+	next      time.Time
+	lastStart time.Time
+	lastEnd   time.Time
+	lastErr   error
+	skipped   int // runs skipped because the previous one was not finished
+}
+
+// Daemon schedules and runs the jobs. Create it with New.
+type Daemon struct {
+	load LoadFunc
+	run  RunFunc
+	log  Logger
+
+	// Replaced in tests.
+	now    func() time.Time
+	after  func(time.Duration) <-chan time.Time
+	jitter func(limit time.Duration) time.Duration
+
+	mu         sync.Mutex
+	jobs       []*jobState
+	maxJitter  time.Duration
+	queue      []string // names of the jobs waiting to run, oldest first
+	running    string   // name of the running job, "" when idle
+	startedAt  time.Time
+	reloadedAt time.Time
+}
+
+// New returns a daemon loading its configuration with load and running
+// backups with run.
+func New(load LoadFunc, run RunFunc, log Logger) *Daemon {
+	return &Daemon{
+		load:   load,
+		run:    run,
+		log:    log,
+		now:    time.Now,
+		after:  time.After,
+		jitter: randomJitter,
+	}
+}
+
+// Run loads the configuration and runs the jobs until ctx is cancelled, then
+// waits for the run in progress, which sees the cancellation, to return.
+// Every value received on reload loads the configuration again. Run only
+// fails when the initial configuration cannot be loaded or the status
+// endpoint cannot listen.
+func (d *Daemon) Run(ctx context.Context, reload <-chan os.Signal) error {
+	cfg, err := d.load()
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.startedAt = d.now()
+	d.mu.Unlock()
+	if err := d.apply(cfg); err != nil {
+		return err
+	}
+	stop, err := d.serveStatus(cfg.Daemon.StatusAddr)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	done := make(chan error, 1)
+	for {
+		d.startNext(ctx, done)
+		select {
+		case <-ctx.Done():
+			d.shutdown(done)
+			return nil
+		case <-reload:
+			d.reload()
+		case err := <-done:
+			d.finish(err)
+		case <-d.after(d.untilNext()):
+			d.fire()
+		}
+	}
+}
+
+// reload loads the configuration again and applies it, keeping the current
+// schedule when the new configuration is invalid.
+func (d *Daemon) reload() {
+	d.log.Info("reloading configuration")
+	cfg, err := d.load()
+	if err == nil {
+		err = d.apply(cfg)
+	}
+	if err != nil {
+		d.log.Error("configuration reload failed, keeping the current schedule", "error", err)
+		return
+	}
+	d.mu.Lock()
+	d.reloadedAt = d.now()
+	d.mu.Unlock()
+}
+
+// apply replaces the jobs with those of cfg. The run history of a job kept
+// across a reload is preserved; its next run is computed again.
+func (d *Daemon) apply(cfg *config.Config) error {
+	jobs, err := Jobs(cfg)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		return ErrNoJobs
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.maxJitter = time.Duration(cfg.Daemon.JitterSecs) * time.Second
+	now := d.now()
+	states := make([]*jobState, 0, len(jobs))
+	for _, job := range jobs {
+		st := &jobState{Job: job}
+		if old := d.find(job.Name); old != nil {
+			st.lastStart, st.lastEnd, st.lastErr, st.skipped = old.lastStart, old.lastEnd, old.lastErr, old.skipped
+		}
+		st.next = d.nextRun(st, now)
+		states = append(states, st)
+	}
+	d.jobs = states
+	// Drop waiting runs of removed jobs.
+	d.queue = slices.DeleteFunc(d.queue, func(name string) bool { return d.find(name) == nil })
+	return nil
+}
+
+// fire queues the jobs that are due and schedules their next run.
+func (d *Daemon) fire() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	for _, st := range d.jobs {
+		if st.next.After(now) {
+			continue
+		}
+		if d.running == st.Name || slices.Contains(d.queue, st.Name) {
+			st.skipped++
+			d.log.Warn("previous run not finished, skipping this one", "target", st.Name, "scheduled", st.next)
+		} else {
+			d.queue = append(d.queue, st.Name)
+		}
+		st.next = d.nextRun(st, now)
+	}
+}
+
+// startNext starts the oldest waiting job when no run is in progress. The
+// run reports its result on done.
+func (d *Daemon) startNext(ctx context.Context, done chan<- error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.running != "" || len(d.queue) == 0 {
+		return
+	}
+	name := d.queue[0]
+	d.queue = d.queue[1:]
+	st := d.find(name)
+	if st == nil {
+		return
+	}
+	d.running = name
+	st.lastStart = d.now()
+	d.log.Info("starting scheduled backup", "target", name, "waiting", len(d.queue))
+
+	cfg := st.Config
+	go func() {
+		done <- d.run(ctx, cfg)
+	}()
+}
+
+// finish records the result of the run in progress.
+func (d *Daemon) finish(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	name := d.running
+	d.running = ""
+	st := d.find(name)
+	if st == nil {
+		// The job was removed by a reload while running.
+		d.log.Info("scheduled backup finished", "target", name, "error", err)
+		return
+	}
+	st.lastEnd = d.now()
+	st.lastErr = err
+	if err != nil {
+		d.log.Error("scheduled backup failed", "target", name, "error", err, "next run", st.next)
+		return
+	}
+	d.log.Info("scheduled backup completed", "target", name,
+		"duration", st.lastEnd.Sub(st.lastStart).Round(time.Second), "next run", st.next)
+}
+
+// shutdown waits for the run in progress, if any.
+func (d *Daemon) shutdown(done <-chan error) {
+	d.mu.Lock()
+	running := d.running
+	d.mu.Unlock()
+	if running == "" {
+		d.log.Info("daemon stopped")
+		return
+	}
+	d.log.Info("stopping, waiting for the run in progress", "target", running)
+	d.finish(<-done)
+	d.log.Info("daemon stopped")
+}
+
+// untilNext returns the time left until the earliest next run.
+func (d *Daemon) untilNext() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var next time.Time
+	for _, st := range d.jobs {
+		if !st.next.IsZero() && (next.IsZero() || st.next.Before(next)) {
+			next = st.next
+		}
+	}
+	if next.IsZero() {
+		return idleWait
+	}
+	return max(next.Sub(d.now()), 0)
+}
+
+// nextRun computes and logs the next run of st after now, jitter included.
+// It must be called with d.mu held.
+func (d *Daemon) nextRun(st *jobState, now time.Time) time.Time {
+	next := st.Schedule.Next(now)
+	if next.IsZero() {
+		d.log.Warn("schedule never fires again", "target", st.Name, "schedule", st.Schedule)
+		return next
+	}
+	if d.maxJitter > 0 {
+		next = next.Add(d.jitter(d.maxJitter))
+	}
+	d.log.Info("next run", "target", st.Name, "schedule", st.Schedule, "at", next)
+	return next
+}
+
+// find returns the current job named name, or nil. It must be called with d.mu held.
+func (d *Daemon) find(name string) *jobState {
+	for _, st := range d.jobs {
+		if st.Name == name {
+			return st
+		}
+	}
+	return nil
+}
+
+// randomJitter returns a random duration in [0, limit].
+func randomJitter(limit time.Duration) time.Duration {
+	return rand.N(limit + 1) //nolint:gosec // scheduling jitter needs no cryptographic randomness
+}
@@ -0,0 +1,285 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errBackup = errors.New("backup failed")
+
+// clock is a settable time source.
+type clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *clock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *clock) set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = t
+}
+
+// fakeRunner records started runs and lets the test decide when they end.
+type fakeRunner struct {
+	started chan string
+	results chan error
+}
+
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{started: make(chan string, 10), results: make(chan error)}
+}
+
+func (r *fakeRunner) run(ctx context.Context, cfg *config.Config) error {
+	r.started <- jobName(cfg.Targets)
+	select {
+	case err := <-r.results:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// harness runs a daemon on a fake clock. Sending on tick wakes the loop as if
+// the earliest next run had come.
+type harness struct {
+	d      *Daemon
+	clock  *clock
+	tick   chan time.Time
+	reload chan os.Signal
+	runner *fakeRunner
+	cancel context.CancelFunc
+	done   chan error
+}
+
+func newHarness(t *testing.T, load LoadFunc) *harness {
+	t.Helper()
+	h := &harness{
+		clock:  &clock{t: time.Date(2026, 10, 16, 10, 0, 30, 0, time.UTC)},
+		tick:   make(chan time.Time),
+		reload: make(chan os.Signal),
+		runner: newFakeRunner(),
+		done:   make(chan error, 1),
+	}
+	h.d = New(load, h.runner.run, slog.New(slog.DiscardHandler))
+	h.d.now = h.clock.now
+	h.d.after = func(time.Duration) <-chan time.Time { return h.tick }
+	h.d.jitter = func(time.Duration) time.Duration { return 0 }
+	return h
+}
+
+func (h *harness) start(t *testing.T) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	go func() { h.done <- h.d.Run(ctx, h.reload) }()
+	t.Cleanup(func() {
+		cancel()
+		<-h.done
+	})
+	require.Eventually(t, func() bool { return len(h.d.Status().Jobs) > 0 }, time.Second, time.Millisecond)
+}
+
+// advance moves the clock and wakes the loop.
+func (h *harness) advance(t time.Time) {
+	h.clock.set(t)
+	h.tick <- t
+}
+
+func (h *harness) job(t *testing.T, target string) JobStatus {
+	t.Helper()
+	for _, js := range h.d.Status().Jobs {
+		if js.Target == target {
+			return js
+		}
+	}
+	t.Fatalf("no job %q", target)
+	return JobStatus{}
+}
+
+func targetsConfig(jitterSecs int, targets ...config.TargetConfig) *config.Config {
+	return &config.Config{
+		Targets: targets,
+		Daemon:  config.DaemonConfig{Schedule: "*/5 * * * *", JitterSecs: jitterSecs},
+	}
+}
+
+func staticLoad(cfg *config.Config) LoadFunc {
+	return func() (*config.Config, error) { return cfg, nil }
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 10, 16, hour, minute, 0, 0, time.UTC)
+}
+
+func TestJobs(t *testing.T) {
+	cfg := targetsConfig(0,
+		config.TargetConfig{Group: 1},
+		config.TargetConfig{Project: 2, Schedule: "@hourly"},
+		config.TargetConfig{Project: 3},
+		config.TargetConfig{User: "alice", Schedule: " @hourly "},
+	)
+	jobs, err := Jobs(cfg)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	// Targets sharing a schedule run in the same job, so the project targets
+	// still take their projects from the group target.
+	assert.Equal(t, "group 1, project 3", jobs[0].Name)
+	assert.Equal(t, "*/5 * * * *", jobs[0].Schedule.String())
+	assert.Equal(t, []config.TargetConfig{{Group: 1}, {Project: 3}}, jobs[0].Config.Targets)
+	assert.Equal(t, "project 2, user alice", jobs[1].Name)
+	assert.Equal(t, "@hourly", jobs[1].Schedule.String())
+	assert.Equal(t, []config.TargetConfig{
+		{Project: 2, Schedule: "@hourly"},
+		{User: "alice", Schedule: " @hourly "},
+	}, jobs[1].Config.Targets)
+	assert.Len(t, cfg.Targets, 4, "the configuration itself is left untouched")
+
+	jobs, err = Jobs(&config.Config{GitlabProjectID: 7, Daemon: config.DaemonConfig{Schedule: "@daily"}})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "project 7", jobs[0].Name)
+
+	_, err = Jobs(&config.Config{GitlabGroupID: 7})
+	require.ErrorIs(t, err, ErrNoJobs)
+}
+
+func TestDaemon_RunsJobsOneAtATime(t *testing.T) {
+	h := newHarness(t, staticLoad(targetsConfig(0,
+		config.TargetConfig{Group: 1},
+		config.TargetConfig{Group: 2, Schedule: "5,10 * * * *"},
+	)))
+	h.start(t)
+	assert.Equal(t, at(10, 5), *h.job(t, "group 1").NextRun)
+
+	h.advance(at(10, 5))
+	assert.Equal(t, "group 1", <-h.runner.started)
+	require.Eventually(t, func() bool { return h.job(t, "group 2").Waiting }, time.Second, time.Millisecond)
+	assert.True(t, h.job(t, "group 1").Running)
+	assert.Equal(t, at(10, 10), *h.job(t, "group 1").NextRun)
+
+	// group 1 is still running and group 2 still waiting: both are skipped.
+	h.advance(at(10, 10))
+	require.Eventually(t, func() bool { return h.job(t, "group 1").Skipped == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, h.job(t, "group 2").Skipped)
+
+	h.clock.set(at(10, 12))
+	h.runner.results <- errBackup
+	assert.Equal(t, "group 2", <-h.runner.started)
+	g1 := h.job(t, "group 1")
+	assert.False(t, g1.Running)
+	assert.Equal(t, errBackup.Error(), g1.LastError)
+	assert.Equal(t, at(10, 5), *g1.LastStart)
+	assert.Equal(t, at(10, 12), *g1.LastEnd)
+	assert.Equal(t, "group 2", h.d.Status().Running)
+
+	h.runner.results <- nil
+	require.Eventually(t, func() bool { return h.d.Status().Running == "" }, time.Second, time.Millisecond)
+	assert.Empty(t, h.job(t, "group 2").LastError)
+}
+
+func TestDaemon_Jitter(t *testing.T) {
+	h := newHarness(t, staticLoad(targetsConfig(60, config.TargetConfig{Group: 1})))
+	var limit time.Duration
+	h.d.jitter = func(l time.Duration) time.Duration {
+		limit = l
+		return 42 * time.Second
+	}
+	h.start(t)
+	assert.Equal(t, time.Minute, limit)
+	assert.Equal(t, at(10, 5).Add(42*time.Second), *h.job(t, "group 1").NextRun)
+
+	// Not due yet without the jitter.
+	h.advance(at(10, 5))
+	h.advance(at(10, 5).Add(42 * time.Second))
+	assert.Equal(t, "group 1", <-h.runner.started)
+}
+
+func TestDaemon_Reload(t *testing.T) {
+	var mu sync.Mutex
+	cfg, loadErr := targetsConfig(0, config.TargetConfig{Group: 1}), error(nil)
+	h := newHarness(t, func() (*config.Config, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return cfg, loadErr
+	})
+	h.start(t)
+
+	mu.Lock()
+	loadErr = errors.New("broken config")
+	mu.Unlock()
+	h.reload <- os.Interrupt
+	assert.Equal(t, "*/5 * * * *", h.job(t, "group 1").Schedule)
+	assert.Nil(t, h.d.Status().ReloadedAt)
+
+	mu.Lock()
+	cfg, loadErr = targetsConfig(0,
+		config.TargetConfig{Group: 1, Schedule: "0 * * * *"},
+		config.TargetConfig{User: "alice"},
+	), nil
+	mu.Unlock()
+	h.reload <- os.Interrupt
+	require.Eventually(t, func() bool { return len(h.d.Status().Jobs) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, "0 * * * *", h.job(t, "group 1").Schedule)
+	assert.Equal(t, at(11, 0), *h.job(t, "group 1").NextRun)
+	assert.Equal(t, at(10, 5), *h.job(t, "user alice").NextRun)
+	assert.NotNil(t, h.d.Status().ReloadedAt)
+}
+
+func TestDaemon_StopWaitsForRun(t *testing.T) {
+	h := newHarness(t, staticLoad(targetsConfig(0, config.TargetConfig{Group: 1})))
+	h.start(t)
+	h.advance(at(10, 5))
+	<-h.runner.started
+
+	h.cancel()
+	require.NoError(t, <-h.done)
+	h.done <- nil // for the cleanup
+	assert.Equal(t, context.Canceled.Error(), h.job(t, "group 1").LastError)
+}
+
+func TestDaemon_Handler(t *testing.T) {
+	h := newHarness(t, staticLoad(targetsConfig(0, config.TargetConfig{Group: 1})))
+	h.start(t)
+
+	rec := httptest.NewRecorder()
+	h.d.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var s Status
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &s))
+	require.Len(t, s.Jobs, 1)
+	assert.Equal(t, "group 1", s.Jobs[0].Target)
+	assert.Equal(t, at(10, 5), *s.Jobs[0].NextRun)
+	assert.Nil(t, s.Jobs[0].LastStart)
+
+	rec = httptest.NewRecorder()
+	h.d.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/status", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestDaemon_RunFailsOnBadConfig(t *testing.T) {
+	d := New(func() (*config.Config, error) { return nil, errBackup }, nil, slog.New(slog.DiscardHandler))
+	require.ErrorIs(t, d.Run(context.Background(), nil), errBackup)
+
+	d = New(staticLoad(&config.Config{GitlabGroupID: 1}), nil, slog.New(slog.DiscardHandler))
+	require.ErrorIs(t, d.Run(context.Background(), nil), ErrNoJobs)
+}
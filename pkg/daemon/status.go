@@ -0,0 +1,116 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	statusReadHeaderTimeout = 5 * time.Second
+	statusShutdownTimeout   = 5 * time.Second
+)
+
+// Status is the document served by the status endpoint.
+type Status struct {
+	StartedAt  time.Time   `json:"startedAt"`
+	ReloadedAt *time.Time  `json:"reloadedAt,omitempty"`
+	Running    string      `json:"running,omitempty"` // target of the run in progress
+	Jobs       []JobStatus `json:"jobs"`
+}
+
+// JobStatus describes one job in Status.
+type JobStatus struct {
+	Target    string     `json:"target"`
+	Schedule  string     `json:"schedule"`
+	NextRun   *time.Time `json:"nextRun,omitempty"` // jitter included
+	Running   bool       `json:"running"`
+	Waiting   bool       `json:"waiting"` // due, waiting for another run to finish
+	LastStart *time.Time `json:"lastStart,omitempty"`
+	LastEnd   *time.Time `json:"lastEnd,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+	Skipped   int        `json:"skipped"` // runs skipped because the previous one was not finished
+}
+
+// Status returns a snapshot of the schedule.
+func (d *Daemon) Status() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := Status{
+		StartedAt:  d.startedAt,
+		ReloadedAt: timePtr(d.reloadedAt),
+		Running:    d.running,
+		Jobs:       make([]JobStatus, 0, len(d.jobs)),
+	}
+	for _, st := range d.jobs {
+		js := JobStatus{
+			Target:    st.Name,
+			Schedule:  st.Schedule.String(),
+			NextRun:   timePtr(st.next),
+			Running:   d.running == st.Name,
+			Waiting:   slices.Contains(d.queue, st.Name),
+			LastStart: timePtr(st.lastStart),
+			LastEnd:   timePtr(st.lastEnd),
+			Skipped:   st.skipped,
+		}
+		if st.lastErr != nil {
+			js.LastError = st.lastErr.Error()
+		}
+		s.Jobs = append(s.Jobs, js)
+	}
+	return s
+}
+
+// Handler returns the HTTP handler of the status endpoint: GET /status
+// returns Status as JSON.
+func (d *Daemon) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d.Status()); err != nil {
+			d.log.Debug("failed to write status response", "error", err)
+		}
+	})
+	return mux
+}
+
+// serveStatus starts the status endpoint on addr, unless addr is empty, and
+// returns the function stopping it. The address is only read at start; a
+// reload does not move the endpoint.
+func (d *Daemon) serveStatus(addr string) (func(), error) {
+	if addr == "" {
+		return func() {}, nil
+	}
+	var lc net.ListenConfig
+	ln, err := lc.Listen(context.Background(), "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("status endpoint: %w", err)
+	}
+	srv := &http.Server{Handler: d.Handler(), ReadHeaderTimeout: statusReadHeaderTimeout}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			d.log.Error("status endpoint stopped", "error", err)
+		}
+	}()
+	d.log.Info("status endpoint listening", "url", "http://"+ln.Addr().String()+"/status")
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), statusShutdownTimeout)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}, nil
+}
+
+// timePtr returns nil for the zero time, so that unset times are omitted.
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
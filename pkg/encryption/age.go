@@ -1,5 +1,6 @@
-// Package encryption provides backup-archive encryption using the age file
-// encryption format (https://age-encryption.org).
+// Package encryption provides backup-archive encryption, and the matching
+// decryption for restore, using the age file encryption format
+// (https://age-encryption.org).
 //
 // age uses public-key cryptography (X25519) and is the recommended encryption
 // option for automated backups: recipient public keys can be safely stored in
@@ -117,39 +118,64 @@ func EncryptFileInPlace(path string, recipients []age.Recipient, armorEnabled bo
 	return nil
 }
 
-// encryptStream encrypts r to w using the provided recipients.
-// When armorEnabled is true, the output is wrapped in age's ASCII armor.
-func encryptStream(r io.Reader, w io.Writer, recipients []age.Recipient, armorEnabled bool) error {
+// NewEncryptWriter returns a writer encrypting everything written to it to w
+// for the given recipients, ASCII-armored when armorEnabled is true. Close
+// must be called to flush the final chunk (and the armor footer); it does not
+// close w. This is the streaming counterpart of EncryptFileInPlace.
+func NewEncryptWriter(w io.Writer, recipients []age.Recipient, armorEnabled bool) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	ew := &encryptWriter{}
 	sink := w
-	var armorW io.WriteCloser
 	if armorEnabled {
-		armorW = armor.NewWriter(w)
-		sink = armorW
+		ew.armorW = armor.NewWriter(w)
+		sink = ew.armorW
 	}
-
 	encW, err := age.Encrypt(sink, recipients...)
 	if err != nil {
-		return fmt.Errorf("init age writer: %w", err)
+		return nil, fmt.Errorf("init age writer: %w", err)
 	}
+	ew.encW = encW
+	return ew, nil
+}
 
-	if _, copyErr := io.Copy(encW, r); copyErr != nil {
-		_ = encW.Close()
-		if armorW != nil {
-			_ = armorW.Close()
-		}
-		return fmt.Errorf("encrypt archive: %w", copyErr)
-	}
+// encryptWriter chains the age writer and the optional armor writer.
+type encryptWriter struct {
+	encW   io.WriteCloser
+	armorW io.WriteCloser
+}
 
-	if closeErr := encW.Close(); closeErr != nil {
-		if armorW != nil {
-			_ = armorW.Close()
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	return e.encW.Write(p) //nolint:wrapcheck // io.Writer contract, wrapped by callers
+}
+
+// Close flushes the age writer, then the armor writer.
+func (e *encryptWriter) Close() error {
+	if err := e.encW.Close(); err != nil {
+		if e.armorW != nil {
+			_ = e.armorW.Close()
 		}
-		return fmt.Errorf("close age writer: %w", closeErr)
+		return fmt.Errorf("close age writer: %w", err)
 	}
-	if armorW != nil {
-		if closeErr := armorW.Close(); closeErr != nil {
-			return fmt.Errorf("close armor writer: %w", closeErr)
+	if e.armorW != nil {
+		if err := e.armorW.Close(); err != nil {
+			return fmt.Errorf("close armor writer: %w", err)
 		}
 	}
 	return nil
 }
+
+// encryptStream encrypts r to w using the provided recipients.
+// When armorEnabled is true, the output is wrapped in age's ASCII armor.
+func encryptStream(r io.Reader, w io.Writer, recipients []age.Recipient, armorEnabled bool) error {
+	encW, err := NewEncryptWriter(w, recipients, armorEnabled)
+	if err != nil {
+		return err
+	}
+	if _, copyErr := io.Copy(encW, r); copyErr != nil {
+		_ = encW.Close()
+		return fmt.Errorf("encrypt archive: %w", copyErr)
+	}
+	return encW.Close()
+}
@@ -177,3 +177,28 @@ func TestEncryptFileInPlace_LeavesNoTempOnSuccess(t *testing.T) {
 	_, err := os.Stat(archive + ".age.tmp")
 	require.True(t, os.IsNotExist(err), "temp file should not remain after success")
 }
+
+func TestNewEncryptWriter_ArmorRoundTrip(t *testing.T) {
+	id := newIdentity(t)
+	var out bytes.Buffer
+	w, err := encryption.NewEncryptWriter(&out, []age.Recipient{id.Recipient()}, true)
+	require.NoError(t, err)
+	// Written in several chunks, as the export download does.
+	for _, chunk := range strings.SplitAfter(sampleArchive, " ") {
+		_, err := io.WriteString(w, chunk)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.True(t, strings.HasPrefix(out.String(), "-----BEGIN AGE ENCRYPTED FILE-----"))
+
+	r, err := age.Decrypt(armor.NewReader(&out), id)
+	require.NoError(t, err)
+	plaintext, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, sampleArchive, string(plaintext))
+}
+
+func TestNewEncryptWriter_NoRecipients(t *testing.T) {
+	_, err := encryption.NewEncryptWriter(io.Discard, nil, false)
+	require.ErrorIs(t, err, encryption.ErrNoRecipients)
+}
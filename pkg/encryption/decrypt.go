@@ -0,0 +1,113 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"filippo.io/age/armor"
+)
+
+var (
+	// ErrNoIdentities is returned when an identity file holds no usable identity.
+	ErrNoIdentities = errors.New("no age identities provided")
+	// ErrIdentityRequired is returned when an archive is age-encrypted but no
+	// identity was provided to decrypt it.
+	ErrIdentityRequired = errors.New("archive is age-encrypted, an age identity is required")
+)
+
+// binaryHeader starts the header of a binary age file.
+const binaryHeader = "age-encryption.org/"
+
+// sshKeyMarker starts a PEM-encoded SSH private key.
+const sshKeyMarker = "-----BEGIN"
+
+// maxLeadingWhitespace is the whitespace age tolerates before an armor header.
+const maxLeadingWhitespace = 1024
+
+// ParseIdentitiesFile reads the private identities used to decrypt archives
+// from a file: native age identities (AGE-SECRET-KEY-1..., one per line, as
+// written by age-keygen) or an unencrypted SSH private key (ssh-ed25519 or
+// ssh-rsa). Passphrase-protected SSH keys are rejected.
+func ParseIdentitiesFile(path string) ([]age.Identity, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from operator config
+	if err != nil {
+		return nil, fmt.Errorf("read identity file %s: %w", path, err)
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(data)), sshKeyMarker) {
+		id, err := agessh.ParseIdentity(data)
+		if err != nil {
+			return nil, fmt.Errorf("identity file %s: parse SSH private key: %w", path, err)
+		}
+		return []age.Identity{id}, nil
+	}
+
+	identities, err := age.ParseIdentities(bytes.NewReader(data))
+	if err != nil {
+		if strings.TrimSpace(stripComments(string(data))) == "" {
+			return nil, fmt.Errorf("identity file %s: %w", path, ErrNoIdentities)
+		}
+		return nil, fmt.Errorf("identity file %s: %w", path, err)
+	}
+	return identities, nil
+}
+
+// stripComments drops the comment lines (starting with '#') of an identity file.
+func stripComments(s string) string {
+	var b strings.Builder
+	for line := range strings.Lines(s) {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// IsEncrypted reports whether the stream buffered by r starts with an age
+// header, binary or ASCII-armored. It only peeks at r, nothing is consumed.
+func IsEncrypted(r *bufio.Reader) (bool, error) {
+	peek, err := r.Peek(maxLeadingWhitespace + len(armor.Header))
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return false, fmt.Errorf("read archive header: %w", err)
+	}
+	if bytes.HasPrefix(peek, []byte(binaryHeader)) {
+		return true, nil
+	}
+	return bytes.HasPrefix(bytes.TrimLeft(peek, " \t\r\n"), []byte(armor.Header)), nil
+}
+
+// NewDecryptReader returns a reader of the plaintext of r. An age-encrypted
+// stream, binary or ASCII-armored, is decrypted on the fly with identities;
+// any other stream is returned as is, so plain archives need no identity.
+// The encrypted result reports whether r was encrypted. ErrIdentityRequired
+// is returned for an encrypted stream when identities is empty.
+//
+// This is the streaming counterpart of encryptStream: the plaintext is only
+// ever held in the returned reader's buffers.
+func NewDecryptReader(r io.Reader, identities []age.Identity) (io.Reader, bool, error) {
+	br := bufio.NewReader(r)
+	encrypted, err := IsEncrypted(br)
+	if err != nil || !encrypted {
+		return br, false, err
+	}
+	if len(identities) == 0 {
+		return nil, true, ErrIdentityRequired
+	}
+
+	src := io.Reader(br)
+	if peek, _ := br.Peek(len(binaryHeader)); !bytes.Equal(peek, []byte(binaryHeader)) {
+		src = armor.NewReader(br)
+	}
+	plain, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return nil, true, fmt.Errorf("decrypt archive: %w", err)
+	}
+	return plain, true, nil
+}
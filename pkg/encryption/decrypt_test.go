@@ -0,0 +1,136 @@
+package encryption_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"github.com/sgaunet/gitlab-backup/pkg/encryption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// encryptSample encrypts sampleArchive to recipient.
+func encryptSample(t *testing.T, recipient age.Recipient, armorEnabled bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := encryption.NewEncryptWriter(&buf, []age.Recipient{recipient}, armorEnabled)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, sampleArchive)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// decryptAll reads the plaintext of data through NewDecryptReader.
+func decryptAll(t *testing.T, data []byte, identities []age.Identity) (string, bool) {
+	t.Helper()
+	r, encrypted, err := encryption.NewDecryptReader(bytes.NewReader(data), identities)
+	require.NoError(t, err)
+	plain, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(plain), encrypted
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "identity")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestNewDecryptReader_RoundTrip(t *testing.T) {
+	id := newIdentity(t)
+	identities, err := encryption.ParseIdentitiesFile(writeFile(t, "# created by age-keygen\n"+id.String()+"\n"))
+	require.NoError(t, err)
+
+	for _, armorEnabled := range []bool{false, true} {
+		plain, encrypted := decryptAll(t, encryptSample(t, id.Recipient(), armorEnabled), identities)
+		assert.True(t, encrypted, "armor=%v", armorEnabled)
+		assert.Equal(t, sampleArchive, plain, "armor=%v", armorEnabled)
+	}
+
+	// Armored files may start with whitespace.
+	armored := append([]byte("\n  \n"), encryptSample(t, id.Recipient(), true)...)
+	plain, encrypted := decryptAll(t, armored, identities)
+	assert.True(t, encrypted)
+	assert.Equal(t, sampleArchive, plain)
+}
+
+func TestNewDecryptReader_PlainPassthrough(t *testing.T) {
+	plain, encrypted := decryptAll(t, []byte(sampleArchive), nil)
+	assert.False(t, encrypted)
+	assert.Equal(t, sampleArchive, plain)
+
+	plain, encrypted = decryptAll(t, nil, nil)
+	assert.False(t, encrypted)
+	assert.Empty(t, plain)
+}
+
+func TestNewDecryptReader_Errors(t *testing.T) {
+	data := encryptSample(t, newIdentity(t).Recipient(), false)
+
+	_, encrypted, err := encryption.NewDecryptReader(bytes.NewReader(data), nil)
+	require.ErrorIs(t, err, encryption.ErrIdentityRequired)
+	assert.True(t, encrypted)
+
+	_, _, err = encryption.NewDecryptReader(bytes.NewReader(data), []age.Identity{newIdentity(t)})
+	var noMatch *age.NoIdentityMatchError
+	require.ErrorAs(t, err, &noMatch)
+}
+
+func TestIsEncrypted_DoesNotConsume(t *testing.T) {
+	data := encryptSample(t, newIdentity(t).Recipient(), true)
+	r := bufio.NewReader(bytes.NewReader(data))
+	encrypted, err := encryption.IsEncrypted(r)
+	require.NoError(t, err)
+	assert.True(t, encrypted)
+	rest, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, rest)
+}
+
+func TestParseIdentitiesFile_SSHKey(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+	identities, err := encryption.ParseIdentitiesFile(writeFile(t, string(pem.EncodeToMemory(block))))
+	require.NoError(t, err)
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	recipient, err := agessh.ParseRecipient(string(ssh.MarshalAuthorizedKey(sshPub)))
+	require.NoError(t, err)
+	plain, encrypted := decryptAll(t, encryptSample(t, recipient, false), identities)
+	assert.True(t, encrypted)
+	assert.Equal(t, sampleArchive, plain)
+
+	// Passphrase-protected keys cannot be used unattended.
+	block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("secret"))
+	require.NoError(t, err)
+	_, err = encryption.ParseIdentitiesFile(writeFile(t, string(pem.EncodeToMemory(block))))
+	require.ErrorContains(t, err, "passphrase")
+}
+
+func TestParseIdentitiesFile_Errors(t *testing.T) {
+	_, err := encryption.ParseIdentitiesFile(writeFile(t, "# no key yet\n\n"))
+	require.ErrorIs(t, err, encryption.ErrNoIdentities)
+
+	_, err = encryption.ParseIdentitiesFile(writeFile(t, "AGE-SECRET-KEY-1NOTAKEY\n"))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, encryption.ErrNoIdentities)
+
+	_, err = encryption.ParseIdentitiesFile(filepath.Join(t.TempDir(), "missing"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	assert.Contains(t, err.Error(), "missing")
+}
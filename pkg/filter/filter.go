@@ -0,0 +1,217 @@
+// Package filter decides which projects of a group or user namespace are
+// backed up.
+//
+// Rules are evaluated in a fixed order and the first rule that leaves a
+// project out gives the reason reported in the run summary. A project left
+// out by no rule is backed up. Rules with a zero value are disabled, so the
+// zero Rules keep every project.
+//
+// Path patterns match the full path of a project (e.g. "group/sub/project").
+// A pattern is a glob, where "*" and "?" stay within one path segment and
+// "**" spans any number of segments, unless it starts with "regex:", in which
+// case the rest is a Go regular expression matched anywhere in the path.
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RegexPrefix marks a path pattern as a regular expression.
+const RegexPrefix = "regex:"
+
+// Visibility levels accepted in Rules.Visibility.
+const (
+	VisibilityPrivate  = "private"
+	VisibilityInternal = "internal"
+	VisibilityPublic   = "public"
+)
+
+const (
+	hoursPerDay = 24
+	bytesPerMB  = 1024 * 1024
+)
+
+// ErrInvalidRule is returned by New when a rule cannot be compiled.
+var ErrInvalidRule = errors.New("invalid filter rule")
+
+// Rules holds the filter settings. Empty lists and zero values disable a rule.
+type Rules struct {
+	IncludePaths    []string // keep only projects matching at least one pattern
+	ExcludePaths    []string // leave out projects matching any pattern
+	IncludeTopics   []string // keep only projects with at least one of these topics
+	ExcludeTopics   []string // leave out projects with any of these topics
+	Visibility      []string // keep only projects with one of these visibility levels
+	ExcludeForks    bool     // leave out forks
+	ExcludeMirrors  bool     // leave out pull mirrors
+	MaxInactiveDays int      // leave out projects without activity for more than N days
+	MaxSizeMB       int64    // leave out projects whose estimated size exceeds N MiB
+}
+
+// Enabled reports whether at least one rule is set.
+func (r Rules) Enabled() bool {
+	return len(r.IncludePaths) > 0 || len(r.ExcludePaths) > 0 ||
+		len(r.IncludeTopics) > 0 || len(r.ExcludeTopics) > 0 ||
+		len(r.Visibility) > 0 || r.ExcludeForks || r.ExcludeMirrors ||
+		r.MaxInactiveDays > 0 || r.MaxSizeMB > 0
+}
+
+// Project holds the project attributes the rules look at.
+type Project struct {
+	FullPath       string
+	Topics         []string
+	Visibility     string
+	Forked         bool
+	Mirror         bool
+	LastActivityAt time.Time // zero when unknown; the inactivity rule is then skipped
+	Size           int64     // bytes, zero when unknown; the size rule is then skipped
+}
+
+// pattern is a compiled path pattern, with its source kept for reasons.
+type pattern struct {
+	source string
+	re     *regexp.Regexp
+}
+
+// Filter is a compiled set of rules. The zero value is not usable; call New.
+type Filter struct {
+	rules    Rules
+	includes []pattern
+	excludes []pattern
+}
+
+// New compiles rules. It fails on a malformed pattern or an unknown
+// visibility level.
+func New(rules Rules) (*Filter, error) {
+	includes, err := compilePatterns(rules.IncludePaths)
+	if err != nil {
+		return nil, err
+	}
+	excludes, err := compilePatterns(rules.ExcludePaths)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range rules.Visibility {
+		switch v {
+		case VisibilityPrivate, VisibilityInternal, VisibilityPublic:
+		default:
+			return nil, fmt.Errorf("%w: unknown visibility %q (want private, internal or public)", ErrInvalidRule, v)
+		}
+	}
+	if rules.MaxInactiveDays < 0 || rules.MaxSizeMB < 0 {
+		return nil, fmt.Errorf("%w: maxInactiveDays and maxSizeMB must not be negative", ErrInvalidRule)
+	}
+	return &Filter{rules: rules, includes: includes, excludes: excludes}, nil
+}
+
+// Exclude reports why p is left out, or "" when p is backed up. now is the
+// reference time of the inactivity rule.
+func (f *Filter) Exclude(p Project, now time.Time) string {
+	for _, reason := range []func(Project, time.Time) string{f.pathReason, f.topicReason, f.attributeReason} {
+		if r := reason(p, now); r != "" {
+			return r
+		}
+	}
+	return ""
+}
+
+// pathReason applies the include and exclude path patterns.
+func (f *Filter) pathReason(p Project, _ time.Time) string {
+	if len(f.includes) > 0 && !slices.ContainsFunc(f.includes, func(pt pattern) bool {
+		return pt.re.MatchString(p.FullPath)
+	}) {
+		return "path matches no include pattern"
+	}
+	for _, pt := range f.excludes {
+		if pt.re.MatchString(p.FullPath) {
+			return fmt.Sprintf("path matches exclude pattern %q", pt.source)
+		}
+	}
+	return ""
+}
+
+// topicReason applies the include and exclude topics.
+func (f *Filter) topicReason(p Project, _ time.Time) string {
+	if len(f.rules.IncludeTopics) > 0 && !slices.ContainsFunc(p.Topics, func(t string) bool {
+		return slices.Contains(f.rules.IncludeTopics, t)
+	}) {
+		return "has none of the include topics"
+	}
+	for _, t := range p.Topics {
+		if slices.Contains(f.rules.ExcludeTopics, t) {
+			return fmt.Sprintf("has exclude topic %q", t)
+		}
+	}
+	return ""
+}
+
+// attributeReason applies the visibility, fork, mirror, inactivity and size rules.
+func (f *Filter) attributeReason(p Project, now time.Time) string {
+	switch {
+	case len(f.rules.Visibility) > 0 && !slices.Contains(f.rules.Visibility, p.Visibility):
+		return fmt.Sprintf("visibility %q not included", p.Visibility)
+	case f.rules.ExcludeForks && p.Forked:
+		return "fork"
+	case f.rules.ExcludeMirrors && p.Mirror:
+		return "mirror"
+	}
+	if f.rules.MaxInactiveDays > 0 && !p.LastActivityAt.IsZero() {
+		if days := int(now.Sub(p.LastActivityAt).Hours() / hoursPerDay); days > f.rules.MaxInactiveDays {
+			return fmt.Sprintf("inactive for %d days (max %d)", days, f.rules.MaxInactiveDays)
+		}
+	}
+	if f.rules.MaxSizeMB > 0 && p.Size > f.rules.MaxSizeMB*bytesPerMB {
+		return fmt.Sprintf("size %d MiB above %d MiB", p.Size/bytesPerMB, f.rules.MaxSizeMB)
+	}
+	return ""
+}
+
+// compilePatterns compiles path patterns, see the package documentation.
+func compilePatterns(sources []string) ([]pattern, error) {
+	patterns := make([]pattern, 0, len(sources))
+	for _, src := range sources {
+		expr, isRegex := strings.CutPrefix(src, RegexPrefix)
+		if !isRegex {
+			expr = globToRegexp(src)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: path pattern %q: %w", ErrInvalidRule, src, err)
+		}
+		patterns = append(patterns, pattern{source: src, re: re})
+	}
+	return patterns, nil
+}
+
+// globToRegexp translates a path glob into an anchored regular expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				// "**/" also matches no directory at all.
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
@@ -0,0 +1,136 @@
+package filter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_Enabled(t *testing.T) {
+	assert.False(t, filter.Rules{}.Enabled())
+	assert.True(t, filter.Rules{ExcludeForks: true}.Enabled())
+	assert.True(t, filter.Rules{ExcludePaths: []string{"a/*"}}.Enabled())
+}
+
+func TestFilter_Exclude(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	project := filter.Project{
+		FullPath:       "grp/sub/app",
+		Topics:         []string{"go", "backend"},
+		Visibility:     filter.VisibilityInternal,
+		LastActivityAt: now.AddDate(0, 0, -10),
+		Size:           300 * 1024 * 1024,
+	}
+
+	tests := []struct {
+		name   string
+		rules  filter.Rules
+		modify func(p *filter.Project)
+		reason string
+	}{
+		{name: "no rules keeps everything", rules: filter.Rules{}},
+		{name: "include glob within segment", rules: filter.Rules{IncludePaths: []string{"grp/*/app"}}},
+		{
+			name:   "single star does not cross segments",
+			rules:  filter.Rules{IncludePaths: []string{"grp/*"}},
+			reason: "path matches no include pattern",
+		},
+		{name: "double star crosses segments", rules: filter.Rules{IncludePaths: []string{"grp/**"}}},
+		{name: "double star slash matches no segment", rules: filter.Rules{IncludePaths: []string{"**/app"}}},
+		{
+			name:   "exclude glob",
+			rules:  filter.Rules{ExcludePaths: []string{"other/*", "grp/sub/*"}},
+			reason: `path matches exclude pattern "grp/sub/*"`,
+		},
+		{
+			name:   "exclude regex matches anywhere",
+			rules:  filter.Rules{ExcludePaths: []string{"regex:/s[uv]b/"}},
+			reason: `path matches exclude pattern "regex:/s[uv]b/"`,
+		},
+		{name: "include topic", rules: filter.Rules{IncludeTopics: []string{"frontend", "go"}}},
+		{
+			name:   "missing include topic",
+			rules:  filter.Rules{IncludeTopics: []string{"frontend"}},
+			reason: "has none of the include topics",
+		},
+		{
+			name:   "exclude topic",
+			rules:  filter.Rules{ExcludeTopics: []string{"backend"}},
+			reason: `has exclude topic "backend"`,
+		},
+		{
+			name:   "visibility",
+			rules:  filter.Rules{Visibility: []string{filter.VisibilityPublic}},
+			reason: `visibility "internal" not included`,
+		},
+		{name: "fork kept without rule", modify: func(p *filter.Project) { p.Forked = true }},
+		{
+			name:   "fork",
+			rules:  filter.Rules{ExcludeForks: true},
+			modify: func(p *filter.Project) { p.Forked = true },
+			reason: "fork",
+		},
+		{
+			name:   "mirror",
+			rules:  filter.Rules{ExcludeMirrors: true},
+			modify: func(p *filter.Project) { p.Mirror = true },
+			reason: "mirror",
+		},
+		{name: "recent activity", rules: filter.Rules{MaxInactiveDays: 30}},
+		{
+			name:   "inactive",
+			rules:  filter.Rules{MaxInactiveDays: 7},
+			reason: "inactive for 10 days (max 7)",
+		},
+		{
+			name:   "unknown activity is kept",
+			rules:  filter.Rules{MaxInactiveDays: 7},
+			modify: func(p *filter.Project) { p.LastActivityAt = time.Time{} },
+		},
+		{name: "size below limit", rules: filter.Rules{MaxSizeMB: 500}},
+		{
+			name:   "size above limit",
+			rules:  filter.Rules{MaxSizeMB: 100},
+			reason: "size 300 MiB above 100 MiB",
+		},
+		{
+			name:   "unknown size is kept",
+			rules:  filter.Rules{MaxSizeMB: 100},
+			modify: func(p *filter.Project) { p.Size = 0 },
+		},
+		{
+			name:   "path rules come first",
+			rules:  filter.Rules{ExcludePaths: []string{"grp/**"}, ExcludeTopics: []string{"go"}},
+			reason: `path matches exclude pattern "grp/**"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := filter.New(tc.rules)
+			require.NoError(t, err)
+			p := project
+			if tc.modify != nil {
+				tc.modify(&p)
+			}
+			assert.Equal(t, tc.reason, f.Exclude(p, now))
+		})
+	}
+}
+
+func TestNew_InvalidRules(t *testing.T) {
+	for name, rules := range map[string]filter.Rules{
+		"bad regex":        {ExcludePaths: []string{"regex:("}},
+		"bad visibility":   {Visibility: []string{"secret"}},
+		"negative days":    {MaxInactiveDays: -1},
+		"negative size":    {MaxSizeMB: -1},
+		"bad include path": {IncludePaths: []string{"regex:[a-"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := filter.New(rules)
+			require.ErrorIs(t, err, filter.ErrInvalidRule)
+		})
+	}
+}
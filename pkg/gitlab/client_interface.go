@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
@@ -13,10 +14,15 @@ import (
 //go:generate go tool github.com/matryer/moq -out mocks/groups.go -pkg mocks . GroupsService
 //go:generate go tool github.com/matryer/moq -out mocks/projects.go -pkg mocks . ProjectsService
 //go:generate go tool github.com/matryer/moq -out mocks/project_import_export.go -pkg mocks . ProjectImportExportService
+//go:generate go tool github.com/matryer/moq -out mocks/group_import_export.go -pkg mocks . GroupImportExportService
 //go:generate go tool github.com/matryer/moq -out mocks/labels.go -pkg mocks . LabelsService
 //go:generate go tool github.com/matryer/moq -out mocks/issues.go -pkg mocks . IssuesService
 //go:generate go tool github.com/matryer/moq -out mocks/notes.go -pkg mocks . NotesService
 //go:generate go tool github.com/matryer/moq -out mocks/commits.go -pkg mocks . CommitsService
+//go:generate go tool github.com/matryer/moq -out mocks/branches.go -pkg mocks . BranchesService
+//go:generate go tool github.com/matryer/moq -out mocks/tags.go -pkg mocks . TagsService
+//go:generate go tool github.com/matryer/moq -out mocks/merge_requests.go -pkg mocks . MergeRequestsService
+//go:generate go tool github.com/matryer/moq -out mocks/milestones.go -pkg mocks . MilestonesService
 
 // GitLabClient defines the interface for GitLab client operations.
 //
@@ -25,10 +31,15 @@ type GitLabClient interface {
 	Groups() GroupsService
 	Projects() ProjectsService
 	ProjectImportExport() ProjectImportExportService
+	GroupImportExport() GroupImportExportService
 	Labels() LabelsService
 	Issues() IssuesService
 	Notes() NotesService
 	Commits() CommitsService
+	Branches() BranchesService
+	Tags() TagsService
+	MergeRequests() MergeRequestsService
+	Milestones() MilestonesService
 }
 
 // GroupsService defines the interface for GitLab Groups API operations.
@@ -39,12 +50,22 @@ type GroupsService interface {
 	ListSubGroups(ctx context.Context, gid any, opt *gitlab.ListSubGroupsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Group, *gitlab.Response, error)
 	//nolint:lll // GitLab API method signatures are inherently long
 	ListGroupProjects(ctx context.Context, gid any, opt *gitlab.ListGroupProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error)
+	//nolint:lll // GitLab API method signatures are inherently long
+	CreateGroup(ctx context.Context, opt *gitlab.CreateGroupOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Group, *gitlab.Response, error)
 }
 
 // ProjectsService defines the interface for GitLab Projects API operations.
 type ProjectsService interface {
 	//nolint:lll // GitLab API method signatures are inherently long
 	GetProject(ctx context.Context, pid any, opt *gitlab.GetProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Project, *gitlab.Response, error)
+	//nolint:lll // GitLab API method signatures are inherently long
+	ListUserProjects(ctx context.Context, uid any, opt *gitlab.ListProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error)
+	//nolint:lll // GitLab API method signatures are inherently long
+	ListProjects(ctx context.Context, opt *gitlab.ListProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error)
+	//nolint:lll // GitLab API method signatures are inherently long
+	EditProject(ctx context.Context, pid any, opt *gitlab.EditProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Project, *gitlab.Response, error)
+	//nolint:lll // GitLab API method signatures are inherently long
+	DeleteProject(ctx context.Context, pid any, opt *gitlab.DeleteProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
 }
 
 // ProjectImportExportService defines the interface for GitLab Project Import/Export API operations.
@@ -59,6 +80,34 @@ type ProjectImportExportService interface {
 	ImportFromFile(ctx context.Context, archive io.Reader, opt *gitlab.ImportFileOptions, options ...gitlab.RequestOptionFunc) (*gitlab.ImportStatus, *gitlab.Response, error)
 	//nolint:lll // GitLab API method signatures are inherently long
 	ImportStatus(ctx context.Context, pid any, options ...gitlab.RequestOptionFunc) (*gitlab.ImportStatus, *gitlab.Response, error)
+	//nolint:lll // GitLab API method signatures are inherently long
+	ImportFailedRelations(ctx context.Context, pid any, options ...gitlab.RequestOptionFunc) ([]*FailedRelation, *gitlab.Response, error)
+}
+
+// FailedRelation is a relation of a project export (an issue, a merge
+// request...) that GitLab failed to import, as listed in the failed_relations
+// of the import status. client-go's ImportStatus does not decode them.
+//
+//nolint:tagliatelle // GitLab API format
+type FailedRelation struct {
+	ID               int64      `json:"id"`
+	CreatedAt        *time.Time `json:"created_at"`
+	ExceptionClass   string     `json:"exception_class"`
+	ExceptionMessage string     `json:"exception_message"`
+	Source           string     `json:"source"`
+	RelationName     string     `json:"relation_name"`
+}
+
+// GroupImportExportService defines the interface for GitLab Group Import/Export API operations.
+type GroupImportExportService interface {
+	//nolint:lll // GitLab API method signatures are inherently long
+	ScheduleExport(ctx context.Context, gid any, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
+	//nolint:lll // GitLab API method signatures are inherently long
+	ExportDownloadStream(ctx context.Context, gid any, w io.Writer, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
+	//nolint:lll // GitLab API method signatures are inherently long
+	ImportFile(ctx context.Context, opt *gitlab.GroupImportFileOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
+	//nolint:lll // GitLab API method signatures are inherently long
+	ImportFromReader(ctx context.Context, archive io.Reader, opt *gitlab.GroupImportFileOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
 }
 
 // LabelsService defines the interface for GitLab Labels API operations.
@@ -91,6 +140,32 @@ type CommitsService interface {
 	ListCommits(ctx context.Context, pid any, opt *gitlab.ListCommitsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Commit, *gitlab.Response, error)
 }
 
+// BranchesService defines the interface for GitLab Branches API operations.
+type BranchesService interface {
+	//nolint:lll // GitLab API method signatures are inherently long
+	ListBranches(ctx context.Context, pid any, opt *gitlab.ListBranchesOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Branch, *gitlab.Response, error)
+	//nolint:lll // GitLab API method signatures are inherently long
+	GetBranch(ctx context.Context, pid any, branch string, options ...gitlab.RequestOptionFunc) (*gitlab.Branch, *gitlab.Response, error)
+}
+
+// TagsService defines the interface for GitLab Tags API operations.
+type TagsService interface {
+	//nolint:lll // GitLab API method signatures are inherently long
+	ListTags(ctx context.Context, pid any, opt *gitlab.ListTagsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Tag, *gitlab.Response, error)
+}
+
+// MergeRequestsService defines the interface for GitLab Merge Requests API operations.
+type MergeRequestsService interface {
+	//nolint:lll // GitLab API method signatures are inherently long
+	ListProjectMergeRequests(ctx context.Context, pid any, opt *gitlab.ListProjectMergeRequestsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.BasicMergeRequest, *gitlab.Response, error)
+}
+
+// MilestonesService defines the interface for GitLab Milestones API operations.
+type MilestonesService interface {
+	//nolint:lll // GitLab API method signatures are inherently long
+	ListMilestones(ctx context.Context, pid any, opt *gitlab.ListMilestonesOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Milestone, *gitlab.Response, error)
+}
+
 // gitlabClientWrapper wraps the official GitLab client to implement our interface.
 type gitlabClientWrapper struct {
 	client *gitlab.Client
@@ -124,6 +199,13 @@ func (w *gitlabClientWrapper) ProjectImportExport() ProjectImportExportService {
 	return &projectImportExportServiceWrapper{service: w.client.ProjectImportExport, client: w.client}
 }
 
+// GroupImportExport returns the group import/export service.
+//
+//nolint:ireturn // Interface return is intentional for dependency injection
+func (w *gitlabClientWrapper) GroupImportExport() GroupImportExportService {
+	return &groupImportExportServiceWrapper{service: w.client.GroupImportExport, client: w.client}
+}
+
 // Labels returns the labels service.
 //
 //nolint:ireturn // Interface return is intentional for dependency injection
@@ -152,6 +234,34 @@ func (w *gitlabClientWrapper) Commits() CommitsService {
 	return &commitsServiceWrapper{service: w.client.Commits}
 }
 
+// Branches returns the branches service.
+//
+//nolint:ireturn // Interface return is intentional for dependency injection
+func (w *gitlabClientWrapper) Branches() BranchesService {
+	return &branchesServiceWrapper{service: w.client.Branches}
+}
+
+// Tags returns the tags service.
+//
+//nolint:ireturn // Interface return is intentional for dependency injection
+func (w *gitlabClientWrapper) Tags() TagsService {
+	return &tagsServiceWrapper{service: w.client.Tags}
+}
+
+// MergeRequests returns the merge requests service.
+//
+//nolint:ireturn // Interface return is intentional for dependency injection
+func (w *gitlabClientWrapper) MergeRequests() MergeRequestsService {
+	return &mergeRequestsServiceWrapper{service: w.client.MergeRequests}
+}
+
+// Milestones returns the milestones service.
+//
+//nolint:ireturn // Interface return is intentional for dependency injection
+func (w *gitlabClientWrapper) Milestones() MilestonesService {
+	return &milestonesServiceWrapper{service: w.client.Milestones}
+}
+
 // groupsServiceWrapper wraps the official GitLab groups service.
 type groupsServiceWrapper struct {
 	service gitlab.GroupsServiceInterface
@@ -190,6 +300,21 @@ func (w *groupsServiceWrapper) ListGroupProjects(ctx context.Context, gid any, o
 	})
 }
 
+// CreateGroup is not retried: a request that timed out may still have created the group.
+//
+//nolint:lll // Wrapper method with long signature
+func (w *groupsServiceWrapper) CreateGroup(_ context.Context, opt *gitlab.CreateGroupOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Group, *gitlab.Response, error) {
+	group, resp, err := w.service.CreateGroup(opt, options...)
+	if err != nil {
+		path := "<nil>"
+		if opt != nil && opt.Path != nil {
+			path = *opt.Path
+		}
+		return nil, resp, fmt.Errorf("failed to create group %s: %w", path, err)
+	}
+	return group, resp, nil
+}
+
 // projectsServiceWrapper wraps the official GitLab projects service.
 type projectsServiceWrapper struct {
 	service gitlab.ProjectsServiceInterface
@@ -206,6 +331,50 @@ func (w *projectsServiceWrapper) GetProject(ctx context.Context, pid any, opt *g
 	})
 }
 
+//nolint:lll // Wrapper method with long signature
+func (w *projectsServiceWrapper) ListUserProjects(ctx context.Context, uid any, opt *gitlab.ListProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+	return retryWithResponse(ctx, fmt.Sprintf("list projects of user %v", uid), func() ([]*gitlab.Project, *gitlab.Response, error) {
+		projects, resp, err := w.service.ListUserProjects(uid, opt, options...)
+		if err != nil {
+			return nil, resp, fmt.Errorf("failed to list projects of user %v: %w", uid, err)
+		}
+		return projects, resp, nil
+	})
+}
+
+//nolint:lll // Wrapper method with long signature
+func (w *projectsServiceWrapper) ListProjects(ctx context.Context, opt *gitlab.ListProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+	return retryWithResponse(ctx, "list projects", func() ([]*gitlab.Project, *gitlab.Response, error) {
+		projects, resp, err := w.service.ListProjects(opt, options...)
+		if err != nil {
+			return nil, resp, fmt.Errorf("failed to list projects: %w", err)
+		}
+		return projects, resp, nil
+	})
+}
+
+// EditProject is not retried: a rename that reached GitLab must not be repeated.
+//
+//nolint:lll // Wrapper method with long signature
+func (w *projectsServiceWrapper) EditProject(_ context.Context, pid any, opt *gitlab.EditProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Project, *gitlab.Response, error) {
+	project, resp, err := w.service.EditProject(pid, opt, options...)
+	if err != nil {
+		return nil, resp, fmt.Errorf("failed to edit project %v: %w", pid, err)
+	}
+	return project, resp, nil
+}
+
+// DeleteProject is not retried: a request that timed out may still have scheduled the deletion.
+//
+//nolint:lll // Wrapper method with long signature
+func (w *projectsServiceWrapper) DeleteProject(_ context.Context, pid any, opt *gitlab.DeleteProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
+	resp, err := w.service.DeleteProject(pid, opt, options...)
+	if err != nil {
+		return resp, fmt.Errorf("failed to delete project %v: %w", pid, err)
+	}
+	return resp, nil
+}
+
 // projectImportExportServiceWrapper wraps the official GitLab project import/export service.
 type projectImportExportServiceWrapper struct {
 	service gitlab.ProjectImportExportServiceInterface
@@ -289,6 +458,102 @@ func (w *projectImportExportServiceWrapper) ImportStatus(ctx context.Context, pi
 	})
 }
 
+// ImportFailedRelations returns the relations the last import of the project
+// failed to import, read from the failed_relations of its import status.
+//
+//nolint:lll // Wrapper method with long signature
+func (w *projectImportExportServiceWrapper) ImportFailedRelations(ctx context.Context, pid any, options ...gitlab.RequestOptionFunc) ([]*FailedRelation, *gitlab.Response, error) {
+	return retryWithResponse(ctx, fmt.Sprintf("get failed relations for project %v", pid), func() ([]*FailedRelation, *gitlab.Response, error) {
+		u := fmt.Sprintf("projects/%v/import", pid)
+		req, err := w.client.NewRequest(http.MethodGet, u, nil, options)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create import status request for project %v: %w", pid, err)
+		}
+		var status struct {
+			FailedRelations []*FailedRelation `json:"failed_relations"` //nolint:tagliatelle // GitLab API format
+		}
+		resp, err := w.client.Do(req, &status)
+		if err != nil {
+			return nil, resp, fmt.Errorf("failed to get failed relations for project %v: %w", pid, err)
+		}
+		return status.FailedRelations, resp, nil
+	})
+}
+
+// groupImportExportServiceWrapper wraps the official GitLab group import/export service.
+type groupImportExportServiceWrapper struct {
+	service gitlab.GroupImportExportServiceInterface
+	client  *gitlab.Client
+}
+
+//nolint:lll // Wrapper method with long signature
+func (w *groupImportExportServiceWrapper) ScheduleExport(ctx context.Context, gid any, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
+	return retryResponseOnly(ctx, fmt.Sprintf("schedule export for group %v", gid), func() (*gitlab.Response, error) {
+		resp, err := w.service.ScheduleExport(gid, options...)
+		if err != nil {
+			return resp, fmt.Errorf("failed to schedule export for group %v: %w", gid, err)
+		}
+		return resp, nil
+	})
+}
+
+// ExportDownloadStream streams the finished group export to writer. Unlike
+// client-go's ExportDownload it does not buffer the whole archive in memory.
+//
+//nolint:lll // Wrapper method with long signature
+func (w *groupImportExportServiceWrapper) ExportDownloadStream(_ context.Context, gid any, writer io.Writer, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
+	u := fmt.Sprintf("groups/%v/export/download", gid)
+	req, err := w.client.NewRequest(http.MethodGet, u, nil, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download request for group %v: %w", gid, err)
+	}
+	resp, err := w.client.Do(req, writer)
+	if err != nil {
+		return resp, fmt.Errorf("failed to stream export download for group %v: %w", gid, err)
+	}
+	return resp, nil
+}
+
+//nolint:lll // Wrapper method with long signature
+func (w *groupImportExportServiceWrapper) ImportFile(_ context.Context, opt *gitlab.GroupImportFileOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
+	resp, err := w.service.ImportFile(opt, options...)
+	if err != nil {
+		path := "<nil>"
+		if opt != nil && opt.Path != nil {
+			path = *opt.Path
+		}
+		return resp, fmt.Errorf("failed to import group from file (path: %s): %w", path, err)
+	}
+	return resp, nil
+}
+
+// ImportFromReader imports a group export read from archive; opt.File is
+// ignored. Unlike client-go's ImportFile the archive does not have to be a
+// file, e.g. it can be decrypted on the fly.
+//
+//nolint:lll // Wrapper method with long signature
+func (w *groupImportExportServiceWrapper) ImportFromReader(_ context.Context, archive io.Reader, opt *gitlab.GroupImportFileOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
+	var form gitlab.GroupImportFileOptions
+	if opt != nil {
+		form = *opt
+	}
+	form.File = nil
+	req, err := w.client.UploadRequest(http.MethodPost, "groups/import", archive, "group.tar.gz",
+		gitlab.UploadFile, &form, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group import request: %w", err)
+	}
+	resp, err := w.client.Do(req, nil)
+	if err != nil {
+		path := "<nil>"
+		if form.Path != nil {
+			path = *form.Path
+		}
+		return resp, fmt.Errorf("failed to import group from stream (path: %s): %w", path, err)
+	}
+	return resp, nil
+}
+
 // labelsServiceWrapper wraps the official GitLab labels service.
 type labelsServiceWrapper struct {
 	service gitlab.LabelsServiceInterface
@@ -385,3 +650,78 @@ func (w *commitsServiceWrapper) ListCommits(ctx context.Context, pid any, opt *g
 		return commits, resp, nil
 	})
 }
+
+// branchesServiceWrapper wraps the official GitLab branches service.
+type branchesServiceWrapper struct {
+	service gitlab.BranchesServiceInterface
+}
+
+//nolint:lll // Wrapper method with long signature
+func (w *branchesServiceWrapper) ListBranches(ctx context.Context, pid any, opt *gitlab.ListBranchesOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Branch, *gitlab.Response, error) {
+	return retryWithResponse(ctx, fmt.Sprintf("list branches for project %v", pid), func() ([]*gitlab.Branch, *gitlab.Response, error) {
+		branches, resp, err := w.service.ListBranches(pid, opt, options...)
+		if err != nil {
+			return nil, resp, fmt.Errorf("failed to list branches for project %v: %w", pid, err)
+		}
+		return branches, resp, nil
+	})
+}
+
+//nolint:lll // Wrapper method with long signature
+func (w *branchesServiceWrapper) GetBranch(ctx context.Context, pid any, branch string, options ...gitlab.RequestOptionFunc) (*gitlab.Branch, *gitlab.Response, error) {
+	return retryWithResponse(ctx, fmt.Sprintf("get branch %s for project %v", branch, pid), func() (*gitlab.Branch, *gitlab.Response, error) {
+		b, resp, err := w.service.GetBranch(pid, branch, options...)
+		if err != nil {
+			return nil, resp, fmt.Errorf("failed to get branch %s for project %v: %w", branch, pid, err)
+		}
+		return b, resp, nil
+	})
+}
+
+// tagsServiceWrapper wraps the official GitLab tags service.
+type tagsServiceWrapper struct {
+	service gitlab.TagsServiceInterface
+}
+
+//nolint:lll // Wrapper method with long signature
+func (w *tagsServiceWrapper) ListTags(ctx context.Context, pid any, opt *gitlab.ListTagsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Tag, *gitlab.Response, error) {
+	return retryWithResponse(ctx, fmt.Sprintf("list tags for project %v", pid), func() ([]*gitlab.Tag, *gitlab.Response, error) {
+		tags, resp, err := w.service.ListTags(pid, opt, options...)
+		if err != nil {
+			return nil, resp, fmt.Errorf("failed to list tags for project %v: %w", pid, err)
+		}
+		return tags, resp, nil
+	})
+}
+
+// mergeRequestsServiceWrapper wraps the official GitLab merge requests service.
+type mergeRequestsServiceWrapper struct {
+	service gitlab.MergeRequestsServiceInterface
+}
+
+//nolint:lll // Wrapper method with long signature
+func (w *mergeRequestsServiceWrapper) ListProjectMergeRequests(ctx context.Context, pid any, opt *gitlab.ListProjectMergeRequestsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.BasicMergeRequest, *gitlab.Response, error) {
+	return retryWithResponse(ctx, fmt.Sprintf("list merge requests for project %v", pid), func() ([]*gitlab.BasicMergeRequest, *gitlab.Response, error) {
+		mrs, resp, err := w.service.ListProjectMergeRequests(pid, opt, options...)
+		if err != nil {
+			return nil, resp, fmt.Errorf("failed to list merge requests for project %v: %w", pid, err)
+		}
+		return mrs, resp, nil
+	})
+}
+
+// milestonesServiceWrapper wraps the official GitLab milestones service.
+type milestonesServiceWrapper struct {
+	service gitlab.MilestonesServiceInterface
+}
+
+//nolint:lll // Wrapper method with long signature
+func (w *milestonesServiceWrapper) ListMilestones(ctx context.Context, pid any, opt *gitlab.ListMilestonesOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Milestone, *gitlab.Response, error) {
+	return retryWithResponse(ctx, fmt.Sprintf("list milestones for project %v", pid), func() ([]*gitlab.Milestone, *gitlab.Response, error) {
+		milestones, resp, err := w.service.ListMilestones(pid, opt, options...)
+		if err != nil {
+			return nil, resp, fmt.Errorf("failed to list milestones for project %v: %w", pid, err)
+		}
+		return milestones, resp, nil
+	})
+}
@@ -3,6 +3,7 @@ package gitlab_test
 import (
 	"bytes"
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -47,6 +48,8 @@ func apiRouter() http.HandlerFunc {
 			writeJSON(w, http.StatusOK, `[{"id":789,"name":"sub"}]`)
 		case r.Method == http.MethodGet && path == "groups/456/projects":
 			writeJSON(w, http.StatusOK, `[{"id":1,"name":"p1"}]`)
+		case r.Method == http.MethodPost && path == "groups":
+			writeJSON(w, http.StatusCreated, `{"id":457,"name":"new","visibility":"internal"}`)
 		case r.Method == http.MethodGet && path == "projects/123/export/download":
 			w.WriteHeader(http.StatusOK)
 			_, _ = w.Write([]byte("BINARY-EXPORT"))
@@ -57,9 +60,20 @@ func apiRouter() http.HandlerFunc {
 		case r.Method == http.MethodPost && path == "projects/import":
 			writeJSON(w, http.StatusCreated, `{"id":77,"import_status":"scheduled"}`)
 		case r.Method == http.MethodGet && path == "projects/123/import":
-			writeJSON(w, http.StatusOK, `{"id":77,"import_status":"finished"}`)
+			writeJSON(w, http.StatusOK, `{"id":77,"import_status":"finished",`+
+				`"failed_relations":[{"id":1,"relation_name":"issues","exception_message":"invalid"}]}`)
 		case r.Method == http.MethodGet && path == "projects/123/repository/commits":
 			writeJSON(w, http.StatusOK, `[{"id":"abc123"}]`)
+		case r.Method == http.MethodGet && path == "projects/123/repository/branches":
+			writeJSON(w, http.StatusOK, `[{"name":"main","default":true}]`)
+		case r.Method == http.MethodGet && path == "projects/123/repository/branches/main":
+			writeJSON(w, http.StatusOK, `{"name":"main","commit":{"id":"abc123"}}`)
+		case r.Method == http.MethodGet && path == "projects/123/repository/tags":
+			writeJSON(w, http.StatusOK, `[{"name":"v1.0.0"}]`)
+		case r.Method == http.MethodGet && path == "projects/123/merge_requests":
+			writeJSON(w, http.StatusOK, `[{"id":60,"iid":6}]`)
+		case r.Method == http.MethodGet && path == "projects/123/milestones":
+			writeJSON(w, http.StatusOK, `[{"id":70,"iid":7}]`)
 		case r.Method == http.MethodGet && path == "projects/123/labels":
 			writeJSON(w, http.StatusOK, `[{"id":10,"name":"bug"}]`)
 		case r.Method == http.MethodPost && path == "projects/123/labels":
@@ -74,6 +88,10 @@ func apiRouter() http.HandlerFunc {
 			writeJSON(w, http.StatusOK, `{"id":50,"iid":5,"title":"updated"}`)
 		case r.Method == http.MethodGet && path == "projects/123":
 			writeJSON(w, http.StatusOK, `{"id":123,"name":"proj"}`)
+		case r.Method == http.MethodPut && path == "projects/123":
+			writeJSON(w, http.StatusOK, `{"id":123,"name":"proj-old","path":"proj-old"}`)
+		case r.Method == http.MethodDelete && path == "projects/123":
+			writeJSON(w, http.StatusAccepted, `{"message":"202 Accepted"}`)
 		default:
 			writeJSON(w, http.StatusNotFound, `{"message":"404 Not Found"}`)
 		}
@@ -101,6 +119,43 @@ func TestWrapper_Groups(t *testing.T) {
 	assert.Equal(t, int64(1), projects[0].ID)
 }
 
+func TestWrapper_CreateGroup(t *testing.T) {
+	srv := httptest.NewServer(apiRouter())
+	defer srv.Close()
+	client := newWrappedClient(t, srv)
+
+	group, _, err := client.Groups().CreateGroup(context.Background(),
+		&gitlabAPI.CreateGroupOptions{Name: gitlabAPI.Ptr("new"), Path: gitlabAPI.Ptr("new")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(457), group.ID)
+	assert.Equal(t, gitlabAPI.InternalVisibility, group.Visibility)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusBadRequest, `{"message":"path has already been taken"}`)
+	}))
+	defer failing.Close()
+	_, _, err = newWrappedClient(t, failing).Groups().CreateGroup(context.Background(),
+		&gitlabAPI.CreateGroupOptions{Name: gitlabAPI.Ptr("taken"), Path: gitlabAPI.Ptr("taken")})
+	require.ErrorContains(t, err, "failed to create group taken")
+}
+
+func TestWrapper_EditAndDeleteProject(t *testing.T) {
+	srv := httptest.NewServer(apiRouter())
+	defer srv.Close()
+	client := newWrappedClient(t, srv)
+
+	project, _, err := client.Projects().EditProject(context.Background(), 123,
+		&gitlabAPI.EditProjectOptions{Name: gitlabAPI.Ptr("proj-old"), Path: gitlabAPI.Ptr("proj-old")})
+	require.NoError(t, err)
+	assert.Equal(t, "proj-old", project.Path)
+
+	_, err = client.Projects().DeleteProject(context.Background(), 123, nil)
+	require.NoError(t, err)
+
+	_, err = client.Projects().DeleteProject(context.Background(), 999, nil)
+	require.ErrorContains(t, err, "failed to delete project 999")
+}
+
 func TestWrapper_Projects(t *testing.T) {
 	srv := httptest.NewServer(apiRouter())
 	defer srv.Close()
@@ -159,6 +214,75 @@ func TestWrapper_ImportFromFile_ErrorEnrichesContext(t *testing.T) {
 	assert.Contains(t, err.Error(), "myns")
 }
 
+func TestWrapper_GroupImportFromReader(t *testing.T) {
+	var fields map[string]string
+	var content string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/groups/import", r.URL.Path)
+		if !assert.NoError(t, r.ParseMultipartForm(1<<20)) {
+			return
+		}
+		fields = map[string]string{}
+		for name, values := range r.MultipartForm.Value {
+			fields[name] = values[0]
+		}
+		f, _, err := r.FormFile("file")
+		if assert.NoError(t, err) {
+			data, _ := io.ReadAll(f)
+			content = string(data)
+		}
+		writeJSON(w, http.StatusAccepted, `{"message":"202 Accepted"}`)
+	}))
+	defer srv.Close()
+	client := newWrappedClient(t, srv)
+
+	_, err := client.GroupImportExport().ImportFromReader(context.Background(), strings.NewReader("group-archive"),
+		&gitlabAPI.GroupImportFileOptions{
+			Name: gitlabAPI.Ptr("restored"), Path: gitlabAPI.Ptr("restored"),
+			File: gitlabAPI.Ptr("/ignored.tar.gz"), ParentID: gitlabAPI.Ptr(int64(12)),
+		})
+	require.NoError(t, err)
+	assert.Equal(t, "group-archive", content)
+	assert.Equal(t, map[string]string{"name": "restored", "path": "restored", "parent_id": "12"}, fields)
+}
+
+func TestWrapper_ImportFromFile_OverrideParams(t *testing.T) {
+	var fields map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !assert.NoError(t, r.ParseMultipartForm(1<<20)) {
+			return
+		}
+		fields = map[string]string{}
+		for name, values := range r.MultipartForm.Value {
+			fields[name] = values[0]
+		}
+		writeJSON(w, http.StatusCreated, `{"id":77,"import_status":"scheduled"}`)
+	}))
+	defer srv.Close()
+	client := newWrappedClient(t, srv)
+
+	_, _, err := client.ProjectImportExport().ImportFromFile(context.Background(), strings.NewReader("archive"),
+		&gitlabAPI.ImportFileOptions{
+			Namespace: gitlabAPI.Ptr("ns"),
+			Path:      gitlabAPI.Ptr("proj"),
+			OverrideParams: &gitlabAPI.CreateProjectOptions{
+				Visibility:      gitlabAPI.Ptr(gitlabAPI.PrivateVisibility),
+				WikiAccessLevel: gitlabAPI.Ptr(gitlabAPI.DisabledAccessControl),
+				LFSEnabled:      gitlabAPI.Ptr(false),
+				DefaultBranch:   gitlabAPI.Ptr("main"),
+			},
+		})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"namespace":                          "ns",
+		"path":                               "proj",
+		"override_params[visibility]":        "private",
+		"override_params[wiki_access_level]": "disabled",
+		"override_params[lfs_enabled]":       "false",
+		"override_params[default_branch]":    "main",
+	}, fields)
+}
+
 func TestWrapper_LabelsIssuesNotesCommits(t *testing.T) {
 	srv := httptest.NewServer(apiRouter())
 	defer srv.Close()
@@ -202,6 +326,52 @@ func TestWrapper_LabelsIssuesNotesCommits(t *testing.T) {
 	assert.Equal(t, "abc123", commits[0].ID)
 }
 
+func TestWrapper_BranchesTagsMergeRequestsMilestones(t *testing.T) {
+	srv := httptest.NewServer(apiRouter())
+	defer srv.Close()
+	client := newWrappedClient(t, srv)
+	ctx := context.Background()
+
+	branches, _, err := client.Branches().ListBranches(ctx, 123, nil)
+	require.NoError(t, err)
+	require.Len(t, branches, 1)
+	assert.True(t, branches[0].Default)
+
+	branch, _, err := client.Branches().GetBranch(ctx, 123, "main")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", branch.Commit.ID)
+
+	_, _, err = client.Branches().GetBranch(ctx, 123, "gone")
+	require.ErrorContains(t, err, "failed to get branch gone for project 123")
+
+	tags, _, err := client.Tags().ListTags(ctx, 123, nil)
+	require.NoError(t, err)
+	assert.Len(t, tags, 1)
+
+	mrs, _, err := client.MergeRequests().ListProjectMergeRequests(ctx, 123, nil)
+	require.NoError(t, err)
+	assert.Len(t, mrs, 1)
+
+	milestones, _, err := client.Milestones().ListMilestones(ctx, 123, nil)
+	require.NoError(t, err)
+	assert.Len(t, milestones, 1)
+}
+
+func TestWrapper_ImportFailedRelations(t *testing.T) {
+	srv := httptest.NewServer(apiRouter())
+	defer srv.Close()
+	client := newWrappedClient(t, srv)
+
+	failed, _, err := client.ProjectImportExport().ImportFailedRelations(context.Background(), 123)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, "issues", failed[0].RelationName)
+	assert.Equal(t, "invalid", failed[0].ExceptionMessage)
+
+	_, _, err = client.ProjectImportExport().ImportFailedRelations(context.Background(), 999)
+	require.ErrorContains(t, err, "failed to get failed relations for project 999")
+}
+
 func TestWrapper_RetryThenSuccess(t *testing.T) {
 	// First response is a retryable 500; the retry wrapper must recover on the
 	// second attempt and return the successful payload.
@@ -195,6 +195,7 @@ type Service struct {
 	rateLimitDownloadAPI  *rate.Limiter
 	rateLimitExportAPI    *rate.Limiter
 	rateLimitImportAPI    *rate.Limiter
+	groupDownloadLimiter  *rate.Limiter // group export downloads double as status checks
 	exportTimeoutDuration time.Duration
 	exportCheckInterval   time.Duration
 }
@@ -238,6 +239,10 @@ func NewGitlabServiceWithTimeout(timeoutMins int) *Service {
 			rate.Every(constants.ImportRateLimitIntervalSeconds*time.Second),
 			constants.ImportRateLimitBurst,
 		),
+		groupDownloadLimiter: rate.NewLimiter(
+			rate.Every(constants.GroupDownloadRateLimitIntervalSeconds*time.Second),
+			constants.GroupDownloadRateLimitBurst,
+		),
 	}
 	return gs
 }
@@ -280,6 +285,40 @@ func WithRateLimiters(download, export, importer *rate.Limiter) ServiceOption {
 	}
 }
 
+// WithGroupDownloadRateLimiter overrides the group export download rate limiter.
+// A nil limiter is ignored, keeping the default.
+func WithGroupDownloadRateLimiter(download *rate.Limiter) ServiceOption {
+	return func(s *Service) {
+		if download != nil {
+			s.groupDownloadLimiter = download
+		}
+	}
+}
+
+// ProjectListOptions selects the projects returned by GetProjectsOfGroup,
+// GetProjectsOfUser and GetInstanceProjects.
+type ProjectListOptions struct {
+	IncludeArchived bool // keep archived projects, left out by default
+}
+
+// ExportOptions tunes ExportProject, ExportProjectStream and ExportGroup.
+type ExportOptions struct {
+	// IncludeArchived makes ExportProject export an archived project instead
+	// of skipping it.
+	IncludeArchived bool
+	// Timeout is how long to wait for GitLab to finish the export, instead of
+	// the service export timeout. Zero or negative keeps the service timeout.
+	Timeout time.Duration
+}
+
+// exportTimeout returns the export timeout that applies with opts.
+func (r *Service) exportTimeout(opts ExportOptions) time.Duration {
+	if opts.Timeout > 0 {
+		return opts.Timeout
+	}
+	return r.exportTimeoutDuration
+}
+
 // NewServiceWithClient builds a Service around an injected GitLabClient for
 // testing and advanced wiring. It reads no environment and creates no HTTP
 // client. SetToken/SetGitlabEndpoint would replace the injected client and
@@ -301,6 +340,10 @@ func NewServiceWithClient(client GitLabClient, opts ...ServiceOption) *Service {
 			rate.Every(constants.ImportRateLimitIntervalSeconds*time.Second),
 			constants.ImportRateLimitBurst,
 		),
+		groupDownloadLimiter: rate.NewLimiter(
+			rate.Every(constants.GroupDownloadRateLimitIntervalSeconds*time.Second),
+			constants.GroupDownloadRateLimitBurst,
+		),
 	}
 	for _, opt := range opts {
 		opt(gs)
@@ -374,24 +417,24 @@ func (r *Service) GetGroup(ctx context.Context, groupID int64) (Group, error) {
 	}
 
 	return Group{
-		ID:   group.ID,
-		Name: group.Name,
+		ID:          group.ID,
+		Name:        group.Name,
+		Path:        group.Path,
+		FullPath:    group.FullPath,
+		Visibility:  string(group.Visibility),
+		Description: group.Description,
 	}, nil
 }
 
 // GetProject returns informations of the project that matches the given ID.
 func (r *Service) GetProject(ctx context.Context, projectID int64) (Project, error) {
-	project, _, err := r.client.Projects().GetProject(ctx, projectID, nil, gitlab.WithContext(ctx))
+	opt := &gitlab.GetProjectOptions{Statistics: gitlab.Ptr(true)}
+	project, _, err := r.client.Projects().GetProject(ctx, projectID, opt, gitlab.WithContext(ctx))
 	if err != nil {
 		return Project{}, fmt.Errorf("error retrieving project: %w", err)
 	}
 
-	return Project{
-		ID:           project.ID,
-		Name:         project.Name,
-		Archived:     project.Archived,
-		ExportStatus: "", // ExportStatus not available in project struct, will be fetched separately when needed
-	}, nil
+	return newProject(project), nil
 }
 
 // Client returns the underlying GitLab client for advanced operations.
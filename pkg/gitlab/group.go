@@ -11,8 +11,14 @@ import (
 // https://docs.gitlab.com/ee/api/groups.html
 // struct fields are not exhaustive - most of them won't be used.
 type Group struct {
-	ID   int64  `json:"id"`
-	Name string `json:"name"`
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	FullPath string `json:"full_path"`
+	// Visibility and Description are recorded in the run manifest so that
+	// gitlab-restore can recreate the group.
+	Visibility  string `json:"visibility"`
+	Description string `json:"description"`
 }
 
 // GetSubgroups returns the list of subgroups of the group.
@@ -36,8 +42,10 @@ func (s *Service) GetSubgroups(ctx context.Context, groupID int64) ([]Group, err
 		// Convert to our Group type
 		for _, sg := range subgroups {
 			allSubgroups = append(allSubgroups, Group{
-				ID:   sg.ID,
-				Name: sg.Name,
+				ID:       sg.ID,
+				Name:     sg.Name,
+				Path:     sg.Path,
+				FullPath: sg.FullPath,
 			})
 		}
 		
@@ -60,7 +68,8 @@ func (s *Service) GetSubgroups(ctx context.Context, groupID int64) ([]Group, err
 }
 
 // GetProjectsOfGroup returns the list of every projects of the group and subgroups.
-func (s *Service) GetProjectsOfGroup(ctx context.Context, groupID int64) ([]Project, error) {
+// Archived projects are left out unless opts.IncludeArchived is set.
+func (s *Service) GetProjectsOfGroup(ctx context.Context, groupID int64, opts ProjectListOptions) ([]Project, error) {
 	// First get all subgroups recursively
 	subgroups, err := s.GetSubgroups(ctx, groupID)
 	if err != nil {
@@ -76,7 +85,7 @@ func (s *Service) GetProjectsOfGroup(ctx context.Context, groupID int64) ([]Proj
 		}
 		// Filter out archived projects
 		for _, project := range projects {
-			if !project.Archived {
+			if !project.Archived || opts.IncludeArchived {
 				res = append(res, project)
 			}
 		}
@@ -89,7 +98,7 @@ func (s *Service) GetProjectsOfGroup(ctx context.Context, groupID int64) ([]Proj
 	}
 	// Filter out archived projects from the main group as well
 	for _, project := range projects {
-		if !project.Archived {
+		if !project.Archived || opts.IncludeArchived {
 			res = append(res, project)
 		}
 	}
@@ -116,12 +125,7 @@ func (s *Service) GetProjectsLst(ctx context.Context, groupID int64) ([]Project,
 		
 		// Convert to our Project type
 		for _, p := range projects {
-			allProjects = append(allProjects, Project{
-				ID:           p.ID,
-				Name:         p.Name,
-				Archived:     p.Archived,
-				ExportStatus: "", // ExportStatus not available in project struct
-			})
+			allProjects = append(allProjects, newProject(p))
 		}
 		
 		if resp.NextPage == 0 {
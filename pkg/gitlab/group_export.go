@@ -0,0 +1,124 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+var (
+	// ErrGroupExportNotAccepted is returned when GitLab does not accept a group export request.
+	ErrGroupExportNotAccepted = errors.New("gitlab did not accept the group export request")
+	// ErrGroupExportTimeout is returned when the group export is not ready before the export timeout.
+	ErrGroupExportTimeout = errors.New("timeout waiting for gitlab to finish the group export")
+)
+
+// ExportGroup exports the group itself (settings, subgroups, labels,
+// milestones, badges, boards, epics...) to archiveFilePath with the group
+// import/export API. Projects are not part of a group export: they are
+// exported separately with ExportProject. Only opts.Timeout applies.
+//
+// GitLab API Reference:
+// https://docs.gitlab.com/ee/api/group_import_export.html
+func (s *Service) ExportGroup(ctx context.Context, group *Group, archiveFilePath string, opts ExportOptions) error {
+	if err := s.rateLimitExportAPI.Wait(ctx); err != nil { // This is a blocking call. Honors the rate limit
+		return fmt.Errorf("%w: %w", ErrRateLimit, err)
+	}
+	resp, err := s.client.GroupImportExport().ScheduleExport(ctx, group.ID, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to schedule export of group %s: %w", group.Name, err)
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("%w (group %s, HTTP %d)", ErrGroupExportNotAccepted, group.Name, resp.StatusCode)
+	}
+	log.Info("ExportGroup (gitlab is creating the archive)", "group name", group.Name)
+	if err := s.waitForGroupExport(ctx, group.ID, archiveFilePath, s.exportTimeout(opts)); err != nil {
+		return fmt.Errorf("failed to export group %s: %w", group.Name, err)
+	}
+	log.Info("ExportGroup (group archive downloaded)", "group name", group.Name)
+	return nil
+}
+
+// waitForGroupExport downloads the group export once GitLab has finished it.
+//
+// The group export API has no status endpoint: the download answers 404 until
+// the archive is ready, so every poll is a download attempt, paced by the
+// group download rate limiter and bounded by timeout.
+func (s *Service) waitForGroupExport(
+	ctx context.Context,
+	groupID int64,
+	archiveFilePath string,
+	timeout time.Duration,
+) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	checkInterval := s.exportCheckInterval
+	if checkInterval <= 0 {
+		checkInterval = constants.ExportCheckIntervalSeconds * time.Second
+	}
+	for {
+		// Give GitLab some time before each attempt: an export is never ready
+		// right after it has been scheduled.
+		select {
+		case <-timeoutCtx.Done():
+			return groupExportWaitErr(ctx, groupID, timeoutCtx.Err())
+		case <-time.After(checkInterval):
+		}
+		if err := s.groupDownloadLimiter.Wait(timeoutCtx); err != nil {
+			return groupExportWaitErr(ctx, groupID, err)
+		}
+		ready, err := s.downloadGroup(timeoutCtx, groupID, archiveFilePath)
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+		log.Info("wait after gitlab to get the group archive", "groupID", groupID)
+	}
+}
+
+// groupExportWaitErr maps a wait failure caused by the export timeout (rather
+// than by the caller's context) to ErrGroupExportTimeout.
+func groupExportWaitErr(ctx context.Context, groupID int64, err error) error {
+	if ctx.Err() == nil {
+		return fmt.Errorf("%w %d: %w", ErrGroupExportTimeout, groupID, err)
+	}
+	return fmt.Errorf("group export cancelled for group %d: %w", groupID, ctx.Err())
+}
+
+// downloadGroup downloads the group export to archiveFilePath through a
+// temporary file. It reports false, without error, while the export is not
+// ready yet.
+func (s *Service) downloadGroup(ctx context.Context, groupID int64, archiveFilePath string) (bool, error) {
+	tmpFile := archiveFilePath + ".tmp"
+	f, err := os.Create(tmpFile) //nolint:gosec // G304: intentional temp file path
+	if err != nil {
+		return false, fmt.Errorf("failed to create temp file %s: %w", tmpFile, err)
+	}
+
+	resp, err := s.client.GroupImportExport().ExportDownloadStream(ctx, groupID, f, gitlab.WithContext(ctx))
+	closeErr := f.Close()
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to download group export: %w", err)
+	}
+	if closeErr != nil {
+		return false, fmt.Errorf("failed to close temp file %s: %w", tmpFile, closeErr)
+	}
+
+	if err := os.Rename(tmpFile, archiveFilePath); err != nil {
+		return false, fmt.Errorf("failed to rename temporary file %s to %s: %w", tmpFile, archiveFilePath, err)
+	}
+	return true, nil
+}
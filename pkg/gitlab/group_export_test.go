@@ -0,0 +1,237 @@
+package gitlab_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab"
+	"github.com/sgaunet/gitlab-backup/pkg/gitlab/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	gitlabAPI "gitlab.com/gitlab-org/api/client-go"
+)
+
+func httpResponse(code int) *gitlabAPI.Response {
+	return &gitlabAPI.Response{Response: &http.Response{StatusCode: code}}
+}
+
+// groupExportService builds a fast-polling service around a group import/export mock.
+func groupExportService(ie *mocks.GroupImportExportServiceMock, opts ...gitlab.ServiceOption) *gitlab.Service {
+	client := &mocks.GitLabClientMock{
+		GroupImportExportFunc: func() gitlab.GroupImportExportService { return ie },
+	}
+	opts = append([]gitlab.ServiceOption{
+		unlimited(),
+		gitlab.WithGroupDownloadRateLimiter(rate.NewLimiter(rate.Inf, 1)),
+		gitlab.WithExportCheckInterval(time.Millisecond),
+	}, opts...)
+	return gitlab.NewServiceWithClient(client, opts...)
+}
+
+func scheduleAccepted(_ context.Context, _ any, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+	return httpResponse(http.StatusAccepted), nil
+}
+
+func TestService_ExportGroup_PollsDownloadUntilReady(t *testing.T) {
+	attempts := 0
+	ie := &mocks.GroupImportExportServiceMock{
+		ScheduleExportFunc: scheduleAccepted,
+		ExportDownloadStreamFunc: func(_ context.Context, gid any, w io.Writer, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			assert.Equal(t, int64(7), gid)
+			attempts++
+			if attempts < 3 {
+				return httpResponse(http.StatusNotFound), errors.New("404 Not Found")
+			}
+			_, _ = w.Write([]byte("group-archive"))
+			return httpResponse(http.StatusOK), nil
+		},
+	}
+	archivePath := filepath.Join(t.TempDir(), "group.tar.gz")
+
+	err := groupExportService(ie).ExportGroup(context.Background(), &gitlab.Group{ID: 7, Name: "grp"},
+		archivePath, gitlab.ExportOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, attempts)
+	data, err := os.ReadFile(archivePath)
+	require.NoError(t, err)
+	assert.Equal(t, "group-archive", string(data))
+	assert.NoFileExists(t, archivePath+".tmp")
+}
+
+func TestService_ExportGroup_NotAccepted(t *testing.T) {
+	ie := &mocks.GroupImportExportServiceMock{
+		ScheduleExportFunc: func(_ context.Context, _ any, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			return httpResponse(http.StatusOK), nil
+		},
+	}
+
+	err := groupExportService(ie).ExportGroup(context.Background(), &gitlab.Group{ID: 7, Name: "grp"},
+		filepath.Join(t.TempDir(), "g.tar.gz"), gitlab.ExportOptions{})
+	require.ErrorIs(t, err, gitlab.ErrGroupExportNotAccepted)
+	assert.Empty(t, ie.ExportDownloadStreamCalls())
+}
+
+func TestService_ExportGroup_Timeout(t *testing.T) {
+	ie := &mocks.GroupImportExportServiceMock{
+		ScheduleExportFunc: scheduleAccepted,
+		ExportDownloadStreamFunc: func(_ context.Context, _ any, _ io.Writer, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			return httpResponse(http.StatusNotFound), errors.New("404 Not Found")
+		},
+	}
+	archivePath := filepath.Join(t.TempDir(), "group.tar.gz")
+
+	svc := groupExportService(ie, gitlab.WithExportTimeout(50*time.Millisecond))
+	err := svc.ExportGroup(context.Background(), &gitlab.Group{ID: 7, Name: "grp"}, archivePath, gitlab.ExportOptions{})
+	require.ErrorIs(t, err, gitlab.ErrGroupExportTimeout)
+	assert.NoFileExists(t, archivePath)
+	assert.NoFileExists(t, archivePath+".tmp")
+}
+
+func TestService_ExportGroup_ContextExportTimeout(t *testing.T) {
+	ie := &mocks.GroupImportExportServiceMock{
+		ScheduleExportFunc: scheduleAccepted,
+		ExportDownloadStreamFunc: func(_ context.Context, _ any, _ io.Writer, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			return httpResponse(http.StatusNotFound), errors.New("404 Not Found")
+		},
+	}
+
+	// The context override wins over the (long) service export timeout.
+	svc := groupExportService(ie, gitlab.WithExportTimeout(time.Hour))
+	err := svc.ExportGroup(context.Background(), &gitlab.Group{ID: 7, Name: "grp"},
+		filepath.Join(t.TempDir(), "group.tar.gz"), gitlab.ExportOptions{Timeout: 50 * time.Millisecond})
+	require.ErrorIs(t, err, gitlab.ErrGroupExportTimeout)
+}
+
+func TestService_ExportGroup_DownloadError(t *testing.T) {
+	ie := &mocks.GroupImportExportServiceMock{
+		ScheduleExportFunc: scheduleAccepted,
+		ExportDownloadStreamFunc: func(_ context.Context, _ any, _ io.Writer, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			return httpResponse(http.StatusForbidden), errors.New("403 Forbidden")
+		},
+	}
+	archivePath := filepath.Join(t.TempDir(), "group.tar.gz")
+
+	err := groupExportService(ie).ExportGroup(context.Background(), &gitlab.Group{ID: 7, Name: "grp"},
+		archivePath, gitlab.ExportOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403 Forbidden")
+	assert.Len(t, ie.ExportDownloadStreamCalls(), 1)
+	assert.NoFileExists(t, archivePath+".tmp")
+}
+
+func TestGroupImportService_ImportGroup(t *testing.T) {
+	var captured *gitlabAPI.GroupImportFileOptions
+	ie := &mocks.GroupImportExportServiceMock{
+		ImportFileFunc: func(_ context.Context, opt *gitlabAPI.GroupImportFileOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			captured = opt
+			return httpResponse(http.StatusAccepted), nil
+		},
+	}
+	groups := &mocks.GroupsServiceMock{
+		GetGroupFunc: func(_ context.Context, gid any, _ *gitlabAPI.GetGroupOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Group, *gitlabAPI.Response, error) {
+			assert.Equal(t, "parent/restored", gid)
+			return &gitlabAPI.Group{ID: 99, FullPath: "parent/restored"}, httpResponse(http.StatusOK), nil
+		},
+	}
+
+	svc := gitlab.NewGroupImportService(ie, groups, rate.NewLimiter(rate.Inf, 1), time.Minute)
+	group, err := svc.ImportGroup(context.Background(), "/tmp/group.tar.gz", "parent/restored", 12)
+	require.NoError(t, err)
+	assert.Equal(t, int64(99), group.ID)
+
+	require.NotNil(t, captured)
+	assert.Equal(t, "restored", *captured.Name)
+	assert.Equal(t, "restored", *captured.Path)
+	assert.Equal(t, "/tmp/group.tar.gz", *captured.File)
+	require.NotNil(t, captured.ParentID)
+	assert.Equal(t, int64(12), *captured.ParentID)
+}
+
+func TestGroupImportService_ImportGroup_TopLevel(t *testing.T) {
+	var captured *gitlabAPI.GroupImportFileOptions
+	ie := &mocks.GroupImportExportServiceMock{
+		ImportFileFunc: func(_ context.Context, opt *gitlabAPI.GroupImportFileOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			captured = opt
+			return httpResponse(http.StatusAccepted), nil
+		},
+	}
+	groups := &mocks.GroupsServiceMock{
+		GetGroupFunc: func(_ context.Context, _ any, _ *gitlabAPI.GetGroupOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Group, *gitlabAPI.Response, error) {
+			return &gitlabAPI.Group{ID: 5}, httpResponse(http.StatusOK), nil
+		},
+	}
+
+	svc := gitlab.NewGroupImportService(ie, groups, rate.NewLimiter(rate.Inf, 1), time.Minute)
+	_, err := svc.ImportGroup(context.Background(), "/tmp/group.tar.gz", "restored", 0)
+	require.NoError(t, err)
+	require.NotNil(t, captured)
+	assert.Nil(t, captured.ParentID)
+}
+
+func TestGroupImportService_ImportGroupFromReader(t *testing.T) {
+	var captured *gitlabAPI.GroupImportFileOptions
+	var content []byte
+	ie := &mocks.GroupImportExportServiceMock{
+		ImportFromReaderFunc: func(_ context.Context, archive io.Reader, opt *gitlabAPI.GroupImportFileOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			captured = opt
+			content, _ = io.ReadAll(archive)
+			return httpResponse(http.StatusAccepted), nil
+		},
+	}
+	groups := &mocks.GroupsServiceMock{
+		GetGroupFunc: func(_ context.Context, _ any, _ *gitlabAPI.GetGroupOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Group, *gitlabAPI.Response, error) {
+			return &gitlabAPI.Group{ID: 99}, httpResponse(http.StatusOK), nil
+		},
+	}
+
+	svc := gitlab.NewGroupImportService(ie, groups, rate.NewLimiter(rate.Inf, 1), time.Minute)
+	group, err := svc.ImportGroupFromReader(context.Background(), strings.NewReader("decrypted"), "parent/restored", 12)
+	require.NoError(t, err)
+	assert.Equal(t, int64(99), group.ID)
+	assert.Equal(t, "decrypted", string(content))
+	require.NotNil(t, captured)
+	assert.Equal(t, "restored", *captured.Path)
+	assert.Nil(t, captured.File)
+	assert.Equal(t, int64(12), *captured.ParentID)
+}
+
+func TestGroupImportService_ImportGroup_Timeout(t *testing.T) {
+	ie := &mocks.GroupImportExportServiceMock{
+		ImportFileFunc: func(_ context.Context, _ *gitlabAPI.GroupImportFileOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			return httpResponse(http.StatusAccepted), nil
+		},
+	}
+	groups := &mocks.GroupsServiceMock{
+		GetGroupFunc: func(_ context.Context, _ any, _ *gitlabAPI.GetGroupOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Group, *gitlabAPI.Response, error) {
+			return nil, httpResponse(http.StatusNotFound), errors.New("404 Group Not Found")
+		},
+	}
+
+	svc := gitlab.NewGroupImportService(ie, groups, rate.NewLimiter(rate.Inf, 1), 20*time.Millisecond)
+	_, err := svc.ImportGroup(context.Background(), "/tmp/group.tar.gz", "restored", 0)
+	require.ErrorIs(t, err, gitlab.ErrImportTimeout)
+}
+
+func TestGroupImportService_ImportGroup_InitiationFails(t *testing.T) {
+	ie := &mocks.GroupImportExportServiceMock{
+		ImportFileFunc: func(_ context.Context, _ *gitlabAPI.GroupImportFileOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			return httpResponse(http.StatusBadRequest), errors.New("400 Bad Request")
+		},
+	}
+	groups := &mocks.GroupsServiceMock{}
+
+	svc := gitlab.NewGroupImportService(ie, groups, rate.NewLimiter(rate.Inf, 1), time.Minute)
+	_, err := svc.ImportGroup(context.Background(), "/tmp/group.tar.gz", "restored", 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to initiate group import")
+	assert.Empty(t, groups.GetGroupCalls())
+}
@@ -0,0 +1,135 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"golang.org/x/time/rate"
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+)
+
+// GroupImportService provides GitLab group import functionality.
+type GroupImportService struct {
+	importExportService GroupImportExportService
+	groups              GroupsService
+	rateLimiterImport   *rate.Limiter
+	timeout             time.Duration
+}
+
+// NewGroupImportService creates a group import service. Group imports share the
+// import rate limiter with project imports.
+// A non-positive timeout falls back to constants.DefaultImportTimeoutMins.
+func NewGroupImportService(
+	importExportService GroupImportExportService,
+	groups GroupsService,
+	rateLimiterImport *rate.Limiter,
+	timeout time.Duration,
+) *GroupImportService {
+	return &GroupImportService{
+		importExportService: importExportService,
+		groups:              groups,
+		rateLimiterImport:   rateLimiterImport,
+		timeout:             resolveImportTimeout(timeout),
+	}
+}
+
+// ImportGroup imports a group export archive as the group fullPath, under the
+// parent group parentID (0 for a top-level group). The last segment of
+// fullPath is used as both path and name of the new group.
+//
+// The group import API returns no import ID to poll, so ImportGroup waits
+// until the group can be fetched by its full path. GitLab keeps importing the
+// group content (subgroups, labels, milestones...) in the background.
+func (s *GroupImportService) ImportGroup(
+	ctx context.Context,
+	archivePath string,
+	fullPath string,
+	parentID int64,
+) (*gitlabapi.Group, error) {
+	return s.importGroup(ctx, fullPath, parentID, func(opt *gitlabapi.GroupImportFileOptions) error {
+		opt.File = &archivePath
+		_, err := s.importExportService.ImportFile(ctx, opt, gitlabapi.WithContext(ctx))
+		return err //nolint:wrapcheck // wrapped by importGroup
+	})
+}
+
+// ImportGroupFromReader is ImportGroup for an archive read from archive
+// instead of a file, e.g. an archive decrypted on the fly.
+func (s *GroupImportService) ImportGroupFromReader(
+	ctx context.Context,
+	archive io.Reader,
+	fullPath string,
+	parentID int64,
+) (*gitlabapi.Group, error) {
+	return s.importGroup(ctx, fullPath, parentID, func(opt *gitlabapi.GroupImportFileOptions) error {
+		_, err := s.importExportService.ImportFromReader(ctx, archive, opt, gitlabapi.WithContext(ctx))
+		return err //nolint:wrapcheck // wrapped by importGroup
+	})
+}
+
+// importGroup initiates the import of fullPath with upload, then waits for the group.
+func (s *GroupImportService) importGroup(
+	ctx context.Context,
+	fullPath string,
+	parentID int64,
+	upload func(opt *gitlabapi.GroupImportFileOptions) error,
+) (*gitlabapi.Group, error) {
+	if err := s.rateLimiterImport.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit wait failed: %w", err)
+	}
+
+	groupPath := path.Base(fullPath)
+	opt := &gitlabapi.GroupImportFileOptions{
+		Name: &groupPath,
+		Path: &groupPath,
+	}
+	if parentID != 0 {
+		opt.ParentID = &parentID
+	}
+	if err := upload(opt); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("group import initiation cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("failed to initiate group import: %w", err)
+	}
+
+	return s.waitForGroup(ctx, fullPath)
+}
+
+// waitForGroup polls the group by full path until it exists or the import timeout expires.
+func (s *GroupImportService) waitForGroup(ctx context.Context, fullPath string) (*gitlabapi.Group, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(constants.ImportPollSeconds * time.Second)
+	defer ticker.Stop()
+
+	for {
+		group, resp, err := s.groups.GetGroup(ctx, fullPath, nil, gitlabapi.WithContext(ctx))
+		switch {
+		case err == nil:
+			return group, nil
+		case resp != nil && resp.StatusCode == http.StatusNotFound:
+			// Not created yet, keep polling.
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return nil, fmt.Errorf("%w after %s (group %s)", ErrImportTimeout, s.timeout, fullPath)
+		default:
+			return nil, fmt.Errorf("failed to get imported group %s: %w", fullPath, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%w after %s (group %s)", ErrImportTimeout, s.timeout, fullPath)
+			}
+			return nil, fmt.Errorf("group import cancelled: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// InstanceScope narrows the projects returned by GetInstanceProjects. The zero
+// value selects every project visible to the token; for an administrator that
+// is every project of the instance, personal namespaces included.
+type InstanceScope struct {
+	Owned      bool // only projects owned by the token user
+	Membership bool // only projects the token user is a member of
+}
+
+// GetInstanceProjects returns every non-archived project of the instance
+// within scope. Archived projects are included only when opts.IncludeArchived
+// is set.
+//
+// The list is read with keyset pagination, which, unlike offset pagination,
+// is not capped at 50,000 projects.
+func (s *Service) GetInstanceProjects(
+	ctx context.Context,
+	scope InstanceScope,
+	opts ProjectListOptions,
+) ([]Project, error) {
+	opt := &gitlab.ListProjectsOptions{
+		ListOptions: gitlab.ListOptions{
+			Pagination: "keyset",
+			PerPage:    100, //nolint:mnd // GitLab API maximum page size
+		},
+		OrderBy:    gitlab.Ptr("id"), // keyset pagination requires ordering by id
+		Sort:       gitlab.Ptr("asc"),
+		Statistics: gitlab.Ptr(true), // sizes for the tmp budget and the size filter
+	}
+	if scope.Owned {
+		opt.Owned = gitlab.Ptr(true)
+	}
+	if scope.Membership {
+		opt.Membership = gitlab.Ptr(true)
+	}
+
+	var res []Project
+	options := []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)}
+	for {
+		projects, resp, err := s.client.Projects().ListProjects(ctx, opt, options...)
+		if err != nil {
+			return nil, fmt.Errorf("error listing projects of the instance: %w", err)
+		}
+
+		for _, p := range projects {
+			if !p.Archived || opts.IncludeArchived {
+				res = append(res, newProject(p))
+			}
+		}
+
+		if resp.NextLink == "" {
+			break
+		}
+		options = []gitlab.RequestOptionFunc{
+			gitlab.WithContext(ctx),
+			gitlab.WithKeysetPaginationParameters(resp.NextLink),
+		}
+	}
+	return res, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"time"
@@ -23,10 +24,50 @@ var (
 // https://docs.gitlab.com/ee/api/projects.html
 // struct fields are not exhaustive - most of them won't be used.
 type Project struct {
-	ID           int64  `json:"id"`
-	Name         string `json:"name"`
-	Archived     bool   `json:"archived"`
-	ExportStatus string `json:"export_status"`
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	Archived          bool   `json:"archived"`
+	ExportStatus      string `json:"export_status"`
+	// EstimatedSize is the export-relevant storage footprint in bytes
+	// (repository, wiki, LFS, uploads and snippets). Zero when GitLab did not
+	// return statistics, which requires at least the Reporter role.
+	EstimatedSize int64 `json:"estimated_size"`
+	// LastActivityAt is GitLab's last_activity_at; zero when not reported.
+	LastActivityAt time.Time `json:"last_activity_at"`
+	Topics         []string  `json:"topics"`
+	Visibility     string    `json:"visibility"`
+	Forked         bool      `json:"forked"` // forked_from_project is set
+	Mirror         bool      `json:"mirror"` // pull mirror
+}
+
+// newProject converts a client-go project into our Project type.
+func newProject(p *gitlab.Project) Project {
+	project := Project{
+		ID:                p.ID,
+		Name:              p.Name,
+		PathWithNamespace: p.PathWithNamespace,
+		Archived:          p.Archived,
+		ExportStatus:      "", // ExportStatus not available in project struct, will be fetched separately when needed
+		EstimatedSize:     estimatedExportSize(p.Statistics),
+		Topics:            p.Topics,
+		Visibility:        string(p.Visibility),
+		Forked:            p.ForkedFromProject != nil,
+		Mirror:            p.Mirror,
+	}
+	if p.LastActivityAt != nil {
+		project.LastActivityAt = *p.LastActivityAt
+	}
+	return project
+}
+
+// estimatedExportSize sums the project statistics that end up in an export
+// archive. Job artifacts, packages and registry images are not exported.
+func estimatedExportSize(stats *gitlab.Statistics) int64 {
+	if stats == nil {
+		return 0
+	}
+	return stats.RepositorySize + stats.WikiSize + stats.LFSObjectsSize + stats.UploadsSize + stats.SnippetsSize
 }
 
 // askExport requests GitLab to schedule a project export via the Export API.
@@ -74,8 +115,9 @@ func (s *Service) askExport(ctx context.Context, projectID int64) (bool, error)
 //   - Context is cancelled (returns context error)
 //   - Maximum retries exceeded for "none" status (returns ErrExportTimeout)
 //
-// The function creates an internal timeout context based on s.exportTimeoutDuration
-// (default: 10 minutes) to prevent indefinite waiting. This is in addition to any
+// The function creates an internal timeout context based on timeout (the
+// service export timeout unless the caller overrides it with
+// ExportOptions.Timeout) to prevent indefinite waiting. This is in addition to any
 // timeout set by the caller.
 //
 // Export Status Values:
@@ -92,6 +134,7 @@ func (s *Service) askExport(ctx context.Context, projectID int64) (bool, error)
 // Parameters:
 //   - ctx: Caller's context for cancellation control
 //   - projectID: GitLab project ID being exported
+//   - timeout: How long to wait for GitLab to finish the export
 //
 // Returns:
 //   - error: nil on success, ErrExportTimeout on timeout, context error on cancellation
@@ -104,9 +147,9 @@ func (s *Service) askExport(ctx context.Context, projectID int64) (bool, error)
 //
 // GitLab API Reference:
 // https://docs.gitlab.com/ee/api/project_import_export.html#export-status
-func (s *Service) waitForExport(ctx context.Context, projectID int64) error {
+func (s *Service) waitForExport(ctx context.Context, projectID int64, timeout time.Duration) error {
 	// Create a context with timeout to avoid waiting forever
-	timeoutCtx, cancel := context.WithTimeout(ctx, s.exportTimeoutDuration)
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	nbTries := 0
@@ -132,7 +175,7 @@ loop:
 		if checkInterval <= 0 {
 			checkInterval = constants.ExportCheckIntervalSeconds * time.Second
 		}
-		if err := s.sleepWithContext(timeoutCtx, projectID, checkInterval); err != nil {
+		if err := s.sleepWithContext(timeoutCtx, projectID, checkInterval, timeout); err != nil {
 			return err
 		}
 	}
@@ -160,6 +203,7 @@ loop:
 //   - ctx: Context to monitor for cancellation/timeout
 //   - projectID: Project ID for error messages (not used functionally)
 //   - duration: How long to sleep if context remains active
+//   - timeout: Export timeout reported when ctx times out
 //
 // Returns:
 //   - error: nil if sleep completed, wrapped context error if cancelled/timeout
@@ -169,12 +213,12 @@ loop:
 // The context error is wrapped with fmt.Errorf to preserve error chain.
 //
 // Used by: waitForExport (for polling delay between status checks).
-func (s *Service) sleepWithContext(ctx context.Context, projectID int64, duration time.Duration) error {
+func (s *Service) sleepWithContext(ctx context.Context, projectID int64, duration, timeout time.Duration) error {
 	select {
 	case <-ctx.Done():
 		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
 			return fmt.Errorf("export timeout after %v for project %d: %w",
-				s.exportTimeoutDuration, projectID, context.DeadlineExceeded)
+				timeout, projectID, context.DeadlineExceeded)
 		}
 		return fmt.Errorf("export cancelled for project %d: %w", projectID, ctx.Err())
 	case <-time.After(duration):
@@ -192,12 +236,41 @@ func (s *Service) getStatusExport(ctx context.Context, projectID int64) (string,
 }
 
 // ExportProject exports the project to the given archive file path.
-func (s *Service) ExportProject(ctx context.Context, project *Project, archiveFilePath string) error {
-	var gitlabAcceptedRequest bool
-	if project.Archived {
+// Archived projects are skipped unless opts.IncludeArchived is set.
+func (s *Service) ExportProject(ctx context.Context, project *Project, archiveFilePath string, opts ExportOptions) error {
+	if project.Archived && !opts.IncludeArchived {
 		log.Warn("SaveProject", "project name", project.Name, "is archived, skip it")
 		return nil
 	}
+	if err := s.scheduleExport(ctx, project, s.exportTimeout(opts)); err != nil {
+		return err
+	}
+	return s.downloadProject(ctx, project.ID, archiveFilePath)
+}
+
+// ExportProjectStream exports project like ExportProject, but writes the
+// archive to w as it is downloaded instead of to a file. Unlike ExportProject,
+// it exports archived projects too: the caller decides which projects to
+// export, and only opts.Timeout applies.
+func (s *Service) ExportProjectStream(ctx context.Context, project *Project, w io.Writer, opts ExportOptions) error {
+	if err := s.scheduleExport(ctx, project, s.exportTimeout(opts)); err != nil {
+		return err
+	}
+	if err := s.rateLimitDownloadAPI.Wait(ctx); err != nil { // This is a blocking call. Honors the rate limit
+		return fmt.Errorf("%w: %w", ErrRateLimit, err)
+	}
+	log.Debug("ExportProjectStream", "projectID", project.ID)
+	_, err := s.client.ProjectImportExport().ExportDownloadStream(ctx, project.ID, w, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to download export: %w", err)
+	}
+	return nil
+}
+
+// scheduleExport asks GitLab to export project and waits, up to timeout, until
+// the archive is ready for download.
+func (s *Service) scheduleExport(ctx context.Context, project *Project, timeout time.Duration) error {
+	var gitlabAcceptedRequest bool
 	err := s.rateLimitExportAPI.Wait(ctx) // This is a blocking call. Honors the rate limit
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrRateLimit, err)
@@ -209,15 +282,11 @@ func (s *Service) ExportProject(ctx context.Context, project *Project, archiveFi
 		}
 	}
 	log.Info("SaveProject (gitlab is creating the archive)", "project name", project.Name)
-	err = s.waitForExport(ctx, project.ID)
+	err = s.waitForExport(ctx, project.ID, timeout)
 	if err != nil {
 		return fmt.Errorf("failed to export project %s: %w", project.Name, err)
 	}
 	log.Info("SaveProject (gitlab has created the archive, download is beginning)", "project name", project.Name)
-	err = s.downloadProject(ctx, project.ID, archiveFilePath)
-	if err != nil {
-		return err
-	}
 	return nil
 }
 
@@ -70,6 +70,16 @@ func NewImportServiceWithRateLimiters(
 	}
 }
 
+// ImportOptions are the optional settings of ImportProject.
+type ImportOptions struct {
+	// Overwrite replaces the project at the target path, if any, with GitLab's
+	// overwrite import parameter.
+	Overwrite bool
+	// OverrideParams, when not nil, are passed as override_params to replace
+	// the project settings recorded by the archive.
+	OverrideParams *gitlabapi.CreateProjectOptions
+}
+
 // ImportProject initiates a GitLab project import and waits for completion.
 // It respects rate limiting and polls the import status until finished or failed.
 //
@@ -80,6 +90,7 @@ func (s *ImportService) ImportProject(
 	archive io.Reader,
 	namespace string,
 	projectPath string,
+	opts ImportOptions,
 ) (*gitlabapi.ImportStatus, error) {
 	// Wait for rate limit
 	if err := s.rateLimiterImport.Wait(ctx); err != nil {
@@ -91,16 +102,16 @@ func (s *ImportService) ImportProject(
 	// original project name from the archive metadata (which would otherwise
 	// trigger "Name has already been taken" when the archive's source project
 	// name collides with another project in the target namespace).
-	importStatus, _, err := s.importExportService.ImportFromFile(
-		ctx,
-		archive,
-		&gitlabapi.ImportFileOptions{
-			Namespace: &namespace,
-			Path:      &projectPath,
-			Name:      &projectPath,
-		},
-		gitlabapi.WithContext(ctx),
-	)
+	opt := &gitlabapi.ImportFileOptions{
+		Namespace:      &namespace,
+		Path:           &projectPath,
+		Name:           &projectPath,
+		OverrideParams: opts.OverrideParams,
+	}
+	if opts.Overwrite {
+		opt.Overwrite = gitlabapi.Ptr(true)
+	}
+	importStatus, _, err := s.importExportService.ImportFromFile(ctx, archive, opt, gitlabapi.WithContext(ctx))
 	if err != nil {
 		// Check if cancellation caused the error
 		if ctx.Err() != nil {
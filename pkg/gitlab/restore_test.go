@@ -46,7 +46,7 @@ func TestImportProject(t *testing.T) {
 
 		// Execute import
 		service := gitlab.NewImportService(mockProjectImportExport, 10*time.Minute)
-		status, err := service.ImportProject(ctx, archiveReader, "namespace", "project-path")
+		status, err := service.ImportProject(ctx, archiveReader, "namespace", "project-path", gitlab.ImportOptions{})
 
 		// Assertions
 		require.NoError(t, err, "Import should succeed")
@@ -64,6 +64,16 @@ func TestImportProject(t *testing.T) {
 		assert.Equal(t, "project-path", *capturedOpts.Name)
 		require.NotNil(t, capturedOpts.Namespace)
 		assert.Equal(t, "namespace", *capturedOpts.Namespace)
+		assert.Nil(t, capturedOpts.OverrideParams, "the archive settings are kept without overrides")
+		assert.Nil(t, capturedOpts.Overwrite, "an existing project is not replaced without Overwrite")
+
+		overrides := &gitlabapi.CreateProjectOptions{Visibility: gitlabapi.Ptr(gitlabapi.PrivateVisibility)}
+		_, err = service.ImportProject(ctx, bytes.NewReader(archiveData), "namespace", "project-path",
+			gitlab.ImportOptions{Overwrite: true, OverrideParams: overrides})
+		require.NoError(t, err)
+		assert.Same(t, overrides, capturedOpts.OverrideParams)
+		require.NotNil(t, capturedOpts.Overwrite)
+		assert.True(t, *capturedOpts.Overwrite)
 	})
 
 	t.Run("ImportInitiationFails", func(t *testing.T) {
@@ -78,7 +88,7 @@ func TestImportProject(t *testing.T) {
 
 		// Execute import
 		service := gitlab.NewImportService(mockProjectImportExport, 10*time.Minute)
-		status, err := service.ImportProject(ctx, archiveReader, "namespace", "project-path")
+		status, err := service.ImportProject(ctx, archiveReader, "namespace", "project-path", gitlab.ImportOptions{})
 
 		// Assertions
 		require.Error(t, err, "Import should fail")
@@ -110,7 +120,7 @@ func TestImportProject(t *testing.T) {
 
 		// Execute import
 		service := gitlab.NewImportService(mockProjectImportExport, 10*time.Minute)
-		_, err := service.ImportProject(ctx, archiveReader, "namespace", "project-path")
+		_, err := service.ImportProject(ctx, archiveReader, "namespace", "project-path", gitlab.ImportOptions{})
 
 		// Assertions
 		require.Error(t, err, "Import should fail when status is failed")
@@ -146,7 +156,7 @@ func TestImportProject(t *testing.T) {
 		// Execute import — service-level timeout is generous; the parent ctx timeout
 		// is what actually fires here. WaitForImport's grace-period check kicks in.
 		service := gitlab.NewImportService(mockProjectImportExport, 10*time.Minute)
-		_, err := service.ImportProject(ctx, archiveReader, "namespace", "project-path")
+		_, err := service.ImportProject(ctx, archiveReader, "namespace", "project-path", gitlab.ImportOptions{})
 
 		// Assertions
 		require.Error(t, err, "Import should timeout")
@@ -261,7 +271,8 @@ func TestNewImportServiceWithRateLimiters_Success(t *testing.T) {
 	service := gitlab.NewImportServiceWithRateLimiters(mock, rate.NewLimiter(rate.Inf, 1), 10*time.Minute)
 	require.NotNil(t, service)
 
-	status, err := service.ImportProject(context.Background(), bytes.NewReader([]byte("data")), "ns", "proj")
+	status, err := service.ImportProject(context.Background(), bytes.NewReader([]byte("data")), "ns", "proj",
+		gitlab.ImportOptions{})
 	require.NoError(t, err)
 	assert.Equal(t, "finished", status.ImportStatus)
 }
@@ -293,7 +304,8 @@ func TestImportProject_UnexpectedStatus(t *testing.T) {
 	}
 
 	service := gitlab.NewImportServiceWithRateLimiters(mock, rate.NewLimiter(rate.Inf, 1), 10*time.Minute)
-	_, err := service.ImportProject(context.Background(), bytes.NewReader([]byte("data")), "ns", "proj")
+	_, err := service.ImportProject(context.Background(), bytes.NewReader([]byte("data")), "ns", "proj",
+		gitlab.ImportOptions{})
 
 	require.Error(t, err)
 	require.ErrorIs(t, err, gitlab.ErrUnexpectedImportStatus)
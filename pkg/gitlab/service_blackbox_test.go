@@ -1,6 +1,7 @@
 package gitlab_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
@@ -52,7 +53,7 @@ func TestService_ExportProject_HappyPath(t *testing.T) {
 	svc := gitlab.NewServiceWithClient(importExportClient(ie), unlimited())
 	archivePath := filepath.Join(t.TempDir(), "proj.tar.gz")
 
-	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "proj"}, archivePath)
+	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "proj"}, archivePath, gitlab.ExportOptions{})
 	require.NoError(t, err)
 
 	downloaded, err = os.ReadFile(archivePath)
@@ -60,15 +61,71 @@ func TestService_ExportProject_HappyPath(t *testing.T) {
 	assert.Equal(t, "archive-content", string(downloaded))
 }
 
+func TestService_ExportProjectStream(t *testing.T) {
+	ie := &mocks.ProjectImportExportServiceMock{
+		ScheduleExportFunc: func(_ context.Context, _ any, _ *gitlabAPI.ScheduleExportOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			return &gitlabAPI.Response{Response: &http.Response{StatusCode: http.StatusAccepted}}, nil
+		},
+		ExportStatusFunc: func(_ context.Context, _ any, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.ExportStatus, *gitlabAPI.Response, error) {
+			return &gitlabAPI.ExportStatus{ExportStatus: "finished"}, &gitlabAPI.Response{}, nil
+		},
+		ExportDownloadStreamFunc: func(_ context.Context, _ any, w io.Writer, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			_, _ = w.Write([]byte("archive-"))
+			_, _ = w.Write([]byte("content"))
+			return &gitlabAPI.Response{}, nil
+		},
+	}
+	svc := gitlab.NewServiceWithClient(importExportClient(ie), unlimited())
+
+	var buf bytes.Buffer
+	err := svc.ExportProjectStream(context.Background(), &gitlab.Project{ID: 1, Name: "proj"}, &buf, gitlab.ExportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "archive-content", buf.String())
+	assert.Len(t, ie.ScheduleExportCalls(), 1)
+
+	ie.ExportDownloadStreamFunc = func(_ context.Context, _ any, _ io.Writer, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+		return nil, errors.New("connection reset")
+	}
+	err = svc.ExportProjectStream(context.Background(), &gitlab.Project{ID: 1, Name: "proj"}, io.Discard,
+		gitlab.ExportOptions{})
+	require.ErrorContains(t, err, "connection reset")
+}
+
 func TestService_ExportProject_ArchivedShortCircuit(t *testing.T) {
 	ie := &mocks.ProjectImportExportServiceMock{} // no funcs -> would panic if called
 	svc := gitlab.NewServiceWithClient(importExportClient(ie), unlimited())
 
-	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "p", Archived: true}, "/does/not/matter")
+	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "p", Archived: true}, "/does/not/matter",
+		gitlab.ExportOptions{})
 	require.NoError(t, err)
 	assert.Empty(t, ie.ScheduleExportCalls(), "archived project must not schedule an export")
 }
 
+func TestService_ExportProject_IncludeArchived(t *testing.T) {
+	ie := &mocks.ProjectImportExportServiceMock{
+		ScheduleExportFunc: func(_ context.Context, _ any, _ *gitlabAPI.ScheduleExportOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			return &gitlabAPI.Response{Response: &http.Response{StatusCode: http.StatusAccepted}}, nil
+		},
+		ExportStatusFunc: func(_ context.Context, _ any, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.ExportStatus, *gitlabAPI.Response, error) {
+			return &gitlabAPI.ExportStatus{ExportStatus: "finished"}, &gitlabAPI.Response{}, nil
+		},
+		ExportDownloadStreamFunc: func(_ context.Context, _ any, w io.Writer, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
+			_, _ = w.Write([]byte("archived-content"))
+			return &gitlabAPI.Response{}, nil
+		},
+	}
+	svc := gitlab.NewServiceWithClient(importExportClient(ie), unlimited())
+	archivePath := filepath.Join(t.TempDir(), "arch.tar.gz")
+
+	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "arch", Archived: true}, archivePath,
+		gitlab.ExportOptions{IncludeArchived: true})
+	require.NoError(t, err)
+	assert.Len(t, ie.ScheduleExportCalls(), 1)
+	content, err := os.ReadFile(archivePath)
+	require.NoError(t, err)
+	assert.Equal(t, "archived-content", string(content))
+}
+
 func TestService_ExportProject_ScheduleError(t *testing.T) {
 	ie := &mocks.ProjectImportExportServiceMock{
 		ScheduleExportFunc: func(_ context.Context, _ any, _ *gitlabAPI.ScheduleExportOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Response, error) {
@@ -77,7 +134,8 @@ func TestService_ExportProject_ScheduleError(t *testing.T) {
 	}
 	svc := gitlab.NewServiceWithClient(importExportClient(ie), unlimited())
 
-	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "p"}, filepath.Join(t.TempDir(), "a.tar.gz"))
+	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "p"}, filepath.Join(t.TempDir(), "a.tar.gz"),
+		gitlab.ExportOptions{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "export request")
 }
@@ -93,7 +151,8 @@ func TestService_ExportProject_WaitTimeout(t *testing.T) {
 	}
 	svc := gitlab.NewServiceWithClient(importExportClient(ie), unlimited(), gitlab.WithExportTimeout(time.Millisecond))
 
-	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "p"}, filepath.Join(t.TempDir(), "a.tar.gz"))
+	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "p"}, filepath.Join(t.TempDir(), "a.tar.gz"),
+		gitlab.ExportOptions{})
 	require.Error(t, err)
 	assert.ErrorIs(t, err, context.DeadlineExceeded)
 }
@@ -115,7 +174,8 @@ func TestService_ExportProject_RetryExhaustion(t *testing.T) {
 		gitlab.WithExportCheckInterval(time.Millisecond),
 	)
 
-	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "p"}, filepath.Join(t.TempDir(), "a.tar.gz"))
+	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "p"}, filepath.Join(t.TempDir(), "a.tar.gz"),
+		gitlab.ExportOptions{})
 	require.Error(t, err)
 	assert.ErrorIs(t, err, gitlab.ErrExportTimeout)
 }
@@ -135,7 +195,7 @@ func TestService_ExportProject_DownloadError(t *testing.T) {
 	svc := gitlab.NewServiceWithClient(importExportClient(ie), unlimited())
 	archivePath := filepath.Join(t.TempDir(), "a.tar.gz")
 
-	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "p"}, archivePath)
+	err := svc.ExportProject(context.Background(), &gitlab.Project{ID: 1, Name: "p"}, archivePath, gitlab.ExportOptions{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "download export")
 
@@ -155,7 +215,14 @@ func TestService_GetProjectsOfGroup(t *testing.T) {
 		ListGroupProjectsFunc: func(_ context.Context, gid any, _ *gitlabAPI.ListGroupProjectsOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Project, *gitlabAPI.Response, error) {
 			switch gid {
 			case int64(200):
-				return []*gitlabAPI.Project{{ID: 2, Name: "sub-proj"}}, &gitlabAPI.Response{NextPage: 0}, nil
+				return []*gitlabAPI.Project{{
+					ID:                2,
+					Name:              "sub-proj",
+					Topics:            []string{"go"},
+					Visibility:        gitlabAPI.InternalVisibility,
+					ForkedFromProject: &gitlabAPI.ForkParent{ID: 9},
+					Mirror:            true,
+				}}, &gitlabAPI.Response{NextPage: 0}, nil
 			case int64(100):
 				return []*gitlabAPI.Project{
 					{ID: 1, Name: "main"},
@@ -171,7 +238,7 @@ func TestService_GetProjectsOfGroup(t *testing.T) {
 	}
 	svc := gitlab.NewServiceWithClient(client, unlimited())
 
-	projects, err := svc.GetProjectsOfGroup(context.Background(), 100)
+	projects, err := svc.GetProjectsOfGroup(context.Background(), 100, gitlab.ProjectListOptions{})
 	require.NoError(t, err)
 
 	// Archived project 3 filtered out; projects from subgroup and main group aggregated.
@@ -183,6 +250,121 @@ func TestService_GetProjectsOfGroup(t *testing.T) {
 	assert.True(t, ids[1], "main project should be present")
 	assert.True(t, ids[2], "subgroup project should be present")
 	assert.False(t, ids[3], "archived project must be filtered out")
+
+	withArchived, err := svc.GetProjectsOfGroup(context.Background(), 100, gitlab.ProjectListOptions{IncludeArchived: true})
+	require.NoError(t, err)
+	assert.Len(t, withArchived, 3, "archived project is kept on request")
+
+	// Attributes used by the project filters are carried over.
+	sub := projects[0]
+	assert.Equal(t, int64(2), sub.ID)
+	assert.Equal(t, []string{"go"}, sub.Topics)
+	assert.Equal(t, "internal", sub.Visibility)
+	assert.True(t, sub.Forked)
+	assert.True(t, sub.Mirror)
+	assert.False(t, projects[1].Forked)
+}
+
+func TestService_GetProjectsOfUser(t *testing.T) {
+	projects := &mocks.ProjectsServiceMock{
+		ListUserProjectsFunc: func(_ context.Context, uid any, opt *gitlabAPI.ListProjectsOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Project, *gitlabAPI.Response, error) {
+			assert.Equal(t, "alice", uid)
+			require.NotNil(t, opt.Statistics)
+			assert.True(t, *opt.Statistics, "statistics must be requested")
+			if opt.Page == 0 {
+				return []*gitlabAPI.Project{
+					{ID: 1, Name: "dotfiles", PathWithNamespace: "alice/dotfiles"},
+					{ID: 2, Name: "old", Archived: true},
+				}, &gitlabAPI.Response{NextPage: 2}, nil
+			}
+			return []*gitlabAPI.Project{{ID: 3, Name: "notes"}}, &gitlabAPI.Response{NextPage: 0}, nil
+		},
+	}
+	client := &mocks.GitLabClientMock{
+		ProjectsFunc: func() gitlab.ProjectsService { return projects },
+	}
+	svc := gitlab.NewServiceWithClient(client, unlimited())
+
+	res, err := svc.GetProjectsOfUser(context.Background(), "alice", gitlab.ProjectListOptions{})
+	require.NoError(t, err)
+
+	// Both pages are read and the archived project is filtered out.
+	require.Len(t, res, 2)
+	assert.Equal(t, int64(1), res[0].ID)
+	assert.Equal(t, "alice/dotfiles", res[0].PathWithNamespace)
+	assert.Equal(t, int64(3), res[1].ID)
+	assert.Len(t, projects.ListUserProjectsCalls(), 2)
+
+	withArchived, err := svc.GetProjectsOfUser(context.Background(), "alice",
+		gitlab.ProjectListOptions{IncludeArchived: true})
+	require.NoError(t, err)
+	assert.Len(t, withArchived, 3, "archived project is kept on request")
+}
+
+func TestService_GetProjectsOfUser_Error(t *testing.T) {
+	projects := &mocks.ProjectsServiceMock{
+		ListUserProjectsFunc: func(_ context.Context, _ any, _ *gitlabAPI.ListProjectsOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Project, *gitlabAPI.Response, error) {
+			return nil, nil, errors.New("404 User Not Found")
+		},
+	}
+	client := &mocks.GitLabClientMock{
+		ProjectsFunc: func() gitlab.ProjectsService { return projects },
+	}
+	svc := gitlab.NewServiceWithClient(client, unlimited())
+
+	_, err := svc.GetProjectsOfUser(context.Background(), "ghost", gitlab.ProjectListOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ghost")
+}
+
+func TestService_GetInstanceProjects(t *testing.T) {
+	projects := &mocks.ProjectsServiceMock{
+		ListProjectsFunc: func(_ context.Context, opt *gitlabAPI.ListProjectsOptions, options ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Project, *gitlabAPI.Response, error) {
+			assert.Equal(t, "keyset", opt.Pagination)
+			require.NotNil(t, opt.OrderBy)
+			assert.Equal(t, "id", *opt.OrderBy)
+			require.NotNil(t, opt.Membership)
+			assert.True(t, *opt.Membership)
+			assert.Nil(t, opt.Owned)
+			if len(options) == 1 {
+				return []*gitlabAPI.Project{
+					{ID: 1, Name: "infra", PathWithNamespace: "ops/infra"},
+					{ID: 2, Name: "old", Archived: true},
+				}, &gitlabAPI.Response{NextLink: "https://gitlab.example.com/api/v4/projects?id_after=2"}, nil
+			}
+			// The next page is requested through the keyset link.
+			return []*gitlabAPI.Project{{ID: 3, Name: "dotfiles", PathWithNamespace: "alice/dotfiles"}}, &gitlabAPI.Response{}, nil
+		},
+	}
+	client := &mocks.GitLabClientMock{
+		ProjectsFunc: func() gitlab.ProjectsService { return projects },
+	}
+	svc := gitlab.NewServiceWithClient(client, unlimited())
+
+	res, err := svc.GetInstanceProjects(context.Background(), gitlab.InstanceScope{Membership: true},
+		gitlab.ProjectListOptions{})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "ops/infra", res[0].PathWithNamespace)
+	assert.Equal(t, "alice/dotfiles", res[1].PathWithNamespace)
+	require.Len(t, projects.ListProjectsCalls(), 2)
+	assert.Len(t, projects.ListProjectsCalls()[1].Options, 2)
+}
+
+func TestService_GetInstanceProjects_Error(t *testing.T) {
+	projects := &mocks.ProjectsServiceMock{
+		ListProjectsFunc: func(_ context.Context, _ *gitlabAPI.ListProjectsOptions, _ ...gitlabAPI.RequestOptionFunc) ([]*gitlabAPI.Project, *gitlabAPI.Response, error) {
+			return nil, nil, errors.New("500 Internal Server Error")
+		},
+	}
+	client := &mocks.GitLabClientMock{
+		ProjectsFunc: func() gitlab.ProjectsService { return projects },
+	}
+	svc := gitlab.NewServiceWithClient(client, unlimited())
+
+	_, err := svc.GetInstanceProjects(context.Background(), gitlab.InstanceScope{}, gitlab.ProjectListOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "instance")
 }
 
 func TestService_Getters(t *testing.T) {
@@ -211,6 +393,30 @@ func TestService_GetProject(t *testing.T) {
 		assert.Equal(t, "answer", p.Name)
 	})
 
+	t.Run("estimated size from statistics", func(t *testing.T) {
+		projects := &mocks.ProjectsServiceMock{
+			GetProjectFunc: func(_ context.Context, _ any, opt *gitlabAPI.GetProjectOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Project, *gitlabAPI.Response, error) {
+				require.NotNil(t, opt)
+				require.NotNil(t, opt.Statistics)
+				assert.True(t, *opt.Statistics, "statistics must be requested")
+				return &gitlabAPI.Project{ID: 42, Name: "answer", Statistics: &gitlabAPI.Statistics{
+					RepositorySize:   100,
+					WikiSize:         20,
+					LFSObjectsSize:   3,
+					UploadsSize:      4,
+					SnippetsSize:     5,
+					JobArtifactsSize: 1000, // not part of an export
+				}}, &gitlabAPI.Response{}, nil
+			},
+		}
+		client := &mocks.GitLabClientMock{ProjectsFunc: func() gitlab.ProjectsService { return projects }}
+		svc := gitlab.NewServiceWithClient(client, unlimited())
+
+		p, err := svc.GetProject(context.Background(), 42)
+		require.NoError(t, err)
+		assert.Equal(t, int64(132), p.EstimatedSize)
+	})
+
 	t.Run("error", func(t *testing.T) {
 		projects := &mocks.ProjectsServiceMock{
 			GetProjectFunc: func(_ context.Context, _ any, _ *gitlabAPI.GetProjectOptions, _ ...gitlabAPI.RequestOptionFunc) (*gitlabAPI.Project, *gitlabAPI.Response, error) {
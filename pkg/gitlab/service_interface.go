@@ -2,6 +2,7 @@ package gitlab
 
 import (
 	"context"
+	"io"
 
 	"golang.org/x/time/rate"
 )
@@ -17,12 +18,25 @@ type BackupService interface {
 	SetToken(token string)
 	// SetGitlabEndpoint sets the GitLab API endpoint.
 	SetGitlabEndpoint(endpoint string)
+	// GetGroup returns the group identified by groupID.
+	GetGroup(ctx context.Context, groupID int64) (Group, error)
 	// GetProject returns the project identified by projectID.
 	GetProject(ctx context.Context, projectID int64) (Project, error)
-	// GetProjectsOfGroup returns every non-archived project of the group and its subgroups.
-	GetProjectsOfGroup(ctx context.Context, groupID int64) ([]Project, error)
+	// GetProjectsOfGroup returns every non-archived project of the group and its
+	// subgroups; archived projects too with opts.IncludeArchived.
+	GetProjectsOfGroup(ctx context.Context, groupID int64, opts ProjectListOptions) ([]Project, error)
+	// GetProjectsOfUser returns every non-archived project of the personal namespace
+	// of username; archived projects too with opts.IncludeArchived.
+	GetProjectsOfUser(ctx context.Context, username string, opts ProjectListOptions) ([]Project, error)
+	// GetInstanceProjects returns every non-archived project visible to the token
+	// within scope; archived projects too with opts.IncludeArchived.
+	GetInstanceProjects(ctx context.Context, scope InstanceScope, opts ProjectListOptions) ([]Project, error)
 	// ExportProject exports project to archiveFilePath.
-	ExportProject(ctx context.Context, project *Project, archiveFilePath string) error
+	ExportProject(ctx context.Context, project *Project, archiveFilePath string, opts ExportOptions) error
+	// ExportProjectStream exports project and writes the archive to w as it is downloaded.
+	ExportProjectStream(ctx context.Context, project *Project, w io.Writer, opts ExportOptions) error
+	// ExportGroup exports the group itself (not its projects) to archiveFilePath.
+	ExportGroup(ctx context.Context, group *Group, archiveFilePath string, opts ExportOptions) error
 }
 
 // Compile-time guarantee that *Service satisfies BackupService.
@@ -22,10 +22,15 @@ type mockGitLabClient struct {
 	groupsService              GroupsService
 	projectsService            ProjectsService
 	projectImportExportService ProjectImportExportService
+	groupImportExportService   GroupImportExportService
 	labelsService              LabelsService
 	issuesService              IssuesService
 	notesService               NotesService
 	commitsService             CommitsService
+	branchesService            BranchesService
+	tagsService                TagsService
+	mergeRequestsService       MergeRequestsService
+	milestonesService          MilestonesService
 }
 
 func (m *mockGitLabClient) Groups() GroupsService {
@@ -40,6 +45,10 @@ func (m *mockGitLabClient) ProjectImportExport() ProjectImportExportService {
 	return m.projectImportExportService
 }
 
+func (m *mockGitLabClient) GroupImportExport() GroupImportExportService {
+	return m.groupImportExportService
+}
+
 func (m *mockGitLabClient) Labels() LabelsService {
 	return m.labelsService
 }
@@ -56,11 +65,28 @@ func (m *mockGitLabClient) Commits() CommitsService {
 	return m.commitsService
 }
 
+func (m *mockGitLabClient) Branches() BranchesService {
+	return m.branchesService
+}
+
+func (m *mockGitLabClient) Tags() TagsService {
+	return m.tagsService
+}
+
+func (m *mockGitLabClient) MergeRequests() MergeRequestsService {
+	return m.mergeRequestsService
+}
+
+func (m *mockGitLabClient) Milestones() MilestonesService {
+	return m.milestonesService
+}
+
 // mockGroupsService is a manual mock implementation of GroupsService
 type mockGroupsService struct {
 	getGroupFunc          func(ctx context.Context, gid any, opt *gitlab.GetGroupOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Group, *gitlab.Response, error)
 	listSubGroupsFunc     func(ctx context.Context, gid any, opt *gitlab.ListSubGroupsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Group, *gitlab.Response, error)
 	listGroupProjectsFunc func(ctx context.Context, gid any, opt *gitlab.ListGroupProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error)
+	createGroupFunc       func(ctx context.Context, opt *gitlab.CreateGroupOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Group, *gitlab.Response, error)
 }
 
 func (m *mockGroupsService) GetGroup(ctx context.Context, gid any, opt *gitlab.GetGroupOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Group, *gitlab.Response, error) {
@@ -84,9 +110,20 @@ func (m *mockGroupsService) ListGroupProjects(ctx context.Context, gid any, opt
 	return nil, nil, nil
 }
 
+func (m *mockGroupsService) CreateGroup(ctx context.Context, opt *gitlab.CreateGroupOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Group, *gitlab.Response, error) {
+	if m.createGroupFunc != nil {
+		return m.createGroupFunc(ctx, opt, options...)
+	}
+	return nil, nil, nil
+}
+
 // mockProjectsService is a manual mock implementation of ProjectsService
 type mockProjectsService struct {
-	getProjectFunc func(ctx context.Context, pid any, opt *gitlab.GetProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Project, *gitlab.Response, error)
+	getProjectFunc       func(ctx context.Context, pid any, opt *gitlab.GetProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Project, *gitlab.Response, error)
+	listUserProjectsFunc func(ctx context.Context, uid any, opt *gitlab.ListProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error)
+	listProjectsFunc     func(ctx context.Context, opt *gitlab.ListProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error)
+	editProjectFunc      func(ctx context.Context, pid any, opt *gitlab.EditProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Project, *gitlab.Response, error)
+	deleteProjectFunc    func(ctx context.Context, pid any, opt *gitlab.DeleteProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
 }
 
 func (m *mockProjectsService) GetProject(ctx context.Context, pid any, opt *gitlab.GetProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Project, *gitlab.Response, error) {
@@ -96,13 +133,42 @@ func (m *mockProjectsService) GetProject(ctx context.Context, pid any, opt *gitl
 	return nil, nil, nil
 }
 
+func (m *mockProjectsService) ListUserProjects(ctx context.Context, uid any, opt *gitlab.ListProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+	if m.listUserProjectsFunc != nil {
+		return m.listUserProjectsFunc(ctx, uid, opt, options...)
+	}
+	return nil, nil, nil
+}
+
+func (m *mockProjectsService) ListProjects(ctx context.Context, opt *gitlab.ListProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+	if m.listProjectsFunc != nil {
+		return m.listProjectsFunc(ctx, opt, options...)
+	}
+	return nil, nil, nil
+}
+
+func (m *mockProjectsService) EditProject(ctx context.Context, pid any, opt *gitlab.EditProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Project, *gitlab.Response, error) {
+	if m.editProjectFunc != nil {
+		return m.editProjectFunc(ctx, pid, opt, options...)
+	}
+	return nil, nil, nil
+}
+
+func (m *mockProjectsService) DeleteProject(ctx context.Context, pid any, opt *gitlab.DeleteProjectOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
+	if m.deleteProjectFunc != nil {
+		return m.deleteProjectFunc(ctx, pid, opt, options...)
+	}
+	return nil, nil
+}
+
 // mockProjectImportExportService is a manual mock implementation of ProjectImportExportService
 type mockProjectImportExportService struct {
-	scheduleExportFunc       func(ctx context.Context, pid any, opt *gitlab.ScheduleExportOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
-	exportStatusFunc         func(ctx context.Context, pid any, options ...gitlab.RequestOptionFunc) (*gitlab.ExportStatus, *gitlab.Response, error)
-	exportDownloadStreamFunc func(ctx context.Context, pid any, w io.Writer, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
-	importFromFileFunc       func(ctx context.Context, archive io.Reader, opt *gitlab.ImportFileOptions, options ...gitlab.RequestOptionFunc) (*gitlab.ImportStatus, *gitlab.Response, error)
-	importStatusFunc         func(ctx context.Context, pid any, options ...gitlab.RequestOptionFunc) (*gitlab.ImportStatus, *gitlab.Response, error)
+	scheduleExportFunc        func(ctx context.Context, pid any, opt *gitlab.ScheduleExportOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
+	exportStatusFunc          func(ctx context.Context, pid any, options ...gitlab.RequestOptionFunc) (*gitlab.ExportStatus, *gitlab.Response, error)
+	exportDownloadStreamFunc  func(ctx context.Context, pid any, w io.Writer, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
+	importFromFileFunc        func(ctx context.Context, archive io.Reader, opt *gitlab.ImportFileOptions, options ...gitlab.RequestOptionFunc) (*gitlab.ImportStatus, *gitlab.Response, error)
+	importStatusFunc          func(ctx context.Context, pid any, options ...gitlab.RequestOptionFunc) (*gitlab.ImportStatus, *gitlab.Response, error)
+	importFailedRelationsFunc func(ctx context.Context, pid any, options ...gitlab.RequestOptionFunc) ([]*FailedRelation, *gitlab.Response, error)
 }
 
 func (m *mockProjectImportExportService) ScheduleExport(ctx context.Context, pid any, opt *gitlab.ScheduleExportOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
@@ -141,6 +207,13 @@ func (m *mockProjectImportExportService) ImportStatus(ctx context.Context, pid a
 	return nil, nil, nil
 }
 
+func (m *mockProjectImportExportService) ImportFailedRelations(ctx context.Context, pid any, options ...gitlab.RequestOptionFunc) ([]*FailedRelation, *gitlab.Response, error) {
+	if m.importFailedRelationsFunc != nil {
+		return m.importFailedRelationsFunc(ctx, pid, options...)
+	}
+	return nil, nil, nil
+}
+
 // Helper function to create a test service with mock client
 func createTestService(client GitLabClient) *Service {
 	return &Service{
@@ -358,6 +431,26 @@ func TestService_GetProjectsLst_Success(t *testing.T) {
 	assert.Equal(t, expected, result)
 }
 
+func TestService_GetProjectsLst_LastActivityAt(t *testing.T) {
+	activity := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	groupsService := &mockGroupsService{
+		listGroupProjectsFunc: func(_ context.Context, _ any, _ *gitlab.ListGroupProjectsOptions, _ ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+			return []*gitlab.Project{
+				{ID: int64(1), Name: "active", LastActivityAt: &activity},
+				{ID: int64(2), Name: "unknown"},
+			}, &gitlab.Response{NextPage: 0}, nil
+		},
+	}
+	service := createTestService(&mockGitLabClient{groupsService: groupsService})
+
+	result, err := service.GetProjectsLst(context.Background(), 1)
+
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.True(t, activity.Equal(result[0].LastActivityAt))
+	assert.True(t, result[1].LastActivityAt.IsZero())
+}
+
 func TestNewGitlabService_CreatesCorrectDefaults(t *testing.T) {
 	service := NewGitlabService()
 
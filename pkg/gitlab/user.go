@@ -0,0 +1,43 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// GetProjectsOfUser returns the list of every non-archived project of the
+// personal namespace of username. Projects the user is only a member of are
+// not included. Archived projects are included only when opts.IncludeArchived
+// is set.
+func (s *Service) GetProjectsOfUser(ctx context.Context, username string, opts ProjectListOptions) ([]Project, error) {
+	opt := &gitlab.ListProjectsOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 20, //nolint:mnd // GitLab API pagination default
+		},
+		OrderBy:    gitlab.Ptr("id"),
+		Sort:       gitlab.Ptr("asc"),
+		Statistics: gitlab.Ptr(true), // sizes for the tmp budget and the size filter
+	}
+
+	var res []Project
+	for {
+		projects, resp, err := s.client.Projects().ListUserProjects(ctx, username, opt, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("error listing projects of user %s: %w", username, err)
+		}
+
+		for _, p := range projects {
+			if !p.Archived || opts.IncludeArchived {
+				res = append(res, newProject(p))
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return res, nil
+}
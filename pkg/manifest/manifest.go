@@ -0,0 +1,115 @@
+// Package manifest defines the JSON run manifest that gitlab-backup writes to
+// storage after every backup run. The manifest lists each project handled by
+// the run together with the archive it produced, so restore and audit tooling
+// do not have to infer anything from archive file names.
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FormatVersion is the manifest format version written by this release.
+const FormatVersion = 1
+
+// Manifest file naming: manifest-{runID}.json at the storage root.
+const (
+	// FilePrefix is the prefix of every manifest key.
+	FilePrefix = "manifest-"
+	// FileExtension is the extension of every manifest key.
+	FileExtension = ".json"
+)
+
+// runIDLayout formats run IDs as sortable UTC timestamps with nanoseconds
+// (e.g. 20261016T030000.123456789Z), so that runs started in the same second
+// never share a manifest.
+const runIDLayout = "20060102T150405.000000000Z"
+
+// Project statuses recorded in the manifest.
+const (
+	StatusSuccess   = "success"
+	StatusSkipped   = "skipped"
+	StatusUnchanged = "unchanged"
+	StatusFiltered  = "filtered"
+	StatusFailed    = "failed"
+)
+
+// ErrUnsupportedVersion is returned when parsing a manifest written by an incompatible version.
+var ErrUnsupportedVersion = errors.New("unsupported manifest version")
+
+// Manifest describes one backup run.
+type Manifest struct {
+	Version        int       `json:"version"`
+	RunID          string    `json:"runId"`
+	ToolVersion    string    `json:"toolVersion"`
+	GitlabEndpoint string    `json:"gitlabEndpoint"`
+	StartedAt      time.Time `json:"startedAt"`
+	FinishedAt     time.Time `json:"finishedAt"`
+	Projects       []Project `json:"projects"`
+	// Groups lists the native exports of the groups themselves
+	// (exportGroupArchive), in the layout of a project entry. It is omitted
+	// when no group was exported.
+	Groups []Project `json:"groups,omitempty"`
+	// Errors lists the failures not tied to a project, such as a backup target
+	// whose projects could not be listed.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Project describes the outcome of one project in a run. Archive fields are
+// empty unless the project was exported successfully.
+type Project struct {
+	ID              int64   `json:"id"`
+	Name            string  `json:"name"`
+	FullPath        string  `json:"fullPath"`
+	Visibility      string  `json:"visibility,omitempty"`  // groups only, to recreate a missing namespace
+	Description     string  `json:"description,omitempty"` // groups only, to recreate a missing namespace
+	Status          string  `json:"status"`
+	Error           string  `json:"error,omitempty"`
+	Reason          string  `json:"reason,omitempty"` // why a filtered project was left out
+	Archived        bool    `json:"archived,omitempty"`
+	ArchiveKey      string  `json:"archiveKey,omitempty"`
+	Size            int64   `json:"size,omitempty"`
+	SHA256          string  `json:"sha256,omitempty"`
+	Encrypted       bool    `json:"encrypted"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// NewRunID returns the run ID for a run started at t.
+func NewRunID(t time.Time) string {
+	return t.UTC().Format(runIDLayout)
+}
+
+// Key returns the storage key of the manifest for runID.
+func Key(runID string) string {
+	return FilePrefix + runID + FileExtension
+}
+
+// IsManifestKey reports whether a storage key names a run manifest.
+func IsManifestKey(key string) bool {
+	base := key[strings.LastIndex(key, "/")+1:]
+	return strings.HasPrefix(base, FilePrefix) && strings.HasSuffix(base, FileExtension)
+}
+
+// Marshal encodes the manifest as indented JSON.
+func (m *Manifest) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return data, nil
+}
+
+// Parse decodes a manifest and checks its format version.
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if m.Version != FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, m.Version)
+	}
+	return &m, nil
+}
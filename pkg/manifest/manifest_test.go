@@ -0,0 +1,62 @@
+package manifest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRunIDAndKey(t *testing.T) {
+	start := time.Date(2026, 10, 16, 5, 4, 3, 120000000, time.FixedZone("CEST", 2*3600))
+	runID := manifest.NewRunID(start)
+	assert.Equal(t, "20261016T030403.120000000Z", runID)
+	assert.Equal(t, "manifest-20261016T030403.120000000Z.json", manifest.Key(runID))
+
+	// Runs started in the same second get distinct IDs, in start order.
+	next := manifest.NewRunID(start.Add(time.Nanosecond))
+	assert.NotEqual(t, runID, next)
+	assert.Less(t, runID, next)
+}
+
+func TestIsManifestKey(t *testing.T) {
+	assert.True(t, manifest.IsManifestKey("manifest-20261016T030403Z.json"))
+	assert.True(t, manifest.IsManifestKey("backups/manifest-20261016T030403Z.json"))
+	assert.False(t, manifest.IsManifestKey("myproj-7.tar.gz"))
+	assert.False(t, manifest.IsManifestKey("manifest-project-7.tar.gz"))
+}
+
+func TestMarshalParseRoundTrip(t *testing.T) {
+	m := &manifest.Manifest{
+		Version:        manifest.FormatVersion,
+		RunID:          "20261016T030403Z",
+		ToolVersion:    "v1.2.3",
+		GitlabEndpoint: "https://gitlab.example.com",
+		StartedAt:      time.Date(2026, 10, 16, 3, 4, 3, 0, time.UTC),
+		FinishedAt:     time.Date(2026, 10, 16, 3, 9, 0, 0, time.UTC),
+		Projects: []manifest.Project{
+			{
+				ID: 7, Name: "myproj", FullPath: "group/myproj", Status: manifest.StatusSuccess,
+				ArchiveKey: "myproj-7.tar.gz", Size: 42, SHA256: "abc", Encrypted: true, DurationSeconds: 1.5,
+			},
+			{ID: 8, Name: "broken", FullPath: "group/broken", Status: manifest.StatusFailed, Error: "boom"},
+		},
+	}
+
+	data, err := m.Marshal()
+	require.NoError(t, err)
+
+	parsed, err := manifest.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, m, parsed)
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := manifest.Parse([]byte("{"))
+	require.Error(t, err)
+
+	_, err = manifest.Parse([]byte(`{"version":2}`))
+	require.ErrorIs(t, err, manifest.ErrUnsupportedVersion)
+}
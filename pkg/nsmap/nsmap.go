@@ -0,0 +1,149 @@
+// Package nsmap rewrites the recorded path of a project or group when it is
+// restored to another namespace, typically on another GitLab instance.
+//
+// A mapping file lists rules tried in order; the first rule matching a path
+// gives its target:
+//
+//	rules:
+//	  # acme/platform/app → eng/platform/app
+//	  - prefix: acme/platform
+//	    target: eng/platform
+//	  # acme/legacy-billing → eng/archive/billing
+//	  - regex: ^acme/legacy-(.+)$
+//	    target: eng/archive/$1
+//
+// A prefix rule matches the path itself and every path below it, on path
+// segment boundaries, and replaces the prefix by the target. A regex rule is
+// a Go regular expression matched anywhere in the path: every match is
+// replaced by the target, where $1 or ${name} expand to the submatches.
+package nsmap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidMapping is returned when a mapping file cannot be parsed.
+var ErrInvalidMapping = errors.New("invalid namespace mapping")
+
+// Rule is one rule of a mapping file: exactly one of Prefix and Regex is set.
+type Rule struct {
+	Prefix string `yaml:"prefix"`
+	Regex  string `yaml:"regex"`
+	Target string `yaml:"target"`
+}
+
+// Mapping is a compiled list of rules. The zero value maps no path; call
+// Parse or Load.
+type Mapping struct {
+	rules []rule
+}
+
+// rule is a compiled Rule.
+type rule struct {
+	Rule
+
+	re *regexp.Regexp
+}
+
+// file is the layout of a mapping file.
+type file struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Load reads and parses the mapping file at filePath.
+func Load(filePath string) (*Mapping, error) {
+	data, err := os.ReadFile(filePath) //nolint:gosec // the path is given by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read namespace mapping: %w", err)
+	}
+	m, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return m, nil
+}
+
+// Parse parses a YAML mapping file. It fails on an unknown field, a rule
+// without target or with other than exactly one of prefix and regex, and a
+// malformed regular expression.
+func Parse(data []byte) (*Mapping, error) {
+	var f file
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMapping, err)
+	}
+	if len(f.Rules) == 0 {
+		return nil, fmt.Errorf("%w: no rules", ErrInvalidMapping)
+	}
+	m := &Mapping{rules: make([]rule, 0, len(f.Rules))}
+	for i, r := range f.Rules {
+		compiled, err := compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rule %d: %w", ErrInvalidMapping, i+1, err)
+		}
+		m.rules = append(m.rules, compiled)
+	}
+	return m, nil
+}
+
+// compile checks and compiles r.
+//
+//nolint:err113 // wrapped with ErrInvalidMapping by the caller
+func compile(r Rule) (rule, error) {
+	r.Prefix = strings.Trim(r.Prefix, "/")
+	r.Target = strings.Trim(r.Target, "/")
+	switch {
+	case r.Target == "":
+		return rule{}, errors.New("target is required")
+	case (r.Prefix == "") == (r.Regex == ""):
+		return rule{}, errors.New("exactly one of prefix and regex is required")
+	case r.Regex == "":
+		return rule{Rule: r}, nil
+	}
+	re, err := regexp.Compile(r.Regex)
+	if err != nil {
+		return rule{}, fmt.Errorf("regex %q: %w", r.Regex, err)
+	}
+	return rule{Rule: r, re: re}, nil
+}
+
+// Map returns the target of the project or group at fullPath, given by the
+// first matching rule, and false when no rule matches or the target is not a
+// valid path.
+func (m *Mapping) Map(fullPath string) (string, bool) {
+	for _, r := range m.rules {
+		target, ok := r.apply(fullPath)
+		if !ok {
+			continue
+		}
+		target = strings.Trim(path.Clean("/"+target), "/")
+		return target, target != ""
+	}
+	return "", false
+}
+
+// apply returns the rewrite of fullPath by r, and false when r does not match.
+func (r rule) apply(fullPath string) (string, bool) {
+	if r.re != nil {
+		if !r.re.MatchString(fullPath) {
+			return "", false
+		}
+		return r.re.ReplaceAllString(fullPath, r.Target), true
+	}
+	if fullPath == r.Prefix {
+		return r.Target, true
+	}
+	if rest, ok := strings.CutPrefix(fullPath, r.Prefix+"/"); ok {
+		return r.Target + "/" + rest, true
+	}
+	return "", false
+}
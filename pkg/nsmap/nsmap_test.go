@@ -0,0 +1,100 @@
+package nsmap_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/nsmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapping_Map(t *testing.T) {
+	m, err := nsmap.Parse([]byte(`
+rules:
+  - prefix: acme/platform/
+    target: /eng/platform
+  - regex: ^acme/legacy-(?P<name>[^/]+)$
+    target: eng/archive/${name}
+  - regex: ^acme/drop(/.*)?$
+    target: $1
+  - prefix: acme
+    target: eng/acme
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		path   string
+		target string
+		ok     bool
+	}{
+		{path: "acme/platform", target: "eng/platform", ok: true},
+		{path: "acme/platform/app", target: "eng/platform/app", ok: true},
+		{path: "acme/platform/sub/lib", target: "eng/platform/sub/lib", ok: true},
+		{path: "acme/platformer/app", target: "eng/acme/platformer/app", ok: true}, // segment boundary
+		{path: "acme/legacy-billing", target: "eng/archive/billing", ok: true},
+		{path: "acme/legacy-billing/api", target: "eng/acme/legacy-billing/api", ok: true},
+		{path: "acme/drop/app", target: "app", ok: true},
+		{path: "acme/drop", ok: false}, // rewritten to an empty path
+		{path: "acme", target: "eng/acme", ok: true},
+		{path: "other/app", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			target, ok := m.Map(tt.path)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.target, target)
+		})
+	}
+}
+
+func TestMapping_FirstRuleWins(t *testing.T) {
+	m, err := nsmap.Parse([]byte(`
+rules:
+  - prefix: acme
+    target: first
+  - prefix: acme/app
+    target: second
+`))
+	require.NoError(t, err)
+
+	target, ok := m.Map("acme/app")
+	assert.True(t, ok)
+	assert.Equal(t, "first/app", target)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		msg  string
+	}{
+		{name: "no rules", data: "rules: []\n", msg: "no rules"},
+		{name: "unknown field", data: "rules:\n  - prefx: acme\n    target: eng\n", msg: "prefx"},
+		{name: "missing target", data: "rules:\n  - prefix: acme\n", msg: "rule 1: target is required"},
+		{name: "prefix and regex", data: "rules:\n  - prefix: acme\n    regex: ^acme\n    target: eng\n", msg: "exactly one"},
+		{name: "neither prefix nor regex", data: "rules:\n  - target: eng\n", msg: "exactly one"},
+		{name: "bad regex", data: "rules:\n  - regex: '(acme'\n    target: eng\n", msg: `regex "(acme"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := nsmap.Parse([]byte(tt.data))
+			require.ErrorIs(t, err, nsmap.ErrInvalidMapping)
+			assert.ErrorContains(t, err, tt.msg)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.yaml")
+	_, err := nsmap.Load(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, os.WriteFile(path, []byte("rules:\n  - prefix: acme\n    target: eng\n"), 0o600))
+	m, err := nsmap.Load(path)
+	require.NoError(t, err)
+	target, ok := m.Map("acme/app")
+	assert.True(t, ok)
+	assert.Equal(t, "eng/app", target)
+}
@@ -0,0 +1,126 @@
+// Package retention decides which backup archives of a project to keep.
+//
+// The policy follows the usual grandfather-father-son scheme: keep the N most
+// recent archives, plus the newest archive of each of the last D days, W ISO
+// weeks and M months that have a backup. An archive kept by any rule is kept;
+// everything else is removed. Archives are bucketed by their UTC time.
+package retention
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule names reported in Decision.Reasons.
+const (
+	ReasonLast    = "last"
+	ReasonDaily   = "daily"
+	ReasonWeekly  = "weekly"
+	ReasonMonthly = "monthly"
+)
+
+// Policy holds the retention rules. Zero disables a rule; a policy with every
+// rule disabled keeps everything.
+type Policy struct {
+	KeepLast    int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+}
+
+// Enabled reports whether at least one rule is set.
+func (p Policy) Enabled() bool {
+	return p.KeepLast > 0 || p.KeepDaily > 0 || p.KeepWeekly > 0 || p.KeepMonthly > 0
+}
+
+// String returns a compact description such as "last=3 daily=7".
+func (p Policy) String() string {
+	var parts []string
+	for _, r := range []struct {
+		name  string
+		count int
+	}{
+		{ReasonLast, p.KeepLast},
+		{ReasonDaily, p.KeepDaily},
+		{ReasonWeekly, p.KeepWeekly},
+		{ReasonMonthly, p.KeepMonthly},
+	} {
+		if r.count > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", r.name, r.count))
+		}
+	}
+	if len(parts) == 0 {
+		return "keep-all"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Archive is one backup archive of a project.
+type Archive struct {
+	Key  string
+	Time time.Time
+}
+
+// Decision tells whether an archive is kept and which rules kept it.
+type Decision struct {
+	Archive Archive
+	Keep    bool
+	Reasons []string
+}
+
+// rule keeps the newest archive of each of the first limit distinct buckets.
+type rule struct {
+	name   string
+	limit  int
+	bucket func(index int, t time.Time) string
+}
+
+// Apply evaluates the policy against the archives of a single project and
+// returns one decision per archive, newest first.
+func Apply(p Policy, archives []Archive) []Decision {
+	decisions := make([]Decision, len(archives))
+	for i, a := range archives {
+		decisions[i] = Decision{Archive: a, Keep: !p.Enabled()}
+	}
+	slices.SortStableFunc(decisions, func(a, b Decision) int {
+		if c := b.Archive.Time.Compare(a.Archive.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(b.Archive.Key, a.Archive.Key)
+	})
+	if !p.Enabled() {
+		return decisions
+	}
+
+	rules := []rule{
+		{ReasonLast, p.KeepLast, func(i int, _ time.Time) string { return strconv.Itoa(i) }},
+		{ReasonDaily, p.KeepDaily, func(_ int, t time.Time) string { return t.UTC().Format(time.DateOnly) }},
+		{ReasonWeekly, p.KeepWeekly, func(_ int, t time.Time) string {
+			year, week := t.UTC().ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{ReasonMonthly, p.KeepMonthly, func(_ int, t time.Time) string { return t.UTC().Format("2006-01") }},
+	}
+	for _, r := range rules {
+		if r.limit <= 0 {
+			continue
+		}
+		seen := make(map[string]bool, r.limit)
+		for i := range decisions {
+			if len(seen) >= r.limit {
+				break
+			}
+			b := r.bucket(i, decisions[i].Archive.Time)
+			if seen[b] {
+				continue
+			}
+			seen[b] = true
+			decisions[i].Keep = true
+			decisions[i].Reasons = append(decisions[i].Reasons, r.name)
+		}
+	}
+	return decisions
+}
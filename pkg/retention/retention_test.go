@@ -0,0 +1,100 @@
+package retention_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/retention"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// daily returns one archive per day at 03:00 UTC, from start going back n days.
+func daily(start time.Time, n int) []retention.Archive {
+	archives := make([]retention.Archive, n)
+	for i := range n {
+		t := start.AddDate(0, 0, -i)
+		archives[i] = retention.Archive{Key: fmt.Sprintf("p-%s.tar.gz", t.Format("20060102")), Time: t}
+	}
+	return archives
+}
+
+func kept(decisions []retention.Decision) []string {
+	var keys []string
+	for _, d := range decisions {
+		if d.Keep {
+			keys = append(keys, d.Archive.Key)
+		}
+	}
+	return keys
+}
+
+func TestPolicy_EnabledAndString(t *testing.T) {
+	assert.False(t, retention.Policy{}.Enabled())
+	assert.Equal(t, "keep-all", retention.Policy{}.String())
+
+	p := retention.Policy{KeepLast: 3, KeepMonthly: 6}
+	assert.True(t, p.Enabled())
+	assert.Equal(t, "last=3 monthly=6", p.String())
+}
+
+func TestApply_DisabledKeepsEverything(t *testing.T) {
+	archives := daily(time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC), 5)
+	decisions := retention.Apply(retention.Policy{}, archives)
+	assert.Len(t, kept(decisions), 5)
+}
+
+func TestApply_KeepLast(t *testing.T) {
+	archives := daily(time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC), 5)
+	// Shuffle input order: Apply must sort newest first.
+	archives[0], archives[4] = archives[4], archives[0]
+
+	decisions := retention.Apply(retention.Policy{KeepLast: 2}, archives)
+
+	require.Len(t, decisions, 5)
+	assert.Equal(t, []string{"p-20261016.tar.gz", "p-20261015.tar.gz"}, kept(decisions))
+	assert.Equal(t, []string{retention.ReasonLast}, decisions[0].Reasons)
+	assert.False(t, decisions[4].Keep)
+}
+
+func TestApply_KeepDailyUsesNewestOfEachDay(t *testing.T) {
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	archives := []retention.Archive{
+		{Key: "morning", Time: day.Add(3 * time.Hour)},
+		{Key: "evening", Time: day.Add(20 * time.Hour)},
+		{Key: "yesterday", Time: day.Add(-4 * time.Hour)},
+		{Key: "two-days-ago", Time: day.Add(-28 * time.Hour)},
+	}
+
+	decisions := retention.Apply(retention.Policy{KeepDaily: 2}, archives)
+
+	assert.Equal(t, []string{"evening", "yesterday"}, kept(decisions))
+}
+
+func TestApply_GFS(t *testing.T) {
+	// 90 daily backups ending on Friday 2026-10-16.
+	archives := daily(time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC), 90)
+
+	decisions := retention.Apply(retention.Policy{KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 3}, archives)
+
+	assert.Equal(t, []string{
+		"p-20261016.tar.gz", // daily, weekly, monthly (October)
+		"p-20261015.tar.gz",
+		"p-20261014.tar.gz",
+		"p-20261013.tar.gz",
+		"p-20261012.tar.gz",
+		"p-20261011.tar.gz", // daily + weekly (Sunday closes ISO week 41)
+		"p-20261010.tar.gz",
+		"p-20261004.tar.gz", // weekly (week 40)
+		"p-20260930.tar.gz", // monthly (September)
+		"p-20260927.tar.gz", // weekly (week 39)
+		"p-20260831.tar.gz", // monthly (August)
+	}, kept(decisions))
+
+	for _, d := range decisions {
+		if d.Archive.Key == "p-20261016.tar.gz" {
+			assert.Equal(t, []string{retention.ReasonDaily, retention.ReasonWeekly, retention.ReasonMonthly}, d.Reasons)
+		}
+	}
+}
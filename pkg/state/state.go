@@ -0,0 +1,117 @@
+// Package state persists per-project backup state between runs so that
+// incremental backups can skip projects that have not changed.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"github.com/sgaunet/gitlab-backup/pkg/fileutil"
+)
+
+// currentVersion is the on-disk format version written by Save.
+const currentVersion = 1
+
+// ErrUnsupportedVersion is returned when the state file was written by an incompatible version.
+var ErrUnsupportedVersion = errors.New("unsupported state file version")
+
+// ProjectState records the last successful backup of a project.
+type ProjectState struct {
+	// LastBackupAt is when the last successful backup finished.
+	LastBackupAt time.Time `json:"lastBackupAt"`
+	// LastActivityAt is the project's last_activity_at as seen by that backup.
+	LastActivityAt time.Time `json:"lastActivityAt"`
+}
+
+// fileFormat is the JSON layout of the state file.
+type fileFormat struct {
+	Version  int                    `json:"version"`
+	Projects map[int64]ProjectState `json:"projects"`
+}
+
+// Store is a JSON file backed, concurrency-safe map of project ID to ProjectState.
+type Store struct {
+	mu       sync.Mutex
+	path     string
+	projects map[int64]ProjectState
+}
+
+// Load reads the state file at path. A missing file yields an empty store,
+// so the first incremental run behaves like a full backup.
+func Load(path string) (*Store, error) {
+	s := &Store{
+		path:     path,
+		projects: make(map[int64]ProjectState),
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // G304: state file path comes from configuration
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read state file %s: %w", path, err)
+	}
+
+	var f fileFormat
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if f.Version != currentVersion {
+		return nil, fmt.Errorf("%w: %d (state file %s)", ErrUnsupportedVersion, f.Version, path)
+	}
+	for id, ps := range f.Projects {
+		s.projects[id] = ps
+	}
+	return s, nil
+}
+
+// Get returns the stored state of a project.
+func (s *Store) Get(projectID int64) (ProjectState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, ok := s.projects[projectID]
+	return ps, ok
+}
+
+// Unchanged reports whether the project has had no activity since its last
+// recorded backup. An unknown activity time is never considered unchanged.
+func (s *Store) Unchanged(projectID int64, lastActivityAt time.Time) bool {
+	if lastActivityAt.IsZero() {
+		return false
+	}
+	ps, ok := s.Get(projectID)
+	if !ok || ps.LastActivityAt.IsZero() {
+		return false
+	}
+	return !lastActivityAt.After(ps.LastActivityAt)
+}
+
+// Record stores a successful backup of a project.
+func (s *Store) Record(projectID int64, backupAt, lastActivityAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.projects[projectID] = ProjectState{
+		LastBackupAt:   backupAt.UTC(),
+		LastActivityAt: lastActivityAt.UTC(),
+	}
+}
+
+// Save writes the store to its file atomically (temporary file, then rename).
+func (s *Store) Save() error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(fileFormat{Version: currentVersion, Projects: s.projects}, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	if err := fileutil.WriteAtomic(s.path, data, constants.DefaultFilePermission); err != nil {
+		return fmt.Errorf("failed to save state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package state_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-backup/pkg/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_MissingFileIsEmpty(t *testing.T) {
+	s, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+
+	_, ok := s.Get(1)
+	assert.False(t, ok)
+}
+
+func TestLoad_InvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+	_, err := state.Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse state file")
+}
+
+func TestLoad_UnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":99,"projects":{}}`), 0o600))
+
+	_, err := state.Load(path)
+	require.ErrorIs(t, err, state.ErrUnsupportedVersion)
+}
+
+func TestStore_SaveAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := state.Load(path)
+	require.NoError(t, err)
+
+	backupAt := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+	activity := time.Date(2026, 10, 15, 12, 30, 0, 0, time.UTC)
+	s.Record(42, backupAt, activity)
+	require.NoError(t, s.Save())
+
+	reloaded, err := state.Load(path)
+	require.NoError(t, err)
+	ps, ok := reloaded.Get(42)
+	require.True(t, ok)
+	assert.True(t, backupAt.Equal(ps.LastBackupAt))
+	assert.True(t, activity.Equal(ps.LastActivityAt))
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestStore_Unchanged(t *testing.T) {
+	s, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+
+	activity := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	s.Record(1, activity.Add(time.Hour), activity)
+
+	assert.True(t, s.Unchanged(1, activity), "same activity timestamp")
+	assert.True(t, s.Unchanged(1, activity.Add(-time.Minute)), "older activity timestamp")
+	assert.False(t, s.Unchanged(1, activity.Add(time.Second)), "new activity")
+	assert.False(t, s.Unchanged(2, activity), "never backed up")
+	assert.False(t, s.Unchanged(1, time.Time{}), "unknown activity")
+}
@@ -14,6 +14,9 @@
 //
 // Archive operations:
 //   - ValidateArchive: Verify tar.gz format
+//   - ValidateArchiveStream: Verify the tar.gz format of a stream (e.g. a decrypted archive)
+//   - ValidateProjectExport: Read the whole archive (tar entries, gzip CRC) and
+//     check the GitLab export entries; WalkArchive visits every entry
 //   - ExtractArchive: Extract archive to temporary directory (with path traversal protection)
 //
 // Archives created by gitlab-backup contain:
@@ -106,6 +109,26 @@ func ExtractArchive(ctx context.Context, archivePath string, destDir string) (*A
 // It checks the file exists, is readable, and has valid gzip/tar format.
 // It does NOT extract the archive.
 func ValidateArchive(archivePath string) error {
+	if err := CheckArchiveFile(archivePath); err != nil {
+		return err
+	}
+
+	// Open file
+	//nolint:gosec // G304: Archive path is provided by caller and validated
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return ValidateArchiveStream(file)
+}
+
+// CheckArchiveFile checks that archivePath is an existing, non-empty regular
+// file, without reading it.
+func CheckArchiveFile(archivePath string) error {
 	// Check file exists
 	info, err := os.Stat(archivePath)
 	if err != nil {
@@ -124,19 +147,15 @@ func ValidateArchive(archivePath string) error {
 	if info.Size() == 0 {
 		return fmt.Errorf("%w: %s", ErrArchiveEmpty, archivePath)
 	}
+	return nil
+}
 
-	// Open file
-	//nolint:gosec // G304: Archive path is provided by caller and validated
-	file, err := os.Open(archivePath)
-	if err != nil {
-		return fmt.Errorf("failed to open archive: %w", err)
-	}
-	defer func() {
-		_ = file.Close()
-	}()
-
+// ValidateArchiveStream validates that r starts a valid tar.gz stream, as
+// ValidateArchive does for a file. It reads the stream up to the first tar
+// header only, e.g. to check a decrypted archive before importing it.
+func ValidateArchiveStream(r io.Reader) error {
 	// Validate gzip format by creating reader
-	gzr, err := gzip.NewReader(file)
+	gzr, err := gzip.NewReader(r)
 	if err != nil {
 		return fmt.Errorf("invalid gzip format: %w", err)
 	}
@@ -2,10 +2,12 @@ package storage_test
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/sgaunet/gitlab-backup/pkg/storage"
@@ -58,6 +60,15 @@ func TestValidateArchive(t *testing.T) {
 	})
 }
 
+func TestValidateArchiveStream(t *testing.T) {
+	data, err := os.ReadFile(createTestArchive(t, map[string]string{"project.json": "{}"}))
+	require.NoError(t, err)
+	require.NoError(t, storage.ValidateArchiveStream(bytes.NewReader(data)))
+
+	err = storage.ValidateArchiveStream(strings.NewReader("age-encryption.org/v1\n"))
+	require.ErrorContains(t, err, "invalid gzip format")
+}
+
 func TestExtractArchive(t *testing.T) {
 	ctx := context.Background()
 
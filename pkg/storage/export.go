@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	// ErrArchiveCorrupted is returned when an archive cannot be read to the
+	// end: truncated, invalid tar entry or gzip checksum mismatch.
+	ErrArchiveCorrupted = errors.New("archive is corrupted")
+	// ErrArchiveIncomplete is returned when an archive lacks entries of a
+	// GitLab project export.
+	ErrArchiveIncomplete = errors.New("archive is not a complete GitLab project export")
+)
+
+// GitLab project export entries checked by ValidateProjectExport.
+const (
+	ExportVersionEntry     = "VERSION"
+	ExportProjectEntry     = "project.json"      // legacy JSON export
+	ExportTreeProjectEntry = "tree/project.json" // ndjson export
+	ExportBundleEntry      = "project.bundle"    // absent for an empty repository
+)
+
+// WalkFunc is called by WalkArchive for every entry of an archive. name is
+// the entry name without its leading "./". content reads the entry; it may
+// be left unread.
+type WalkFunc func(name string, hdr *tar.Header, content io.Reader) error
+
+// WalkArchive reads the whole tar.gz stream r, calling fn (when not nil) for
+// every entry. Reading to the end checks every tar header, the size of every
+// entry and the gzip checksum, so a truncated or corrupted archive fails with
+// ErrArchiveCorrupted. An error returned by fn stops the walk as is.
+//
+// ctx is checked between entries: walking a large archive takes a while.
+func WalkArchive(ctx context.Context, r io.Reader, fn WalkFunc) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("%w: invalid gzip format: %w", ErrArchiveCorrupted, err)
+	}
+	defer func() {
+		_ = gzr.Close()
+	}()
+
+	tr := tar.NewReader(gzr)
+	for {
+		if ctx.Err() != nil {
+			return fmt.Errorf("operation cancelled: %w", ctx.Err())
+		}
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: invalid tar format: %w", ErrArchiveCorrupted, err)
+		}
+		name := EntryName(hdr.Name)
+		if fn != nil {
+			if err := fn(name, hdr, tr); err != nil {
+				return err
+			}
+		}
+		// Read what fn left: the tar reader checks the entry size.
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return fmt.Errorf("%w: entry %s: %w", ErrArchiveCorrupted, name, err)
+		}
+	}
+
+	// The gzip checksum is only verified once the stream is read to its end,
+	// past the tar end-of-archive blocks.
+	if _, err := io.Copy(io.Discard, gzr); err != nil {
+		return fmt.Errorf("%w: %w", ErrArchiveCorrupted, err)
+	}
+	return nil
+}
+
+// EntryName normalizes the name of an archive entry: GitLab exports are
+// created from "." so their entries start with "./".
+func EntryName(name string) string {
+	return strings.TrimPrefix(path.Clean(strings.TrimPrefix(name, "./")), "/")
+}
+
+// ValidateArchiveStreamFull is ValidateArchiveStream reading the whole stream
+// (see WalkArchive), for archives that are not project exports.
+func ValidateArchiveStreamFull(ctx context.Context, r io.Reader) error {
+	return WalkArchive(ctx, r, nil)
+}
+
+// ValidateProjectExport reads the whole archive at archivePath (see
+// WalkArchive) and checks that it holds a GitLab project export.
+func ValidateProjectExport(ctx context.Context, archivePath string) error {
+	if err := CheckArchiveFile(archivePath); err != nil {
+		return err
+	}
+	//nolint:gosec // G304: Archive path is provided by caller and validated
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return ValidateProjectExportStream(ctx, file)
+}
+
+// ValidateProjectExportStream reads the whole tar.gz stream r (see
+// WalkArchive) and checks that it holds the entries of a GitLab project
+// export: VERSION, and project.json (legacy) or tree/project.json (ndjson).
+// The repository bundle project.bundle is not required: GitLab exports a
+// project with an empty repository without it. Missing entries wrap
+// ErrArchiveIncomplete.
+func ValidateProjectExportStream(ctx context.Context, r io.Reader) error {
+	entries := make(map[string]bool)
+	err := WalkArchive(ctx, r, func(name string, _ *tar.Header, _ io.Reader) error {
+		entries[name] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if missing := missingExportEntries(entries); len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrArchiveIncomplete, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// missingExportEntries returns the project export entries absent from
+// entries. project.bundle is optional, see ValidateProjectExportStream.
+func missingExportEntries(entries map[string]bool) []string {
+	var missing []string
+	if !entries[ExportVersionEntry] {
+		missing = append(missing, ExportVersionEntry)
+	}
+	if !entries[ExportProjectEntry] && !entries[ExportTreeProjectEntry] {
+		missing = append(missing, ExportProjectEntry+" or "+ExportTreeProjectEntry)
+	}
+	return missing
+}
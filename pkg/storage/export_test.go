@@ -0,0 +1,121 @@
+package storage_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportArchive returns a tar.gz holding names, each with its name as content.
+func exportArchive(t *testing.T, names ...string) []byte {
+	t.Helper()
+	files := make([][2]string, 0, len(names))
+	for _, name := range names {
+		files = append(files, [2]string{name, name})
+	}
+	return exportFiles(t, files...)
+}
+
+// exportFiles returns a tar.gz holding files, as {name, content} pairs.
+func exportFiles(t *testing.T, files ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for _, f := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f[0], Mode: 0o600, Size: int64(len(f[1]))}))
+		_, err := tw.Write([]byte(f[1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return buf.Bytes()
+}
+
+func TestValidateProjectExportStream(t *testing.T) {
+	ctx := context.Background()
+	ndjson := exportArchive(t, "./VERSION", "./tree/project.json", "./tree/project/issues.ndjson", "./project.bundle")
+	require.NoError(t, storage.ValidateProjectExportStream(ctx, bytes.NewReader(ndjson)))
+
+	legacy := exportArchive(t, "VERSION", "project.json", "project.bundle")
+	require.NoError(t, storage.ValidateProjectExportStream(ctx, bytes.NewReader(legacy)))
+
+	err := storage.ValidateProjectExportStream(ctx, bytes.NewReader(exportArchive(t, "./VERSION", "./uploads/a.png")))
+	require.ErrorIs(t, err, storage.ErrArchiveIncomplete)
+	assert.Contains(t, err.Error(), "missing project.json or tree/project.json")
+}
+
+func TestValidateProjectExportStream_EmptyRepository(t *testing.T) {
+	// GitLab exports a project with an empty repository without project.bundle.
+	data := exportArchive(t, "./VERSION", "./tree/project.json", "./tree/project/labels.ndjson")
+	require.NoError(t, storage.ValidateProjectExportStream(context.Background(), bytes.NewReader(data)))
+}
+
+func TestValidateProjectExportStream_Corrupted(t *testing.T) {
+	ctx := context.Background()
+	data := exportArchive(t, "VERSION", "project.json", "project.bundle")
+
+	// The gzip trailer is the CRC-32, then the size, of the content.
+	badChecksum := bytes.Clone(data)
+	badChecksum[len(badChecksum)-8] ^= 0xff
+
+	tests := map[string][]byte{
+		"not gzip":     []byte("not gzip data"),
+		"truncated":    data[:len(data)-10],
+		"bad checksum": badChecksum,
+	}
+	for name, archive := range tests {
+		err := storage.ValidateProjectExportStream(ctx, bytes.NewReader(archive))
+		require.ErrorIs(t, err, storage.ErrArchiveCorrupted, name)
+		// The quick check reads the first header only and misses the damage.
+		if name != "not gzip" {
+			require.NoError(t, storage.ValidateArchiveStream(bytes.NewReader(archive)), name)
+		}
+	}
+}
+
+func TestValidateProjectExport(t *testing.T) {
+	ctx := context.Background()
+	archivePath := filepath.Join(t.TempDir(), "app-1.tar.gz")
+	require.NoError(t, os.WriteFile(archivePath, exportArchive(t, "VERSION", "project.json", "project.bundle"), 0o600))
+	require.NoError(t, storage.ValidateProjectExport(ctx, archivePath))
+
+	require.ErrorIs(t, storage.ValidateProjectExport(ctx, archivePath+".missing"), storage.ErrArchiveNotFound)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, storage.ValidateProjectExport(cancelled, archivePath), context.Canceled)
+}
+
+func TestWalkArchive(t *testing.T) {
+	data := exportArchive(t, "./VERSION", "./tree/project.json", "./project.bundle")
+	visited := map[string]string{}
+	err := storage.WalkArchive(context.Background(), bytes.NewReader(data),
+		func(name string, _ *tar.Header, content io.Reader) error {
+			if name == "VERSION" {
+				b, err := io.ReadAll(content)
+				visited[name] = string(b)
+				return err
+			}
+			visited[name] = ""
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"VERSION": "./VERSION", "tree/project.json": "", "project.bundle": ""}, visited)
+
+	stop := assert.AnError
+	err = storage.WalkArchive(context.Background(), bytes.NewReader(data),
+		func(string, *tar.Header, io.Reader) error { return stop })
+	require.ErrorIs(t, err, stop)
+
+	require.NoError(t, storage.ValidateArchiveStreamFull(context.Background(), bytes.NewReader(data)))
+}
@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Kinds of export reported by ExportSummary.Kind.
+const (
+	ExportKindProject = "project"
+	ExportKindGroup   = "group"
+)
+
+// Entries of a GitLab export read by InspectExport.
+const (
+	exportRelationDir = "tree/project/"
+	exportLFSDir      = "lfs-objects/"
+	exportGroupTree   = "tree/groups/_all.ndjson" // ndjson group export
+	exportGroupJSON   = "group.json"              // legacy group export
+	ndjsonExt         = ".ndjson"
+)
+
+// bundleSignatures are the first lines of the git bundle formats.
+var bundleSignatures = []string{"# v2 git bundle\n", "# v3 git bundle\n"}
+
+// errInvalidBundle is returned when project.bundle is not a git bundle.
+var errInvalidBundle = errors.New("invalid repository bundle")
+
+// ExportSummary describes the content of a GitLab export archive, see
+// InspectExport. The counts are those of a project export; they stay zero
+// for a group export.
+type ExportSummary struct {
+	Kind        string `json:"kind"`           // ExportKindProject or ExportKindGroup
+	Version     string `json:"version"`        // content of VERSION, the export format version
+	Name        string `json:"name,omitempty"` // project name, when the export records it
+	Description string `json:"description,omitempty"`
+	Entries     int    `json:"entries"`
+	Size        int64  `json:"size"` // uncompressed size of the entries
+
+	Issues        int `json:"issues"`
+	MergeRequests int `json:"mergeRequests"`
+	Labels        int `json:"labels"`
+	Milestones    int `json:"milestones"`
+	Pipelines     int `json:"ciPipelines"`
+	Releases      int `json:"releases"`
+	LFSObjects    int `json:"lfsObjects"`
+	Refs          int `json:"repositoryRefs"` // refs of project.bundle, HEAD included
+	Branches      int `json:"branches"`
+	Tags          int `json:"tags"`
+
+	// DefaultBranch is the branch HEAD points to in project.bundle, and
+	// HeadCommit its commit; both are empty when the bundle records no HEAD.
+	DefaultBranch string `json:"defaultBranch,omitempty"`
+	HeadCommit    string `json:"headCommit,omitempty"`
+	// EmptyRepository is set when the project export has no project.bundle,
+	// as GitLab exports a project with an empty repository.
+	EmptyRepository bool `json:"emptyRepository,omitempty"`
+
+	// Missing lists the project export entries GitLab needs to import it
+	// that the archive lacks, see ValidateProjectExportStream.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Complete reports whether the archive holds every entry GitLab needs to
+// import it. Group exports are not checked.
+func (s *ExportSummary) Complete() bool {
+	return len(s.Missing) == 0
+}
+
+// projectAttributes are the attributes of project.json read by
+// InspectExport. Relations are only set in a legacy JSON export: an ndjson
+// export stores each relation in tree/project/<relation>.ndjson.
+type projectAttributes struct {
+	Name          string            `json:"name"`
+	Description   string            `json:"description"`
+	Issues        []json.RawMessage `json:"issues"`
+	MergeRequests []json.RawMessage `json:"merge_requests"` //nolint:tagliatelle // GitLab export format
+	Labels        []json.RawMessage `json:"labels"`
+	Milestones    []json.RawMessage `json:"milestones"`
+	Pipelines     []json.RawMessage `json:"ci_pipelines"` //nolint:tagliatelle // GitLab export format
+	Releases      []json.RawMessage `json:"releases"`
+}
+
+// InspectExport reads the whole tar.gz stream r (see WalkArchive) and
+// summarizes the GitLab export it holds: its version, the project name and
+// description, and the number of issues, merge requests, labels, milestones,
+// CI pipelines, releases, LFS objects and repository refs. Both the ndjson
+// and the legacy JSON export formats are read.
+//
+// A truncated or corrupted archive, or an entry that cannot be parsed, fails
+// with ErrArchiveCorrupted; an incomplete project export is reported by
+// ExportSummary.Missing. A legacy project.json is decoded in memory.
+func InspectExport(ctx context.Context, r io.Reader) (*ExportSummary, error) {
+	s := &ExportSummary{Kind: ExportKindProject}
+	entries := make(map[string]bool)
+	err := WalkArchive(ctx, r, func(name string, hdr *tar.Header, content io.Reader) error {
+		entries[name] = true
+		if hdr.Typeflag != tar.TypeReg {
+			return nil
+		}
+		s.Entries++
+		s.Size += hdr.Size
+		if err := s.readEntry(name, content); err != nil {
+			return fmt.Errorf("%w: entry %s: %w", ErrArchiveCorrupted, name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if entries[exportGroupTree] || entries[exportGroupJSON] {
+		s.Kind = ExportKindGroup
+		return s, nil
+	}
+	s.Missing = missingExportEntries(entries)
+	s.EmptyRepository = !entries[ExportBundleEntry]
+	return s, nil
+}
+
+// readEntry adds the entry name of a project export, read from content, to
+// the summary.
+func (s *ExportSummary) readEntry(name string, content io.Reader) error {
+	switch {
+	case name == ExportVersionEntry:
+		return s.readVersion(content)
+	case name == ExportProjectEntry || name == ExportTreeProjectEntry:
+		return s.readProject(content)
+	case name == ExportBundleEntry:
+		return s.readBundle(content)
+	case strings.HasPrefix(name, exportLFSDir):
+		s.LFSObjects++
+	case strings.HasPrefix(name, exportRelationDir) && strings.HasSuffix(name, ndjsonExt):
+		relation := strings.TrimSuffix(strings.TrimPrefix(name, exportRelationDir), ndjsonExt)
+		if count := s.relationCount(relation); count != nil {
+			n, err := countLines(content)
+			*count += n
+			return err
+		}
+	}
+	return nil
+}
+
+// relationCount returns the counter of a project relation, nil for the
+// relations that are not summarized.
+func (s *ExportSummary) relationCount(relation string) *int {
+	switch relation {
+	case "issues":
+		return &s.Issues
+	case "merge_requests":
+		return &s.MergeRequests
+	case "labels":
+		return &s.Labels
+	case "milestones":
+		return &s.Milestones
+	case "ci_pipelines":
+		return &s.Pipelines
+	case "releases":
+		return &s.Releases
+	default:
+		return nil
+	}
+}
+
+// readVersion reads VERSION, the version of the export format.
+func (s *ExportSummary) readVersion(content io.Reader) error {
+	const maxVersionSize = 64
+	version, err := io.ReadAll(io.LimitReader(content, maxVersionSize))
+	if err != nil {
+		return fmt.Errorf("failed to read version: %w", err)
+	}
+	s.Version = strings.TrimSpace(string(version))
+	return nil
+}
+
+// readProject reads project.json: the project attributes, and in a legacy
+// JSON export every relation of the project.
+func (s *ExportSummary) readProject(content io.Reader) error {
+	var p projectAttributes
+	if err := json.NewDecoder(content).Decode(&p); err != nil {
+		return fmt.Errorf("invalid project attributes: %w", err)
+	}
+	s.Name, s.Description = p.Name, p.Description
+	s.Issues += len(p.Issues)
+	s.MergeRequests += len(p.MergeRequests)
+	s.Labels += len(p.Labels)
+	s.Milestones += len(p.Milestones)
+	s.Pipelines += len(p.Pipelines)
+	s.Releases += len(p.Releases)
+	return nil
+}
+
+// readBundle counts the refs listed in the header of the git bundle: after
+// the signature (and the capabilities of a v3 bundle), a "<oid> <ref>" line
+// per ref, "-<oid>" prerequisites, then an empty line before the pack data.
+// The default branch is the first branch at the commit of HEAD, as the
+// bundle does not record which branch HEAD refers to.
+func (s *ExportSummary) readBundle(content io.Reader) error {
+	var heads [][2]string
+	br := bufio.NewReader(content)
+	signature, err := br.ReadString('\n')
+	if err != nil || !slices.Contains(bundleSignatures, signature) {
+		return errInvalidBundle
+	}
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("%w: %w", errInvalidBundle, err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			s.DefaultBranch = defaultBranch(heads, s.HeadCommit)
+			return nil
+		}
+		if strings.HasPrefix(line, "@") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		oid, ref, _ := strings.Cut(line, " ")
+		s.Refs++
+		switch {
+		case ref == "HEAD":
+			s.HeadCommit = oid
+		case strings.HasPrefix(ref, "refs/heads/"):
+			s.Branches++
+			heads = append(heads, [2]string{strings.TrimPrefix(ref, "refs/heads/"), oid})
+		case strings.HasPrefix(ref, "refs/tags/"):
+			s.Tags++
+		}
+	}
+}
+
+// defaultBranch returns the first of the branches, given as name and commit,
+// at commit head, empty if there is none.
+func defaultBranch(heads [][2]string, head string) string {
+	if head == "" {
+		return ""
+	}
+	for _, branch := range heads {
+		if branch[1] == head {
+			return branch[0]
+		}
+	}
+	return ""
+}
+
+// countLines counts the lines of an ndjson stream, one record per line.
+// Lines are not read whole: a record may be larger than any buffer.
+func countLines(r io.Reader) (int, error) {
+	buf := make([]byte, 32*1024) //nolint:mnd // read buffer size
+	lines, last := 0, byte('\n')
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if errors.Is(err, io.EOF) {
+			if last != '\n' {
+				lines++ // last record without a trailing newline
+			}
+			return lines, nil
+		}
+		if err != nil {
+			return lines, fmt.Errorf("failed to read records: %w", err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBundle = "# v2 git bundle\n" +
+	"-1111111111111111111111111111111111111111 prerequisite\n" +
+	"2222222222222222222222222222222222222222 HEAD\n" +
+	"2222222222222222222222222222222222222222 refs/heads/main\n" +
+	"3333333333333333333333333333333333333333 refs/heads/feature\n" +
+	"4444444444444444444444444444444444444444 refs/tags/v1.0.0\n" +
+	"\nPACK\x00\x00\x00\x02"
+
+func TestInspectExport_NDJSON(t *testing.T) {
+	data := exportFiles(t,
+		[2]string{"./VERSION", "0.2.4\n"},
+		[2]string{"./tree/project.json", `{"name":"app","description":"The app","visibility_level":0}`},
+		[2]string{"./tree/project/issues.ndjson", "{\"iid\":1}\n{\"iid\":2}\n{\"iid\":3}"},
+		[2]string{"./tree/project/merge_requests.ndjson", "{\"iid\":1}\n{\"iid\":2}\n"},
+		[2]string{"./tree/project/labels.ndjson", "{\"title\":\"bug\"}\n"},
+		[2]string{"./tree/project/milestones.ndjson", ""},
+		[2]string{"./tree/project/ci_pipelines.ndjson", "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n{\"id\":4}\n"},
+		[2]string{"./tree/project/releases.ndjson", "{\"tag\":\"v1.0.0\"}\n"},
+		[2]string{"./tree/project/snippets.ndjson", "{\"id\":1}\n"},
+		[2]string{"./lfs-objects/0123abcd", "lfs"},
+		[2]string{"./lfs-objects/4567efgh", "lfs"},
+		[2]string{"./lfs-objects.json", "{}"},
+		[2]string{"./project.bundle", testBundle},
+	)
+
+	s, err := storage.InspectExport(context.Background(), bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, &storage.ExportSummary{
+		Kind:          storage.ExportKindProject,
+		Version:       "0.2.4",
+		Name:          "app",
+		Description:   "The app",
+		Entries:       13,
+		Size:          s.Size,
+		Issues:        3,
+		MergeRequests: 2,
+		Labels:        1,
+		Pipelines:     4,
+		Releases:      1,
+		LFSObjects:    2,
+		Refs:          4,
+		Branches:      2,
+		Tags:          1,
+		DefaultBranch: "main",
+		HeadCommit:    "2222222222222222222222222222222222222222",
+	}, s)
+	assert.Positive(t, s.Size)
+	assert.True(t, s.Complete())
+}
+
+func TestInspectExport_LegacyJSON(t *testing.T) {
+	project := `{"name":"legacy","description":"","issues":[{},{}],"merge_requests":[{}],` +
+		`"labels":[{},{},{}],"milestones":[{}],"ci_pipelines":[],"releases":[{}]}`
+	data := exportFiles(t,
+		[2]string{"VERSION", "0.2.4"},
+		[2]string{"project.json", project},
+	)
+
+	s, err := storage.InspectExport(context.Background(), bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", s.Name)
+	assert.Equal(t, []int{2, 1, 3, 1, 0, 1},
+		[]int{s.Issues, s.MergeRequests, s.Labels, s.Milestones, s.Pipelines, s.Releases})
+	assert.True(t, s.Complete(), "a project with an empty repository has no project.bundle")
+	assert.True(t, s.EmptyRepository)
+}
+
+func TestInspectExport_Group(t *testing.T) {
+	data := exportFiles(t,
+		[2]string{"VERSION", "0.2.4"},
+		[2]string{"GITLAB_VERSION", "17.0.0"},
+		[2]string{"tree/groups/_all.ndjson", "{\"id\":1}\n"},
+	)
+	s, err := storage.InspectExport(context.Background(), bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, storage.ExportKindGroup, s.Kind)
+	assert.True(t, s.Complete(), "a group export is not checked for project entries")
+}
+
+func TestInspectExport_Corrupted(t *testing.T) {
+	ctx := context.Background()
+	data := exportFiles(t, [2]string{"VERSION", "0.2.4"}, [2]string{"project.bundle", "not a bundle"})
+	_, err := storage.InspectExport(ctx, bytes.NewReader(data))
+	require.ErrorIs(t, err, storage.ErrArchiveCorrupted)
+	assert.Contains(t, err.Error(), "project.bundle")
+
+	data = exportFiles(t, [2]string{"project.json", `{"name":`})
+	_, err = storage.InspectExport(ctx, bytes.NewReader(data))
+	require.ErrorIs(t, err, storage.ErrArchiveCorrupted)
+
+	data = exportArchive(t, "VERSION", "tree/project/issues.ndjson")
+	_, err = storage.InspectExport(ctx, bytes.NewReader(data[:len(data)-10]))
+	require.ErrorIs(t, err, storage.ErrArchiveCorrupted)
+}
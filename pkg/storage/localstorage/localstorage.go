@@ -6,9 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
 )
 
 var (
@@ -16,16 +21,27 @@ var (
 	ErrShortWrite = errors.New("short write")
 )
 
+const (
+	// partialSuffix ends the name of the temporary file of SaveStream.
+	partialSuffix = ".partial"
+	// stalePartialAge is how long a temporary file of SaveStream must be left
+	// untouched before NewLocalStorage deletes it as the leftover of a crash.
+	stalePartialAge = 24 * time.Hour
+)
+
 // LocalStorage implements storage interface for local file system.
 type LocalStorage struct {
 	dirpath string
 }
 
-// NewLocalStorage creates a new LocalStorage instance.
+// NewLocalStorage creates a new LocalStorage instance. Temporary files left
+// in dirpath by an interrupted SaveStream are deleted, see removeStalePartials.
 func NewLocalStorage(dirpath string) *LocalStorage {
-	return &LocalStorage{
+	s := &LocalStorage{
 		dirpath: dirpath,
 	}
+	s.removeStalePartials(time.Now().Add(-stalePartialAge))
+	return s
 }
 
 // SaveFile saves the file in localstorage with context cancellation support.
@@ -43,7 +59,13 @@ func (s *LocalStorage) SaveFile(ctx context.Context, archiveFilePath string, dst
 	defer func() { _ = src.Close() }()
 
 	// save file in localstorage
-	dstPath := s.dirpath + "/" + dstFilename
+	dstPath, err := s.keyPath(dstFilename)
+	if err != nil {
+		return err
+	}
+	if err := s.makeParentDirs(dstPath); err != nil {
+		return err
+	}
 	fDst, err := os.Create(dstPath) //nolint:gosec // G304: File creation is intentional for backup functionality
 	if err != nil {
 		return fmt.Errorf("failed to create destination file %s/%s: %w", s.dirpath, dstFilename, err)
@@ -54,6 +76,64 @@ func (s *LocalStorage) SaveFile(ctx context.Context, archiveFilePath string, dst
 	return copyWithContext(ctx, fDst, src, dstPath)
 }
 
+// SaveStream stores everything read from r under key. The data is written to
+// a temporary file next to the destination, renamed into place once r is fully
+// read, so a failed or cancelled stream never leaves a partial archive.
+func (s *LocalStorage) SaveStream(ctx context.Context, r io.Reader, key string) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("operation cancelled before starting: %w", ctx.Err())
+	}
+	dstPath, err := s.keyPath(key)
+	if err != nil {
+		return err
+	}
+	if err := s.makeParentDirs(dstPath); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), "."+filepath.Base(dstPath)+".*"+partialSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %w", dstPath, err)
+	}
+	tmpPath := tmp.Name()
+
+	// copyWithContext removes tmpPath on failure.
+	copyErr := copyWithContext(ctx, tmp, r, tmpPath)
+	closeErr := tmp.Close()
+	if copyErr != nil {
+		return copyErr
+	}
+	if closeErr != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to close temporary file %s: %w", tmpPath, closeErr)
+	}
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename %s to %s: %w", tmpPath, dstPath, err)
+	}
+	return nil
+}
+
+// removeStalePartials deletes the temporary files of SaveStream last modified
+// before cutoff: a process killed while streaming leaves its temporary file
+// behind. More recent ones may belong to a stream in progress and are kept.
+// Failures are ignored, a leftover file only takes up space.
+func (s *LocalStorage) removeStalePartials(cutoff time.Time) {
+	_ = filepath.WalkDir(s.dirpath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isPartial(d.Name()) {
+			return nil //nolint:nilerr // best effort, unreadable entries are skipped
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().Before(cutoff) {
+			_ = os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// isPartial reports whether name is that of a temporary file of SaveStream.
+func isPartial(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, partialSuffix)
+}
+
 // copyWithContext performs a buffered copy with periodic context cancellation checks.
 // It cleans up the destination file on error or cancellation.
 func copyWithContext(ctx context.Context, dst io.Writer, src io.Reader, dstPath string) error {
@@ -88,3 +168,94 @@ func copyWithContext(ctx context.Context, dst io.Writer, src io.Reader, dstPath
 
 	return nil
 }
+
+// List returns the files below the storage directory whose slash-separated
+// relative path starts with prefix.
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]storage.Object, error) {
+	var objects []storage.Object
+	err := filepath.WalkDir(s.dirpath, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("listing cancelled: %w", ctx.Err())
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dirpath, path)
+		if err != nil {
+			return fmt.Errorf("failed to compute key of %s: %w", path, err)
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		objects = append(objects, storage.Object{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", s.dirpath, err)
+	}
+	return objects, nil
+}
+
+// Delete removes the file stored under key.
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	path, err := s.keyPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	return nil
+}
+
+// Open returns a reader of the file stored under key.
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("operation cancelled before starting: %w", ctx.Err())
+	}
+	path, err := s.keyPath(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path) //nolint:gosec // G304: path is confined to the storage directory by keyPath
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", storage.ErrObjectNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// makeParentDirs creates the sub-directories of a key containing "/".
+// The storage directory itself must already exist: it is never created.
+func (s *LocalStorage) makeParentDirs(dstPath string) error {
+	dir := filepath.Dir(dstPath)
+	if dir == filepath.Clean(s.dirpath) {
+		return nil
+	}
+	if _, err := os.Stat(s.dirpath); err != nil {
+		return fmt.Errorf("storage directory %s: %w", s.dirpath, err)
+	}
+	if err := os.MkdirAll(dir, constants.DefaultDirPermission); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	return nil
+}
+
+// keyPath maps a storage key to a path inside the storage directory,
+// rejecting keys that would escape it.
+func (s *LocalStorage) keyPath(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("%w: %q", storage.ErrInvalidKey, key)
+	}
+	return filepath.Join(s.dirpath, filepath.FromSlash(key)), nil
+}
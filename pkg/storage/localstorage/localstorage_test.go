@@ -3,10 +3,14 @@ package localstorage_test
 import (
 	"bytes"
 	"context"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
 	"github.com/sgaunet/gitlab-backup/pkg/storage/localstorage"
 	"github.com/stretchr/testify/require"
 )
@@ -80,6 +84,22 @@ func TestSaveFileWithWrontDestinationFolder(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestSaveFile_NestedKey(t *testing.T) {
+	tempDir := t.TempDir()
+	srcPath := filepath.Join(t.TempDir(), "source")
+	require.NoError(t, os.WriteFile(srcPath, []byte("nested"), 0o600))
+
+	storage := localstorage.NewLocalStorage(tempDir)
+	require.NoError(t, storage.SaveFile(context.Background(), srcPath, "grp/sub/proj/2026/10/16/proj-1.tar.gz"))
+
+	got, err := os.ReadFile(filepath.Join(tempDir, "grp", "sub", "proj", "2026", "10", "16", "proj-1.tar.gz"))
+	require.NoError(t, err)
+	require.Equal(t, "nested", string(got))
+
+	// Keys escaping the storage directory are rejected.
+	require.Error(t, storage.SaveFile(context.Background(), srcPath, "../escape.tar.gz"))
+}
+
 // TestSaveFile_LargeFile copies a source larger than the copy buffer so the
 // multi-iteration copy loop and the EOF-break path are exercised.
 func TestSaveFile_LargeFile(t *testing.T) {
@@ -141,3 +161,134 @@ func TestSaveFile_ContextCancellation(t *testing.T) {
 	_, statErr := os.Stat(dstFilePath)
 	require.True(t, os.IsNotExist(statErr), "Destination file should be cleaned up after cancellation")
 }
+
+func TestList(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "a-1.tar.gz"), []byte("aaa"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "grp", "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "grp", "sub", "b-2.tar.gz"), []byte("b"), 0o600))
+
+	storage := localstorage.NewLocalStorage(tempDir)
+
+	all, err := storage.List(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	keys := map[string]int64{}
+	for _, o := range all {
+		keys[o.Key] = o.Size
+		require.False(t, o.ModTime.IsZero())
+	}
+	require.Equal(t, map[string]int64{"a-1.tar.gz": 3, "grp/sub/b-2.tar.gz": 1}, keys)
+
+	filtered, err := storage.List(context.Background(), "grp/")
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	require.Equal(t, "grp/sub/b-2.tar.gz", filtered[0].Key)
+}
+
+func TestDelete(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "grp"), 0o755))
+	path := filepath.Join(tempDir, "grp", "b-2.tar.gz")
+	require.NoError(t, os.WriteFile(path, []byte("b"), 0o600))
+
+	storage := localstorage.NewLocalStorage(tempDir)
+	require.NoError(t, storage.Delete(context.Background(), "grp/b-2.tar.gz"))
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+
+	// Missing objects and keys escaping the storage directory are errors.
+	require.Error(t, storage.Delete(context.Background(), "grp/b-2.tar.gz"))
+	require.Error(t, storage.Delete(context.Background(), "../outside"))
+	require.Error(t, storage.Delete(context.Background(), ""))
+}
+
+func TestOpen(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "grp"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "grp", "b-2.tar.gz"), []byte("archive"), 0o600))
+
+	s := localstorage.NewLocalStorage(tempDir)
+	r, err := s.Open(context.Background(), "grp/b-2.tar.gz")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "archive", string(data))
+
+	_, err = s.Open(context.Background(), "grp/missing.tar.gz")
+	require.ErrorIs(t, err, storage.ErrObjectNotFound)
+	_, err = s.Open(context.Background(), "../outside")
+	require.ErrorIs(t, err, storage.ErrInvalidKey)
+}
+
+func TestSaveStream(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := localstorage.NewLocalStorage(tempDir)
+
+	require.NoError(t, storage.SaveStream(context.Background(), bytes.NewReader([]byte("archive")), "grp/a-1.tar.gz"))
+	data, err := os.ReadFile(filepath.Join(tempDir, "grp", "a-1.tar.gz"))
+	require.NoError(t, err)
+	require.Equal(t, "archive", string(data))
+
+	// A failing stream leaves neither the archive nor the temporary file.
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("partial"))
+		pw.CloseWithError(errors.New("download interrupted"))
+	}()
+	err = storage.SaveStream(context.Background(), pr, "grp/b-2.tar.gz")
+	require.ErrorContains(t, err, "download interrupted")
+	entries, err := os.ReadDir(filepath.Join(tempDir, "grp"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "a-1.tar.gz", entries[0].Name())
+
+	require.Error(t, storage.SaveStream(context.Background(), bytes.NewReader(nil), "../outside"))
+}
+
+func TestSaveStream_ContextCancellation(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := localstorage.NewLocalStorage(tempDir)
+
+	// The context is cancelled while the stream is being written.
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("partial"))
+		cancel()
+		_, _ = pw.Write([]byte("more"))
+		pw.Close()
+	}()
+	err := storage.SaveStream(ctx, pr, "grp/a-1.tar.gz")
+	require.ErrorIs(t, err, context.Canceled)
+
+	// Neither the archive nor the temporary file is left behind.
+	entries, err := os.ReadDir(filepath.Join(tempDir, "grp"))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestNewLocalStorage_RemovesStalePartials(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "grp"), 0o750))
+	write := func(name string, age time.Duration) string {
+		t.Helper()
+		path := filepath.Join(tempDir, "grp", name)
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+		modTime := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+		return path
+	}
+	stale := write(".a-1.tar.gz.123.partial", 48*time.Hour)
+	inProgress := write(".b-2.tar.gz.456.partial", time.Minute)
+	archive := write("c-3.partial", 48*time.Hour)
+
+	localstorage.NewLocalStorage(tempDir)
+
+	// Only the temporary file left untouched for long is deleted: a recent
+	// one may belong to a stream in progress.
+	require.NoFileExists(t, stale)
+	require.FileExists(t, inProgress)
+	require.FileExists(t, archive)
+}
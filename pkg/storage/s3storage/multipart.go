@@ -0,0 +1,311 @@
+package s3storage
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrTooManyParts is returned when a stream needs more than constants.S3MaxParts parts.
+var ErrTooManyParts = errors.New("archive exceeds the maximum number of multipart upload parts")
+
+// multipartUpload uploads r under fullKey in parts of partSize bytes, up to
+// s.concurrency parts at a time. Each part carries its SHA-256 checksum, which
+// S3 verifies on receipt and keeps with the object.
+//
+// An earlier upload of fullKey left incomplete (the process was killed, or
+// could not abort it) is resumed: its parts whose size and checksum match the
+// data read from r are kept rather than sent again. On failure the upload is
+// aborted, which deletes the parts already sent.
+func (s *S3Storage) multipartUpload(ctx context.Context, fullKey string, r io.Reader, partSize int64) error {
+	uploadID, existing, err := s.startMultipartUpload(ctx, fullKey)
+	if err != nil {
+		return err
+	}
+
+	parts, err := s.uploadParts(ctx, fullKey, uploadID, r, partSize, existing)
+	if err == nil {
+		_, err = s.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(s.bucket),
+			Key:             aws.String(fullKey),
+			UploadId:        uploadID,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+		if err != nil {
+			err = fmt.Errorf("failed to complete multipart upload of %s: %w", fullKey, err)
+		}
+	}
+	if err != nil {
+		// ctx may be the cancelled one: abort regardless.
+		if abortErr := s.abortUpload(context.WithoutCancel(ctx), fullKey, uploadID); abortErr != nil {
+			return fmt.Errorf("%w (%w)", err, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// startMultipartUpload returns the upload to resume for fullKey and its
+// uploaded parts by number, or starts a new upload. Incomplete uploads of
+// fullKey that are not resumed (older ones, or without SHA-256 checksums) are
+// aborted. When the credentials may not list (or abort) incomplete uploads, a
+// new upload is started without looking for one to resume.
+func (s *S3Storage) startMultipartUpload(
+	ctx context.Context,
+	fullKey string,
+) (*string, map[int32]types.Part, error) {
+	resume, existing, err := s.resumableUpload(ctx, fullKey)
+	if err != nil && !isAccessDenied(err) {
+		return nil, nil, err
+	}
+	if resume != nil {
+		return resume, existing, nil
+	}
+
+	created, err := s.s3Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:            aws.String(s.bucket),
+		Key:               aws.String(fullKey),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start multipart upload of %s to S3 bucket %s: %w", fullKey, s.bucket, err)
+	}
+	return created.UploadId, nil, nil
+}
+
+// resumableUpload returns the incomplete upload of fullKey to resume and its
+// uploaded parts by number, or nil when there is none.
+func (s *S3Storage) resumableUpload(ctx context.Context, fullKey string) (*string, map[int32]types.Part, error) {
+	resume, err := s.incompleteUpload(ctx, fullKey)
+	if err != nil || resume == nil {
+		return nil, nil, err
+	}
+	existing, err := s.uploadedParts(ctx, fullKey, resume)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resume, existing, nil
+}
+
+// incompleteUpload returns the ID of the most recent incomplete upload of
+// fullKey with SHA-256 checksums, or nil, after aborting the other ones.
+func (s *S3Storage) incompleteUpload(ctx context.Context, fullKey string) (*string, error) {
+	var uploads []types.MultipartUpload
+	paginator := s3.NewListMultipartUploadsPaginator(s.s3Client, &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(fullKey),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list multipart uploads of %s: %w", fullKey, err)
+		}
+		for _, u := range page.Uploads {
+			if aws.ToString(u.Key) == fullKey {
+				uploads = append(uploads, u)
+			}
+		}
+	}
+	// Most recent first.
+	slices.SortFunc(uploads, func(a, b types.MultipartUpload) int {
+		return aws.ToTime(b.Initiated).Compare(aws.ToTime(a.Initiated))
+	})
+
+	var resume *string
+	for _, u := range uploads {
+		if resume == nil && u.ChecksumAlgorithm == types.ChecksumAlgorithmSha256 {
+			resume = u.UploadId
+			continue
+		}
+		if err := s.abortUpload(ctx, fullKey, u.UploadId); err != nil {
+			return nil, err
+		}
+	}
+	return resume, nil
+}
+
+// uploadedParts returns the parts of upload uploadID of fullKey by number.
+func (s *S3Storage) uploadedParts(ctx context.Context, fullKey string, uploadID *string) (map[int32]types.Part, error) {
+	parts := map[int32]types.Part{}
+	paginator := s3.NewListPartsPaginator(s.s3Client, &s3.ListPartsInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(fullKey),
+		UploadId: uploadID,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list uploaded parts of %s: %w", fullKey, err)
+		}
+		for _, p := range page.Parts {
+			parts[aws.ToInt32(p.PartNumber)] = p
+		}
+	}
+	return parts, nil
+}
+
+// uploadParts reads r in parts of partSize bytes and uploads those not in
+// existing, s.concurrency at a time. It returns the completed parts in order.
+func (s *S3Storage) uploadParts(
+	ctx context.Context,
+	fullKey string,
+	uploadID *string,
+	r io.Reader,
+	partSize int64,
+	existing map[int32]types.Part,
+) ([]types.CompletedPart, error) {
+	g, gctx := errgroup.WithContext(ctx)
+	u := &partUploader{s: s, ctx: gctx, group: g, fullKey: fullKey, uploadID: uploadID, existing: existing}
+	// Part buffers, allocated on first use and recycled: at most s.concurrency
+	// parts are held in memory.
+	buffers := make(chan []byte, s.concurrency)
+	for range s.concurrency {
+		buffers <- nil
+	}
+
+	for number := int32(1); ; number++ {
+		var buf []byte
+		select {
+		case buf = <-buffers:
+		case <-gctx.Done():
+		}
+		if gctx.Err() != nil {
+			break // an upload failed, g.Wait reports it
+		}
+		if buf == nil {
+			buf = make([]byte, partSize)
+		}
+		n, err := io.ReadFull(r, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			g.Go(func() error { return fmt.Errorf("failed to read data for %s: %w", fullKey, err) })
+			break
+		}
+		if n == 0 {
+			break
+		}
+		if number > constants.S3MaxParts {
+			g.Go(func() error { return fmt.Errorf("%w (%d parts of %d bytes)", ErrTooManyParts, number-1, partSize) })
+			break
+		}
+		u.send(number, buf[:n], func() { buffers <- buf })
+		if n < len(buf) {
+			break // short read: this was the last part
+		}
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err //nolint:wrapcheck // errors of the group are already wrapped
+	}
+	return u.completedParts(), nil
+}
+
+// partUploader uploads the parts of one multipart upload.
+type partUploader struct {
+	s        *S3Storage
+	ctx      context.Context //nolint:containedctx // scoped to one uploadParts call
+	group    *errgroup.Group
+	fullKey  string
+	uploadID *string
+	existing map[int32]types.Part // parts of a resumed upload
+
+	mu        sync.Mutex
+	completed []types.CompletedPart
+}
+
+// send uploads part number in the background, unless the resumed upload
+// already holds the same data. release is called once part is no longer used.
+func (u *partUploader) send(number int32, part []byte, release func()) {
+	checksum := partChecksum(part)
+	if p, ok := u.existing[number]; ok && aws.ToInt64(p.Size) == int64(len(part)) &&
+		aws.ToString(p.ChecksumSHA256) == checksum {
+		u.complete(p.ETag, number, checksum)
+		release()
+		return
+	}
+	u.group.Go(func() error {
+		defer release()
+		out, err := u.s.s3Client.UploadPart(u.ctx, &s3.UploadPartInput{
+			Bucket:         aws.String(u.s.bucket),
+			Key:            aws.String(u.fullKey),
+			UploadId:       u.uploadID,
+			PartNumber:     aws.Int32(number),
+			Body:           bytes.NewReader(part),
+			ChecksumSHA256: aws.String(checksum),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload part %d of %s: %w", number, u.fullKey, err)
+		}
+		u.complete(out.ETag, number, checksum)
+		return nil
+	})
+}
+
+// complete records an uploaded part.
+func (u *partUploader) complete(etag *string, number int32, checksum string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.completed = append(u.completed, types.CompletedPart{
+		ETag:           etag,
+		PartNumber:     aws.Int32(number),
+		ChecksumSHA256: aws.String(checksum),
+	})
+}
+
+// completedParts returns the uploaded parts by part number.
+func (u *partUploader) completedParts() []types.CompletedPart {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	slices.SortFunc(u.completed, func(a, b types.CompletedPart) int {
+		return cmp.Compare(aws.ToInt32(a.PartNumber), aws.ToInt32(b.PartNumber))
+	})
+	return u.completed
+}
+
+// abortUpload aborts upload uploadID of fullKey and deletes its parts.
+func (s *S3Storage) abortUpload(ctx context.Context, fullKey string, uploadID *string) error {
+	_, err := s.s3Client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(fullKey),
+		UploadId: uploadID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to abort multipart upload %s of %s: %w", aws.ToString(uploadID), fullKey, err)
+	}
+	return nil
+}
+
+// isAccessDenied reports whether err is an S3 access denied error, returned
+// when the credentials lack the permission of the request.
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDenied"
+}
+
+// partChecksum returns the base64 SHA-256 of a part, as S3 expects it.
+func partChecksum(part []byte) string {
+	sum := sha256.Sum256(part)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// filePartSize returns the part size of a file of size bytes: partSize, or
+// the smallest whole number of megabytes fitting the file in S3MaxParts parts.
+func filePartSize(size, partSize int64) int64 {
+	minSize := (size + constants.S3MaxParts - 1) / constants.S3MaxParts
+	if minSize <= partSize {
+		return partSize
+	}
+	return (minSize + constants.MB - 1) / constants.MB * constants.MB
+}
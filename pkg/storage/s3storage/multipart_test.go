@@ -0,0 +1,112 @@
+package s3storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+)
+
+func TestFilePartSize(t *testing.T) {
+	const partSize = 16 * constants.MB
+	tests := []struct {
+		name string
+		size int64
+		want int64
+	}{
+		{name: "small file", size: 100 * constants.MB, want: partSize},
+		{name: "largest file at the part size", size: constants.S3MaxParts * partSize, want: partSize},
+		{name: "larger file", size: constants.S3MaxParts*partSize + 1, want: 17 * constants.MB},
+		{name: "1TB", size: constants.TB, want: 105 * constants.MB},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filePartSize(tt.size, partSize)
+			if got != tt.want {
+				t.Errorf("filePartSize(%d) = %d, want %d", tt.size, got, tt.want)
+			}
+			if (tt.size+got-1)/got > constants.S3MaxParts {
+				t.Errorf("%d parts of %d bytes exceed the S3 limit", (tt.size+got-1)/got, got)
+			}
+		})
+	}
+}
+
+// deniedListingServer is a fake S3 endpoint refusing to list multipart
+// uploads and accepting the requests of a new upload. It records the
+// requests it receives, e.g. "CreateMultipartUpload".
+func deniedListingServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, name)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		q := r.URL.Query()
+		switch {
+		case r.Method == http.MethodGet && q.Has("uploads"):
+			record("ListMultipartUploads")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+		case r.Method == http.MethodPost && q.Has("uploads"):
+			record("CreateMultipartUpload")
+			fmt.Fprint(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket>`+
+				`<Key>archive.tar.gz</Key><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodPut && q.Has("partNumber"):
+			record("UploadPart")
+			w.Header().Set("ETag", `"etag-`+q.Get("partNumber")+`"`)
+		case r.Method == http.MethodPost && q.Has("uploadId"):
+			record("CompleteMultipartUpload")
+			fmt.Fprint(w, `<CompleteMultipartUploadResult><Bucket>bucket</Bucket>`+
+				`<Key>archive.tar.gz</Key><ETag>"etag"</ETag></CompleteMultipartUploadResult>`)
+		default:
+			record(r.Method + " " + r.URL.String())
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(requests)
+	}
+}
+
+func TestMultipartUpload_ListingDenied(t *testing.T) {
+	srv, requests := deniedListingServer(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	s, err := NewS3Storage(context.Background(), "us-east-1", srv.URL, "bucket", "", WithPartSize(1024))
+	if err != nil {
+		t.Fatalf("NewS3Storage failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "archive.tar.gz")
+	if err := os.WriteFile(path, make([]byte, 2500), 0o600); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	// Without the permission to list incomplete uploads, nothing is resumed
+	// and a new upload is sent.
+	if err := s.SaveFile(context.Background(), path, "archive.tar.gz"); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	want := []string{
+		"ListMultipartUploads", "CreateMultipartUpload",
+		"UploadPart", "UploadPart", "UploadPart", "CompleteMultipartUpload",
+	}
+	if got := requests(); !slices.Equal(got, want) {
+		t.Errorf("requests = %v, want %v", got, want)
+	}
+}
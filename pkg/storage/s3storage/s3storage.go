@@ -2,12 +2,15 @@
 package s3storage
 
 import (
+	"bytes"
 	"context"
 	"crypto/md5" //nolint:gosec // G501: MD5 required for S3 Content-MD5 header
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	// "github.com/minio/minio-go/v7/pkg/credentials".
 
@@ -15,6 +18,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
 )
 
 // S3Storage implements storage interface for AWS S3.
@@ -25,18 +31,56 @@ type S3Storage struct {
 	region   string
 	bucket   string
 	path     string
+
+	partSize    int64 // multipart upload part size in bytes
+	concurrency int   // parts uploaded in parallel
+}
+
+// Option configures an S3Storage.
+type Option func(*S3Storage)
+
+// WithPartSize sets the multipart upload part size in bytes (default
+// constants.DefaultS3PartSizeMB). Zero or negative keeps the default.
+func WithPartSize(size int64) Option {
+	return func(s *S3Storage) {
+		if size > 0 {
+			s.partSize = size
+		}
+	}
+}
+
+// WithUploadConcurrency sets the number of parts uploaded in parallel
+// (default constants.DefaultS3UploadConcurrency). Zero or negative keeps the default.
+func WithUploadConcurrency(n int) Option {
+	return func(s *S3Storage) {
+		if n > 0 {
+			s.concurrency = n
+		}
+	}
 }
 
 // NewS3Storage creates a new S3Storage.
 // The context is used for AWS SDK configuration loading and may respect timeout/cancellation.
-func NewS3Storage(ctx context.Context, region string, endpoint string, bucket string, path string) (*S3Storage, error) {
+func NewS3Storage(
+	ctx context.Context,
+	region string,
+	endpoint string,
+	bucket string,
+	path string,
+	opts ...Option,
+) (*S3Storage, error) {
 	var err error
 
 	s := &S3Storage{
-		endpoint: endpoint,
-		region:   region,
-		bucket:   bucket,
-		path:     path,
+		endpoint:    endpoint,
+		region:      region,
+		bucket:      bucket,
+		path:        path,
+		partSize:    constants.DefaultS3PartSizeMB * constants.MB,
+		concurrency: constants.DefaultS3UploadConcurrency,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
 	err = s.initClient(ctx)
 	if err != nil {
@@ -57,7 +101,8 @@ func (s *S3Storage) CreateBucket(ctx context.Context) error {
 	return nil
 }
 
-// SaveFile saves the file in s3.
+// SaveFile saves the file in s3. Files larger than one part are sent with a
+// multipart upload, see multipartUpload.
 func (s *S3Storage) SaveFile(ctx context.Context, archiveFilePath string, dstFilename string) (err error) {
 	// Open file once
 	f, openErr := os.Open(archiveFilePath) //nolint:gosec // G304: File access is intentional for backup functionality
@@ -70,6 +115,14 @@ func (s *S3Storage) SaveFile(ctx context.Context, archiveFilePath string, dstFil
 		}
 	}()
 
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat archive file %s: %w", archiveFilePath, err)
+	}
+	if info.Size() > s.partSize {
+		return s.multipartUpload(ctx, s.fullKey(dstFilename), f, filePartSize(info.Size(), s.partSize))
+	}
+
 	// First pass: calculate MD5
 	hash := md5.New() //nolint:gosec // G401: MD5 required for S3 Content-MD5 header
 	_, err = io.Copy(hash, f)
@@ -85,7 +138,7 @@ func (s *S3Storage) SaveFile(ctx context.Context, archiveFilePath string, dstFil
 
 	// Second pass: upload with ContentMD5
 	md5b64 := base64.StdEncoding.EncodeToString(hash.Sum(nil))
-	fullKey := s.path + "/" + dstFilename
+	fullKey := s.fullKey(dstFilename)
 	_, err = s.s3Client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:     aws.String(s.bucket),
 		Key:        aws.String(fullKey),
@@ -100,6 +153,37 @@ func (s *S3Storage) SaveFile(ctx context.Context, archiveFilePath string, dstFil
 	return nil
 }
 
+// SaveStream stores everything read from r under key. A stream shorter than
+// one part is sent with a single PutObject, a longer one with a multipart
+// upload completed once r is fully read, see multipartUpload.
+func (s *S3Storage) SaveStream(ctx context.Context, r io.Reader, key string) error {
+	fullKey := s.fullKey(key)
+	first := make([]byte, s.partSize)
+	n, err := io.ReadFull(r, first)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return s.putObject(ctx, fullKey, first[:n])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read stream for %s: %w", fullKey, err)
+	}
+	return s.multipartUpload(ctx, fullKey, io.MultiReader(bytes.NewReader(first), r), s.partSize)
+}
+
+// putObject uploads data under fullKey in a single request.
+func (s *S3Storage) putObject(ctx context.Context, fullKey string, data []byte) error {
+	sum := md5.Sum(data) //nolint:gosec // G401: MD5 required for S3 Content-MD5 header
+	_, err := s.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:     aws.String(s.bucket),
+		Key:        aws.String(fullKey),
+		Body:       bytes.NewReader(data),
+		ContentMD5: aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s to S3 bucket %s: %w", fullKey, s.bucket, err)
+	}
+	return nil
+}
+
 // GetFile downloads a file from S3 and saves it to the specified local path.
 func (s *S3Storage) GetFile(ctx context.Context, key string, localPath string) (err error) {
 	// Create local file
@@ -115,10 +199,7 @@ func (s *S3Storage) GetFile(ctx context.Context, key string, localPath string) (
 	}()
 
 	// Construct full S3 key
-	fullKey := s.path + "/" + key
-	if s.path == "" {
-		fullKey = key
-	}
+	fullKey := s.fullKey(key)
 
 	// Download from S3
 	result, getErr := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
@@ -144,6 +225,79 @@ func (s *S3Storage) GetFile(ctx context.Context, key string, localPath string) (
 	return nil
 }
 
+// List returns the objects below the bucket path whose key (relative to the
+// bucket path) starts with prefix.
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]storage.Object, error) {
+	root := s.fullKey("")
+	paginator := s3.NewListObjectsV2Paginator(s.s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(root + prefix),
+	})
+
+	var objects []storage.Object
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list S3 bucket %s (prefix: %s): %w", s.bucket, root+prefix, err)
+		}
+		for _, obj := range page.Contents {
+			object := storage.Object{
+				Key:  strings.TrimPrefix(aws.ToString(obj.Key), root),
+				Size: aws.ToInt64(obj.Size),
+			}
+			if obj.LastModified != nil {
+				object.ModTime = *obj.LastModified
+			}
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+// Delete removes the object stored under key (relative to the bucket path).
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if key == "" {
+		return fmt.Errorf("%w: empty key", storage.ErrInvalidKey)
+	}
+	fullKey := s.fullKey(key)
+	_, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fullKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s from S3 bucket %s: %w", fullKey, s.bucket, err)
+	}
+	return nil
+}
+
+// Open returns a reader of the object stored under key (relative to the
+// bucket path), streamed from S3.
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if key == "" {
+		return nil, fmt.Errorf("%w: empty key", storage.ErrInvalidKey)
+	}
+	fullKey := s.fullKey(key)
+	result, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fullKey),
+	})
+	if noSuchKey := (*types.NoSuchKey)(nil); errors.As(err, &noSuchKey) {
+		return nil, fmt.Errorf("%w: %s in S3 bucket %s", storage.ErrObjectNotFound, fullKey, s.bucket)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s from S3 bucket %s: %w", fullKey, s.bucket, err)
+	}
+	return result.Body, nil
+}
+
+// fullKey prefixes key with the configured bucket path.
+func (s *S3Storage) fullKey(key string) string {
+	if s.path == "" {
+		return key
+	}
+	return s.path + "/" + key
+}
+
 // initClient initializes the s3 client with context support.
 func (s *S3Storage) initClient(ctx context.Context) error {
 	if os.Getenv("AWS_ACCESS_KEY_ID") != "" {
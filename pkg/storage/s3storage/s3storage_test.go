@@ -1,11 +1,17 @@
 package s3storage_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"testing"
+	"testing/iotest"
 
+	"github.com/sgaunet/gitlab-backup/pkg/constants"
+	"github.com/sgaunet/gitlab-backup/pkg/storage"
 	"github.com/sgaunet/gitlab-backup/pkg/storage/s3storage"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -302,3 +308,188 @@ func BenchmarkSaveFile(b *testing.B) {
 		}
 	}
 }
+
+// newMinioStorage starts a MinIO container and returns an S3Storage rooted at
+// bucket "tests", path "tests", with the bucket already created.
+func newMinioStorage(t *testing.T, opts ...s3storage.Option) *s3storage.S3Storage {
+	t.Helper()
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "minio/minio:RELEASE.2023-04-13T03-08-07Z.fips",
+		ExposedPorts: []string{"9000/tcp"},
+		WaitingFor:   wait.ForLog("Console: http://0.0.0.0:8080"),
+		Env: map[string]string{
+			"MINIO_ROOT_USER":     "minioadminn",
+			"MINIO_ROOT_PASSWORD": "minioadminn",
+			"MINIO_BUCKET":        "tests",
+		},
+		Cmd: []string{"server", "/export", "--console-address", "0.0.0.0:8080"},
+	}
+	minio, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start MinIO container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := minio.Terminate(ctx); err != nil {
+			t.Errorf("Failed to terminate MinIO container: %v", err)
+		}
+	})
+
+	endpoint, err := minio.Endpoint(ctx, "")
+	if err != nil {
+		t.Fatalf("Failed to get MinIO endpoint: %v", err)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "minioadminn")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "minioadminn")
+
+	s3, err := s3storage.NewS3Storage(ctx, "us-east-1", fmt.Sprintf("http://%s", endpoint), "tests", "tests", opts...)
+	if err != nil {
+		t.Fatalf("Failed to create S3Storage: %v", err)
+	}
+	if err := s3.CreateBucket(ctx); err != nil {
+		t.Fatalf("Failed to create bucket: %v", err)
+	}
+	return s3
+}
+
+func TestS3Storage_ListAndDelete(t *testing.T) {
+	ctx := context.Background()
+	s3 := newMinioStorage(t)
+
+	for _, key := range []string{"a-1.tar.gz", "grp/b-2.tar.gz"} {
+		if err := s3.SaveFile(ctx, "../../../README.md", key); err != nil {
+			t.Fatalf("SaveFile %s failed: %v", key, err)
+		}
+	}
+
+	objects, err := s3.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("expected 2 objects, got %d: %+v", len(objects), objects)
+	}
+	for _, o := range objects {
+		if o.Key != "a-1.tar.gz" && o.Key != "grp/b-2.tar.gz" {
+			t.Errorf("unexpected key %q (keys must be relative to the bucket path)", o.Key)
+		}
+		if o.Size == 0 || o.ModTime.IsZero() {
+			t.Errorf("expected size and modification time for %q, got %+v", o.Key, o)
+		}
+	}
+
+	filtered, err := s3.List(ctx, "grp/")
+	if err != nil {
+		t.Fatalf("List with prefix failed: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Key != "grp/b-2.tar.gz" {
+		t.Errorf("unexpected filtered listing: %+v", filtered)
+	}
+
+	r, err := s3.Open(ctx, "grp/b-2.tar.gz")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	opened, err := io.ReadAll(r)
+	_ = r.Close()
+	readme, _ := os.ReadFile("../../../README.md")
+	if err != nil || !bytes.Equal(opened, readme) {
+		t.Errorf("Open returned %d bytes (error %v), want the %d bytes of README.md", len(opened), err, len(readme))
+	}
+
+	if err := s3.Delete(ctx, "a-1.tar.gz"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s3.Open(ctx, "a-1.tar.gz"); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Errorf("expected ErrObjectNotFound opening a deleted object, got %v", err)
+	}
+	remaining, err := s3.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List after delete failed: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].Key != "grp/b-2.tar.gz" {
+		t.Errorf("unexpected listing after delete: %+v", remaining)
+	}
+}
+
+func TestS3Storage_SaveStream(t *testing.T) {
+	ctx := context.Background()
+	const partSize = constants.S3MinPartSizeMB * constants.MB
+	s3 := newMinioStorage(t, s3storage.WithPartSize(partSize), s3storage.WithUploadConcurrency(2))
+
+	// One small object (single PutObject) and one of two and a half parts (multipart).
+	small := []byte("small archive")
+	large := make([]byte, 2*partSize+partSize/2)
+	for i := range large {
+		large[i] = byte(i % 251)
+	}
+	for key, content := range map[string][]byte{"small.tar.gz": small, "grp/large.tar.gz": large} {
+		if err := s3.SaveStream(ctx, bytes.NewReader(content), key); err != nil {
+			t.Fatalf("SaveStream %s failed: %v", key, err)
+		}
+		downloadPath := t.TempDir() + "/download"
+		if err := s3.GetFile(ctx, key, downloadPath); err != nil {
+			t.Fatalf("GetFile %s failed: %v", key, err)
+		}
+		downloaded, err := os.ReadFile(downloadPath)
+		if err != nil {
+			t.Fatalf("Failed to read downloaded file: %v", err)
+		}
+		if !bytes.Equal(downloaded, content) {
+			t.Errorf("content mismatch for %s: got %d bytes, want %d", key, len(downloaded), len(content))
+		}
+	}
+
+	// A stream failing after the first part aborts the upload: no object is left.
+	failing := io.MultiReader(bytes.NewReader(large[:partSize+1]),
+		iotest.ErrReader(errors.New("download interrupted")))
+	if err := s3.SaveStream(ctx, failing, "failed.tar.gz"); err == nil {
+		t.Fatal("expected SaveStream to fail")
+	}
+	objects, err := s3.List(ctx, "failed")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("expected no object after a failed stream, got %+v", objects)
+	}
+}
+
+func TestS3Storage_SaveFile_Multipart(t *testing.T) {
+	ctx := context.Background()
+	const partSize = constants.S3MinPartSizeMB * constants.MB
+	s3 := newMinioStorage(t, s3storage.WithPartSize(partSize), s3storage.WithUploadConcurrency(3))
+
+	// Four full parts and a short one, uploaded three at a time.
+	content := make([]byte, 4*partSize+1234)
+	for i := range content {
+		content[i] = byte(i % 253)
+	}
+	path := t.TempDir() + "/archive.tar.gz"
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	// Twice: the second upload replaces the first object.
+	for range 2 {
+		if err := s3.SaveFile(ctx, path, "grp/archive.tar.gz"); err != nil {
+			t.Fatalf("SaveFile failed: %v", err)
+		}
+	}
+
+	downloadPath := t.TempDir() + "/download"
+	if err := s3.GetFile(ctx, "grp/archive.tar.gz", downloadPath); err != nil {
+		t.Fatalf("GetFile failed: %v", err)
+	}
+	downloaded, err := os.ReadFile(downloadPath)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if !bytes.Equal(downloaded, content) {
+		t.Errorf("content mismatch: got %d bytes, want %d", len(downloaded), len(content))
+	}
+}
@@ -2,9 +2,42 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"io"
+	"time"
 )
 
-// Storage interface defines methods for saving backup files.
+var (
+	// ErrInvalidKey is returned when a storage key is empty or escapes the storage root.
+	ErrInvalidKey = errors.New("invalid storage key")
+	// ErrObjectNotFound is returned by Open when nothing is stored under the key.
+	ErrObjectNotFound = errors.New("object not found")
+)
+
+// Object describes a stored object. Key is relative to the storage root
+// (local directory or S3 bucket path) and always uses forward slashes.
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage interface defines methods for saving, listing and deleting backup files.
 type Storage interface {
 	SaveFile(ctx context.Context, archiveFilePath string, dstFilename string) error
+	// List returns every object whose key starts with prefix ("" lists everything).
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Delete removes the object stored under key.
+	Delete(ctx context.Context, key string) error
+	// Open returns a reader of the object stored under key, or an error
+	// wrapping ErrObjectNotFound. The caller closes it.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// StreamSaver is implemented by the storages that can store an object read
+// from a stream, without a local copy of it. SaveStream stores everything read
+// from r under key; the object only becomes visible once r is fully read, so
+// an error returned by r (e.g. a failed export download) leaves nothing behind.
+type StreamSaver interface {
+	SaveStream(ctx context.Context, r io.Reader, key string) error
 }